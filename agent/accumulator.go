@@ -1,7 +1,6 @@
 package agent
 
 import (
-	"log"
 	"time"
 
 	"github.com/influxdata/telegraf"
@@ -15,7 +14,9 @@ var (
 
 type MetricMaker interface {
 	Name() string
+	LogName() string
 	MakeMetric(metric telegraf.Metric) telegraf.Metric
+	Log() telegraf.Logger
 }
 
 type accumulator struct {
@@ -111,7 +112,7 @@ func (ac *accumulator) AddError(err error) {
 		return
 	}
 	NErrors.Incr(1)
-	log.Printf("E! [%s]: Error in plugin: %v", ac.maker.Name(), err)
+	ac.maker.Log().Errorf("Error in plugin: %v", err)
 }
 
 func (ac *accumulator) SetPrecision(precision time.Duration) {
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -147,6 +148,14 @@ func (tm *TestMetricMaker) Name() string {
 	return "TestPlugin"
 }
 
+func (tm *TestMetricMaker) LogName() string {
+	return tm.Name()
+}
+
 func (tm *TestMetricMaker) MakeMetric(metric telegraf.Metric) telegraf.Metric {
 	return metric
 }
+
+func (tm *TestMetricMaker) Log() telegraf.Logger {
+	return testutil.Logger{Name: tm.Name()}
+}
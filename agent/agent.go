@@ -2,9 +2,11 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"runtime"
+	"strings"
 	"sync"
 	"time"
 
@@ -15,9 +17,87 @@ import (
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
 )
 
+// ErrRestartRequired is returned by Reload when the new configuration
+// cannot be applied to the running agent.
+var ErrRestartRequired = errors.New("configuration change requires a restart")
+
 // Agent runs a set of plugins.
 type Agent struct {
 	Config *config.Config
+
+	// mu guards the plugin lists of the Config while the agent is running.
+	mu sync.RWMutex
+
+	// reloadMu serializes reloads with the shutdown of the agent.
+	reloadMu sync.Mutex
+	pipeline *pipeline
+}
+
+// pipeline holds the state of a running agent needed to start and stop
+// single plugins.
+type pipeline struct {
+	ctx    context.Context
+	inputC chan telegraf.Metric
+	procC  chan telegraf.Metric
+	aggC   chan telegraf.Metric
+
+	inputs      map[*models.RunningInput]*unit
+	processors  map[*models.RunningProcessor]*stream
+	aggregators map[*models.RunningAggregator]*unit
+	outputs     map[*models.RunningOutput]*unit
+}
+
+func newPipeline(ctx context.Context) *pipeline {
+	return &pipeline{
+		ctx:         ctx,
+		inputC:      make(chan telegraf.Metric, 100),
+		procC:       make(chan telegraf.Metric, 100),
+		aggC:        make(chan telegraf.Metric, 100),
+		inputs:      make(map[*models.RunningInput]*unit),
+		processors:  make(map[*models.RunningProcessor]*stream),
+		aggregators: make(map[*models.RunningAggregator]*unit),
+		outputs:     make(map[*models.RunningOutput]*unit),
+	}
+}
+
+// unit is a goroutine running a single plugin.
+type unit struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newUnit(ctx context.Context, run func(ctx context.Context)) *unit {
+	ctx, cancel := context.WithCancel(ctx)
+	u := &unit{
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go func() {
+		defer close(u.done)
+		run(ctx)
+	}()
+	return u
+}
+
+// stop cancels the unit and waits for it to return.
+func (u *unit) stop() {
+	u.cancel()
+	<-u.done
+}
+
+// stream forwards the metrics emitted by a streaming processor.
+type stream struct {
+	metricC chan telegraf.Metric
+	done    chan struct{}
+}
+
+// processorMaker adapts a RunningProcessor to the MetricMaker interface.
+type processorMaker struct {
+	*models.RunningProcessor
+}
+
+func (m processorMaker) Name() string {
+	return "processors." + m.Config.Name
 }
 
 // NewAgent returns an Agent for the given Config.
@@ -45,112 +125,336 @@ func (a *Agent) Run(ctx context.Context) error {
 		return err
 	}
 
+	if a.Config.Agent.HealthServiceAddress != "" {
+		h := newHealthServer(a)
+		err := h.Start(a.Config.Agent.HealthServiceAddress)
+		if err != nil {
+			return err
+		}
+		defer h.Stop()
+	}
+
 	log.Printf("D! [agent] Connecting outputs")
 	err = a.connectOutputs(ctx)
 	if err != nil {
 		return err
 	}
 
-	inputC := make(chan telegraf.Metric, 100)
-	procC := make(chan telegraf.Metric, 100)
+	p := newPipeline(ctx)
 	outputC := make(chan telegraf.Metric, 100)
 
-	startTime := time.Now()
-
-	log.Printf("D! [agent] Starting service inputs")
-	err = a.startServiceInputs(ctx, inputC)
+	log.Printf("D! [agent] Starting streaming processors")
+	err = a.startProcessors(p)
 	if err != nil {
+		a.closeOutputs()
 		return err
 	}
 
+	startTime := time.Now()
+
+	for _, output := range a.Config.Outputs {
+		a.startOutput(p, output, startTime)
+	}
+
+	// Before calling Add, initialize the aggregation window.  This ensures
+	// that any metric created after start time will be aggregated.
+	for _, agg := range a.Config.Aggregators {
+		a.startAggregator(p, agg, startTime)
+	}
+
 	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		err := a.runOutputs(outputC)
+		if err != nil {
+			log.Printf("E! [agent] Error running outputs: %v", err)
+		}
+	}()
 
-	src := inputC
-	dst := inputC
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		err := a.runAggregators(p, p.procC, outputC)
+		if err != nil {
+			log.Printf("E! [agent] Error running aggregators: %v", err)
+		}
+		close(outputC)
+		log.Printf("D! [agent] Output channel closed")
+	}()
 
 	wg.Add(1)
-	go func(dst chan telegraf.Metric) {
+	go func() {
 		defer wg.Done()
 
-		err := a.runInputs(ctx, startTime, dst)
+		err := a.runProcessors(p, p.inputC, p.procC)
 		if err != nil {
-			log.Printf("E! [agent] Error running inputs: %v", err)
+			log.Printf("E! [agent] Error running processors: %v", err)
+		}
+		close(p.procC)
+		log.Printf("D! [agent] Processor channel closed")
+	}()
+
+	log.Printf("D! [agent] Starting service inputs")
+	err = a.startServiceInputs(ctx, p.inputC)
+	if err == nil {
+		a.runInputs(p, startTime)
+
+		a.reloadMu.Lock()
+		a.pipeline = p
+		a.reloadMu.Unlock()
+
+		<-ctx.Done()
+	}
+
+	// Reloads are blocked until the agent is stopped.
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
+	a.pipeline = nil
+
+	if err == nil {
+		for input, u := range p.inputs {
+			u.stop()
+			delete(p.inputs, input)
+			input.Status().SetRunning(false)
 		}
 
 		log.Printf("D! [agent] Stopping service inputs")
 		a.stopServiceInputs()
+	}
 
-		close(dst)
-		log.Printf("D! [agent] Input channel closed")
-	}(dst)
+	close(p.inputC)
+	log.Printf("D! [agent] Input channel closed")
 
-	src = dst
+	wg.Wait()
 
-	if len(a.Config.Processors) > 0 {
-		dst = procC
+	log.Println("I! [agent] Hang on, flushing any cached metrics before shutdown")
+	for output, u := range p.outputs {
+		u.stop()
+		delete(p.outputs, output)
+	}
 
-		wg.Add(1)
-		go func(src, dst chan telegraf.Metric) {
-			defer wg.Done()
+	log.Printf("D! [agent] Closing outputs")
+	a.closeOutputs()
 
-			err := a.runProcessors(src, dst)
-			if err != nil {
-				log.Printf("E! [agent] Error running processors: %v", err)
-			}
-			close(dst)
-			log.Printf("D! [agent] Processor channel closed")
-		}(src, dst)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("D! [agent] Stopped Successfully")
+	return nil
+}
 
-		src = dst
+// Reload applies the new configuration to the running agent.  Only the
+// plugins that were added, removed or modified are started and stopped,
+// unchanged plugins keep running along with their buffered metrics.
+//
+// ErrRestartRequired is returned if the agent settings or global tags
+// changed.  If a new plugin fails to initialize or connect the running agent
+// is left unchanged.
+func (a *Agent) Reload(c *config.Config) (*config.Diff, error) {
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
+
+	p := a.pipeline
+	if p == nil {
+		return nil, errors.New("agent is not running")
 	}
 
-	if len(a.Config.Aggregators) > 0 {
-		dst = outputC
+	diff := config.Compare(a.Config, c)
+	if diff.AgentChanged || diff.SecretStoresChanged {
+		return diff, ErrRestartRequired
+	}
+	if !diff.Changed() {
+		return diff, nil
+	}
 
-		wg.Add(1)
-		go func(src, dst chan telegraf.Metric) {
-			defer wg.Done()
+	old := a.Config
+	handover, err := a.initAdded(p, old, c, diff)
+	if err != nil {
+		return diff, err
+	}
 
-			err := a.runAggregators(startTime, src, dst)
-			if err != nil {
-				log.Printf("E! [agent] Error running aggregators: %v", err)
-			}
-			close(dst)
-			log.Printf("D! [agent] Output channel closed")
-		}(src, dst)
+	var removedInputs []*models.RunningInput
+	for _, j := range diff.Inputs.Removed {
+		removedInputs = append(removedInputs, old.Inputs[j])
+	}
+	var removedProcessors []*models.RunningProcessor
+	for _, j := range diff.Processors.Removed {
+		removedProcessors = append(removedProcessors, old.Processors[j])
+	}
+	var removedAggregators []*models.RunningAggregator
+	for _, j := range diff.Aggregators.Removed {
+		removedAggregators = append(removedAggregators, old.Aggregators[j])
+	}
+	var removedOutputs []*models.RunningOutput
+	for _, j := range diff.Outputs.Removed {
+		if _, ok := handover[old.Outputs[j]]; !ok {
+			removedOutputs = append(removedOutputs, old.Outputs[j])
+		}
+	}
 
-		src = dst
+	// Stop inputs first so that no metrics are lost in removed plugins.
+	for _, input := range removedInputs {
+		a.stopInput(p, input)
 	}
 
-	wg.Add(1)
-	go func(src chan telegraf.Metric) {
-		defer wg.Done()
+	a.mu.Lock()
+	a.Config.Merge(c, diff)
+	a.mu.Unlock()
+
+	for _, processor := range removedProcessors {
+		a.stopProcessor(p, processor)
+	}
+
+	now := time.Now()
+	for _, agg := range removedAggregators {
+		a.stopAggregator(p, agg)
+	}
+	for _, i := range diff.Aggregators.Added {
+		a.startAggregator(p, c.Aggregators[i], now)
+	}
+
+	// The outputs that took over a disk buffer are the only ones adding to
+	// it now.
+	for prev, output := range handover {
+		prev.Close()
+		output.ClaimBuffer()
+	}
+	for _, i := range diff.Outputs.Added {
+		a.startOutput(p, c.Outputs[i], now)
+	}
+	for _, output := range removedOutputs {
+		a.stopOutput(p, output)
+		output.Close()
+	}
 
-		err := a.runOutputs(startTime, src)
+	for _, i := range diff.Inputs.Added {
+		err := a.startInput(p, c.Inputs[i], now)
 		if err != nil {
-			log.Printf("E! [agent] Error running outputs: %v", err)
+			log.Printf("E! [agent] Service for input %s failed to start: %v",
+				c.Inputs[i].Name(), err)
 		}
-	}(src)
+	}
 
-	wg.Wait()
+	return diff, nil
+}
 
-	log.Printf("D! [agent] Closing outputs")
-	a.closeOutputs()
+// initAdded initializes the plugins added in the new configuration, connects
+// the added outputs and starts the added streaming processors.
+//
+// Removed outputs with the same disk buffer directory as an added output are
+// stopped and hand their open buffer over to the added output, they keep
+// receiving metrics into the buffer until the configuration is merged.  They
+// are returned mapped to the output that took over their buffer.
+func (a *Agent) initAdded(
+	p *pipeline,
+	old *config.Config,
+	c *config.Config,
+	diff *config.Diff,
+) (map[*models.RunningOutput]*models.RunningOutput, error) {
+	// The added plugins use the secret stores of the new configuration.
+	err := c.InitSecretStores()
+	if err != nil {
+		return nil, err
+	}
 
-	log.Printf("D! [agent] Stopped Successfully")
-	return nil
+	for _, i := range diff.Inputs.Added {
+		err := c.Inputs[i].Init()
+		if err != nil {
+			return nil, fmt.Errorf("could not initialize input %s: %v",
+				c.Inputs[i].Config.Name, err)
+		}
+	}
+	for _, i := range diff.Processors.Added {
+		err := c.Processors[i].Init()
+		if err != nil {
+			return nil, fmt.Errorf("could not initialize processor %s: %v",
+				c.Processors[i].Config.Name, err)
+		}
+	}
+	for _, i := range diff.Aggregators.Added {
+		err := c.Aggregators[i].Init()
+		if err != nil {
+			return nil, fmt.Errorf("could not initialize aggregator %s: %v",
+				c.Aggregators[i].Config.Name, err)
+		}
+	}
+
+	buffers := make(map[string]*models.RunningOutput)
+	for _, j := range diff.Outputs.Removed {
+		output := old.Outputs[j]
+		if output.Config.BufferStrategy == "disk" {
+			buffers[output.Config.BufferDirectory] = output
+		}
+	}
+
+	handover := make(map[*models.RunningOutput]*models.RunningOutput)
+	var connected []*models.RunningOutput
+	for _, i := range diff.Outputs.Added {
+		output := c.Outputs[i]
+		if output.Config.BufferStrategy == "disk" {
+			if prev, ok := buffers[output.Config.BufferDirectory]; ok {
+				a.stopOutput(p, prev)
+				output.ShareBuffer(prev)
+				handover[prev] = output
+			}
+		}
+
+		err := output.Init()
+		if err == nil {
+			err = output.Connect()
+		}
+		if err != nil {
+			for _, output := range connected {
+				output.Close()
+			}
+			a.restoreOutputs(p, handover)
+			return nil, fmt.Errorf("could not start output %s: %v",
+				output.Config.Name, err)
+		}
+		connected = append(connected, output)
+	}
+
+	var started []*models.RunningProcessor
+	for _, i := range diff.Processors.Added {
+		processor := c.Processors[i]
+		err := a.startProcessor(p, processor)
+		if err != nil {
+			for _, processor := range started {
+				a.stopProcessor(p, processor)
+			}
+			for _, output := range connected {
+				output.Close()
+			}
+			a.restoreOutputs(p, handover)
+			return nil, fmt.Errorf("could not start processor %s: %v",
+				processor.Config.Name, err)
+		}
+		started = append(started, processor)
+	}
+	return handover, nil
+}
+
+// restoreOutputs gives the disk buffers handed over during a failed reload
+// back to the removed outputs and starts them again.
+func (a *Agent) restoreOutputs(
+	p *pipeline,
+	handover map[*models.RunningOutput]*models.RunningOutput,
+) {
+	for prev := range handover {
+		prev.ClaimBuffer()
+		a.startOutput(p, prev, time.Now())
+	}
 }
 
-// Test runs the inputs once and prints the output to stdout in line protocol.
+// Test runs the inputs once, passes the metrics through the processors and
+// aggregators, and prints the result to stdout in line protocol.
 func (a *Agent) Test(ctx context.Context, waitDuration time.Duration) error {
 	var wg sync.WaitGroup
-	metricC := make(chan telegraf.Metric)
-	nulC := make(chan telegraf.Metric)
-	defer func() {
-		close(metricC)
-		close(nulC)
-		wg.Wait()
-	}()
+	outputC := make(chan telegraf.Metric, 100)
 
 	wg.Add(1)
 	go func() {
@@ -158,7 +462,7 @@ func (a *Agent) Test(ctx context.Context, waitDuration time.Duration) error {
 
 		s := influx.NewSerializer()
 		s.SetFieldSortOrder(influx.SortFields)
-		for metric := range metricC {
+		for metric := range outputC {
 			octets, err := s.Serialize(metric)
 			if err == nil {
 				fmt.Print("> ", string(octets))
@@ -167,6 +471,164 @@ func (a *Agent) Test(ctx context.Context, waitDuration time.Duration) error {
 		}
 	}()
 
+	err := a.initPipeline()
+	if err != nil {
+		close(outputC)
+		wg.Wait()
+		return err
+	}
+
+	a.logProcessorStages()
+
+	err = a.runOnce(ctx, waitDuration, outputC)
+	wg.Wait()
+	return err
+}
+
+// logProcessorStages logs the processors applied to the metrics of the
+// inputs and to the aggregations, in the order they are applied.
+func (a *Agent) logProcessorStages() {
+	var pre, post []string
+	for _, processor := range a.Config.Processors {
+		name := processor.Config.Name
+		if processor.Config.Alias != "" {
+			name += "::" + processor.Config.Alias
+		}
+		if processor.IsPreAggregation() {
+			pre = append(pre, name)
+		}
+		if processor.IsPostAggregation() {
+			post = append(post, name)
+		}
+	}
+	log.Printf("I! [agent] Pre-aggregation processors: %s", strings.Join(pre, " "))
+	log.Printf("I! [agent] Post-aggregation processors: %s", strings.Join(post, " "))
+}
+
+// Once runs a single gather cycle through the processors and aggregators and
+// writes the result to the outputs.  An error is returned if any output
+// failed to write.
+func (a *Agent) Once(ctx context.Context, waitDuration time.Duration) error {
+	log.Printf("D! [agent] Initializing plugins")
+	err := a.initPlugins()
+	if err != nil {
+		return err
+	}
+
+	log.Printf("D! [agent] Connecting outputs")
+	err = a.connectOutputs(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.closeOutputs()
+
+	var wg sync.WaitGroup
+	outputC := make(chan telegraf.Metric, 100)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		err := a.runOutputs(outputC)
+		if err != nil {
+			log.Printf("E! [agent] Error running outputs: %v", err)
+		}
+	}()
+
+	err = a.runOnce(ctx, waitDuration, outputC)
+	wg.Wait()
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+	for _, output := range a.Config.Outputs {
+		interval := a.Config.Agent.FlushInterval.Duration
+		if output.Config.FlushInterval != 0 {
+			interval = output.Config.FlushInterval
+		}
+
+		err := a.flushOnce(output, interval, output.Write)
+		if err != nil {
+			output.Log().Errorf("Error writing to output: %v", err)
+			failed = append(failed, output.LogName())
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to write to outputs: %s", strings.Join(failed, " "))
+	}
+	return nil
+}
+
+// runOnce gathers each input a single time and sends the metrics through the
+// processors and aggregators to dst.  Service inputs are given waitDuration
+// to produce metrics.  The aggregators are pushed after all inputs are
+// stopped.
+//
+// dst is closed once all metrics have been processed.
+func (a *Agent) runOnce(
+	ctx context.Context,
+	waitDuration time.Duration,
+	dst chan<- telegraf.Metric,
+) error {
+	p := newPipeline(ctx)
+
+	err := a.startProcessors(p)
+	if err != nil {
+		close(dst)
+		return err
+	}
+
+	startTime := time.Now()
+	for _, agg := range a.Config.Aggregators {
+		a.startAggregator(p, agg, startTime)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		err := a.runAggregators(p, p.procC, dst)
+		if err != nil {
+			log.Printf("E! [agent] Error running aggregators: %v", err)
+		}
+		close(dst)
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		err := a.runProcessors(p, p.inputC, p.procC)
+		if err != nil {
+			log.Printf("E! [agent] Error running processors: %v", err)
+		}
+		close(p.procC)
+	}()
+
+	err = a.gatherOnceAll(ctx, waitDuration, p.inputC)
+
+	close(p.inputC)
+	wg.Wait()
+	return err
+}
+
+// gatherOnceAll runs Gather on every input and waits for service inputs to
+// produce metrics.
+func (a *Agent) gatherOnceAll(
+	ctx context.Context,
+	waitDuration time.Duration,
+	dst chan<- telegraf.Metric,
+) error {
+	var wg sync.WaitGroup
+	nulC := make(chan telegraf.Metric)
+	defer func() {
+		close(nulC)
+		wg.Wait()
+	}()
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -184,26 +646,18 @@ func (a *Agent) Test(ctx context.Context, waitDuration time.Duration) error {
 
 	if hasServiceInputs {
 		log.Printf("D! [agent] Starting service inputs")
-		err := a.startServiceInputs(ctx, metricC)
+		err := a.startServiceInputs(ctx, dst)
 		if err != nil {
 			return err
 		}
 	}
 
 	for _, input := range a.Config.Inputs {
-		err := input.Init()
-		if err != nil {
-			return err
-		}
-
-		select {
-		case <-ctx.Done():
-			return nil
-		default:
+		if ctx.Err() != nil {
 			break
 		}
 
-		acc := NewAccumulator(input, metricC)
+		acc := NewAccumulator(input, dst)
 		acc.SetPrecision(a.Precision())
 
 		// Special instructions for some inputs. cpu, for example, needs to be
@@ -239,46 +693,80 @@ func (a *Agent) Test(ctx context.Context, waitDuration time.Duration) error {
 
 // runInputs starts and triggers the periodic gather for Inputs.
 //
-// When the context is done the timers are stopped and this function returns
-// after all ongoing Gather calls complete.
-func (a *Agent) runInputs(
-	ctx context.Context,
-	startTime time.Time,
-	dst chan<- telegraf.Metric,
-) error {
-	var wg sync.WaitGroup
+// Each input runs until it is stopped with stopInput or the context of the
+// pipeline is done.
+func (a *Agent) runInputs(p *pipeline, startTime time.Time) {
 	for _, input := range a.Config.Inputs {
-		interval := a.Config.Agent.Interval.Duration
-		jitter := a.Config.Agent.CollectionJitter.Duration
+		p.inputs[input] = a.gatherUnit(p, input, startTime)
+	}
+}
 
-		// Overwrite agent interval if this plugin has its own.
-		if input.Config.Interval != 0 {
-			interval = input.Config.Interval
-		}
+// gatherUnit starts the periodic gather of a single input.
+func (a *Agent) gatherUnit(
+	p *pipeline,
+	input *models.RunningInput,
+	startTime time.Time,
+) *unit {
+	interval := a.Config.Agent.Interval.Duration
+	jitter := a.Config.Agent.CollectionJitter.Duration
 
-		acc := NewAccumulator(input, dst)
-		acc.SetPrecision(a.Precision())
+	// Overwrite agent interval if this plugin has its own.
+	if input.Config.Interval != 0 {
+		interval = input.Config.Interval
+	}
 
-		wg.Add(1)
-		go func(input *models.RunningInput) {
-			defer wg.Done()
+	acc := NewAccumulator(input, p.inputC)
+	acc.SetPrecision(a.Precision())
 
-			if a.Config.Agent.RoundInterval {
-				err := internal.SleepContext(
-					ctx, internal.AlignDuration(startTime, interval))
-				if err != nil {
-					return
-				}
+	input.Status().SetRunning(true)
+	return newUnit(p.ctx, func(ctx context.Context) {
+		if a.Config.Agent.RoundInterval {
+			err := internal.SleepContext(
+				ctx, internal.AlignDuration(startTime, interval))
+			if err != nil {
+				return
 			}
+		}
+
+		a.gatherOnInterval(ctx, acc, input, interval, jitter)
+	})
+}
+
+// startInput starts an input added to the running agent.
+func (a *Agent) startInput(
+	p *pipeline,
+	input *models.RunningInput,
+	startTime time.Time,
+) error {
+	if si, ok := input.Input.(telegraf.ServiceInput); ok {
+		acc := NewAccumulator(input, p.inputC)
+		acc.SetPrecision(time.Nanosecond)
 
-			a.gatherOnInterval(ctx, acc, input, interval, jitter)
-		}(input)
+		err := si.Start(acc)
+		if err != nil {
+			input.Status().Failure(err)
+			return err
+		}
 	}
-	wg.Wait()
 
+	p.inputs[input] = a.gatherUnit(p, input, startTime)
 	return nil
 }
 
+// stopInput stops an input of the running agent, returning after any ongoing
+// Gather call completes.
+func (a *Agent) stopInput(p *pipeline, input *models.RunningInput) {
+	if u, ok := p.inputs[input]; ok {
+		u.stop()
+		delete(p.inputs, input)
+	}
+	input.Status().SetRunning(false)
+
+	if si, ok := input.Input.(telegraf.ServiceInput); ok {
+		si.Stop()
+	}
+}
+
 // gather runs an input's gather function periodically until the context is
 // done.
 func (a *Agent) gatherOnInterval(
@@ -334,13 +822,17 @@ func (a *Agent) gatherOnce(
 			return err
 		case <-ticker.C:
 			log.Printf("W! [agent] input %q did not complete within its interval",
-				input.Name())
+				input.LogName())
 		}
 	}
 }
 
 // runProcessors applies processors to metrics.
+//
+// Runs until src is closed and all metrics have been processed, including
+// the metrics emitted by streaming processors.
 func (a *Agent) runProcessors(
+	p *pipeline,
 	src <-chan telegraf.Metric,
 	agg chan<- telegraf.Metric,
 ) error {
@@ -352,19 +844,170 @@ func (a *Agent) runProcessors(
 		}
 	}
 
+	a.stopProcessors(p)
 	return nil
 }
 
-// applyProcessors applies all processors to a metric.
+// applyProcessors applies the pre-aggregation processors to a metric.
 func (a *Agent) applyProcessors(m telegraf.Metric) []telegraf.Metric {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	metrics := []telegraf.Metric{m}
+	for _, processor := range a.Config.Processors {
+		if !processor.IsPreAggregation() {
+			continue
+		}
+		metrics = processor.Apply(metrics...)
+	}
+
+	return metrics
+}
+
+// applyProcessorsAfter applies the pre-aggregation processors that follow the
+// given processor to a metric emitted by it.  If the processor has been removed
+// the metric is returned unprocessed.
+func (a *Agent) applyProcessorsAfter(
+	rp *models.RunningProcessor,
+	m telegraf.Metric,
+) []telegraf.Metric {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	metrics := []telegraf.Metric{m}
+	for i, processor := range a.Config.Processors {
+		if processor != rp {
+			continue
+		}
+		for _, processor := range a.Config.Processors[i+1:] {
+			if !processor.IsPreAggregation() {
+				continue
+			}
+			metrics = processor.Apply(metrics...)
+		}
+		break
+	}
+
+	return metrics
+}
+
+// applyAggProcessors applies the post-aggregation processors to an
+// aggregation.
+func (a *Agent) applyAggProcessors(m telegraf.Metric) []telegraf.Metric {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
 	metrics := []telegraf.Metric{m}
 	for _, processor := range a.Config.Processors {
+		if !processor.IsPostAggregation() {
+			continue
+		}
 		metrics = processor.Apply(metrics...)
 	}
 
 	return metrics
 }
 
+// startProcessors starts all streaming processors.  If one fails to start
+// the processors already started are stopped.
+func (a *Agent) startProcessors(p *pipeline) error {
+	for _, processor := range a.Config.Processors {
+		err := a.startProcessor(p, processor)
+		if err != nil {
+			a.stopProcessors(p)
+			return fmt.Errorf("could not start processor %s: %v",
+				processor.Config.Name, err)
+		}
+	}
+	return nil
+}
+
+// startProcessor starts a streaming processor and forwards the metrics it
+// emits to the processors that follow it.  Other processors are ignored.
+func (a *Agent) startProcessor(p *pipeline, processor *models.RunningProcessor) error {
+	if !processor.IsStreaming() {
+		processor.Status().SetRunning(true)
+		return nil
+	}
+
+	s := &stream{
+		metricC: make(chan telegraf.Metric, 100),
+		done:    make(chan struct{}),
+	}
+
+	acc := NewAccumulator(processorMaker{processor}, s.metricC)
+	acc.SetPrecision(a.Precision())
+
+	err := processor.Start(acc)
+	if err != nil {
+		processor.Status().Failure(err)
+		return err
+	}
+	processor.Status().SetRunning(true)
+
+	go func() {
+		defer close(s.done)
+		for metric := range s.metricC {
+			metrics := a.applyProcessorsAfter(processor, metric)
+			for _, metric := range metrics {
+				p.procC <- metric
+			}
+		}
+	}()
+
+	a.mu.Lock()
+	p.processors[processor] = s
+	a.mu.Unlock()
+	return nil
+}
+
+// stopProcessor stops a streaming processor and waits until the metrics it
+// emitted have been forwarded.
+func (a *Agent) stopProcessor(p *pipeline, processor *models.RunningProcessor) {
+	a.mu.Lock()
+	s, ok := p.processors[processor]
+	delete(p.processors, processor)
+	a.mu.Unlock()
+
+	processor.Status().SetRunning(false)
+
+	if ok {
+		processor.Stop()
+		close(s.metricC)
+		<-s.done
+	}
+}
+
+// stopProcessors stops the streaming processors in order, so that the
+// metrics emitted by a processor can still pass through the streaming
+// processors that follow it.
+func (a *Agent) stopProcessors(p *pipeline) {
+	a.mu.RLock()
+	processors := make([]*models.RunningProcessor, 0, len(p.processors))
+	for _, processor := range a.Config.Processors {
+		if _, ok := p.processors[processor]; ok {
+			processors = append(processors, processor)
+		}
+	}
+	a.mu.RUnlock()
+
+	for _, processor := range processors {
+		a.stopProcessor(p, processor)
+	}
+
+	// Processors that were removed from the configuration.
+	a.mu.RLock()
+	processors = processors[:0]
+	for processor := range p.processors {
+		processors = append(processors, processor)
+	}
+	a.mu.RUnlock()
+
+	for _, processor := range processors {
+		a.stopProcessor(p, processor)
+	}
+}
+
 func updateWindow(start time.Time, roundInterval bool, period time.Duration) (time.Time, time.Time) {
 	var until time.Time
 	if roundInterval {
@@ -381,78 +1024,90 @@ func updateWindow(start time.Time, roundInterval bool, period time.Duration) (ti
 	return since, until
 }
 
-// runAggregators adds metrics to the aggregators and triggers their periodic
-// push call.
+// runAggregators adds metrics to the aggregators and applies the processors
+// to the aggregations produced by their periodic push.
 //
 // Runs until src is closed and all metrics have been processed.  Will call
 // push one final time before returning.
 func (a *Agent) runAggregators(
-	startTime time.Time,
+	p *pipeline,
 	src <-chan telegraf.Metric,
 	dst chan<- telegraf.Metric,
 ) error {
-	ctx, cancel := context.WithCancel(context.Background())
-
-	// Before calling Add, initialize the aggregation window.  This ensures
-	// that any metric created after start time will be aggregated.
-	for _, agg := range a.Config.Aggregators {
-		since, until := updateWindow(startTime, a.Config.Agent.RoundInterval, agg.Period())
-		agg.UpdateWindow(since, until)
-	}
-
-	var wg sync.WaitGroup
-	wg.Add(1)
+	done := make(chan struct{})
 	go func() {
-		defer wg.Done()
-		for metric := range src {
-			var dropOriginal bool
-			for _, agg := range a.Config.Aggregators {
-				if ok := agg.Add(metric); ok {
-					dropOriginal = true
-				}
-			}
-
-			if !dropOriginal {
+		defer close(done)
+		for metric := range p.aggC {
+			metrics := a.applyAggProcessors(metric)
+			for _, metric := range metrics {
 				dst <- metric
-			} else {
-				metric.Drop()
 			}
 		}
-		cancel()
 	}()
 
-	aggregations := make(chan telegraf.Metric, 100)
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-
-		var aggWg sync.WaitGroup
+	for metric := range src {
+		var dropOriginal bool
+		a.mu.RLock()
 		for _, agg := range a.Config.Aggregators {
-			aggWg.Add(1)
-			go func(agg *models.RunningAggregator) {
-				defer aggWg.Done()
-
-				acc := NewAccumulator(agg, aggregations)
-				acc.SetPrecision(a.Precision())
-				a.push(ctx, agg, acc)
-			}(agg)
+			if ok := agg.Add(metric); ok {
+				dropOriginal = true
+			}
 		}
+		a.mu.RUnlock()
 
-		aggWg.Wait()
-		close(aggregations)
-	}()
-
-	for metric := range aggregations {
-		metrics := a.applyProcessors(metric)
-		for _, metric := range metrics {
+		if !dropOriginal {
 			dst <- metric
+		} else {
+			metric.Drop()
 		}
 	}
 
-	wg.Wait()
+	for agg := range p.aggregators {
+		a.stopAggregator(p, agg)
+	}
+	close(p.aggC)
+	<-done
+
 	return nil
 }
 
+// startAggregator initializes the aggregation window of an aggregator and
+// starts its periodic push.
+func (a *Agent) startAggregator(
+	p *pipeline,
+	agg *models.RunningAggregator,
+	startTime time.Time,
+) {
+	since, until := updateWindow(startTime, a.Config.Agent.RoundInterval, agg.Period())
+	agg.UpdateWindow(since, until)
+
+	acc := NewAccumulator(agg, p.aggC)
+	acc.SetPrecision(a.Precision())
+
+	u := newUnit(context.Background(), func(ctx context.Context) {
+		a.push(ctx, agg, acc)
+	})
+
+	a.mu.Lock()
+	p.aggregators[agg] = u
+	a.mu.Unlock()
+	agg.Status().SetRunning(true)
+}
+
+// stopAggregator stops the periodic push of an aggregator after a final push.
+func (a *Agent) stopAggregator(p *pipeline, agg *models.RunningAggregator) {
+	a.mu.Lock()
+	u, ok := p.aggregators[agg]
+	delete(p.aggregators, agg)
+	a.mu.Unlock()
+
+	agg.Status().SetRunning(false)
+
+	if ok {
+		u.stop()
+	}
+}
+
 // push runs the push for a single aggregator every period.
 func (a *Agent) push(
 	ctx context.Context,
@@ -477,45 +1132,16 @@ func (a *Agent) push(
 	}
 }
 
-// runOutputs triggers the periodic write for Outputs.
+// runOutputs adds metrics to the Outputs.
 //
-
-// Runs until src is closed and all metrics have been processed.  Will call
-// Write one final time before returning.
-func (a *Agent) runOutputs(
-	startTime time.Time,
-	src <-chan telegraf.Metric,
-) error {
-	interval := a.Config.Agent.FlushInterval.Duration
-	jitter := a.Config.Agent.FlushJitter.Duration
-
-	ctx, cancel := context.WithCancel(context.Background())
-
-	var wg sync.WaitGroup
-	for _, output := range a.Config.Outputs {
-		interval := interval
-		// Overwrite agent flush_interval if this plugin has its own.
-		if output.Config.FlushInterval != 0 {
-			interval = output.Config.FlushInterval
-		}
-
-		wg.Add(1)
-		go func(output *models.RunningOutput) {
-			defer wg.Done()
-
-			if a.Config.Agent.RoundInterval {
-				err := internal.SleepContext(
-					ctx, internal.AlignDuration(startTime, interval))
-				if err != nil {
-					return
-				}
-			}
-
-			a.flush(ctx, output, interval, jitter)
-		}(output)
-	}
-
+// Runs until src is closed and all metrics have been processed.  The periodic
+// writes are triggered by the output units started with startOutput.
+func (a *Agent) runOutputs(src <-chan telegraf.Metric) error {
 	for metric := range src {
+		a.mu.RLock()
+		if len(a.Config.Outputs) == 0 {
+			metric.Drop()
+		}
 		for i, output := range a.Config.Outputs {
 			if i == len(a.Config.Outputs)-1 {
 				output.AddMetric(metric)
@@ -523,15 +1149,47 @@ func (a *Agent) runOutputs(
 				output.AddMetric(metric.Copy())
 			}
 		}
+		a.mu.RUnlock()
 	}
 
-	log.Println("I! [agent] Hang on, flushing any cached metrics before shutdown")
-	cancel()
-	wg.Wait()
-
 	return nil
 }
 
+// startOutput starts the periodic write of an output.
+func (a *Agent) startOutput(
+	p *pipeline,
+	output *models.RunningOutput,
+	startTime time.Time,
+) {
+	interval := a.Config.Agent.FlushInterval.Duration
+	jitter := a.Config.Agent.FlushJitter.Duration
+
+	// Overwrite agent flush_interval if this plugin has its own.
+	if output.Config.FlushInterval != 0 {
+		interval = output.Config.FlushInterval
+	}
+
+	p.outputs[output] = newUnit(context.Background(), func(ctx context.Context) {
+		if a.Config.Agent.RoundInterval {
+			err := internal.SleepContext(
+				ctx, internal.AlignDuration(startTime, interval))
+			if err != nil {
+				return
+			}
+		}
+
+		a.flush(ctx, output, interval, jitter)
+	})
+}
+
+// stopOutput stops the periodic write of an output after a final write.
+func (a *Agent) stopOutput(p *pipeline, output *models.RunningOutput) {
+	if u, ok := p.outputs[output]; ok {
+		u.stop()
+		delete(p.outputs, output)
+	}
+}
+
 // flush runs an output's flush function periodically until the context is
 // done.
 func (a *Agent) flush(
@@ -546,8 +1204,12 @@ func (a *Agent) flush(
 	defer ticker.Stop()
 
 	logError := func(err error) {
-		if err != nil {
-			log.Printf("E! [agent] Error writing to output [%s]: %v", output.Name, err)
+		switch err {
+		case nil:
+		case models.ErrRetryBackoff, models.ErrCircuitOpen:
+			output.Log().Debugf("Skipped writing to output: %v", err)
+		default:
+			output.Log().Errorf("Error writing to output: %v", err)
 		}
 	}
 
@@ -555,7 +1217,7 @@ func (a *Agent) flush(
 		// Favor shutdown over other methods.
 		select {
 		case <-ctx.Done():
-			logError(a.flushOnce(output, interval, output.Write))
+			logError(a.flushOnce(output, interval, output.WriteFinal))
 			return
 		default:
 		}
@@ -572,7 +1234,7 @@ func (a *Agent) flush(
 				logError(a.flushOnce(output, interval, output.WriteBatch))
 			}
 		case <-ctx.Done():
-			logError(a.flushOnce(output, interval, output.Write))
+			logError(a.flushOnce(output, interval, output.WriteFinal))
 			return
 		}
 	}
@@ -600,7 +1262,7 @@ func (a *Agent) flushOnce(
 			return err
 		case <-ticker.C:
 			log.Printf("W! [agent] output %q did not complete within its flush interval",
-				output.Name)
+				output.LogName())
 			output.LogBufferStatus()
 		}
 	}
@@ -609,6 +1271,28 @@ func (a *Agent) flushOnce(
 
 // initPlugins runs the Init function on plugins.
 func (a *Agent) initPlugins() error {
+	err := a.initPipeline()
+	if err != nil {
+		return err
+	}
+	for _, output := range a.Config.Outputs {
+		err := output.Init()
+		if err != nil {
+			return fmt.Errorf("could not initialize output %s: %v",
+				output.Config.Name, err)
+		}
+	}
+	return nil
+}
+
+// initPipeline runs the Init function on the secret stores, inputs,
+// processors and aggregators.
+func (a *Agent) initPipeline() error {
+	err := a.Config.InitSecretStores()
+	if err != nil {
+		return err
+	}
+
 	for _, input := range a.Config.Inputs {
 		err := input.Init()
 		if err != nil {
@@ -630,13 +1314,6 @@ func (a *Agent) initPlugins() error {
 				aggregator.Config.Name, err)
 		}
 	}
-	for _, output := range a.Config.Outputs {
-		err := output.Init()
-		if err != nil {
-			return fmt.Errorf("could not initialize output %s: %v",
-				output.Config.Name, err)
-		}
-	}
 	return nil
 }
 
@@ -644,7 +1321,7 @@ func (a *Agent) initPlugins() error {
 func (a *Agent) connectOutputs(ctx context.Context) error {
 	for _, output := range a.Config.Outputs {
 		log.Printf("D! [agent] Attempting connection to output: %s\n", output.Name)
-		err := output.Output.Connect()
+		err := output.Connect()
 		if err != nil {
 			log.Printf("E! [agent] Failed to connect to output %s, retrying in 15s, "+
 				"error was '%s' \n", output.Name, err)
@@ -654,7 +1331,7 @@ func (a *Agent) connectOutputs(ctx context.Context) error {
 				return err
 			}
 
-			err = output.Output.Connect()
+			err = output.Connect()
 			if err != nil {
 				return err
 			}
@@ -691,6 +1368,7 @@ func (a *Agent) startServiceInputs(
 			if err != nil {
 				log.Printf("E! [agent] Service for input %s failed to start: %v",
 					input.Name(), err)
+				input.Status().Failure(err)
 
 				for _, si := range started {
 					si.Stop()
@@ -742,7 +1420,7 @@ func panicRecover(input *models.RunningInput) {
 		trace := make([]byte, 2048)
 		runtime.Stack(trace, true)
 		log.Printf("E! FATAL: Input [%s] panicked: %s, Stack:\n%s\n",
-			input.Name(), err, trace)
+			input.LogName(), err, trace)
 		log.Println("E! PLEASE REPORT THIS PANIC ON GITHUB with " +
 			"stack trace, configuration, and OS information: " +
 			"https://github.com/influxdata/telegraf/issues/new/choose")
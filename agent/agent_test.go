@@ -1,16 +1,287 @@
 package agent
 
 import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/models"
 	_ "github.com/influxdata/telegraf/plugins/inputs/all"
 	_ "github.com/influxdata/telegraf/plugins/outputs/all"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+type onceInput struct{}
+
+func (i *onceInput) SampleConfig() string { return "" }
+func (i *onceInput) Description() string  { return "" }
+func (i *onceInput) Gather(acc telegraf.Accumulator) error {
+	acc.AddFields("cpu", map[string]interface{}{"value": 42}, nil)
+	return nil
+}
+
+type onceProcessor struct{}
+
+func (p *onceProcessor) SampleConfig() string { return "" }
+func (p *onceProcessor) Description() string  { return "" }
+func (p *onceProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	for _, m := range in {
+		m.AddTag("processed", "true")
+	}
+	return in
+}
+
+// streamProcessor holds the metrics passed to Apply and emits them on Stop.
+type streamProcessor struct {
+	acc     telegraf.Accumulator
+	metrics []telegraf.Metric
+}
+
+func (p *streamProcessor) SampleConfig() string { return "" }
+func (p *streamProcessor) Description() string  { return "" }
+func (p *streamProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	p.metrics = append(p.metrics, in...)
+	return nil
+}
+func (p *streamProcessor) Start(acc telegraf.Accumulator) error {
+	p.acc = acc
+	return nil
+}
+func (p *streamProcessor) Stop() {
+	for _, m := range p.metrics {
+		m.AddTag("streamed", "true")
+		p.acc.AddMetric(m)
+	}
+}
+
+type onceOutput struct {
+	sync.Mutex
+	metrics []telegraf.Metric
+	err     error
+}
+
+func (o *onceOutput) SampleConfig() string { return "" }
+func (o *onceOutput) Description() string  { return "" }
+func (o *onceOutput) Connect() error       { return nil }
+func (o *onceOutput) Close() error         { return nil }
+func (o *onceOutput) Write(metrics []telegraf.Metric) error {
+	o.Lock()
+	defer o.Unlock()
+	if o.err != nil {
+		return o.err
+	}
+	o.metrics = append(o.metrics, metrics...)
+	return nil
+}
+
+func newOnceAgent(t *testing.T, output *onceOutput) *Agent {
+	c := config.NewConfig()
+	c.Agent.Hostname = "localhost"
+	c.Inputs = append(c.Inputs, models.NewRunningInput(&onceInput{},
+		&models.InputConfig{Name: "once"}))
+	c.Processors = append(c.Processors, models.NewRunningProcessor(
+		&onceProcessor{}, &models.ProcessorConfig{Name: "once"}))
+	c.Outputs = append(c.Outputs, models.NewRunningOutput("once", output,
+		&models.OutputConfig{Name: "once"}, 0, 0))
+
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+	return a
+}
+
+func TestAgent_Once(t *testing.T) {
+	output := &onceOutput{}
+	a := newOnceAgent(t, output)
+
+	err := a.Once(context.Background(), 0)
+	require.NoError(t, err)
+
+	require.Len(t, output.metrics, 1)
+	m := output.metrics[0]
+	require.Equal(t, "cpu", m.Name())
+	require.Equal(t, map[string]string{"processed": "true"}, m.Tags())
+}
+
+func TestAgent_OnceStreamingProcessor(t *testing.T) {
+	output := &onceOutput{}
+	a := newOnceAgent(t, output)
+	a.Config.Processors = append(models.RunningProcessors{
+		models.NewRunningProcessor(&streamProcessor{},
+			&models.ProcessorConfig{Name: "stream"}),
+	}, a.Config.Processors...)
+
+	err := a.Once(context.Background(), 0)
+	require.NoError(t, err)
+
+	// Metrics emitted by a streaming processor pass through the processors
+	// that follow it.
+	require.Len(t, output.metrics, 1)
+	m := output.metrics[0]
+	require.Equal(t, "cpu", m.Name())
+	require.Equal(t, map[string]string{"processed": "true", "streamed": "true"}, m.Tags())
+}
+
+// prefixProcessor prefixes the measurement name, it is not idempotent.
+type prefixProcessor struct {
+	prefix string
+}
+
+func (p *prefixProcessor) SampleConfig() string { return "" }
+func (p *prefixProcessor) Description() string  { return "" }
+func (p *prefixProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	for _, m := range in {
+		m.SetName(p.prefix + m.Name())
+	}
+	return in
+}
+
+// countAggregator counts the metrics added to it.
+type countAggregator struct {
+	count int
+}
+
+func (a *countAggregator) SampleConfig() string { return "" }
+func (a *countAggregator) Description() string  { return "" }
+func (a *countAggregator) Add(in telegraf.Metric) {
+	a.count++
+}
+func (a *countAggregator) Push(acc telegraf.Accumulator) {
+	acc.AddFields("count", map[string]interface{}{"value": a.count}, nil)
+}
+func (a *countAggregator) Reset() {
+	a.count = 0
+}
+
+func TestAgent_OnceProcessorStage(t *testing.T) {
+	output := &onceOutput{}
+	a := newOnceAgent(t, output)
+	a.Config.Processors = models.RunningProcessors{
+		models.NewRunningProcessor(&prefixProcessor{prefix: "pre_"},
+			&models.ProcessorConfig{Name: "pre", Stage: models.ProcessorStagePre}),
+		models.NewRunningProcessor(&prefixProcessor{prefix: "post_"},
+			&models.ProcessorConfig{Name: "post", Stage: models.ProcessorStagePost}),
+		models.NewRunningProcessor(&prefixProcessor{prefix: "both_"},
+			&models.ProcessorConfig{Name: "both"}),
+	}
+	a.Config.Aggregators = append(a.Config.Aggregators,
+		models.NewRunningAggregator(&countAggregator{}, &models.AggregatorConfig{
+			Name:   "count",
+			Period: time.Hour,
+		}))
+
+	err := a.Once(context.Background(), 0)
+	require.NoError(t, err)
+
+	var names []string
+	for _, m := range output.metrics {
+		names = append(names, m.Name())
+	}
+	sort.Strings(names)
+	require.Equal(t, []string{"both_post_count", "both_pre_cpu"}, names)
+}
+
+func TestAgent_OnceWriteError(t *testing.T) {
+	output := &onceOutput{err: errors.New("write failed")}
+	a := newOnceAgent(t, output)
+
+	err := a.Once(context.Background(), 0)
+	require.Error(t, err)
+}
+
+// countInput adds a metric with the next number of a shared counter on each
+// gather.
+type countInput struct {
+	n *int64
+}
+
+func (i *countInput) SampleConfig() string { return "" }
+func (i *countInput) Description() string  { return "" }
+func (i *countInput) Gather(acc telegraf.Accumulator) error {
+	n := atomic.AddInt64(i.n, 1) - 1
+	acc.AddFields("count", map[string]interface{}{"n": n}, nil)
+	return nil
+}
+
+// slowOutput takes a while to connect.
+type slowOutput struct {
+	onceOutput
+}
+
+func (o *slowOutput) Connect() error {
+	time.Sleep(100 * time.Millisecond)
+	return nil
+}
+
+func TestAgent_ReloadDiskBufferHandover(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-buffer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var n int64
+	newConfig := func(output telegraf.Output) *config.Config {
+		c := config.NewConfig()
+		c.Agent.Hostname = "localhost"
+		c.Agent.Interval.Duration = time.Millisecond
+		c.Agent.FlushInterval.Duration = 10 * time.Millisecond
+		c.Agent.RoundInterval = false
+		c.Inputs = append(c.Inputs, models.NewRunningInput(
+			&countInput{n: &n}, &models.InputConfig{Name: "count"}))
+		c.Outputs = append(c.Outputs, models.NewRunningOutput("once", output,
+			&models.OutputConfig{
+				Name:            "once",
+				BufferStrategy:  "disk",
+				BufferDirectory: dir,
+			}, 0, 0))
+		return c
+	}
+
+	oldOutput := &onceOutput{}
+	a, err := NewAgent(newConfig(oldOutput))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- a.Run(ctx)
+	}()
+
+	// The input keeps adding metrics while the new output connects.
+	newOutput := &slowOutput{}
+	for {
+		_, err = a.Reload(newConfig(newOutput))
+		if err == nil || err.Error() != "agent is not running" {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+
+	written := make(map[int64]int)
+	for _, m := range append(oldOutput.metrics, newOutput.metrics...) {
+		v, ok := m.GetField("n")
+		require.True(t, ok)
+		written[v.(int64)]++
+	}
+	require.NotEmpty(t, oldOutput.metrics)
+	require.NotEmpty(t, newOutput.metrics)
+	for i := int64(0); i < atomic.LoadInt64(&n); i++ {
+		require.Equal(t, 1, written[i], "metric %d", i)
+	}
+}
+
 func TestAgent_OmitHostname(t *testing.T) {
 	c := config.NewConfig()
 	c.Agent.OmitHostname = true
@@ -0,0 +1,165 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/influxdata/telegraf/internal/models"
+)
+
+// AgentStatus is the status of the agent and its plugins served by the
+// health server.
+type AgentStatus struct {
+	Ready       bool                  `json:"ready"`
+	Reasons     []string              `json:"reasons,omitempty"`
+	Inputs      []models.PluginStatus `json:"inputs"`
+	Processors  []models.PluginStatus `json:"processors"`
+	Aggregators []models.PluginStatus `json:"aggregators"`
+	Outputs     []models.PluginStatus `json:"outputs"`
+}
+
+// healthServer serves the health of the agent over HTTP.  /health/live
+// responds with 200 as long as the agent is running.  /health/ready responds
+// with 200 if all plugins are running and none has failed maxFailures times in
+// a row, and 503 otherwise.  /status responds with the AgentStatus.
+type healthServer struct {
+	agent       *Agent
+	maxFailures int64
+
+	server   *http.Server
+	listener net.Listener
+	done     chan struct{}
+}
+
+func newHealthServer(a *Agent) *healthServer {
+	h := &healthServer{
+		agent:       a,
+		maxFailures: int64(a.Config.Agent.HealthMaxFailures),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health/live", h.serveLive)
+	mux.HandleFunc("/health/ready", h.serveReady)
+	mux.HandleFunc("/status", h.serveStatus)
+	h.server = &http.Server{
+		Handler:      mux,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+	return h
+}
+
+// Start listens on the address and serves requests in the background.
+func (h *healthServer) Start(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("could not start health server: %v", err)
+	}
+	h.listener = listener
+	h.done = make(chan struct{})
+
+	go func() {
+		defer close(h.done)
+		err := h.server.Serve(listener)
+		if err != http.ErrServerClosed {
+			log.Printf("E! [agent] Health server stopped: %v", err)
+		}
+	}()
+
+	log.Printf("I! [agent] Health server listening on %s", listener.Addr())
+	return nil
+}
+
+// Stop stops the server, waiting for active requests to complete.
+func (h *healthServer) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := h.server.Shutdown(ctx)
+	if err != nil {
+		log.Printf("E! [agent] Error stopping health server: %v", err)
+	}
+	<-h.done
+}
+
+// Status returns the status of the agent and its plugins.
+func (h *healthServer) Status() *AgentStatus {
+	a := h.agent
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	s := &AgentStatus{
+		Inputs:      []models.PluginStatus{},
+		Processors:  []models.PluginStatus{},
+		Aggregators: []models.PluginStatus{},
+		Outputs:     []models.PluginStatus{},
+	}
+	for _, input := range a.Config.Inputs {
+		s.Inputs = append(s.Inputs, input.PluginStatus())
+	}
+	for _, processor := range a.Config.Processors {
+		s.Processors = append(s.Processors, processor.PluginStatus())
+	}
+	for _, agg := range a.Config.Aggregators {
+		s.Aggregators = append(s.Aggregators, agg.PluginStatus())
+	}
+	for _, output := range a.Config.Outputs {
+		s.Outputs = append(s.Outputs, output.PluginStatus())
+	}
+
+	check := func(kind string, plugins []models.PluginStatus) {
+		for _, ps := range plugins {
+			name := kind + "." + ps.Name
+			if ps.Alias != "" {
+				name += "::" + ps.Alias
+			}
+			if !ps.Running {
+				s.Reasons = append(s.Reasons, name+" is not running")
+			} else if h.maxFailures > 0 && ps.ConsecutiveFailures >= h.maxFailures {
+				s.Reasons = append(s.Reasons, fmt.Sprintf("%s failed %d times in a row",
+					name, ps.ConsecutiveFailures))
+			}
+		}
+	}
+	check("inputs", s.Inputs)
+	check("processors", s.Processors)
+	check("aggregators", s.Aggregators)
+	check("outputs", s.Outputs)
+	s.Ready = len(s.Reasons) == 0
+
+	return s
+}
+
+func (h *healthServer) serveLive(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (h *healthServer) serveReady(w http.ResponseWriter, r *http.Request) {
+	s := h.Status()
+	if !s.Ready {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"status":  "not ready",
+			"reasons": s.Reasons,
+		})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (h *healthServer) serveStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.Status())
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		log.Printf("E! [agent] Error writing health response: %v", err)
+	}
+}
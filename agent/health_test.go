@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func getHealth(t *testing.T, h *healthServer, path string) (int, map[string]interface{}) {
+	rec := httptest.NewRecorder()
+	h.server.Handler.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	return rec.Code, body
+}
+
+func TestHealthServer(t *testing.T) {
+	output := &onceOutput{}
+	a := newOnceAgent(t, output)
+	a.Config.Agent.HealthMaxFailures = 2
+	h := newHealthServer(a)
+
+	code, _ := getHealth(t, h, "/health/live")
+	require.Equal(t, http.StatusOK, code)
+
+	// Nothing is running before the agent is started.
+	code, body := getHealth(t, h, "/health/ready")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, []interface{}{
+		"inputs.once is not running",
+		"processors.once is not running",
+		"outputs.once is not running",
+	}, body["reasons"])
+
+	input := a.Config.Inputs[0]
+	input.Status().SetRunning(true)
+	a.Config.Processors[0].Status().SetRunning(true)
+	require.NoError(t, a.Config.Outputs[0].Connect())
+
+	code, _ = getHealth(t, h, "/health/ready")
+	require.Equal(t, http.StatusOK, code)
+
+	input.Status().Failure(errors.New("connection refused"))
+	code, _ = getHealth(t, h, "/health/ready")
+	require.Equal(t, http.StatusOK, code)
+	input.Status().Failure(errors.New("connection refused"))
+	code, body = getHealth(t, h, "/health/ready")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, []interface{}{"inputs.once failed 2 times in a row"}, body["reasons"])
+
+	// The status is served regardless of readiness.
+	code, body = getHealth(t, h, "/status")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, false, body["ready"])
+	inputs := body["inputs"].([]interface{})
+	require.Len(t, inputs, 1)
+	status := inputs[0].(map[string]interface{})
+	require.Equal(t, "once", status["name"])
+	require.Equal(t, true, status["running"])
+	require.Equal(t, "connection refused", status["last_error"])
+	require.Equal(t, float64(2), status["consecutive_failures"])
+	outputs := body["outputs"].([]interface{})
+	require.Equal(t, float64(10000), outputs[0].(map[string]interface{})["buffer_limit"])
+	require.Equal(t, []interface{}{}, body["aggregators"])
+
+	input.Status().Success()
+	code, _ = getHealth(t, h, "/health/ready")
+	require.Equal(t, http.StatusOK, code)
+}
@@ -1,10 +1,12 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net/http"
 	_ "net/http/pprof" // Comment this line to disable pprof endpoint.
@@ -15,16 +17,21 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/agent"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/secret"
 	"github.com/influxdata/telegraf/logger"
 	_ "github.com/influxdata/telegraf/plugins/aggregators/all"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	_ "github.com/influxdata/telegraf/plugins/inputs/all"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	_ "github.com/influxdata/telegraf/plugins/outputs/all"
+	_ "github.com/influxdata/telegraf/plugins/parsers/all"
 	_ "github.com/influxdata/telegraf/plugins/processors/all"
+	_ "github.com/influxdata/telegraf/plugins/secretstores/all"
+	_ "github.com/influxdata/telegraf/plugins/serializers/all"
 	"github.com/kardianos/service"
 )
 
@@ -35,7 +42,8 @@ var pprofAddr = flag.String("pprof-addr", "",
 var fQuiet = flag.Bool("quiet", false,
 	"run in quiet mode")
 var fTest = flag.Bool("test", false, "enable test mode: gather metrics, print them out, and exit")
-var fTestWait = flag.Int("test-wait", 0, "wait up to this many seconds for service inputs to complete in test mode")
+var fTestWait = flag.Int("test-wait", 0, "wait up to this many seconds for service inputs to complete in test or once mode")
+var fOnce = flag.Bool("once", false, "run one gather and exit")
 var fConfig = flag.String("config", "", "configuration file to load")
 var fConfigDirectory = flag.String("config-directory", "",
 	"directory containing additional *.conf files")
@@ -87,70 +95,128 @@ func reloadLoop(
 
 		ctx, cancel := context.WithCancel(context.Background())
 
+		// restart stops the agent and starts it again with a newly loaded
+		// configuration.
+		restart := func() {
+			<-reload
+			reload <- true
+			cancel()
+		}
+
+		hup := make(chan struct{}, 1)
 		signals := make(chan os.Signal)
 		signal.Notify(signals, os.Interrupt, syscall.SIGHUP,
 			syscall.SIGTERM, syscall.SIGINT)
 		go func() {
-			select {
-			case sig := <-signals:
-				if sig == syscall.SIGHUP {
-					log.Printf("I! Reloading Telegraf config")
-					<-reload
-					reload <- true
+			for {
+				select {
+				case sig := <-signals:
+					if sig == syscall.SIGHUP {
+						log.Printf("I! Reloading Telegraf config")
+						select {
+						case hup <- struct{}{}:
+						default:
+						}
+						continue
+					}
+					cancel()
+				case <-stop:
+					cancel()
+				case <-ctx.Done():
 				}
-				cancel()
-			case <-stop:
-				cancel()
+				signal.Stop(signals)
+				return
 			}
 		}()
 
-		err := runAgent(ctx, inputFilters, outputFilters)
+		err := runAgent(ctx, inputFilters, outputFilters, hup, restart)
 		if err != nil && err != context.Canceled {
 			log.Fatalf("E! [telegraf] Error running agent: %v", err)
 		}
+		cancel()
 	}
 }
 
-func runAgent(ctx context.Context,
-	inputFilters []string,
-	outputFilters []string,
-) error {
-	// Setup default logging. This may need to change after reading the config
-	// file, but we can configure it to use our logger implementation now.
-	logger.SetupLogging(logger.LogConfig{})
-	log.Printf("I! Starting Telegraf %s", version)
-
-	// If no other options are specified, load the config file and run.
+// loadConfig loads the configuration files and checks that they describe a
+// runnable agent.
+func loadConfig(inputFilters, outputFilters []string) (*config.Config, error) {
 	c := config.NewConfig()
 	c.OutputFilters = outputFilters
 	c.InputFilters = inputFilters
 	err := c.LoadConfig(*fConfig)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if *fConfigDirectory != "" {
 		err = c.LoadDirectory(*fConfigDirectory)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 	if !*fTest && len(c.Outputs) == 0 {
-		return errors.New("Error: no outputs found, did you provide a valid config file?")
+		return nil, errors.New("Error: no outputs found, did you provide a valid config file?")
 	}
 	if len(c.Inputs) == 0 {
-		return errors.New("Error: no inputs found, did you provide a valid config file?")
+		return nil, errors.New("Error: no inputs found, did you provide a valid config file?")
 	}
 
 	if int64(c.Agent.Interval.Duration) <= 0 {
-		return fmt.Errorf("Agent interval must be positive, found %s",
+		return nil, fmt.Errorf("Agent interval must be positive, found %s",
 			c.Agent.Interval.Duration)
 	}
 
 	if int64(c.Agent.FlushInterval.Duration) <= 0 {
-		return fmt.Errorf("Agent flush_interval must be positive; found %s",
+		return nil, fmt.Errorf("Agent flush_interval must be positive; found %s",
 			c.Agent.Interval.Duration)
 	}
+	return c, nil
+}
+
+// reloadAgent applies a new configuration to the running agent.  Plugins are
+// added and removed in place, changes to the agent settings require a full
+// restart.
+func reloadAgent(
+	ag *agent.Agent,
+	inputFilters []string,
+	outputFilters []string,
+	restart func(),
+) {
+	c, err := loadConfig(inputFilters, outputFilters)
+	if err != nil {
+		log.Printf("E! [telegraf] Error loading config, keeping current config: %v", err)
+		return
+	}
+
+	diff, err := ag.Reload(c)
+	if err == agent.ErrRestartRequired {
+		log.Printf("I! [telegraf] Agent settings changed, restarting")
+		restart()
+		return
+	}
+	if err != nil {
+		log.Printf("E! [telegraf] Error reloading config, keeping current config: %v", err)
+		return
+	}
+	log.Printf("I! [telegraf] Reloaded config: %s", diff)
+}
+
+func runAgent(ctx context.Context,
+	inputFilters []string,
+	outputFilters []string,
+	hup <-chan struct{},
+	restart func(),
+) error {
+	// Setup default logging. This may need to change after reading the config
+	// file, but we can configure it to use our logger implementation now.
+	logger.SetupLogging(logger.LogConfig{})
+	log.Printf("I! Starting Telegraf %s", version)
+
+	// If no other options are specified, load the config file and run.
+	c, err := loadConfig(inputFilters, outputFilters)
+	if err != nil {
+		return err
+	}
 
 	ag, err := agent.NewAgent(c)
 	if err != nil {
@@ -169,6 +235,11 @@ func runAgent(ctx context.Context,
 
 	logger.SetupLogging(logConfig)
 
+	if *fOnce {
+		wait := time.Duration(*fTestWait) * time.Second
+		return ag.Once(ctx, wait)
+	}
+
 	if *fTest || *fTestWait != 0 {
 		testWaitDuration := time.Duration(*fTestWait) * time.Second
 		return ag.Test(ctx, testWaitDuration)
@@ -198,9 +269,63 @@ func runAgent(ctx context.Context,
 		}
 	}
 
+	go func() {
+		for {
+			select {
+			case <-hup:
+				reloadAgent(ag, inputFilters, outputFilters, restart)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
 	return ag.Run(ctx)
 }
 
+// setSecret stores a secret read from stdin in a secret store of the
+// configuration.  The arguments are "set", the id of the store and the key.
+func setSecret(args []string) error {
+	if len(args) != 3 || args[0] != "set" {
+		return errors.New("usage: telegraf --config <file> secret set <store> <key>")
+	}
+	id, key := args[1], args[2]
+
+	c := config.NewConfig()
+	err := c.LoadConfig(*fConfig)
+	if err != nil {
+		return err
+	}
+	if *fConfigDirectory != "" {
+		err = c.LoadDirectory(*fConfigDirectory)
+		if err != nil {
+			return err
+		}
+	}
+
+	store, ok := c.SecretStores[id]
+	if !ok {
+		return fmt.Errorf("unknown secret store %q", id)
+	}
+	setter, ok := store.(telegraf.SecretSetter)
+	if !ok {
+		return fmt.Errorf("secret store %q does not support setting secrets", id)
+	}
+	if p, ok := store.(telegraf.Initializer); ok {
+		err = p.Init()
+		if err != nil {
+			return fmt.Errorf("could not initialize secret store %s: %v", id, err)
+		}
+	}
+
+	value, err := ioutil.ReadAll(os.Stdin)
+	defer secret.Zero(value)
+	if err != nil {
+		return err
+	}
+	return setter.Set(key, bytes.TrimRight(value, "\r\n"))
+}
+
 func usageExit(rc int) {
 	fmt.Println(internal.Usage)
 	os.Exit(rc)
@@ -310,6 +435,12 @@ func main() {
 				processorFilters,
 			)
 			return
+		case "secret":
+			err := setSecret(args[1:])
+			if err != nil {
+				log.Fatalf("E! %s", err)
+			}
+			return
 		}
 	}
 
@@ -342,8 +473,9 @@ func main() {
 	case *fUsage != "":
 		err := config.PrintInputConfig(*fUsage)
 		err2 := config.PrintOutputConfig(*fUsage)
-		if err != nil && err2 != nil {
-			log.Fatalf("E! %s and %s", err, err2)
+		err3 := config.PrintDataFormatConfig(*fUsage)
+		if err != nil && err2 != nil && err3 != nil {
+			log.Fatalf("E! %s, %s and %s", err, err2, err3)
 		}
 		return
 	}
@@ -6,11 +6,11 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
-	"math"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"runtime"
 	"sort"
@@ -21,6 +21,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/internal/secret"
 	"github.com/influxdata/telegraf/plugins/aggregators"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/outputs"
@@ -66,6 +67,17 @@ type Config struct {
 	Aggregators []*models.RunningAggregator
 	// Processors have a slice wrapper type because they need to be sorted
 	Processors models.RunningProcessors
+
+	// SecretStores holds the secret stores by their id.
+	SecretStores map[string]telegraf.SecretStore
+
+	// fingerprints holds the content of the table each plugin was created
+	// from, used to compare configurations.
+	fingerprints map[interface{}]string
+
+	// secrets holds the secrets of all plugins, they are checked against
+	// the secret stores once all configuration files are loaded.
+	secrets []*secret.Secret
 }
 
 func NewConfig() *Config {
@@ -76,12 +88,14 @@ func NewConfig() *Config {
 			RoundInterval:              true,
 			FlushInterval:              internal.Duration{Duration: 10 * time.Second},
 			LogfileRotationMaxArchives: 5,
+			HealthMaxFailures:          3,
 		},
 
 		Tags:          make(map[string]string),
 		Inputs:        make([]*models.RunningInput, 0),
 		Outputs:       make([]*models.RunningOutput, 0),
 		Processors:    make([]*models.RunningProcessor, 0),
+		SecretStores:  make(map[string]telegraf.SecretStore),
 		InputFilters:  make([]string, 0),
 		OutputFilters: make([]string, 0),
 	}
@@ -163,6 +177,14 @@ type AgentConfig struct {
 
 	Hostname     string
 	OmitHostname bool
+
+	// HealthServiceAddress is the address of the HTTP server reporting the
+	// status of the plugins.  When empty the server is not started.
+	HealthServiceAddress string `toml:"health_service_address"`
+
+	// HealthMaxFailures is the number of consecutive failures after which a
+	// plugin makes the agent not ready.  When zero failures are ignored.
+	HealthMaxFailures int `toml:"health_max_failures"`
 }
 
 // Inputs returns a list of strings of the configured inputs.
@@ -305,6 +327,14 @@ var agentConfig = `
   ## If set to true, do no set the "host" tag in the telegraf agent.
   omit_hostname = false
 
+  ## Address of the HTTP server reporting the status of the plugins on
+  ## /status, /health/live and /health/ready.  If empty the server is disabled.
+  # health_service_address = ":8090"
+
+  ## Number of consecutive failed gathers or writes after which a plugin makes
+  ## /health/ready fail.  When set to 0 failures are ignored.
+  # health_max_failures = 3
+
 `
 
 var outputHeader = `
@@ -602,6 +632,46 @@ func PrintOutputConfig(name string) error {
 	return nil
 }
 
+// PrintDataFormatConfig prints the options of the parser and serializer of a
+// single data format.
+func PrintDataFormatConfig(name string) error {
+	var formats []interface{}
+	if creator, ok := parsers.Parsers[name]; ok {
+		formats = append(formats, creator(name))
+	}
+	if creator, ok := serializers.Serializers[name]; ok {
+		formats = append(formats, creator())
+	}
+	if len(formats) == 0 {
+		return errors.New(fmt.Sprintf("Data format %s not found", name))
+	}
+
+	for _, format := range formats {
+		if p, ok := format.(printer); ok {
+			printFormatConfig(name, p)
+		}
+	}
+	return nil
+}
+
+func printFormatConfig(name string, p printer) {
+	fmt.Printf("\n# %s\n  data_format = %q\n", p.Description(), name)
+
+	config := p.SampleConfig()
+	if config == "" {
+		fmt.Printf("  # no configuration\n")
+		return
+	}
+	lines := strings.Split(config, "\n")
+	for i, line := range lines {
+		if i == 0 || i == len(lines)-1 {
+			fmt.Print("\n")
+			continue
+		}
+		fmt.Print(strings.TrimRight(line, " ") + "\n")
+	}
+}
+
 func (c *Config) LoadDirectory(path string) error {
 	walkfn := func(thispath string, info os.FileInfo, _ error) error {
 		if info == nil {
@@ -786,6 +856,20 @@ func (c *Config) LoadConfig(path string) error {
 						pluginName, path)
 				}
 			}
+		case "secretstores":
+			for pluginName, pluginVal := range subTable.Fields {
+				switch pluginSubTable := pluginVal.(type) {
+				case []*ast.Table:
+					for _, t := range pluginSubTable {
+						if err = c.addSecretStore(pluginName, t); err != nil {
+							return fmt.Errorf("Error parsing %s, %s", path, err)
+						}
+					}
+				default:
+					return fmt.Errorf("Unsupported config format: %s, file %s",
+						pluginName, path)
+				}
+			}
 		// Assume it's an input input for legacy config file support if no other
 		// identifiers are present
 		default:
@@ -889,6 +973,7 @@ func (c *Config) addAggregator(name string, table *ast.Table) error {
 		return fmt.Errorf("Undefined but requested aggregator: %s", name)
 	}
 	aggregator := creator()
+	source := fingerprint(table)
 
 	conf, err := buildAggregator(name, table)
 	if err != nil {
@@ -898,8 +983,13 @@ func (c *Config) addAggregator(name string, table *ast.Table) error {
 	if err := toml.UnmarshalTable(table, aggregator); err != nil {
 		return err
 	}
+	if err := c.linkSecrets("aggregators."+name, aggregator); err != nil {
+		return err
+	}
 
-	c.Aggregators = append(c.Aggregators, models.NewRunningAggregator(aggregator, conf))
+	ra := models.NewRunningAggregator(aggregator, conf)
+	c.setFingerprint(ra, source)
+	c.Aggregators = append(c.Aggregators, ra)
 	return nil
 }
 
@@ -909,6 +999,30 @@ func (c *Config) addProcessor(name string, table *ast.Table) error {
 		return fmt.Errorf("Undefined but requested processor: %s", name)
 	}
 	processor := creator()
+	source := fingerprint(table)
+
+	// Processors that exchange metrics with another program, such as execd,
+	// may need both a parser and a serializer for the same data_format.
+	dataFormat, hasDataFormat := table.Fields["data_format"]
+
+	if t, ok := processor.(parsers.ParserInput); ok {
+		parser, err := buildParser(name, table)
+		if err != nil {
+			return err
+		}
+		t.SetParser(parser)
+	}
+
+	if t, ok := processor.(serializers.SerializerOutput); ok {
+		if hasDataFormat {
+			table.Fields["data_format"] = dataFormat
+		}
+		serializer, err := buildSerializer(name, table)
+		if err != nil {
+			return err
+		}
+		t.SetSerializer(serializer)
+	}
 
 	processorConfig, err := buildProcessor(name, table)
 	if err != nil {
@@ -918,13 +1032,12 @@ func (c *Config) addProcessor(name string, table *ast.Table) error {
 	if err := toml.UnmarshalTable(table, processor); err != nil {
 		return err
 	}
-
-	rf := &models.RunningProcessor{
-		Name:      name,
-		Processor: processor,
-		Config:    processorConfig,
+	if err := c.linkSecrets("processors."+name, processor); err != nil {
+		return err
 	}
 
+	rf := models.NewRunningProcessor(processor, processorConfig)
+	c.setFingerprint(rf, source)
 	c.Processors = append(c.Processors, rf)
 	return nil
 }
@@ -938,6 +1051,7 @@ func (c *Config) addOutput(name string, table *ast.Table) error {
 		return fmt.Errorf("Undefined but requested output: %s", name)
 	}
 	output := creator()
+	source := fingerprint(table)
 
 	// If the output has a SetSerializer function, then this means it can write
 	// arbitrary types of output, so build the serializer and set it.
@@ -955,12 +1069,26 @@ func (c *Config) addOutput(name string, table *ast.Table) error {
 		return err
 	}
 
+	if outputConfig.BufferStrategy == "disk" {
+		for _, ro := range c.Outputs {
+			if ro.Config.BufferStrategy == "disk" &&
+				filepath.Clean(ro.Config.BufferDirectory) == filepath.Clean(outputConfig.BufferDirectory) {
+				return fmt.Errorf("outputs %s and %s share the buffer_directory %q",
+					ro.Name, name, outputConfig.BufferDirectory)
+			}
+		}
+	}
+
 	if err := toml.UnmarshalTable(table, output); err != nil {
 		return err
 	}
+	if err := c.linkSecrets("outputs."+name, output); err != nil {
+		return err
+	}
 
 	ro := models.NewRunningOutput(name, output, outputConfig,
 		c.Agent.MetricBatchSize, c.Agent.MetricBufferLimit)
+	c.setFingerprint(ro, source)
 	c.Outputs = append(c.Outputs, ro)
 	return nil
 }
@@ -979,6 +1107,7 @@ func (c *Config) addInput(name string, table *ast.Table) error {
 		return fmt.Errorf("Undefined but requested input: %s", name)
 	}
 	input := creator()
+	source := fingerprint(table)
 
 	// If the input has a SetParser function, then this means it can accept
 	// arbitrary types of input, so build the parser and set it.
@@ -993,13 +1122,15 @@ func (c *Config) addInput(name string, table *ast.Table) error {
 
 	switch t := input.(type) {
 	case parsers.ParserFuncInput:
-		config, err := getParserConfig(name, table)
+		fn, err := buildParserFunc(name, table)
 		if err != nil {
 			return err
 		}
-		t.SetParserFunc(func() (parsers.Parser, error) {
-			return parsers.NewParser(config)
-		})
+		// Create a parser to report invalid options when loading the config.
+		if _, err := fn(); err != nil {
+			return err
+		}
+		t.SetParserFunc(fn)
 	}
 
 	pluginConfig, err := buildInput(name, table)
@@ -1010,9 +1141,13 @@ func (c *Config) addInput(name string, table *ast.Table) error {
 	if err := toml.UnmarshalTable(table, input); err != nil {
 		return err
 	}
+	if err := c.linkSecrets("inputs."+name, input); err != nil {
+		return err
+	}
 
 	rp := models.NewRunningInput(input, pluginConfig)
 	rp.SetDefaultTags(c.Tags)
+	c.setFingerprint(rp, source)
 	c.Inputs = append(c.Inputs, rp)
 	return nil
 }
@@ -1027,6 +1162,25 @@ func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, err
 		Period: time.Second * 30,
 	}
 
+	if node, ok := tbl.Fields["alias"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				conf.Alias = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["log_level"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				conf.LogLevel = str.Value
+			}
+		}
+	}
+	if _, err := models.ParseLogLevel(conf.LogLevel); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+
 	if node, ok := tbl.Fields["period"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
@@ -1098,6 +1252,8 @@ func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, err
 		}
 	}
 
+	delete(tbl.Fields, "alias")
+	delete(tbl.Fields, "log_level")
 	delete(tbl.Fields, "period")
 	delete(tbl.Fields, "delay")
 	delete(tbl.Fields, "drop_original")
@@ -1119,6 +1275,25 @@ func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, err
 func buildProcessor(name string, tbl *ast.Table) (*models.ProcessorConfig, error) {
 	conf := &models.ProcessorConfig{Name: name}
 
+	if node, ok := tbl.Fields["alias"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				conf.Alias = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["log_level"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				conf.LogLevel = str.Value
+			}
+		}
+	}
+	if _, err := models.ParseLogLevel(conf.LogLevel); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+
 	if node, ok := tbl.Fields["order"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if b, ok := kv.Value.(*ast.Integer); ok {
@@ -1131,7 +1306,25 @@ func buildProcessor(name string, tbl *ast.Table) (*models.ProcessorConfig, error
 		}
 	}
 
+	if node, ok := tbl.Fields["stage"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				conf.Stage = str.Value
+			}
+		}
+	}
+	switch conf.Stage {
+	case "", models.ProcessorStageBoth, models.ProcessorStagePre, models.ProcessorStagePost:
+	default:
+		return nil, fmt.Errorf("%s: invalid stage %q, must be one of %q, %q or %q",
+			name, conf.Stage, models.ProcessorStageBoth,
+			models.ProcessorStagePre, models.ProcessorStagePost)
+	}
+
+	delete(tbl.Fields, "alias")
+	delete(tbl.Fields, "log_level")
 	delete(tbl.Fields, "order")
+	delete(tbl.Fields, "stage")
 	var err error
 	conf.Filter, err = buildFilter(tbl)
 	if err != nil {
@@ -1282,6 +1475,25 @@ func buildFilter(tbl *ast.Table) (models.Filter, error) {
 // models.InputConfig to be inserted into models.RunningInput
 func buildInput(name string, tbl *ast.Table) (*models.InputConfig, error) {
 	cp := &models.InputConfig{Name: name}
+	if node, ok := tbl.Fields["alias"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				cp.Alias = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["log_level"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				cp.LogLevel = str.Value
+			}
+		}
+	}
+	if _, err := models.ParseLogLevel(cp.LogLevel); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+
 	if node, ok := tbl.Fields["interval"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
@@ -1328,6 +1540,8 @@ func buildInput(name string, tbl *ast.Table) (*models.InputConfig, error) {
 		}
 	}
 
+	delete(tbl.Fields, "alias")
+	delete(tbl.Fields, "log_level")
 	delete(tbl.Fields, "name_prefix")
 	delete(tbl.Fields, "name_suffix")
 	delete(tbl.Fields, "name_override")
@@ -1341,660 +1555,318 @@ func buildInput(name string, tbl *ast.Table) (*models.InputConfig, error) {
 	return cp, nil
 }
 
-// buildParser grabs the necessary entries from the ast.Table for creating
-// a parsers.Parser object, and creates it, which can then be added onto
-// an Input object.
+// buildParser creates the parser of the data_format set in the table, using
+// the name as the default metric name.  The options of the format are
+// removed from the table.
 func buildParser(name string, tbl *ast.Table) (parsers.Parser, error) {
-	config, err := getParserConfig(name, tbl)
+	fn, err := buildParserFunc(name, tbl)
 	if err != nil {
 		return nil, err
 	}
-	return parsers.NewParser(config)
+	return fn()
 }
 
-func getParserConfig(name string, tbl *ast.Table) (*parsers.Config, error) {
-	c := &parsers.Config{}
-
-	if node, ok := tbl.Fields["data_format"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if str, ok := kv.Value.(*ast.String); ok {
-				c.DataFormat = str.Value
-			}
-		}
-	}
+// deprecatedParserOptions are parser options that no longer have an effect,
+// they are accepted with a warning so that existing configurations load.
+var deprecatedParserOptions = []string{
+	"csv_field_columns",
+}
 
+// buildParserFunc returns a function creating a new parser of the data_format
+// set in the table on each call.  The options of the format are removed from
+// the table.
+func buildParserFunc(name string, tbl *ast.Table) (parsers.ParserFunc, error) {
+	defaultFormat := "influx"
 	// Legacy support, exec plugin originally parsed JSON by default.
-	if name == "exec" && c.DataFormat == "" {
-		c.DataFormat = "json"
-	} else if c.DataFormat == "" {
-		c.DataFormat = "influx"
-	}
-
-	if node, ok := tbl.Fields["separator"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if str, ok := kv.Value.(*ast.String); ok {
-				c.Separator = str.Value
-			}
-		}
-	}
-
-	if node, ok := tbl.Fields["templates"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if ary, ok := kv.Value.(*ast.Array); ok {
-				for _, elem := range ary.Value {
-					if str, ok := elem.(*ast.String); ok {
-						c.Templates = append(c.Templates, str.Value)
-					}
-				}
-			}
-		}
-	}
-
-	if node, ok := tbl.Fields["tag_keys"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if ary, ok := kv.Value.(*ast.Array); ok {
-				for _, elem := range ary.Value {
-					if str, ok := elem.(*ast.String); ok {
-						c.TagKeys = append(c.TagKeys, str.Value)
-					}
-				}
-			}
-		}
-	}
-
-	if node, ok := tbl.Fields["json_string_fields"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if ary, ok := kv.Value.(*ast.Array); ok {
-				for _, elem := range ary.Value {
-					if str, ok := elem.(*ast.String); ok {
-						c.JSONStringFields = append(c.JSONStringFields, str.Value)
-					}
-				}
-			}
-		}
-	}
-
-	if node, ok := tbl.Fields["json_name_key"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if str, ok := kv.Value.(*ast.String); ok {
-				c.JSONNameKey = str.Value
-			}
-		}
+	if name == "exec" {
+		defaultFormat = "json"
 	}
+	dataFormat := getDataFormat(tbl, defaultFormat)
 
-	if node, ok := tbl.Fields["json_query"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if str, ok := kv.Value.(*ast.String); ok {
-				c.JSONQuery = str.Value
-			}
+	for _, key := range deprecatedParserOptions {
+		if _, ok := tbl.Fields[key]; ok {
+			log.Printf("W! Option %q of %q is deprecated and has no effect", key, name)
+			delete(tbl.Fields, key)
 		}
 	}
 
-	if node, ok := tbl.Fields["json_time_key"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if str, ok := kv.Value.(*ast.String); ok {
-				c.JSONTimeKey = str.Value
-			}
-		}
-	}
-
-	if node, ok := tbl.Fields["json_time_format"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if str, ok := kv.Value.(*ast.String); ok {
-				c.JSONTimeFormat = str.Value
-			}
-		}
-	}
-
-	if node, ok := tbl.Fields["json_timezone"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if str, ok := kv.Value.(*ast.String); ok {
-				c.JSONTimezone = str.Value
-			}
-		}
+	creator, ok := parsers.Parsers[dataFormat]
+	if !ok {
+		return nil, fmt.Errorf("Invalid data format: %s", dataFormat)
 	}
+	options := getFormatOptions(tbl, creator(name))
 
-	if node, ok := tbl.Fields["data_type"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if str, ok := kv.Value.(*ast.String); ok {
-				c.DataType = str.Value
-			}
+	return func() (parsers.Parser, error) {
+		parser := creator(name)
+		if err := initFormat(options, parser); err != nil {
+			return nil, err
 		}
-	}
+		return parser, nil
+	}, nil
+}
 
-	if node, ok := tbl.Fields["collectd_auth_file"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if str, ok := kv.Value.(*ast.String); ok {
-				c.CollectdAuthFile = str.Value
-			}
-		}
-	}
+// buildSerializer creates the serializer of the data_format set in the table.
+// The options of the format are removed from the table.
+func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error) {
+	dataFormat := getDataFormat(tbl, "influx")
 
-	if node, ok := tbl.Fields["collectd_security_level"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if str, ok := kv.Value.(*ast.String); ok {
-				c.CollectdSecurityLevel = str.Value
-			}
-		}
+	creator, ok := serializers.Serializers[dataFormat]
+	if !ok {
+		return nil, fmt.Errorf("Invalid data format: %s", dataFormat)
 	}
-
-	if node, ok := tbl.Fields["collectd_parse_multivalue"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if str, ok := kv.Value.(*ast.String); ok {
-				c.CollectdSplit = str.Value
-			}
-		}
+	serializer := creator()
+	options := getFormatOptions(tbl, serializer)
+	if err := initFormat(options, serializer); err != nil {
+		return nil, err
 	}
+	return serializer, nil
+}
 
-	if node, ok := tbl.Fields["collectd_typesdb"]; ok {
+// getDataFormat removes the data_format from the table and returns it, or
+// the defaultFormat if it is not set.
+func getDataFormat(tbl *ast.Table, defaultFormat string) string {
+	dataFormat := defaultFormat
+	if node, ok := tbl.Fields["data_format"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
-			if ary, ok := kv.Value.(*ast.Array); ok {
-				for _, elem := range ary.Value {
-					if str, ok := elem.(*ast.String); ok {
-						c.CollectdTypesDB = append(c.CollectdTypesDB, str.Value)
-					}
-				}
+			if str, ok := kv.Value.(*ast.String); ok && str.Value != "" {
+				dataFormat = str.Value
 			}
 		}
 	}
+	delete(tbl.Fields, "data_format")
+	return dataFormat
+}
 
-	if node, ok := tbl.Fields["dropwizard_metric_registry_path"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if str, ok := kv.Value.(*ast.String); ok {
-				c.DropwizardMetricRegistryPath = str.Value
-			}
-		}
-	}
-	if node, ok := tbl.Fields["dropwizard_time_path"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if str, ok := kv.Value.(*ast.String); ok {
-				c.DropwizardTimePath = str.Value
-			}
-		}
+// getFormatOptions moves the options of a parser or serializer, the keys of
+// its toml tagged fields, from the table of the plugin into a new table.
+func getFormatOptions(tbl *ast.Table, format interface{}) *ast.Table {
+	options := &ast.Table{
+		Position: tbl.Position,
+		Line:     tbl.Line,
+		Name:     tbl.Name,
+		Type:     tbl.Type,
+		Fields:   make(map[string]interface{}),
 	}
-	if node, ok := tbl.Fields["dropwizard_time_format"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if str, ok := kv.Value.(*ast.String); ok {
-				c.DropwizardTimeFormat = str.Value
-			}
-		}
-	}
-	if node, ok := tbl.Fields["dropwizard_tags_path"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if str, ok := kv.Value.(*ast.String); ok {
-				c.DropwizardTagsPath = str.Value
-			}
-		}
-	}
-	c.DropwizardTagPathsMap = make(map[string]string)
-	if node, ok := tbl.Fields["dropwizard_tag_paths"]; ok {
-		if subtbl, ok := node.(*ast.Table); ok {
-			for name, val := range subtbl.Fields {
-				if kv, ok := val.(*ast.KeyValue); ok {
-					if str, ok := kv.Value.(*ast.String); ok {
-						c.DropwizardTagPathsMap[name] = str.Value
-					}
-				}
-			}
+	for _, key := range formatOptionKeys(reflect.TypeOf(format)) {
+		if node, ok := tbl.Fields[key]; ok {
+			options.Fields[key] = node
+			delete(tbl.Fields, key)
 		}
 	}
+	return options
+}
 
-	//for grok data_format
-	if node, ok := tbl.Fields["grok_named_patterns"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if ary, ok := kv.Value.(*ast.Array); ok {
-				for _, elem := range ary.Value {
-					if str, ok := elem.(*ast.String); ok {
-						c.GrokNamedPatterns = append(c.GrokNamedPatterns, str.Value)
-					}
-				}
-			}
-		}
+// formatOptionKeys returns the toml keys of the exported fields of the struct,
+// including the fields of embedded structs.
+func formatOptionKeys(t reflect.Type) []string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
 	}
-
-	if node, ok := tbl.Fields["grok_patterns"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if ary, ok := kv.Value.(*ast.Array); ok {
-				for _, elem := range ary.Value {
-					if str, ok := elem.(*ast.String); ok {
-						c.GrokPatterns = append(c.GrokPatterns, str.Value)
-					}
-				}
-			}
-		}
+	if t.Kind() != reflect.Struct {
+		return nil
 	}
 
-	if node, ok := tbl.Fields["grok_custom_patterns"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if str, ok := kv.Value.(*ast.String); ok {
-				c.GrokCustomPatterns = str.Value
-			}
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			keys = append(keys, formatOptionKeys(field.Type)...)
+			continue
 		}
-	}
-
-	if node, ok := tbl.Fields["grok_custom_pattern_files"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if ary, ok := kv.Value.(*ast.Array); ok {
-				for _, elem := range ary.Value {
-					if str, ok := elem.(*ast.String); ok {
-						c.GrokCustomPatternFiles = append(c.GrokCustomPatternFiles, str.Value)
-					}
-				}
-			}
+		key := field.Tag.Get("toml")
+		if field.PkgPath != "" || key == "" || key == "-" {
+			continue
 		}
+		keys = append(keys, key)
 	}
+	return keys
+}
 
-	if node, ok := tbl.Fields["grok_timezone"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if str, ok := kv.Value.(*ast.String); ok {
-				c.GrokTimezone = str.Value
-			}
-		}
+// initFormat decodes the options into the parser or serializer and
+// initializes it.
+func initFormat(options *ast.Table, format interface{}) error {
+	if err := toml.UnmarshalTable(options, format); err != nil {
+		return err
 	}
-
-	if node, ok := tbl.Fields["grok_unique_timestamp"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if str, ok := kv.Value.(*ast.String); ok {
-				c.GrokUniqueTimestamp = str.Value
-			}
-		}
+	if f, ok := format.(telegraf.Initializer); ok {
+		return f.Init()
 	}
+	return nil
+}
 
-	//for csv parser
-	if node, ok := tbl.Fields["csv_column_names"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if ary, ok := kv.Value.(*ast.Array); ok {
-				for _, elem := range ary.Value {
-					if str, ok := elem.(*ast.String); ok {
-						c.CSVColumnNames = append(c.CSVColumnNames, str.Value)
-					}
-				}
-			}
-		}
+// buildOutput parses output specific items from the ast.Table,
+// builds the filter and returns an
+// models.OutputConfig to be inserted into models.RunningInput
+// Note: error exists in the return for future calls that might require error
+func buildOutput(name string, tbl *ast.Table) (*models.OutputConfig, error) {
+	filter, err := buildFilter(tbl)
+	if err != nil {
+		return nil, err
 	}
-
-	if node, ok := tbl.Fields["csv_column_types"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if ary, ok := kv.Value.(*ast.Array); ok {
-				for _, elem := range ary.Value {
-					if str, ok := elem.(*ast.String); ok {
-						c.CSVColumnTypes = append(c.CSVColumnTypes, str.Value)
-					}
-				}
-			}
-		}
+	oc := &models.OutputConfig{
+		Name:   name,
+		Filter: filter,
 	}
 
-	if node, ok := tbl.Fields["csv_tag_columns"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if ary, ok := kv.Value.(*ast.Array); ok {
-				for _, elem := range ary.Value {
-					if str, ok := elem.(*ast.String); ok {
-						c.CSVTagColumns = append(c.CSVTagColumns, str.Value)
-					}
-				}
-			}
-		}
+	// TODO
+	// Outputs don't support FieldDrop/FieldPass, so set to NameDrop/NamePass
+	if len(oc.Filter.FieldDrop) > 0 {
+		oc.Filter.NameDrop = oc.Filter.FieldDrop
 	}
-
-	if node, ok := tbl.Fields["csv_delimiter"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if str, ok := kv.Value.(*ast.String); ok {
-				c.CSVDelimiter = str.Value
-			}
-		}
+	if len(oc.Filter.FieldPass) > 0 {
+		oc.Filter.NamePass = oc.Filter.FieldPass
 	}
 
-	if node, ok := tbl.Fields["csv_comment"]; ok {
+	if node, ok := tbl.Fields["alias"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
-				c.CSVComment = str.Value
+				oc.Alias = str.Value
 			}
 		}
 	}
 
-	if node, ok := tbl.Fields["csv_measurement_column"]; ok {
+	if node, ok := tbl.Fields["log_level"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
-				c.CSVMeasurementColumn = str.Value
+				oc.LogLevel = str.Value
 			}
 		}
 	}
-
-	if node, ok := tbl.Fields["csv_timestamp_column"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if str, ok := kv.Value.(*ast.String); ok {
-				c.CSVTimestampColumn = str.Value
-			}
-		}
+	if _, err := models.ParseLogLevel(oc.LogLevel); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
 	}
 
-	if node, ok := tbl.Fields["csv_timestamp_format"]; ok {
+	if node, ok := tbl.Fields["flush_interval"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
-				c.CSVTimestampFormat = str.Value
-			}
-		}
-	}
-
-	if node, ok := tbl.Fields["csv_header_row_count"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if integer, ok := kv.Value.(*ast.Integer); ok {
-				v, err := integer.Int()
+				dur, err := time.ParseDuration(str.Value)
 				if err != nil {
 					return nil, err
 				}
-				c.CSVHeaderRowCount = int(v)
+
+				oc.FlushInterval = dur
 			}
 		}
 	}
 
-	if node, ok := tbl.Fields["csv_skip_rows"]; ok {
+	if node, ok := tbl.Fields["metric_buffer_limit"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if integer, ok := kv.Value.(*ast.Integer); ok {
 				v, err := integer.Int()
 				if err != nil {
 					return nil, err
 				}
-				c.CSVSkipRows = int(v)
+				oc.MetricBufferLimit = int(v)
 			}
 		}
 	}
 
-	if node, ok := tbl.Fields["csv_skip_columns"]; ok {
+	if node, ok := tbl.Fields["metric_batch_size"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if integer, ok := kv.Value.(*ast.Integer); ok {
 				v, err := integer.Int()
 				if err != nil {
 					return nil, err
 				}
-				c.CSVSkipColumns = int(v)
-			}
-		}
-	}
-
-	if node, ok := tbl.Fields["csv_trim_space"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if str, ok := kv.Value.(*ast.Boolean); ok {
-				//for config with no quotes
-				val, err := strconv.ParseBool(str.Value)
-				c.CSVTrimSpace = val
-				if err != nil {
-					return nil, fmt.Errorf("E! parsing to bool: %v", err)
-				}
-			}
-		}
-	}
-
-	if node, ok := tbl.Fields["form_urlencoded_tag_keys"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if ary, ok := kv.Value.(*ast.Array); ok {
-				for _, elem := range ary.Value {
-					if str, ok := elem.(*ast.String); ok {
-						c.FormUrlencodedTagKeys = append(c.FormUrlencodedTagKeys, str.Value)
-					}
-				}
-			}
-		}
-	}
-
-	c.MetricName = name
-
-	delete(tbl.Fields, "data_format")
-	delete(tbl.Fields, "separator")
-	delete(tbl.Fields, "templates")
-	delete(tbl.Fields, "tag_keys")
-	delete(tbl.Fields, "json_name_key")
-	delete(tbl.Fields, "json_query")
-	delete(tbl.Fields, "json_string_fields")
-	delete(tbl.Fields, "json_time_format")
-	delete(tbl.Fields, "json_time_key")
-	delete(tbl.Fields, "json_timezone")
-	delete(tbl.Fields, "data_type")
-	delete(tbl.Fields, "collectd_auth_file")
-	delete(tbl.Fields, "collectd_security_level")
-	delete(tbl.Fields, "collectd_typesdb")
-	delete(tbl.Fields, "collectd_parse_multivalue")
-	delete(tbl.Fields, "dropwizard_metric_registry_path")
-	delete(tbl.Fields, "dropwizard_time_path")
-	delete(tbl.Fields, "dropwizard_time_format")
-	delete(tbl.Fields, "dropwizard_tags_path")
-	delete(tbl.Fields, "dropwizard_tag_paths")
-	delete(tbl.Fields, "grok_named_patterns")
-	delete(tbl.Fields, "grok_patterns")
-	delete(tbl.Fields, "grok_custom_patterns")
-	delete(tbl.Fields, "grok_custom_pattern_files")
-	delete(tbl.Fields, "grok_timezone")
-	delete(tbl.Fields, "grok_unique_timestamp")
-	delete(tbl.Fields, "csv_column_names")
-	delete(tbl.Fields, "csv_column_types")
-	delete(tbl.Fields, "csv_comment")
-	delete(tbl.Fields, "csv_delimiter")
-	delete(tbl.Fields, "csv_field_columns")
-	delete(tbl.Fields, "csv_header_row_count")
-	delete(tbl.Fields, "csv_measurement_column")
-	delete(tbl.Fields, "csv_skip_columns")
-	delete(tbl.Fields, "csv_skip_rows")
-	delete(tbl.Fields, "csv_tag_columns")
-	delete(tbl.Fields, "csv_timestamp_column")
-	delete(tbl.Fields, "csv_timestamp_format")
-	delete(tbl.Fields, "csv_trim_space")
-	delete(tbl.Fields, "form_urlencoded_tag_keys")
-
-	return c, nil
-}
-
-// buildSerializer grabs the necessary entries from the ast.Table for creating
-// a serializers.Serializer object, and creates it, which can then be added onto
-// an Output object.
-func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error) {
-	c := &serializers.Config{TimestampUnits: time.Duration(1 * time.Second)}
-
-	if node, ok := tbl.Fields["data_format"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if str, ok := kv.Value.(*ast.String); ok {
-				c.DataFormat = str.Value
+				oc.MetricBatchSize = int(v)
 			}
 		}
 	}
 
-	if c.DataFormat == "" {
-		c.DataFormat = "influx"
-	}
-
-	if node, ok := tbl.Fields["prefix"]; ok {
+	if node, ok := tbl.Fields["buffer_strategy"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
-				c.Prefix = str.Value
+				oc.BufferStrategy = str.Value
 			}
 		}
 	}
 
-	if node, ok := tbl.Fields["template"]; ok {
+	if node, ok := tbl.Fields["buffer_directory"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
-				c.Template = str.Value
-			}
-		}
-	}
-
-	if node, ok := tbl.Fields["influx_max_line_bytes"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if integer, ok := kv.Value.(*ast.Integer); ok {
-				v, err := integer.Int()
-				if err != nil {
-					return nil, err
-				}
-				c.InfluxMaxLineBytes = int(v)
-			}
-		}
-	}
-
-	if node, ok := tbl.Fields["influx_sort_fields"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if b, ok := kv.Value.(*ast.Boolean); ok {
-				var err error
-				c.InfluxSortFields, err = b.Boolean()
-				if err != nil {
-					return nil, err
-				}
-			}
-		}
-	}
-
-	if node, ok := tbl.Fields["influx_uint_support"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if b, ok := kv.Value.(*ast.Boolean); ok {
-				var err error
-				c.InfluxUintSupport, err = b.Boolean()
-				if err != nil {
-					return nil, err
-				}
+				oc.BufferDirectory = str.Value
 			}
 		}
 	}
 
-	if node, ok := tbl.Fields["graphite_tag_support"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if b, ok := kv.Value.(*ast.Boolean); ok {
-				var err error
-				c.GraphiteTagSupport, err = b.Boolean()
-				if err != nil {
-					return nil, err
-				}
-			}
-		}
-	}
-
-	if node, ok := tbl.Fields["json_timestamp_units"]; ok {
+	if node, ok := tbl.Fields["buffer_sync"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
-				timestampVal, err := time.ParseDuration(str.Value)
-				if err != nil {
-					return nil, fmt.Errorf("Unable to parse json_timestamp_units as a duration, %s", err)
-				}
-				// now that we have a duration, truncate it to the nearest
-				// power of ten (just in case)
-				nearest_exponent := int64(math.Log10(float64(timestampVal.Nanoseconds())))
-				new_nanoseconds := int64(math.Pow(10.0, float64(nearest_exponent)))
-				c.TimestampUnits = time.Duration(new_nanoseconds)
-			}
-		}
-	}
-
-	if node, ok := tbl.Fields["splunkmetric_hec_routing"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if b, ok := kv.Value.(*ast.Boolean); ok {
-				var err error
-				c.HecRouting, err = b.Boolean()
-				if err != nil {
-					return nil, err
-				}
+				oc.BufferSync = str.Value
 			}
 		}
 	}
 
-	if node, ok := tbl.Fields["wavefront_source_override"]; ok {
+	if node, ok := tbl.Fields["buffer_size_limit"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
-			if ary, ok := kv.Value.(*ast.Array); ok {
-				for _, elem := range ary.Value {
-					if str, ok := elem.(*ast.String); ok {
-						c.WavefrontSourceOverride = append(c.WavefrontSourceOverride, str.Value)
-					}
-				}
+			var size internal.Size
+			if err := size.UnmarshalTOML([]byte(kv.Value.Source())); err != nil {
+				return nil, fmt.Errorf("invalid buffer_size_limit: %v", err)
 			}
+			oc.BufferSizeLimit = size.Size
 		}
 	}
 
-	if node, ok := tbl.Fields["wavefront_use_strict"]; ok {
+	if node, ok := tbl.Fields["buffer_segment_size"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
-			if b, ok := kv.Value.(*ast.Boolean); ok {
-				var err error
-				c.WavefrontUseStrict, err = b.Boolean()
-				if err != nil {
-					return nil, err
-				}
+			var size internal.Size
+			if err := size.UnmarshalTOML([]byte(kv.Value.Source())); err != nil {
+				return nil, fmt.Errorf("invalid buffer_segment_size: %v", err)
 			}
+			oc.BufferSegmentSize = size.Size
 		}
 	}
 
-	delete(tbl.Fields, "influx_max_line_bytes")
-	delete(tbl.Fields, "influx_sort_fields")
-	delete(tbl.Fields, "influx_uint_support")
-	delete(tbl.Fields, "graphite_tag_support")
-	delete(tbl.Fields, "data_format")
-	delete(tbl.Fields, "prefix")
-	delete(tbl.Fields, "template")
-	delete(tbl.Fields, "json_timestamp_units")
-	delete(tbl.Fields, "splunkmetric_hec_routing")
-	delete(tbl.Fields, "wavefront_source_override")
-	delete(tbl.Fields, "wavefront_use_strict")
-	return serializers.NewSerializer(c)
-}
-
-// buildOutput parses output specific items from the ast.Table,
-// builds the filter and returns an
-// models.OutputConfig to be inserted into models.RunningInput
-// Note: error exists in the return for future calls that might require error
-func buildOutput(name string, tbl *ast.Table) (*models.OutputConfig, error) {
-	filter, err := buildFilter(tbl)
-	if err != nil {
-		return nil, err
-	}
-	oc := &models.OutputConfig{
-		Name:   name,
-		Filter: filter,
-	}
-
-	// TODO
-	// Outputs don't support FieldDrop/FieldPass, so set to NameDrop/NamePass
-	if len(oc.Filter.FieldDrop) > 0 {
-		oc.Filter.NameDrop = oc.Filter.FieldDrop
+	durations := []struct {
+		key string
+		dur *time.Duration
+	}{
+		{"write_timeout", &oc.WriteTimeout},
+		{"retry_initial_interval", &oc.RetryInitialInterval},
+		{"retry_max_interval", &oc.RetryMaxInterval},
+		{"retry_max_age", &oc.RetryMaxAge},
+		{"circuit_breaker_cooldown", &oc.CircuitBreakerCooldown},
 	}
-	if len(oc.Filter.FieldPass) > 0 {
-		oc.Filter.NamePass = oc.Filter.FieldPass
-	}
-
-	if node, ok := tbl.Fields["flush_interval"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if str, ok := kv.Value.(*ast.String); ok {
-				dur, err := time.ParseDuration(str.Value)
-				if err != nil {
-					return nil, err
-				}
-
-				oc.FlushInterval = dur
-			}
-		}
-	}
-
-	if node, ok := tbl.Fields["metric_buffer_limit"]; ok {
-		if kv, ok := node.(*ast.KeyValue); ok {
-			if integer, ok := kv.Value.(*ast.Integer); ok {
-				v, err := integer.Int()
-				if err != nil {
-					return nil, err
+	for _, d := range durations {
+		if node, ok := tbl.Fields[d.key]; ok {
+			if kv, ok := node.(*ast.KeyValue); ok {
+				var dur internal.Duration
+				if err := dur.UnmarshalTOML([]byte(kv.Value.Source())); err != nil {
+					return nil, fmt.Errorf("invalid %s: %v", d.key, err)
 				}
-				oc.MetricBufferLimit = int(v)
+				*d.dur = dur.Duration
 			}
 		}
 	}
 
-	if node, ok := tbl.Fields["metric_batch_size"]; ok {
+	if node, ok := tbl.Fields["circuit_breaker_threshold"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if integer, ok := kv.Value.(*ast.Integer); ok {
 				v, err := integer.Int()
 				if err != nil {
 					return nil, err
 				}
-				oc.MetricBatchSize = int(v)
+				oc.CircuitBreakerThreshold = int(v)
 			}
 		}
 	}
 
+	delete(tbl.Fields, "alias")
+	delete(tbl.Fields, "log_level")
 	delete(tbl.Fields, "flush_interval")
 	delete(tbl.Fields, "metric_buffer_limit")
 	delete(tbl.Fields, "metric_batch_size")
+	delete(tbl.Fields, "buffer_strategy")
+	delete(tbl.Fields, "buffer_directory")
+	delete(tbl.Fields, "buffer_size_limit")
+	delete(tbl.Fields, "buffer_segment_size")
+	delete(tbl.Fields, "buffer_sync")
+	delete(tbl.Fields, "write_timeout")
+	delete(tbl.Fields, "retry_initial_interval")
+	delete(tbl.Fields, "retry_max_interval")
+	delete(tbl.Fields, "retry_max_age")
+	delete(tbl.Fields, "circuit_breaker_threshold")
+	delete(tbl.Fields, "circuit_breaker_cooldown")
 
 	return oc, nil
 }
@@ -1,12 +1,16 @@
 package config
 
 import (
+	"math"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/inputs/exec"
 	"github.com/influxdata/telegraf/plugins/inputs/http_listener_v2"
@@ -14,6 +18,12 @@ import (
 	"github.com/influxdata/telegraf/plugins/inputs/procstat"
 	httpOut "github.com/influxdata/telegraf/plugins/outputs/http"
 	"github.com/influxdata/telegraf/plugins/parsers"
+	_ "github.com/influxdata/telegraf/plugins/parsers/all"
+	"github.com/influxdata/telegraf/plugins/parsers/json"
+	"github.com/influxdata/telegraf/plugins/processors"
+	_ "github.com/influxdata/telegraf/plugins/secretstores/file"
+	"github.com/influxdata/telegraf/plugins/serializers"
+	_ "github.com/influxdata/telegraf/plugins/serializers/all"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -146,12 +156,7 @@ func TestConfig_LoadDirectory(t *testing.T) {
 		"Testdata did not produce correct memcached metadata.")
 
 	ex := inputs.Inputs["exec"]().(*exec.Exec)
-	p, err := parsers.NewParser(&parsers.Config{
-		MetricName: "exec",
-		DataFormat: "json",
-	})
-	assert.NoError(t, err)
-	ex.SetParser(p)
+	ex.SetParser(&json.JSONParser{MetricName: "exec"})
 	ex.Command = "/usr/bin/myothercollector --foo=bar"
 	eConfig := &models.InputConfig{
 		Name:              "exec",
@@ -251,3 +256,325 @@ func TestConfig_BadOrdering(t *testing.T) {
 	require.Error(t, err, "bad ordering")
 	assert.Equal(t, "Error parsing ./testdata/non_slice_slice.toml, line 4: cannot unmarshal TOML array into string (need slice)", err.Error())
 }
+
+func TestConfig_DiskBuffer(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/disk_buffer.toml")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(c.Outputs))
+
+	oc := c.Outputs[0].Config
+	assert.Equal(t, "disk", oc.BufferStrategy)
+	assert.Equal(t, "/var/lib/telegraf/buffer/http", oc.BufferDirectory)
+	assert.Equal(t, int64(1024*1024), oc.BufferSizeLimit)
+	assert.Equal(t, int64(65536), oc.BufferSegmentSize)
+	assert.Equal(t, "always", oc.BufferSync)
+}
+
+func TestConfig_OutputRetry(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/output_retry.toml")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(c.Outputs))
+
+	oc := c.Outputs[0].Config
+	assert.Equal(t, 30*time.Second, oc.WriteTimeout)
+	assert.Equal(t, 10*time.Second, oc.RetryInitialInterval)
+	assert.Equal(t, 2*time.Minute, oc.RetryMaxInterval)
+	assert.Equal(t, time.Hour, oc.RetryMaxAge)
+	assert.Equal(t, 5, oc.CircuitBreakerThreshold)
+	assert.Equal(t, 5*time.Minute, oc.CircuitBreakerCooldown)
+}
+
+func TestConfig_DiskBufferSharedDirectory(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/disk_buffer_shared_directory.toml")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "share the buffer_directory")
+}
+
+func TestConfig_SecretStores(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/secretstores.toml")
+	require.NoError(t, err)
+	require.Len(t, c.SecretStores, 1)
+	require.NoError(t, c.InitSecretStores())
+
+	require.Len(t, c.Outputs, 1)
+	output, ok := c.Outputs[0].Output.(*httpOut.HTTP)
+	require.True(t, ok)
+
+	username, err := output.Username.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "telegraf", string(username))
+
+	password, err := output.Password.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", string(password))
+}
+
+func TestConfig_SecretStoreUnknown(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/secretstores_unknown.toml")
+	require.NoError(t, err)
+
+	err = c.InitSecretStores()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown secret store "vault"`)
+}
+
+func TestConfig_SecretUnsupportedField(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/secretstores_unsupported.toml")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(),
+		`outputs.http: option "headers" references a secret but does not support secrets`)
+}
+
+func TestConfig_PluginLogging(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/plugin_logging.toml")
+	require.NoError(t, err)
+
+	require.Len(t, c.Inputs, 1)
+	assert.Equal(t, "primary", c.Inputs[0].Config.Alias)
+	assert.Equal(t, "debug", c.Inputs[0].Config.LogLevel)
+	assert.Equal(t, "inputs.memcached::primary", c.Inputs[0].LogName())
+	assert.Equal(t, []string{"localhost"},
+		c.Inputs[0].Input.(*memcached.Memcached).Servers)
+
+	require.Len(t, c.Outputs, 1)
+	assert.Equal(t, "backup", c.Outputs[0].Config.Alias)
+	assert.Equal(t, "error", c.Outputs[0].Config.LogLevel)
+	assert.Equal(t, "outputs.http::backup", c.Outputs[0].LogName())
+}
+
+func TestConfig_InvalidLogLevel(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/invalid_log_level.toml")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid log_level")
+}
+
+type dataFormatProcessor struct {
+	parser     parsers.Parser
+	serializer serializers.Serializer
+}
+
+func (p *dataFormatProcessor) SampleConfig() string { return "" }
+func (p *dataFormatProcessor) Description() string  { return "" }
+func (p *dataFormatProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	return in
+}
+func (p *dataFormatProcessor) SetParser(parser parsers.Parser) {
+	p.parser = parser
+}
+func (p *dataFormatProcessor) SetSerializer(serializer serializers.Serializer) {
+	p.serializer = serializer
+}
+
+func TestConfig_ProcessorDataFormat(t *testing.T) {
+	processors.Add("data_format", func() telegraf.Processor {
+		return &dataFormatProcessor{}
+	})
+
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/processor_data_format.toml")
+	require.NoError(t, err)
+	require.Len(t, c.Processors, 1)
+
+	// The data_format applies to both the parser and the serializer.
+	p := c.Processors[0].Processor.(*dataFormatProcessor)
+	metrics, err := p.parser.Parse([]byte(`{"name": "cpu", "value": 42}`))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	require.Equal(t, "cpu", metrics[0].Name())
+
+	b, err := p.serializer.Serialize(metrics[0])
+	require.NoError(t, err)
+	require.Contains(t, string(b), `"name":"cpu"`)
+}
+
+type parserProcessor struct {
+	parser parsers.Parser
+}
+
+func (p *parserProcessor) SampleConfig() string { return "" }
+func (p *parserProcessor) Description() string  { return "" }
+func (p *parserProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	return in
+}
+func (p *parserProcessor) SetParser(parser parsers.Parser) {
+	p.parser = parser
+}
+
+func TestConfig_XMLParser(t *testing.T) {
+	processors.Add("parser_only", func() telegraf.Processor {
+		return &parserProcessor{}
+	})
+
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/xml_parser.toml")
+	require.NoError(t, err)
+	require.Len(t, c.Processors, 1)
+
+	p := c.Processors[0].Processor.(*parserProcessor)
+	metrics, err := p.parser.Parse([]byte(`
+		<sensors>
+			<sensor id="a"><temperature>21.5</temperature><errors>2</errors></sensor>
+			<sensor id="b"><temperature>19</temperature><errors>0</errors></sensor>
+		</sensors>`))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+	require.Equal(t, "sensor", metrics[0].Name())
+	require.Equal(t, map[string]string{"id": "a"}, metrics[0].Tags())
+	require.Equal(t, map[string]interface{}{
+		"temperature": 21.5,
+		"errors":      int64(2),
+	}, metrics[0].Fields())
+}
+
+func TestConfig_ProtobufParser(t *testing.T) {
+	processors.Add("parser_only", func() telegraf.Processor {
+		return &parserProcessor{}
+	})
+
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/protobuf_parser.toml")
+	require.NoError(t, err)
+	require.Len(t, c.Processors, 1)
+
+	msg := proto.NewBuffer(nil)
+	require.NoError(t, msg.EncodeVarint(1<<3|proto.WireBytes))
+	require.NoError(t, msg.EncodeStringBytes("a"))
+	require.NoError(t, msg.EncodeVarint(2<<3|proto.WireFixed64))
+	require.NoError(t, msg.EncodeFixed64(math.Float64bits(21.5)))
+
+	buf := proto.NewBuffer(nil)
+	require.NoError(t, buf.EncodeRawBytes(msg.Bytes()))
+	require.NoError(t, buf.EncodeRawBytes(msg.Bytes()))
+
+	p := c.Processors[0].Processor.(*parserProcessor)
+	metrics, err := p.parser.Parse(buf.Bytes())
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+	require.Equal(t, "parser_only", metrics[0].Name())
+	require.Equal(t, map[string]string{"id": "a"}, metrics[0].Tags())
+	require.Equal(t, map[string]interface{}{"temperature": 21.5}, metrics[0].Fields())
+}
+
+type serializerProcessor struct {
+	serializer serializers.Serializer
+}
+
+func (p *serializerProcessor) SampleConfig() string { return "" }
+func (p *serializerProcessor) Description() string  { return "" }
+func (p *serializerProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	return in
+}
+func (p *serializerProcessor) SetSerializer(serializer serializers.Serializer) {
+	p.serializer = serializer
+}
+
+func TestConfig_CSVSerializer(t *testing.T) {
+	processors.Add("serializer_only", func() telegraf.Processor {
+		return &serializerProcessor{}
+	})
+
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/csv_serializer.toml")
+	require.NoError(t, err)
+	require.Len(t, c.Processors, 1)
+
+	p := c.Processors[0].Processor.(*serializerProcessor)
+	m, err := metric.New("cpu",
+		map[string]string{"host": "server01"},
+		map[string]interface{}{"value": 42.0},
+		time.Unix(0, 0))
+	require.NoError(t, err)
+
+	b, err := p.serializer.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, "name;host;value\ncpu;server01;42\n", string(b))
+}
+
+func TestConfig_UnknownFormatOption(t *testing.T) {
+	processors.Add("parser_only", func() telegraf.Processor {
+		return &parserProcessor{}
+	})
+
+	// The option belongs to the csv data format.
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/unknown_format_option.toml")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "csv_header_row_count")
+}
+
+func TestConfig_DeprecatedFormatOption(t *testing.T) {
+	processors.Add("parser_only", func() telegraf.Processor {
+		return &parserProcessor{}
+	})
+
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/deprecated_format_option.toml")
+	require.NoError(t, err)
+	require.Len(t, c.Processors, 1)
+}
+
+func TestConfig_InvalidDataFormat(t *testing.T) {
+	processors.Add("parser_only", func() telegraf.Processor {
+		return &parserProcessor{}
+	})
+
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/invalid_data_format.toml")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Invalid data format: unknown")
+}
+
+func TestPrintDataFormatConfig(t *testing.T) {
+	require.NoError(t, PrintDataFormatConfig("csv"))
+	require.Error(t, PrintDataFormatConfig("unknown"))
+}
+
+type stageProcessor struct{}
+
+func (p *stageProcessor) SampleConfig() string { return "" }
+func (p *stageProcessor) Description() string  { return "" }
+func (p *stageProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	return in
+}
+
+func TestConfig_ProcessorStage(t *testing.T) {
+	processors.Add("stage", func() telegraf.Processor {
+		return &stageProcessor{}
+	})
+
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/processor_stage.toml")
+	require.NoError(t, err)
+	require.Len(t, c.Processors, 3)
+
+	require.Equal(t, models.ProcessorStagePre, c.Processors[0].Stage())
+	require.True(t, c.Processors[0].IsPreAggregation())
+	require.False(t, c.Processors[0].IsPostAggregation())
+
+	require.Equal(t, models.ProcessorStagePost, c.Processors[1].Stage())
+	require.False(t, c.Processors[1].IsPreAggregation())
+	require.True(t, c.Processors[1].IsPostAggregation())
+
+	require.Equal(t, models.ProcessorStageBoth, c.Processors[2].Stage())
+	require.True(t, c.Processors[2].IsPreAggregation())
+	require.True(t, c.Processors[2].IsPostAggregation())
+}
+
+func TestConfig_ProcessorInvalidStage(t *testing.T) {
+	processors.Add("stage", func() telegraf.Processor {
+		return &stageProcessor{}
+	})
+
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/processor_invalid_stage.toml")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid stage")
+}
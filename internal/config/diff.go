@@ -0,0 +1,311 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/toml/ast"
+)
+
+// PluginChanges describes how the plugins of one type differ between two
+// configurations.  A plugin that was modified is reported as both removed
+// and added.
+type PluginChanges struct {
+	// Unchanged maps the index of a plugin in the new configuration to the
+	// index of the identical plugin in the old configuration.
+	Unchanged map[int]int
+
+	// Added holds indexes into the new configuration.
+	Added []int
+
+	// Removed holds indexes into the old configuration.
+	Removed []int
+
+	AddedNames    []string
+	RemovedNames  []string
+	ModifiedNames []string
+}
+
+// Changed returns true if any plugin was added, removed or modified.
+func (p *PluginChanges) Changed() bool {
+	return len(p.Added) > 0 || len(p.Removed) > 0
+}
+
+// Diff describes the differences between two configurations.
+type Diff struct {
+	// AgentChanged is set if the agent table or the global tags differ, such
+	// changes affect every plugin and cannot be applied in place.
+	AgentChanged bool
+
+	// SecretStoresChanged is set if a secret store was added, removed or
+	// modified.  Running plugins keep using the stores they were loaded
+	// with, so such changes cannot be applied in place.
+	SecretStoresChanged bool
+
+	Inputs      PluginChanges
+	Processors  PluginChanges
+	Aggregators PluginChanges
+	Outputs     PluginChanges
+}
+
+// Changed returns true if the configurations differ.
+func (d *Diff) Changed() bool {
+	return d.AgentChanged ||
+		d.SecretStoresChanged ||
+		d.Inputs.Changed() ||
+		d.Processors.Changed() ||
+		d.Aggregators.Changed() ||
+		d.Outputs.Changed()
+}
+
+// String returns a summary of the added, removed and modified plugins.
+func (d *Diff) String() string {
+	var added, removed, modified []string
+	collect := func(kind string, p *PluginChanges) {
+		for _, name := range p.AddedNames {
+			added = append(added, kind+"."+name)
+		}
+		for _, name := range p.RemovedNames {
+			removed = append(removed, kind+"."+name)
+		}
+		for _, name := range p.ModifiedNames {
+			modified = append(modified, kind+"."+name)
+		}
+	}
+	collect("inputs", &d.Inputs)
+	collect("processors", &d.Processors)
+	collect("aggregators", &d.Aggregators)
+	collect("outputs", &d.Outputs)
+
+	if !d.Changed() {
+		return "no changes"
+	}
+
+	var parts []string
+	if d.AgentChanged {
+		parts = append(parts, "agent settings changed")
+	}
+	if d.SecretStoresChanged {
+		parts = append(parts, "secret stores changed")
+	}
+	if len(added) > 0 {
+		parts = append(parts, "added: "+strings.Join(added, " "))
+	}
+	if len(removed) > 0 {
+		parts = append(parts, "removed: "+strings.Join(removed, " "))
+	}
+	if len(modified) > 0 {
+		parts = append(parts, "modified: "+strings.Join(modified, " "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Compare returns the differences between the old and the new
+// configuration.  Plugins are considered identical if they have the same name
+// and their configuration tables have the same content, ignoring comments and
+// whitespace.
+func Compare(old, new *Config) *Diff {
+	d := &Diff{
+		AgentChanged: !reflect.DeepEqual(old.Agent, new.Agent) ||
+			!reflect.DeepEqual(old.Tags, new.Tags),
+		SecretStoresChanged: !reflect.DeepEqual(
+			old.secretStoreFingerprints(), new.secretStoreFingerprints()),
+	}
+
+	var oldKeys, newKeys []pluginKey
+	for _, p := range old.Inputs {
+		oldKeys = append(oldKeys, old.pluginKey(p.Config.Name, p))
+	}
+	for _, p := range new.Inputs {
+		newKeys = append(newKeys, new.pluginKey(p.Config.Name, p))
+	}
+	d.Inputs = comparePlugins(oldKeys, newKeys)
+
+	oldKeys, newKeys = nil, nil
+	for _, p := range old.Processors {
+		oldKeys = append(oldKeys, old.pluginKey(p.Name, p))
+	}
+	for _, p := range new.Processors {
+		newKeys = append(newKeys, new.pluginKey(p.Name, p))
+	}
+	d.Processors = comparePlugins(oldKeys, newKeys)
+
+	oldKeys, newKeys = nil, nil
+	for _, p := range old.Aggregators {
+		oldKeys = append(oldKeys, old.pluginKey(p.Config.Name, p))
+	}
+	for _, p := range new.Aggregators {
+		newKeys = append(newKeys, new.pluginKey(p.Config.Name, p))
+	}
+	d.Aggregators = comparePlugins(oldKeys, newKeys)
+
+	oldKeys, newKeys = nil, nil
+	for _, p := range old.Outputs {
+		oldKeys = append(oldKeys, old.pluginKey(p.Name, p))
+	}
+	for _, p := range new.Outputs {
+		newKeys = append(newKeys, new.pluginKey(p.Name, p))
+	}
+	d.Outputs = comparePlugins(oldKeys, newKeys)
+
+	return d
+}
+
+type pluginKey struct {
+	name        string
+	fingerprint string
+	// loaded is false for plugins that were not loaded from a file, these
+	// are never considered identical.
+	loaded bool
+}
+
+func (c *Config) pluginKey(name string, plugin interface{}) pluginKey {
+	fingerprint, ok := c.fingerprints[plugin]
+	return pluginKey{name: name, fingerprint: fingerprint, loaded: ok}
+}
+
+func comparePlugins(old, new []pluginKey) PluginChanges {
+	p := PluginChanges{Unchanged: make(map[int]int)}
+
+	matched := make([]bool, len(old))
+	for i, key := range new {
+		found := false
+		if key.loaded {
+			for j, oldKey := range old {
+				if !matched[j] && oldKey == key {
+					matched[j] = true
+					p.Unchanged[i] = j
+					found = true
+					break
+				}
+			}
+		}
+		if !found {
+			p.Added = append(p.Added, i)
+		}
+	}
+	for j := range old {
+		if !matched[j] {
+			p.Removed = append(p.Removed, j)
+		}
+	}
+
+	// Pair up removed and added plugins of the same name for reporting.
+	removed := make(map[string]int)
+	for _, j := range p.Removed {
+		removed[old[j].name]++
+	}
+	for _, i := range p.Added {
+		name := new[i].name
+		if removed[name] > 0 {
+			removed[name]--
+			p.ModifiedNames = append(p.ModifiedNames, name)
+		} else {
+			p.AddedNames = append(p.AddedNames, name)
+		}
+	}
+	for _, j := range p.Removed {
+		name := old[j].name
+		if removed[name] > 0 {
+			removed[name]--
+			p.RemovedNames = append(p.RemovedNames, name)
+		}
+	}
+	return p
+}
+
+// setFingerprint records the fingerprint of the table a plugin was created
+// from.
+func (c *Config) setFingerprint(plugin interface{}, fingerprint string) {
+	if c.fingerprints == nil {
+		c.fingerprints = make(map[interface{}]string)
+	}
+	c.fingerprints[plugin] = fingerprint
+}
+
+// fingerprint returns the content of the table and its sub-tables in a
+// canonical form, so that comments, whitespace and the order of the keys do
+// not affect it.
+func fingerprint(table *ast.Table) string {
+	keys := make([]string, 0, len(table.Fields))
+	for key := range table.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		switch field := table.Fields[key].(type) {
+		case *ast.KeyValue:
+			fmt.Fprintf(&b, "%s=%s\n", key, field.Value.Source())
+		case *ast.Table:
+			fmt.Fprintf(&b, "[%s]\n%s", key, fingerprint(field))
+		case []*ast.Table:
+			for _, t := range field {
+				fmt.Fprintf(&b, "[[%s]]\n%s", key, fingerprint(t))
+			}
+		}
+	}
+	return b.String()
+}
+
+// Merge replaces the plugins of the configuration with the plugins of the new
+// configuration.  Plugins that are unchanged according to the diff keep their
+// current instance, so that their state is retained.
+func (c *Config) Merge(new *Config, d *Diff) {
+	fingerprints := make(map[interface{}]string)
+
+	inputs := make([]*models.RunningInput, len(new.Inputs))
+	for i, p := range new.Inputs {
+		if j, ok := d.Inputs.Unchanged[i]; ok {
+			inputs[i] = c.Inputs[j]
+		} else {
+			inputs[i] = p
+		}
+		fingerprints[inputs[i]] = new.fingerprints[p]
+	}
+
+	processors := make(models.RunningProcessors, len(new.Processors))
+	for i, p := range new.Processors {
+		if j, ok := d.Processors.Unchanged[i]; ok {
+			processors[i] = c.Processors[j]
+		} else {
+			processors[i] = p
+		}
+		fingerprints[processors[i]] = new.fingerprints[p]
+	}
+
+	aggregators := make([]*models.RunningAggregator, len(new.Aggregators))
+	for i, p := range new.Aggregators {
+		if j, ok := d.Aggregators.Unchanged[i]; ok {
+			aggregators[i] = c.Aggregators[j]
+		} else {
+			aggregators[i] = p
+		}
+		fingerprints[aggregators[i]] = new.fingerprints[p]
+	}
+
+	outputs := make([]*models.RunningOutput, len(new.Outputs))
+	for i, p := range new.Outputs {
+		if j, ok := d.Outputs.Unchanged[i]; ok {
+			outputs[i] = c.Outputs[j]
+		} else {
+			outputs[i] = p
+		}
+		fingerprints[outputs[i]] = new.fingerprints[p]
+	}
+
+	// Secret stores are never merged, see Diff.SecretStoresChanged.
+	for _, store := range c.SecretStores {
+		fingerprints[store] = c.fingerprints[store]
+	}
+
+	c.Inputs = inputs
+	c.Processors = processors
+	c.Aggregators = aggregators
+	c.Outputs = outputs
+	c.fingerprints = fingerprints
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadTestConfig(t *testing.T, path string) *Config {
+	c := NewConfig()
+	c.Agent.Hostname = "localhost"
+	err := c.LoadConfig(path)
+	require.NoError(t, err)
+	return c
+}
+
+func TestCompare_Unchanged(t *testing.T) {
+	old := loadTestConfig(t, "./testdata/reload/old.toml")
+	new := loadTestConfig(t, "./testdata/reload/old.toml")
+
+	d := Compare(old, new)
+	assert.False(t, d.Changed())
+	assert.Equal(t, "no changes", d.String())
+	assert.Len(t, d.Inputs.Unchanged, 4)
+	assert.Len(t, d.Outputs.Unchanged, 2)
+}
+
+func TestCompare_Changed(t *testing.T) {
+	old := loadTestConfig(t, "./testdata/reload/old.toml")
+	new := loadTestConfig(t, "./testdata/reload/new.toml")
+
+	d := Compare(old, new)
+	require.True(t, d.Changed())
+	assert.False(t, d.AgentChanged)
+
+	assert.Len(t, d.Inputs.Unchanged, 2)
+	assert.Len(t, d.Inputs.Added, 2)
+	assert.Len(t, d.Inputs.Removed, 2)
+	assert.Equal(t, []string{"exec"}, d.Inputs.AddedNames)
+	assert.Equal(t, []string{"procstat"}, d.Inputs.RemovedNames)
+	assert.Equal(t, []string{"memcached"}, d.Inputs.ModifiedNames)
+
+	assert.Len(t, d.Outputs.Unchanged, 1)
+	assert.Equal(t, []string{"http"}, d.Outputs.ModifiedNames)
+
+	assert.Equal(t,
+		"added: inputs.exec; removed: inputs.procstat; modified: inputs.memcached outputs.http",
+		d.String())
+}
+
+func TestCompare_AgentChanged(t *testing.T) {
+	old := loadTestConfig(t, "./testdata/reload/old.toml")
+	new := loadTestConfig(t, "./testdata/reload/agent.toml")
+
+	d := Compare(old, new)
+	assert.True(t, d.AgentChanged)
+}
+
+func TestCompare_SecretStoresChanged(t *testing.T) {
+	old := loadTestConfig(t, "./testdata/reload/old.toml")
+	new := loadTestConfig(t, "./testdata/reload/secretstores.toml")
+
+	d := Compare(old, new)
+	assert.True(t, d.SecretStoresChanged)
+	assert.False(t, d.Inputs.Changed())
+	assert.Equal(t, "secret stores changed", d.String())
+
+	again := loadTestConfig(t, "./testdata/reload/secretstores.toml")
+	d = Compare(new, again)
+	assert.False(t, d.Changed())
+
+	// Stores are kept when merging unrelated plugin changes.
+	new.Merge(again, d)
+	assert.False(t, Compare(new, again).Changed())
+}
+
+func TestMerge_KeepsUnchangedPlugins(t *testing.T) {
+	old := loadTestConfig(t, "./testdata/reload/old.toml")
+	new := loadTestConfig(t, "./testdata/reload/new.toml")
+
+	d := Compare(old, new)
+	unchanged := make(map[interface{}]bool)
+	for _, j := range d.Inputs.Unchanged {
+		unchanged[old.Inputs[j]] = true
+	}
+	for _, j := range d.Outputs.Unchanged {
+		unchanged[old.Outputs[j]] = true
+	}
+
+	old.Merge(new, d)
+	require.Len(t, old.Inputs, 4)
+	require.Len(t, old.Outputs, 2)
+
+	reused := 0
+	for _, input := range old.Inputs {
+		if unchanged[input] {
+			reused++
+		}
+	}
+	for _, output := range old.Outputs {
+		if unchanged[output] {
+			reused++
+		}
+	}
+	assert.Equal(t, 3, reused)
+
+	// Comparing against the merged configuration finds no changes.
+	again := loadTestConfig(t, "./testdata/reload/new.toml")
+	assert.False(t, Compare(old, again).Changed())
+}
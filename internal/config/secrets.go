@@ -0,0 +1,190 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/internal/secret"
+	"github.com/influxdata/telegraf/plugins/secretstores"
+	"github.com/influxdata/telegraf/selfstat"
+	"github.com/influxdata/toml"
+	"github.com/influxdata/toml/ast"
+)
+
+// secretStoreIDRe matches the valid ids of a secret store, as used in
+// references of the form @{id:key}.
+var secretStoreIDRe = regexp.MustCompile(`^\w+$`)
+
+func (c *Config) addSecretStore(name string, table *ast.Table) error {
+	creator, ok := secretstores.SecretStores[name]
+	if !ok {
+		return fmt.Errorf("Undefined but requested secretstore: %s", name)
+	}
+	store := creator()
+	source := name + "\n" + fingerprint(table)
+
+	var id string
+	if node, ok := table.Fields["id"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				id = str.Value
+			}
+		}
+	}
+	if !secretStoreIDRe.MatchString(id) {
+		return fmt.Errorf("secretstores.%s: id must be set and may only contain "+
+			"letters, digits and underscores", name)
+	}
+	if _, ok := c.SecretStores[id]; ok {
+		return fmt.Errorf("secretstores.%s: duplicate id %q", name, id)
+	}
+	delete(table.Fields, "id")
+
+	if err := toml.UnmarshalTable(table, store); err != nil {
+		return err
+	}
+
+	tags := map[string]string{"secretstore": name, "id": id}
+	logger := models.NewLogger("secretstores."+name+"::"+id, "",
+		selfstat.Register("secretstore", "errors", tags))
+	models.SetLoggerOnPlugin(store, logger)
+
+	c.setFingerprint(store, source)
+	c.SecretStores[id] = store
+	return nil
+}
+
+// InitSecretStores runs the Init function of the secret stores and checks
+// that the secrets of all plugins only reference configured stores.
+func (c *Config) InitSecretStores() error {
+	for id, store := range c.SecretStores {
+		if p, ok := store.(telegraf.Initializer); ok {
+			err := p.Init()
+			if err != nil {
+				return fmt.Errorf("could not initialize secret store %s: %v", id, err)
+			}
+		}
+	}
+
+	for _, s := range c.secrets {
+		for _, ref := range s.References() {
+			if _, ok := c.SecretStores[ref.Store]; !ok {
+				return fmt.Errorf("secret @{%s:%s} references unknown secret store %q",
+					ref.Store, ref.Key, ref.Store)
+			}
+		}
+	}
+	return nil
+}
+
+// resolveSecret returns the current value of a secret in a store.
+func (c *Config) resolveSecret(store, key string) ([]byte, error) {
+	s, ok := c.SecretStores[store]
+	if !ok {
+		return nil, fmt.Errorf("unknown secret store %q", store)
+	}
+	return s.Get(key)
+}
+
+// linkSecrets connects the secrets in the fields of a plugin to the secret
+// stores of the configuration.  References to secrets are only resolved in
+// secret fields, an error is returned if any other string field contains one
+// so that the reference is not used verbatim.
+func (c *Config) linkSecrets(name string, plugin interface{}) error {
+	var secrets []*secret.Secret
+	visited := make(map[uintptr]bool)
+	err := walkSecrets(reflect.ValueOf(plugin), "", visited, func(s *secret.Secret) {
+		secrets = append(secrets, s)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+
+	for _, s := range secrets {
+		s.Link(secret.ResolverFunc(c.resolveSecret))
+		c.secrets = append(c.secrets, s)
+	}
+	return nil
+}
+
+var secretType = reflect.TypeOf(secret.Secret{})
+
+// walkSecrets calls fn for each Secret reachable through the exported fields
+// of v.  An error is returned for a string that references a secret.
+func walkSecrets(
+	v reflect.Value,
+	path string,
+	visited map[uintptr]bool,
+	fn func(*secret.Secret),
+) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || visited[v.Pointer()] {
+			return nil
+		}
+		visited[v.Pointer()] = true
+		return walkSecrets(v.Elem(), path, visited, fn)
+	case reflect.Interface:
+		if !v.IsNil() {
+			return walkSecrets(v.Elem(), path, visited, fn)
+		}
+	case reflect.Struct:
+		if v.Type() == secretType {
+			if v.CanAddr() {
+				fn(v.Addr().Interface().(*secret.Secret))
+			}
+			return nil
+		}
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			name := strings.Split(field.Tag.Get("toml"), ",")[0]
+			if name == "" {
+				name = field.Name
+			}
+			if path != "" {
+				name = path + "." + name
+			}
+			err := walkSecrets(v.Field(i), name, visited, fn)
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			err := walkSecrets(v.Index(i), path, visited, fn)
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			err := walkSecrets(v.MapIndex(key), path, visited, fn)
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.String:
+		if secret.HasReference(v.String()) {
+			return fmt.Errorf("option %q references a secret but does not "+
+				"support secrets", path)
+		}
+	}
+	return nil
+}
+
+// secretStoreFingerprints returns the fingerprints of the secret stores by
+// their id.
+func (c *Config) secretStoreFingerprints() map[string]string {
+	fingerprints := make(map[string]string, len(c.SecretStores))
+	for id, store := range c.SecretStores {
+		fingerprints[id] = c.fingerprints[store]
+	}
+	return fingerprints
+}
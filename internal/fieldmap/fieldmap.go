@@ -0,0 +1,126 @@
+package fieldmap
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// Mapping selects the measurement, tags, fields and timestamp of a metric
+// from the flattened values of a decoded record.
+type Mapping struct {
+	// MetricName is used as the metric name if Measurement is empty.
+	MetricName string
+
+	// Measurement is the key of the value used as the metric name.
+	Measurement string
+
+	// Tags are the keys of the values used as tags.
+	Tags []string
+
+	// Fields are the keys of the values used as fields, if empty all values
+	// not used otherwise are added as fields.
+	Fields []string
+
+	// Timestamp is the key of the value used as the metric time, if empty
+	// the current time is used.
+	Timestamp string
+
+	// TimestampFormat is the format of the Timestamp, either a Go time
+	// layout or one of unix, unix_ms, unix_us or unix_ns.  Defaults to unix.
+	TimestampFormat string
+
+	DefaultTags map[string]string
+	TimeFunc    func() time.Time
+}
+
+// Metric creates a metric from the values, which must be of type bool,
+// int64, uint64, float64, string or time.Time.
+func (m *Mapping) Metric(values map[string]interface{}) (telegraf.Metric, error) {
+	name := m.MetricName
+	if m.Measurement != "" {
+		v, ok := values[m.Measurement]
+		if !ok {
+			return nil, fmt.Errorf("measurement %q not found", m.Measurement)
+		}
+		name = toString(v)
+		delete(values, m.Measurement)
+	}
+
+	var timestamp time.Time
+	if m.Timestamp != "" {
+		v, ok := values[m.Timestamp]
+		if !ok {
+			return nil, fmt.Errorf("timestamp %q not found", m.Timestamp)
+		}
+		var err error
+		timestamp, err = m.parseTime(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp %q: %v", m.Timestamp, err)
+		}
+		delete(values, m.Timestamp)
+	} else if m.TimeFunc != nil {
+		timestamp = m.TimeFunc()
+	} else {
+		timestamp = time.Now()
+	}
+
+	tags := make(map[string]string)
+	for k, v := range m.DefaultTags {
+		tags[k] = v
+	}
+	for _, key := range m.Tags {
+		if v, ok := values[key]; ok {
+			tags[key] = toString(v)
+			delete(values, key)
+		}
+	}
+
+	fields := make(map[string]interface{})
+	if len(m.Fields) > 0 {
+		for _, key := range m.Fields {
+			if v, ok := values[key]; ok {
+				fields[key] = toField(v)
+			}
+		}
+	} else {
+		for key, v := range values {
+			fields[key] = toField(v)
+		}
+	}
+
+	return metric.New(name, tags, fields, timestamp)
+}
+
+func (m *Mapping) parseTime(value interface{}) (time.Time, error) {
+	format := m.TimestampFormat
+	if format == "" {
+		format = "unix"
+	}
+
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case uint64:
+		return internal.ParseTimestamp(int64(v), format)
+	default:
+		return internal.ParseTimestamp(v, format)
+	}
+}
+
+func toField(value interface{}) interface{} {
+	if v, ok := value.(time.Time); ok {
+		return v.UnixNano()
+	}
+	return value
+}
+
+func toString(value interface{}) string {
+	if v, ok := value.(time.Time); ok {
+		return v.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprintf("%v", value)
+}
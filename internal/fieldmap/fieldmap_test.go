@@ -0,0 +1,105 @@
+package fieldmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var exptime = time.Date(2020, time.August, 1, 15, 4, 3, 0, time.UTC)
+
+func TestMetric(t *testing.T) {
+	tests := []struct {
+		name     string
+		mapping  Mapping
+		values   map[string]interface{}
+		expected telegraf.Metric
+	}{
+		{
+			name: "defaults",
+			mapping: Mapping{
+				MetricName:  "test",
+				DefaultTags: map[string]string{"source": "kafka"},
+				TimeFunc:    func() time.Time { return exptime },
+			},
+			values: map[string]interface{}{
+				"value":   42.0,
+				"created": exptime,
+			},
+			expected: testutil.MustMetric("test",
+				map[string]string{"source": "kafka"},
+				map[string]interface{}{
+					"value":   42.0,
+					"created": exptime.UnixNano(),
+				},
+				exptime),
+		},
+		{
+			name: "mapping",
+			mapping: Mapping{
+				MetricName:      "test",
+				Measurement:     "name",
+				Tags:            []string{"host", "created", "missing"},
+				Fields:          []string{"value"},
+				Timestamp:       "time",
+				TimestampFormat: "unix_ms",
+			},
+			values: map[string]interface{}{
+				"name":    "cpu",
+				"host":    "server01",
+				"created": exptime,
+				"value":   int64(42),
+				"other":   true,
+				"time":    uint64(exptime.UnixNano() / int64(time.Millisecond)),
+			},
+			expected: testutil.MustMetric("cpu",
+				map[string]string{
+					"host":    "server01",
+					"created": "2020-08-01T15:04:03Z",
+				},
+				map[string]interface{}{
+					"value": int64(42),
+				},
+				exptime),
+		},
+		{
+			name: "time layout",
+			mapping: Mapping{
+				MetricName:      "test",
+				Timestamp:       "time",
+				TimestampFormat: "2006-01-02T15:04:05Z07:00",
+			},
+			values: map[string]interface{}{
+				"time":  "2020-08-01T15:04:03Z",
+				"value": 42.0,
+			},
+			expected: testutil.MustMetric("test",
+				map[string]string{},
+				map[string]interface{}{
+					"value": 42.0,
+				},
+				exptime),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := tt.mapping.Metric(tt.values)
+			require.NoError(t, err)
+			testutil.RequireMetricEqual(t, tt.expected, m)
+		})
+	}
+}
+
+func TestMetricErrors(t *testing.T) {
+	_, err := (&Mapping{Measurement: "name"}).Metric(map[string]interface{}{"value": 1.0})
+	require.Error(t, err)
+
+	_, err = (&Mapping{Timestamp: "time"}).Metric(map[string]interface{}{"value": 1.0})
+	require.Error(t, err)
+
+	_, err = (&Mapping{Timestamp: "time"}).Metric(map[string]interface{}{"time": "yesterday"})
+	require.Error(t, err)
+}
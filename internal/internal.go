@@ -336,6 +336,24 @@ func CompressWithGzip(data io.Reader) (io.Reader, error) {
 	return pipeReader, err
 }
 
+// FormatTimestamp formats the time in one of the formats accepted by
+// ParseTimestamp, either a unix epoch of various precision or a Go time
+// layout.
+func FormatTimestamp(t time.Time, format string) string {
+	switch strings.ToLower(format) {
+	case "unix":
+		return strconv.FormatInt(t.Unix(), 10)
+	case "unix_ms":
+		return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+	case "unix_us":
+		return strconv.FormatInt(t.UnixNano()/int64(time.Microsecond), 10)
+	case "unix_ns":
+		return strconv.FormatInt(t.UnixNano(), 10)
+	default:
+		return t.Format(format)
+	}
+}
+
 // ParseTimestamp with no location provided parses a timestamp value as UTC
 func ParseTimestamp(timestamp interface{}, format string) (time.Time, error) {
 	return ParseTimestampWithLocation(timestamp, format, "UTC")
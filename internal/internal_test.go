@@ -318,6 +318,25 @@ func TestParseTimestamp(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestFormatTimestamp(t *testing.T) {
+	tm := time.Date(2019, time.February, 20, 21, 50, 34, 29665000, time.UTC)
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"unix", "1550699434"},
+		{"unix_ms", "1550699434029"},
+		{"unix_us", "1550699434029665"},
+		{"unix_ns", "1550699434029665000"},
+		{"2006-01-02 15:04:05.000000", "2019-02-20 21:50:34.029665"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			require.Equal(t, tt.expected, FormatTimestamp(tm, tt.format))
+		})
+	}
+}
+
 func TestParseTimestampWithLocation(t *testing.T) {
 	time, err := ParseTimestampWithLocation("2019-02-20 21:50:34.029665", "2006-01-02 15:04:05.000000", "UTC")
 	assert.Nil(t, err)
@@ -0,0 +1,375 @@
+// Package mmdb reads MaxMind DB files, the format of the GeoIP2 and GeoLite2
+// databases.
+//
+// The format is described in the MaxMind DB File Format Specification
+// version 2.0, the whole file is kept in memory.
+package mmdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net"
+)
+
+// metadataMarker precedes the metadata at the end of the file.
+var metadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// dataSectionSeparator is the number of zero bytes between the search tree
+// and the data section.
+const dataSectionSeparator = 16
+
+// maxDepth is the maximum nesting of maps and arrays, it stops a corrupt
+// database with cyclic pointers from overflowing the stack.
+const maxDepth = 512
+
+// Data types of the data section.
+const (
+	typeExtended = iota
+	typePointer
+	typeString
+	typeDouble
+	typeBytes
+	typeUint16
+	typeUint32
+	typeMap
+	typeInt32
+	typeUint64
+	typeUint128
+	typeArray
+	typeContainer
+	typeEndMarker
+	typeBool
+	typeFloat
+)
+
+// Metadata describes the database.
+type Metadata struct {
+	NodeCount    uint
+	RecordSize   uint
+	IPVersion    uint
+	DatabaseType string
+	BuildEpoch   uint64
+}
+
+// Reader looks up addresses in a database.
+type Reader struct {
+	Metadata Metadata
+
+	tree       []byte
+	data       []byte
+	ipv4Start  uint
+	nodeLength uint
+}
+
+// Open reads the database file.
+func Open(path string) (*Reader, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return FromBytes(buf)
+}
+
+// FromBytes returns a reader of the database in buf.
+func FromBytes(buf []byte) (*Reader, error) {
+	i := bytes.LastIndex(buf, metadataMarker)
+	if i < 0 {
+		return nil, fmt.Errorf("invalid MaxMind DB, metadata not found")
+	}
+
+	meta := buf[i+len(metadataMarker):]
+	d := decoder{buf: meta}
+	v, _, err := d.decode(0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MaxMind DB metadata: %v", err)
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid MaxMind DB metadata, expecting a map")
+	}
+
+	r := &Reader{
+		Metadata: Metadata{
+			NodeCount:    uint(toUint64(m["node_count"])),
+			RecordSize:   uint(toUint64(m["record_size"])),
+			IPVersion:    uint(toUint64(m["ip_version"])),
+			BuildEpoch:   toUint64(m["build_epoch"]),
+			DatabaseType: fmt.Sprint(m["database_type"]),
+		},
+	}
+	switch r.Metadata.RecordSize {
+	case 24, 28, 32:
+	default:
+		return nil, fmt.Errorf("unsupported MaxMind DB record size %d", r.Metadata.RecordSize)
+	}
+	if r.Metadata.IPVersion != 4 && r.Metadata.IPVersion != 6 {
+		return nil, fmt.Errorf("unsupported MaxMind DB IP version %d", r.Metadata.IPVersion)
+	}
+
+	r.nodeLength = r.Metadata.RecordSize / 4
+	treeSize := r.Metadata.NodeCount * r.nodeLength
+	if treeSize+dataSectionSeparator > uint(i) {
+		return nil, fmt.Errorf("invalid MaxMind DB, search tree exceeds the file")
+	}
+	r.tree = buf[:treeSize]
+	r.data = buf[treeSize+dataSectionSeparator : i]
+
+	// IPv4 addresses are in the ::/96 subnet of IPv6 databases.
+	if r.Metadata.IPVersion == 6 {
+		node := uint(0)
+		for n := 0; n < 96 && node < r.Metadata.NodeCount; n++ {
+			node = r.record(node, 0)
+		}
+		r.ipv4Start = node
+	}
+	return r, nil
+}
+
+// Lookup returns the data of the network containing the address, false if
+// the address is not in the database.
+func (r *Reader) Lookup(ip net.IP) (interface{}, bool, error) {
+	node := uint(0)
+	bits := 128
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+		bits = 32
+		node = r.ipv4Start
+	} else if r.Metadata.IPVersion == 4 {
+		return nil, false, fmt.Errorf("IPv6 address %s in an IPv4 database", ip)
+	} else if len(ip) != net.IPv6len {
+		return nil, false, fmt.Errorf("invalid IP address %v", []byte(ip))
+	}
+
+	for i := 0; i < bits && node < r.Metadata.NodeCount; i++ {
+		bit := uint(ip[i>>3]>>(7-uint(i&7))) & 1
+		node = r.record(node, bit)
+	}
+
+	switch {
+	case node == r.Metadata.NodeCount:
+		return nil, false, nil
+	case node < r.Metadata.NodeCount:
+		return nil, false, fmt.Errorf("invalid MaxMind DB, search tree deeper than the address")
+	}
+
+	offset := node - r.Metadata.NodeCount - dataSectionSeparator
+	d := decoder{buf: r.data}
+	v, _, err := d.decode(offset, 0)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid MaxMind DB data: %v", err)
+	}
+	return v, true, nil
+}
+
+// record returns the left (0) or right (1) record of the node.
+func (r *Reader) record(node, bit uint) uint {
+	b := r.tree[node*r.nodeLength : (node+1)*r.nodeLength]
+	switch r.Metadata.RecordSize {
+	case 24:
+		b = b[bit*3:]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		if bit == 0 {
+			return uint(b[3]&0xF0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3]&0x0F)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		return uint(binary.BigEndian.Uint32(b[bit*4:]))
+	}
+}
+
+// decoder decodes the values of the data section.
+type decoder struct {
+	buf []byte
+}
+
+// decode returns the value at the offset and the offset following it, depth
+// is the number of maps and arrays the value is nested in.
+func (d *decoder) decode(offset uint, depth int) (interface{}, uint, error) {
+	if depth > maxDepth {
+		return nil, 0, fmt.Errorf("data nested deeper than %d levels", maxDepth)
+	}
+
+	typ, size, offset, err := d.control(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if typ == typePointer {
+		pointer, next, err := d.pointer(size, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		// The specification does not allow a pointer to a pointer.
+		typ, _, _, err := d.control(pointer)
+		if err != nil {
+			return nil, 0, err
+		}
+		if typ == typePointer {
+			return nil, 0, fmt.Errorf("pointer at offset %d points to a pointer", offset-1)
+		}
+		v, _, err := d.decode(pointer, depth)
+		return v, next, err
+	}
+
+	// Each element takes at least one byte, reject sizes that cannot fit
+	// before allocating.
+	if (typ == typeMap || typ == typeArray) && size > uint(len(d.buf))-offset {
+		return nil, 0, fmt.Errorf("%d elements exceed the data section", size)
+	}
+
+	switch typ {
+	case typeMap:
+		m := make(map[string]interface{}, size)
+		for i := uint(0); i < size; i++ {
+			var k, v interface{}
+			k, offset, err = d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			key, ok := k.(string)
+			if !ok {
+				return nil, 0, fmt.Errorf("map key is not a string")
+			}
+			v, offset, err = d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			m[key] = v
+		}
+		return m, offset, nil
+	case typeArray:
+		a := make([]interface{}, 0, size)
+		for i := uint(0); i < size; i++ {
+			var v interface{}
+			v, offset, err = d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			a = append(a, v)
+		}
+		return a, offset, nil
+	case typeBool:
+		return size != 0, offset, nil
+	}
+
+	if offset+size > uint(len(d.buf)) {
+		return nil, 0, fmt.Errorf("value exceeds the data section")
+	}
+	b := d.buf[offset : offset+size]
+	next := offset + size
+
+	switch typ {
+	case typeString:
+		return string(b), next, nil
+	case typeBytes:
+		return append([]byte(nil), b...), next, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, fmt.Errorf("invalid double size %d", size)
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), next, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, fmt.Errorf("invalid float size %d", size)
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), next, nil
+	case typeUint16, typeUint32, typeUint64:
+		if size > 8 {
+			return nil, 0, fmt.Errorf("invalid unsigned integer size %d", size)
+		}
+		return uintValue(b), next, nil
+	case typeInt32:
+		if size > 4 {
+			return nil, 0, fmt.Errorf("invalid int32 size %d", size)
+		}
+		return int64(int32(uintValue(b))), next, nil
+	case typeUint128:
+		if size > 16 {
+			return nil, 0, fmt.Errorf("invalid uint128 size %d", size)
+		}
+		return append([]byte(nil), b...), next, nil
+	}
+	return nil, 0, fmt.Errorf("unsupported data type %d", typ)
+}
+
+// control returns the type and size of the value at the offset, and the
+// offset of its payload.
+func (d *decoder) control(offset uint) (uint, uint, uint, error) {
+	if offset >= uint(len(d.buf)) {
+		return 0, 0, 0, fmt.Errorf("offset %d exceeds the data section", offset)
+	}
+	ctrl := d.buf[offset]
+	offset++
+
+	typ := uint(ctrl >> 5)
+	if typ == typeExtended {
+		if offset >= uint(len(d.buf)) {
+			return 0, 0, 0, fmt.Errorf("unexpected end of the data section")
+		}
+		typ = 7 + uint(d.buf[offset])
+		offset++
+	}
+
+	size := uint(ctrl & 0x1F)
+	if typ == typePointer || size < 29 {
+		return typ, size, offset, nil
+	}
+
+	n := size - 28
+	if offset+n > uint(len(d.buf)) {
+		return 0, 0, 0, fmt.Errorf("unexpected end of the data section")
+	}
+	ext := uint(uintValue(d.buf[offset : offset+n]))
+	switch size {
+	case 29:
+		size = 29 + ext
+	case 30:
+		size = 285 + ext
+	default:
+		size = 65821 + ext
+	}
+	return typ, size, offset + n, nil
+}
+
+// pointer returns the offset the pointer refers to and the offset following
+// the pointer, size holds the five low bits of its control byte.
+func (d *decoder) pointer(size, offset uint) (uint, uint, error) {
+	n := (size>>3)&0x3 + 1
+	if offset+n > uint(len(d.buf)) {
+		return 0, 0, fmt.Errorf("unexpected end of the data section")
+	}
+	b := uint(uintValue(d.buf[offset : offset+n]))
+	v := size & 0x7
+
+	var pointer uint
+	switch n {
+	case 1:
+		pointer = v<<8 | b
+	case 2:
+		pointer = (v<<16 | b) + 2048
+	case 3:
+		pointer = (v<<24 | b) + 526336
+	default:
+		pointer = b
+	}
+	return pointer, offset + n, nil
+}
+
+func uintValue(b []byte) uint64 {
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v
+}
+
+func toUint64(v interface{}) uint64 {
+	if n, ok := v.(uint64); ok {
+		return n
+	}
+	return 0
+}
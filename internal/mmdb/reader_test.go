@@ -0,0 +1,458 @@
+package mmdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// node is a node of the search tree built by the writer, a child is either a
+// node, data or empty.
+type node struct {
+	children [2]*node
+	data     [2]interface{}
+}
+
+// writer builds a database for the tests.
+type writer struct {
+	ipVersion  int
+	recordSize int
+	root       node
+}
+
+func (w *writer) insert(cidr string, data interface{}) {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	ip := network.IP
+	ones, _ := network.Mask.Size()
+	if w.ipVersion == 6 && len(ip) == net.IPv4len {
+		ip = append(make(net.IP, 12), ip...)
+		ones += 96
+	}
+
+	n := &w.root
+	for i := 0; i < ones; i++ {
+		bit := (ip[i>>3] >> (7 - uint(i&7))) & 1
+		if i == ones-1 {
+			n.data[bit] = data
+			break
+		}
+		if n.children[bit] == nil {
+			n.children[bit] = &node{}
+		}
+		n = n.children[bit]
+	}
+}
+
+func (w *writer) bytes() []byte {
+	// Number the nodes breadth first.
+	var nodes []*node
+	index := map[*node]int{}
+	queue := []*node{&w.root}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		index[n] = len(nodes)
+		nodes = append(nodes, n)
+		for _, c := range n.children {
+			if c != nil {
+				queue = append(queue, c)
+			}
+		}
+	}
+
+	var data bytes.Buffer
+	var tree bytes.Buffer
+	for _, n := range nodes {
+		var records [2]uint32
+		for bit := range records {
+			switch {
+			case n.children[bit] != nil:
+				records[bit] = uint32(index[n.children[bit]])
+			case n.data[bit] != nil:
+				offset := data.Len()
+				data.Write(encode(n.data[bit]))
+				records[bit] = uint32(len(nodes) + dataSectionSeparator + offset)
+			default:
+				records[bit] = uint32(len(nodes))
+			}
+		}
+		tree.Write(encodeNode(records, w.recordSize))
+	}
+
+	var buf bytes.Buffer
+	buf.Write(tree.Bytes())
+	buf.Write(make([]byte, dataSectionSeparator))
+	buf.Write(data.Bytes())
+	buf.Write(metadataMarker)
+	buf.Write(encode(map[string]interface{}{
+		"node_count":                  uint32(len(nodes)),
+		"record_size":                 uint16(w.recordSize),
+		"ip_version":                  uint16(w.ipVersion),
+		"database_type":               "Test",
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint64(1577836800),
+	}))
+	return buf.Bytes()
+}
+
+func encodeNode(records [2]uint32, recordSize int) []byte {
+	l, r := records[0], records[1]
+	switch recordSize {
+	case 24:
+		return []byte{byte(l >> 16), byte(l >> 8), byte(l), byte(r >> 16), byte(r >> 8), byte(r)}
+	case 28:
+		return []byte{
+			byte(l >> 16), byte(l >> 8), byte(l),
+			byte(l>>20)&0xF0 | byte(r>>24)&0x0F,
+			byte(r >> 16), byte(r >> 8), byte(r),
+		}
+	default:
+		b := make([]byte, 8)
+		binary.BigEndian.PutUint32(b, l)
+		binary.BigEndian.PutUint32(b[4:], r)
+		return b
+	}
+}
+
+func encodeControl(typ, size int) []byte {
+	var b []byte
+	var ext []byte
+	switch {
+	case size < 29:
+	case size < 285:
+		ext = []byte{byte(size - 29)}
+		size = 29
+	case size < 65821:
+		ext = []byte{byte((size - 285) >> 8), byte(size - 285)}
+		size = 30
+	default:
+		s := size - 65821
+		ext = []byte{byte(s >> 16), byte(s >> 8), byte(s)}
+		size = 31
+	}
+	if typ > 7 {
+		b = []byte{byte(size), byte(typ - 7)}
+	} else {
+		b = []byte{byte(typ<<5 | size)}
+	}
+	return append(b, ext...)
+}
+
+func encodeUint(typ int, v uint64) []byte {
+	var b []byte
+	for ; v > 0; v >>= 8 {
+		b = append([]byte{byte(v)}, b...)
+	}
+	return append(encodeControl(typ, len(b)), b...)
+}
+
+func encode(v interface{}) []byte {
+	switch v := v.(type) {
+	case string:
+		return append(encodeControl(typeString, len(v)), v...)
+	case float64:
+		b := make([]byte, 8)
+		binary.BigEndian.PutUint64(b, math.Float64bits(v))
+		return append(encodeControl(typeDouble, 8), b...)
+	case float32:
+		b := make([]byte, 4)
+		binary.BigEndian.PutUint32(b, math.Float32bits(v))
+		return append(encodeControl(typeFloat, 4), b...)
+	case bool:
+		size := 0
+		if v {
+			size = 1
+		}
+		return encodeControl(typeBool, size)
+	case uint16:
+		return encodeUint(typeUint16, uint64(v))
+	case uint32:
+		return encodeUint(typeUint32, uint64(v))
+	case uint64:
+		return encodeUint(typeUint64, v)
+	case int32:
+		b := make([]byte, 4)
+		binary.BigEndian.PutUint32(b, uint32(v))
+		return append(encodeControl(typeInt32, 4), b...)
+	case []interface{}:
+		b := encodeControl(typeArray, len(v))
+		for _, e := range v {
+			b = append(b, encode(e)...)
+		}
+		return b
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b := encodeControl(typeMap, len(v))
+		for _, k := range keys {
+			b = append(b, encode(k)...)
+			b = append(b, encode(v[k])...)
+		}
+		return b
+	}
+	panic("unsupported type")
+}
+
+func TestLookup(t *testing.T) {
+	city := map[string]interface{}{
+		"country": map[string]interface{}{
+			"iso_code": "DE",
+			"names":    map[string]interface{}{"en": "Germany", "de": "Deutschland"},
+		},
+		"location": map[string]interface{}{
+			"latitude":  52.52,
+			"longitude": 13.405,
+		},
+		"subdivisions": []interface{}{
+			map[string]interface{}{"iso_code": "BE"},
+		},
+		"is_anycast": true,
+		"offset":     int32(-3600),
+		"accuracy":   float32(0.5),
+		"geoname_id": uint32(2950159),
+	}
+	asn := map[string]interface{}{
+		"autonomous_system_number":       uint32(64512),
+		"autonomous_system_organization": "Example Networks",
+	}
+
+	for _, ipVersion := range []int{4, 6} {
+		for _, recordSize := range []int{24, 28, 32} {
+			w := &writer{ipVersion: ipVersion, recordSize: recordSize}
+			w.insert("192.0.2.0/24", city)
+			w.insert("198.51.100.128/25", asn)
+			if ipVersion == 6 {
+				w.insert("2001:db8::/32", asn)
+			}
+
+			r, err := FromBytes(w.bytes())
+			require.NoError(t, err)
+			require.Equal(t, uint(recordSize), r.Metadata.RecordSize)
+			require.Equal(t, uint(ipVersion), r.Metadata.IPVersion)
+			require.Equal(t, "Test", r.Metadata.DatabaseType)
+			require.Equal(t, uint64(1577836800), r.Metadata.BuildEpoch)
+
+			v, ok, err := r.Lookup(net.ParseIP("192.0.2.17"))
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, map[string]interface{}{
+				"country": map[string]interface{}{
+					"iso_code": "DE",
+					"names":    map[string]interface{}{"en": "Germany", "de": "Deutschland"},
+				},
+				"location": map[string]interface{}{
+					"latitude":  52.52,
+					"longitude": 13.405,
+				},
+				"subdivisions": []interface{}{
+					map[string]interface{}{"iso_code": "BE"},
+				},
+				"is_anycast": true,
+				"offset":     int64(-3600),
+				"accuracy":   0.5,
+				"geoname_id": uint64(2950159),
+			}, v)
+
+			v, ok, err = r.Lookup(net.ParseIP("198.51.100.200"))
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, uint64(64512), v.(map[string]interface{})["autonomous_system_number"])
+
+			_, ok, err = r.Lookup(net.ParseIP("198.51.100.1"))
+			require.NoError(t, err)
+			require.False(t, ok)
+
+			if ipVersion == 6 {
+				v, ok, err = r.Lookup(net.ParseIP("2001:db8::1"))
+				require.NoError(t, err)
+				require.True(t, ok)
+				require.Equal(t, "Example Networks", v.(map[string]interface{})["autonomous_system_organization"])
+			} else {
+				_, _, err = r.Lookup(net.ParseIP("2001:db8::1"))
+				require.Error(t, err)
+			}
+		}
+	}
+}
+
+func TestDecodeSizesAndPointers(t *testing.T) {
+	long := string(bytes.Repeat([]byte("x"), 300))
+	buf := encode(long)
+	// A map whose value is a pointer to the string at offset 0.
+	offset := len(buf)
+	buf = append(buf, encodeControl(typeMap, 1)...)
+	buf = append(buf, encode("key")...)
+	buf = append(buf, byte(typePointer<<5), 0)
+
+	d := decoder{buf: buf}
+	v, next, err := d.decode(0, 0)
+	require.NoError(t, err)
+	require.Equal(t, long, v)
+	require.Equal(t, uint(offset), next)
+
+	v, next, err = d.decode(uint(offset), 0)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"key": long}, v)
+	require.Equal(t, uint(len(buf)), next)
+}
+
+func TestDecodePointerToPointer(t *testing.T) {
+	buf := encode("value")
+	// A pointer to a pointer to the string at offset 0.
+	offset := len(buf)
+	buf = append(buf, byte(typePointer<<5), 0)
+	buf = append(buf, byte(typePointer<<5), byte(offset))
+
+	d := decoder{buf: buf}
+	_, _, err := d.decode(uint(offset), 0)
+	require.NoError(t, err)
+	_, _, err = d.decode(uint(offset+2), 0)
+	require.Error(t, err)
+}
+
+func TestDecodeCyclicPointer(t *testing.T) {
+	// A map whose value is a pointer back to the map itself.
+	buf := encodeControl(typeMap, 1)
+	buf = append(buf, encode("key")...)
+	buf = append(buf, byte(typePointer<<5), 0)
+
+	d := decoder{buf: buf}
+	_, _, err := d.decode(0, 0)
+	require.Error(t, err)
+}
+
+// The databases in testdata are published by MaxMind, see testdata/README.md.
+func TestMaxMindDatabases(t *testing.T) {
+	tests := []struct {
+		name     string
+		ip       string
+		expected interface{}
+		found    bool
+	}{
+		{name: "ipv4", ip: "1.1.1.1", expected: map[string]interface{}{"ip": "1.1.1.1"}, found: true},
+		{name: "ipv4", ip: "1.1.1.3", expected: map[string]interface{}{"ip": "1.1.1.2"}, found: true},
+		{name: "ipv4", ip: "1.1.1.16", expected: map[string]interface{}{"ip": "1.1.1.16"}, found: true},
+		{name: "ipv4", ip: "1.1.1.0"},
+		{name: "ipv4", ip: "2.2.2.2"},
+		{name: "ipv6", ip: "::1:ffff:ffff", expected: map[string]interface{}{"ip": "::1:ffff:ffff"}, found: true},
+		{name: "ipv6", ip: "::2:0:58", expected: map[string]interface{}{"ip": "::2:0:58"}, found: true},
+		{name: "ipv6", ip: "1.1.1.1"},
+		{name: "mixed", ip: "1.1.1.1", expected: map[string]interface{}{"ip": "::1.1.1.1"}, found: true},
+		{name: "mixed", ip: "1.1.1.3", expected: map[string]interface{}{"ip": "::1.1.1.2"}, found: true},
+		{name: "mixed", ip: "::1:ffff:ffff", expected: map[string]interface{}{"ip": "::1:ffff:ffff"}, found: true},
+		{name: "mixed", ip: "::2:0:58", expected: map[string]interface{}{"ip": "::2:0:58"}, found: true},
+	}
+
+	for _, tt := range tests {
+		for _, recordSize := range []int{24, 28, 32} {
+			r, err := Open(fmt.Sprintf("testdata/MaxMind-DB-test-%s-%d.mmdb", tt.name, recordSize))
+			require.NoError(t, err)
+			require.Equal(t, uint(recordSize), r.Metadata.RecordSize)
+
+			v, ok, err := r.Lookup(net.ParseIP(tt.ip))
+			require.NoError(t, err)
+			require.Equal(t, tt.found, ok, "%s-%d %s", tt.name, recordSize, tt.ip)
+			require.Equal(t, tt.expected, v, "%s-%d %s", tt.name, recordSize, tt.ip)
+		}
+	}
+}
+
+func TestMaxMindDecoder(t *testing.T) {
+	r, err := Open("testdata/MaxMind-DB-test-decoder.mmdb")
+	require.NoError(t, err)
+	require.Equal(t, "MaxMind DB Decoder Test", r.Metadata.DatabaseType)
+
+	v, ok, err := r.Lookup(net.ParseIP("1.1.1.1"))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, map[string]interface{}{
+		"array":   []interface{}{uint64(1), uint64(2), uint64(3)},
+		"boolean": true,
+		"bytes":   []byte{0, 0, 0, 42},
+		"double":  42.123456,
+		"float":   float64(float32(1.1)),
+		"int32":   int64(-268435456),
+		"map": map[string]interface{}{
+			"mapX": map[string]interface{}{
+				"arrayX":       []interface{}{uint64(7), uint64(8), uint64(9)},
+				"utf8_stringX": "hello",
+			},
+		},
+		"uint16":      uint64(100),
+		"uint32":      uint64(268435456),
+		"uint64":      uint64(1) << 60,
+		"uint128":     append([]byte{1}, make([]byte, 15)...),
+		"utf8_string": "unicode! \u262f - \u266b",
+	}, v)
+
+	v, ok, err = r.Lookup(net.ParseIP("255.255.255.255"))
+	require.NoError(t, err)
+	require.True(t, ok)
+	m := v.(map[string]interface{})
+	require.Equal(t, math.Inf(1), m["double"])
+	require.Equal(t, int64(math.MaxInt32), m["int32"])
+	require.Equal(t, uint64(math.MaxUint64), m["uint64"])
+	require.Equal(t, bytes.Repeat([]byte{0xff}, 16), m["uint128"])
+
+	r, err = Open("testdata/MaxMind-DB-test-nested.mmdb")
+	require.NoError(t, err)
+	v, ok, err = r.Lookup(net.ParseIP("1.1.1.1"))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, map[string]interface{}{
+		"map1": map[string]interface{}{
+			"map2": map[string]interface{}{
+				"array": []interface{}{
+					map[string]interface{}{
+						"map3": map[string]interface{}{"a": uint64(1), "b": uint64(2), "c": uint64(3)},
+					},
+				},
+			},
+		},
+	}, v)
+
+	_, err = Open("testdata/MaxMind-DB-test-metadata-pointers.mmdb")
+	require.NoError(t, err)
+}
+
+func TestMaxMindBadDatabases(t *testing.T) {
+	for _, name := range []string{
+		"MaxMind-DB-test-broken-pointers-24",
+		"cyclic-data-structure",
+		"libmaxminddb-deep-array-nesting",
+		"libmaxminddb-deep-nesting",
+		"libmaxminddb-offset-integer-overflow",
+		"libmaxminddb-oversized-array",
+		"libmaxminddb-oversized-map",
+	} {
+		r, err := Open("testdata/" + name + ".mmdb")
+		if err == nil {
+			_, _, err = r.Lookup(net.ParseIP("1.1.1.16"))
+		}
+		require.Error(t, err, name)
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	_, err := FromBytes([]byte("not a database"))
+	require.Error(t, err)
+
+	w := &writer{ipVersion: 4, recordSize: 24}
+	w.insert("192.0.2.0/24", "value")
+	buf := w.bytes()
+	_, err = FromBytes(buf[12:])
+	require.Error(t, err)
+}
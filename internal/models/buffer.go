@@ -2,6 +2,7 @@ package models
 
 import (
 	"sync"
+	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/selfstat"
@@ -12,18 +13,41 @@ var (
 	AgentMetricsDropped = selfstat.Register("agent", "metrics_dropped", map[string]string{})
 )
 
-// Buffer stores metrics in a circular buffer.
-type Buffer struct {
-	sync.Mutex
-	buf   []telegraf.Metric
-	first int // index of the first/oldest metric
-	last  int // one after the index of the last/newest metric
-	size  int // number of metrics currently in the buffer
-	cap   int // the capacity of the buffer
-
-	batchFirst int // index of the first metric in the batch
-	batchSize  int // number of metrics currently in the batch
+// MetricBuffer is the storage used by a RunningOutput for metrics that have
+// not yet been written.
+//
+// Metrics are removed from the buffer using Batch, and the batch must then be
+// returned using either Accept, when the write succeeded, or Reject, when the
+// metrics should be retried.
+type MetricBuffer interface {
+	// Len returns the number of metrics currently in the buffer.
+	Len() int
+
+	// Add adds metrics to the buffer and returns number of dropped metrics.
+	Add(metrics ...telegraf.Metric) int
+
+	// Batch returns a slice containing up to batchSize metrics.
+	Batch(batchSize int) []telegraf.Metric
+
+	// Accept marks the batch, acquired from Batch(), as successfully written.
+	Accept(batch []telegraf.Metric)
+
+	// Reject returns the batch, acquired from Batch(), to the buffer and
+	// marks it as unsent.
+	Reject(batch []telegraf.Metric)
+
+	// Drop removes the metrics of the batch, acquired from Batch(), for
+	// which drop returns true and counts them as dropped.  The drop function
+	// is passed the time the metric was added to the buffer.  The remaining
+	// metrics are returned to the buffer as with Reject.
+	Drop(batch []telegraf.Metric, drop func(m telegraf.Metric, added time.Time) bool)
+
+	// Close releases any resources held by the buffer.
+	Close() error
+}
 
+// bufferStats are the selfstat counters shared by all buffer types.
+type bufferStats struct {
 	MetricsAdded   selfstat.Stat
 	MetricsWritten selfstat.Stat
 	MetricsDropped selfstat.Stat
@@ -31,15 +55,8 @@ type Buffer struct {
 	BufferLimit    selfstat.Stat
 }
 
-// NewBuffer returns a new empty Buffer with the given capacity.
-func NewBuffer(name string, capacity int) *Buffer {
-	b := &Buffer{
-		buf:   make([]telegraf.Metric, capacity),
-		first: 0,
-		last:  0,
-		size:  0,
-		cap:   capacity,
-
+func newBufferStats(name string, capacity int) bufferStats {
+	stats := bufferStats{
 		MetricsAdded: selfstat.Register(
 			"write",
 			"metrics_added",
@@ -66,8 +83,61 @@ func NewBuffer(name string, capacity int) *Buffer {
 			map[string]string{"output": name},
 		),
 	}
-	b.BufferSize.Set(int64(0))
-	b.BufferLimit.Set(int64(capacity))
+	stats.BufferSize.Set(int64(0))
+	stats.BufferLimit.Set(int64(capacity))
+	return stats
+}
+
+func (s *bufferStats) metricAdded() {
+	s.MetricsAdded.Incr(1)
+}
+
+func (s *bufferStats) metricWritten(metric telegraf.Metric) {
+	AgentMetricsWritten.Incr(1)
+	s.MetricsWritten.Incr(1)
+	metric.Accept()
+}
+
+func (s *bufferStats) metricDropped(metric telegraf.Metric) {
+	AgentMetricsDropped.Incr(1)
+	s.MetricsDropped.Incr(1)
+	metric.Reject()
+}
+
+// Buffer stores metrics in a circular buffer.
+type Buffer struct {
+	sync.Mutex
+	bufferStats
+
+	buf   []telegraf.Metric
+	first int // index of the first/oldest metric
+	last  int // one after the index of the last/newest metric
+	size  int // number of metrics currently in the buffer
+	cap   int // the capacity of the buffer
+
+	added []time.Time // time each metric of buf was added
+
+	batchFirst int         // index of the first metric in the batch
+	batchSize  int         // number of metrics currently in the batch
+	batchAdded []time.Time // time each metric of the batch was added
+
+	now func() time.Time
+}
+
+// NewBuffer returns a new empty Buffer with the given capacity.
+func NewBuffer(name string, capacity int) *Buffer {
+	b := &Buffer{
+		bufferStats: newBufferStats(name, capacity),
+
+		buf:   make([]telegraf.Metric, capacity),
+		added: make([]time.Time, capacity),
+		first: 0,
+		last:  0,
+		size:  0,
+		cap:   capacity,
+
+		now: time.Now,
+	}
 	return b
 }
 
@@ -83,22 +153,6 @@ func (b *Buffer) length() int {
 	return min(b.size+b.batchSize, b.cap)
 }
 
-func (b *Buffer) metricAdded() {
-	b.MetricsAdded.Incr(1)
-}
-
-func (b *Buffer) metricWritten(metric telegraf.Metric) {
-	AgentMetricsWritten.Incr(1)
-	b.MetricsWritten.Incr(1)
-	metric.Accept()
-}
-
-func (b *Buffer) metricDropped(metric telegraf.Metric) {
-	AgentMetricsDropped.Incr(1)
-	b.MetricsDropped.Incr(1)
-	metric.Reject()
-}
-
 func (b *Buffer) add(m telegraf.Metric) int {
 	dropped := 0
 	// Check if Buffer is full
@@ -115,6 +169,7 @@ func (b *Buffer) add(m telegraf.Metric) int {
 	b.metricAdded()
 
 	b.buf[b.last] = m
+	b.added[b.last] = b.now()
 	b.last = b.next(b.last)
 
 	if b.size == b.cap {
@@ -158,10 +213,13 @@ func (b *Buffer) Batch(batchSize int) []telegraf.Metric {
 	b.batchFirst %= b.cap
 	b.batchSize = outLen
 
+	b.batchAdded = make([]time.Time, outLen)
 	batchIndex := b.batchFirst
 	for i := range out {
 		out[len(out)-1-i] = b.buf[batchIndex]
+		b.batchAdded[len(out)-1-i] = b.added[batchIndex]
 		b.buf[batchIndex] = nil
+		b.added[batchIndex] = time.Time{}
 		batchIndex = b.next(batchIndex)
 	}
 
@@ -189,7 +247,38 @@ func (b *Buffer) Reject(batch []telegraf.Metric) {
 	b.Lock()
 	defer b.Unlock()
 
+	b.reject(batch, b.batchAdded)
+}
+
+// Drop removes the metrics of the batch for which drop returns true and
+// returns the rest of the batch to the buffer.
+func (b *Buffer) Drop(batch []telegraf.Metric, drop func(m telegraf.Metric, added time.Time) bool) {
+	b.Lock()
+	defer b.Unlock()
+
+	keep := make([]telegraf.Metric, 0, len(batch))
+	keepAdded := make([]time.Time, 0, len(batch))
+	for i, m := range batch {
+		var added time.Time
+		if i < len(b.batchAdded) {
+			added = b.batchAdded[i]
+		}
+		if drop(m, added) {
+			b.metricDropped(m)
+		} else {
+			keep = append(keep, m)
+			keepAdded = append(keepAdded, added)
+		}
+	}
+	b.reject(keep, keepAdded)
+}
+
+// reject returns the batch to the buffer, added holds the time each metric
+// of the batch was added.
+func (b *Buffer) reject(batch []telegraf.Metric, added []time.Time) {
 	if len(batch) == 0 {
+		b.resetBatch()
+		b.BufferSize.Set(int64(b.length()))
 		return
 	}
 
@@ -213,7 +302,9 @@ func (b *Buffer) Reject(batch []telegraf.Metric) {
 		}
 
 		b.buf[re] = b.buf[rp]
+		b.added[re] = b.added[rp]
 		b.buf[rp] = nil
+		b.added[rp] = time.Time{}
 	}
 
 	// Copy metrics from the batch back into the buffer; recall that the
@@ -222,6 +313,9 @@ func (b *Buffer) Reject(batch []telegraf.Metric) {
 		if i < restore {
 			re = b.prev(re)
 			b.buf[re] = batch[i]
+			if i < len(added) {
+				b.added[re] = added[i]
+			}
 			b.size = min(b.size+1, b.cap)
 		} else {
 			b.metricDropped(batch[i])
@@ -268,9 +362,15 @@ func (b *Buffer) prev(index int) int {
 	return index
 }
 
+// Close is a no-op for the in-memory buffer.
+func (b *Buffer) Close() error {
+	return nil
+}
+
 func (b *Buffer) resetBatch() {
 	b.batchFirst = 0
 	b.batchSize = 0
+	b.batchAdded = nil
 }
 
 func min(a, b int) int {
@@ -0,0 +1,831 @@
+package models
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+const (
+	// Default size at which a write-ahead log segment is rotated.
+	DEFAULT_BUFFER_SEGMENT_SIZE = 32 * 1024 * 1024
+
+	walSegmentExt = ".wal"
+	walAckFile    = "ack"
+
+	// record header: id (8 bytes), payload length (4 bytes), crc32 (4 bytes)
+	walHeaderSize = 16
+)
+
+var errCorruptRecord = errors.New("corrupt write-ahead log record")
+
+// DiskBufferConfig contains the settings of a DiskBuffer.
+type DiskBufferConfig struct {
+	// Directory holding the write-ahead log segments.
+	Directory string
+
+	// SizeLimit is the maximum number of bytes of unwritten metrics kept on
+	// disk; when set to 0 only the metric count limit applies.
+	SizeLimit int64
+
+	// SegmentSize is the size after which the active segment is rotated.
+	SegmentSize int64
+
+	// Sync selects when the active segment is flushed to disk, either
+	// "flush", before a batch is taken from the buffer, or "always", after
+	// metrics are added.
+	Sync string
+}
+
+// DiskBuffer stores metrics in a write-ahead log on disk so that unwritten
+// metrics survive a restart of the agent.
+//
+// Batches are returned oldest first.  The log is made of segment files, named
+// after the id of their first record, and an ack file containing the id of
+// the oldest unacknowledged record.  Segments are removed once all of their
+// records have been acknowledged.
+type DiskBuffer struct {
+	sync.Mutex
+	bufferStats
+
+	BufferBytes selfstat.Stat
+
+	dir         string
+	cap         int   // maximum number of metrics
+	sizeLimit   int64 // maximum bytes of unwritten metrics, 0 for no limit
+	segmentSize int64
+	syncAlways  bool
+
+	segments []*walSegment // oldest first, the last segment is active
+	active   *os.File
+	dirty    bool       // the active segment has unsynced writes
+	entries  []walEntry // unacknowledged records, oldest first
+	pending  int64      // bytes of unacknowledged records
+	nextID   uint64
+
+	batchSize int // number of entries, from the front, in the current batch
+	batchDrop int // number of batch metrics dropped while the batch was out
+
+	now func() time.Time
+}
+
+type walSegment struct {
+	path string
+	size int64
+	live int // number of unacknowledged records
+}
+
+// walEntry is an unacknowledged record.  Records recovered from disk do not
+// know when they were added, the modification time of their segment is used.
+type walEntry struct {
+	id      uint64
+	segment *walSegment
+	offset  int64
+	size    int64
+	added   time.Time
+}
+
+// NewDiskBuffer opens, or creates, the write-ahead log in the configured
+// directory and returns a buffer containing all unacknowledged metrics.
+func NewDiskBuffer(name string, capacity int, config DiskBufferConfig) (*DiskBuffer, error) {
+	if config.Directory == "" {
+		return nil, errors.New("buffer_directory must be set when using the disk buffer")
+	}
+	if config.SegmentSize <= 0 {
+		config.SegmentSize = DEFAULT_BUFFER_SEGMENT_SIZE
+	}
+	switch config.Sync {
+	case "", "flush", "always":
+	default:
+		return nil, fmt.Errorf("invalid buffer_sync %q", config.Sync)
+	}
+
+	err := os.MkdirAll(config.Directory, 0750)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &DiskBuffer{
+		bufferStats: newBufferStats(name, capacity),
+		BufferBytes: selfstat.Register(
+			"write",
+			"buffer_disk_bytes",
+			map[string]string{"output": name},
+		),
+
+		dir:         config.Directory,
+		cap:         capacity,
+		sizeLimit:   config.SizeLimit,
+		segmentSize: config.SegmentSize,
+		syncAlways:  config.Sync == "always",
+
+		now: time.Now,
+	}
+
+	err = b.recover()
+	if err != nil {
+		return nil, err
+	}
+
+	err = b.rotate()
+	if err != nil {
+		return nil, err
+	}
+
+	for b.overLimit(0) {
+		b.dropOldest()
+	}
+	b.removeSegments()
+	err = b.writeAck()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b.entries) > 0 {
+		log.Printf("I! [buffer] Recovered %d unwritten metrics from %s",
+			len(b.entries), b.dir)
+	}
+	b.updateStats()
+	return b, nil
+}
+
+// Len returns the number of metrics currently in the buffer.
+func (b *DiskBuffer) Len() int {
+	b.Lock()
+	defer b.Unlock()
+
+	return len(b.entries)
+}
+
+// Add writes metrics to the log and returns number of dropped metrics.  The
+// metrics are accepted once they have been written to disk.
+func (b *DiskBuffer) Add(metrics ...telegraf.Metric) int {
+	b.Lock()
+	defer b.Unlock()
+
+	dropped := 0
+	for _, m := range metrics {
+		data := encodeMetric(m)
+		for b.overLimit(int64(walHeaderSize + len(data))) {
+			b.dropOldest()
+			dropped++
+		}
+
+		err := b.append(data)
+		if err != nil {
+			log.Printf("E! [buffer] Error writing metric to %s: %v", b.dir, err)
+			b.metricDropped(m)
+			dropped++
+			continue
+		}
+
+		b.metricAdded()
+		m.Accept()
+	}
+
+	if b.syncAlways {
+		b.sync()
+	}
+	b.removeSegments()
+	b.updateStats()
+	return dropped
+}
+
+// Batch returns a slice containing up to batchSize of the oldest metrics.
+// The batch must not be modified by the client.
+func (b *DiskBuffer) Batch(batchSize int) []telegraf.Metric {
+	b.Lock()
+	defer b.Unlock()
+
+	// Persist the metrics added since the last flush.
+	b.sync()
+
+	out := make([]telegraf.Metric, 0, min(len(b.entries), batchSize))
+	files := make(map[*walSegment]*os.File)
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+
+	for len(out) < batchSize && len(out) < len(b.entries) {
+		entry := b.entries[len(out)]
+		m, err := b.read(files, entry)
+		if err != nil {
+			// The record cannot be recovered, drop it so that it does not
+			// block the rest of the log.
+			log.Printf("E! [buffer] Error reading metric %d from %s: %v",
+				entry.id, entry.segment.path, err)
+			b.removeEntry(len(out))
+			AgentMetricsDropped.Incr(1)
+			b.MetricsDropped.Incr(1)
+			continue
+		}
+		out = append(out, m)
+	}
+
+	b.batchSize = len(out)
+	b.batchDrop = 0
+	return out
+}
+
+// Accept marks the batch, acquired from Batch(), as successfully written and
+// removes it from the log.
+func (b *DiskBuffer) Accept(batch []telegraf.Metric) {
+	b.Lock()
+	defer b.Unlock()
+
+	for _, m := range batch[min(b.batchDrop, len(batch)):] {
+		b.metricWritten(m)
+	}
+
+	for _, entry := range b.entries[:b.batchSize] {
+		entry.segment.live--
+		b.pending -= entry.size
+	}
+	b.entries = b.entries[b.batchSize:]
+
+	b.resetBatch()
+	b.removeSegments()
+	err := b.writeAck()
+	if err != nil {
+		log.Printf("E! [buffer] Error writing ack file in %s: %v", b.dir, err)
+	}
+	b.updateStats()
+}
+
+// Reject returns the batch, acquired from Batch(), to the buffer and marks it
+// as unsent.  Since the metrics are still on disk there is nothing to restore.
+func (b *DiskBuffer) Reject(batch []telegraf.Metric) {
+	b.Lock()
+	defer b.Unlock()
+
+	b.resetBatch()
+	b.updateStats()
+}
+
+// Drop removes the metrics of the batch for which drop returns true from the
+// log, the rest of the batch remains in the log.  Since the ack file only
+// records the oldest unacknowledged record, dropped metrics that were newer
+// than a kept metric are recovered again after a restart.
+func (b *DiskBuffer) Drop(batch []telegraf.Metric, drop func(m telegraf.Metric, added time.Time) bool) {
+	b.Lock()
+	defer b.Unlock()
+
+	// Metrics dropped while the batch was out have already been removed.
+	for i := len(batch) - 1; i >= b.batchDrop; i-- {
+		if drop(batch[i], b.entries[i-b.batchDrop].added) {
+			b.metricDropped(batch[i])
+			b.removeEntry(i - b.batchDrop)
+		}
+	}
+
+	b.resetBatch()
+	b.removeSegments()
+	err := b.writeAck()
+	if err != nil {
+		log.Printf("E! [buffer] Error writing ack file in %s: %v", b.dir, err)
+	}
+	b.updateStats()
+}
+
+// Close syncs and closes the active segment.  Unacknowledged metrics remain
+// on disk and are recovered by the next call to NewDiskBuffer.
+func (b *DiskBuffer) Close() error {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.active == nil {
+		return nil
+	}
+	err := b.active.Sync()
+	if cerr := b.active.Close(); err == nil {
+		err = cerr
+	}
+	b.active = nil
+	return err
+}
+
+// sync flushes the writes to the active segment to disk.
+func (b *DiskBuffer) sync() {
+	if !b.dirty || b.active == nil {
+		return
+	}
+	err := b.active.Sync()
+	if err != nil {
+		log.Printf("E! [buffer] Error syncing segment in %s: %v", b.dir, err)
+		return
+	}
+	b.dirty = false
+}
+
+func (b *DiskBuffer) resetBatch() {
+	b.batchSize = 0
+	b.batchDrop = 0
+}
+
+// overLimit returns true if adding a record of the given size would exceed
+// the limits of the buffer.  A size of 0 checks the current contents.
+func (b *DiskBuffer) overLimit(size int64) bool {
+	if len(b.entries) == 0 {
+		return false
+	}
+
+	count := len(b.entries)
+	if size > 0 {
+		count++
+	}
+	if count > b.cap {
+		return true
+	}
+	return b.sizeLimit > 0 && b.pending+size > b.sizeLimit
+}
+
+// dropOldest removes the oldest metric from the buffer, if the metric is part
+// of the current batch it is still reported to the output but will not be
+// retried.
+func (b *DiskBuffer) dropOldest() {
+	if b.batchSize > 0 {
+		b.batchSize--
+		b.batchDrop++
+	}
+	b.removeEntry(0)
+	AgentMetricsDropped.Incr(1)
+	b.MetricsDropped.Incr(1)
+}
+
+func (b *DiskBuffer) removeEntry(i int) {
+	entry := b.entries[i]
+	entry.segment.live--
+	b.pending -= entry.size
+	b.entries = append(b.entries[:i], b.entries[i+1:]...)
+}
+
+// append writes a record to the active segment, rotating the segment first
+// if it is full.
+func (b *DiskBuffer) append(data []byte) error {
+	segment := b.segments[len(b.segments)-1]
+	if segment.size >= b.segmentSize {
+		err := b.rotate()
+		if err != nil {
+			return err
+		}
+		segment = b.segments[len(b.segments)-1]
+	}
+
+	record := make([]byte, walHeaderSize+len(data))
+	binary.BigEndian.PutUint64(record[0:], b.nextID)
+	binary.BigEndian.PutUint32(record[8:], uint32(len(data)))
+	binary.BigEndian.PutUint32(record[12:], crc32.ChecksumIEEE(data))
+	copy(record[walHeaderSize:], data)
+
+	_, err := b.active.Write(record)
+	if err != nil {
+		// Discard any partial write so that the segment stays readable.
+		b.active.Truncate(segment.size)
+		b.active.Seek(segment.size, io.SeekStart)
+		return err
+	}
+
+	b.entries = append(b.entries, walEntry{
+		id:      b.nextID,
+		segment: segment,
+		offset:  segment.size,
+		size:    int64(len(record)),
+		added:   b.now(),
+	})
+	b.dirty = true
+	segment.size += int64(len(record))
+	segment.live++
+	b.pending += int64(len(record))
+	b.nextID++
+	return nil
+}
+
+// rotate syncs and closes the active segment and starts a new one.
+func (b *DiskBuffer) rotate() error {
+	if b.active != nil {
+		b.sync()
+		err := b.active.Close()
+		if err != nil {
+			return err
+		}
+		b.active = nil
+		b.dirty = false
+	}
+
+	path := filepath.Join(b.dir, fmt.Sprintf("%020d%s", b.nextID, walSegmentExt))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0640)
+	if err != nil {
+		return err
+	}
+	err = syncDir(b.dir)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	b.active = f
+	b.segments = append(b.segments, &walSegment{path: path})
+	return nil
+}
+
+// removeSegments deletes all inactive segments without unacknowledged
+// records.
+func (b *DiskBuffer) removeSegments() {
+	segments := b.segments[:0]
+	for i, segment := range b.segments {
+		if segment.live == 0 && i != len(b.segments)-1 {
+			err := os.Remove(segment.path)
+			if err != nil && !os.IsNotExist(err) {
+				log.Printf("W! [buffer] Error removing segment %s: %v",
+					segment.path, err)
+			}
+			continue
+		}
+		segments = append(segments, segment)
+	}
+	b.segments = segments
+}
+
+// writeAck persists the id of the oldest unacknowledged record.
+func (b *DiskBuffer) writeAck() error {
+	id := b.nextID
+	if len(b.entries) > 0 {
+		id = b.entries[0].id
+	}
+
+	path := filepath.Join(b.dir, walAckFile)
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0640)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(strconv.FormatUint(id, 10))
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmp, path)
+	if err != nil {
+		return err
+	}
+	return syncDir(b.dir)
+}
+
+func (b *DiskBuffer) readAck() (uint64, error) {
+	octets, err := ioutil.ReadFile(filepath.Join(b.dir, walAckFile))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(octets)), 10, 64)
+}
+
+// recover loads the index of all unacknowledged records from the segments
+// found in the buffer directory.
+func (b *DiskBuffer) recover() error {
+	ack, err := b.readAck()
+	if err != nil {
+		return fmt.Errorf("reading ack file: %v", err)
+	}
+	b.nextID = ack
+
+	paths, err := filepath.Glob(filepath.Join(b.dir, "*"+walSegmentExt))
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		segment := &walSegment{path: path}
+		err := b.scan(segment, ack)
+		if err != nil {
+			return fmt.Errorf("reading segment %s: %v", path, err)
+		}
+
+		// Empty segments may share their name with the next active segment.
+		if segment.size == 0 {
+			err := os.Remove(path)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		b.segments = append(b.segments, segment)
+	}
+	return nil
+}
+
+// scan indexes the records of a segment, a truncated or corrupt tail is
+// removed from the segment.
+func (b *DiskBuffer) scan(segment *walSegment, ack uint64) error {
+	f, err := os.OpenFile(segment.path, os.O_RDWR, 0640)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	var offset int64
+	header := make([]byte, walHeaderSize)
+	for {
+		_, err := f.ReadAt(header, offset)
+		if err == io.EOF && offset == fileSize(f) {
+			break
+		}
+
+		var id uint64
+		var length int64
+		if err == nil {
+			id = binary.BigEndian.Uint64(header[0:])
+			length = int64(binary.BigEndian.Uint32(header[8:]))
+			if offset+walHeaderSize+length > fileSize(f) {
+				err = io.ErrUnexpectedEOF
+			}
+		}
+		if err == nil {
+			data := make([]byte, length)
+			_, err = f.ReadAt(data, offset+walHeaderSize)
+			if err == nil && crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(header[12:]) {
+				err = errCorruptRecord
+			}
+		}
+		if err != nil {
+			log.Printf("W! [buffer] Truncating segment %s at offset %d: %v",
+				segment.path, offset, err)
+			err = f.Truncate(offset)
+			if err != nil {
+				return err
+			}
+			break
+		}
+
+		size := walHeaderSize + length
+		if id >= ack {
+			b.entries = append(b.entries, walEntry{
+				id:      id,
+				segment: segment,
+				offset:  offset,
+				size:    size,
+				added:   info.ModTime(),
+			})
+			segment.live++
+			b.pending += size
+		}
+		if id >= b.nextID {
+			b.nextID = id + 1
+		}
+		offset += size
+	}
+
+	segment.size = offset
+	return nil
+}
+
+// read decodes the metric of a record, files holds the segments opened for
+// reading and is updated as needed.
+func (b *DiskBuffer) read(files map[*walSegment]*os.File, entry walEntry) (telegraf.Metric, error) {
+	f, ok := files[entry.segment]
+	if !ok {
+		var err error
+		f, err = os.Open(entry.segment.path)
+		if err != nil {
+			return nil, err
+		}
+		files[entry.segment] = f
+	}
+
+	record := make([]byte, entry.size)
+	_, err := f.ReadAt(record, entry.offset)
+	if err != nil {
+		return nil, err
+	}
+
+	data := record[walHeaderSize:]
+	if crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(record[12:]) {
+		return nil, errCorruptRecord
+	}
+	return decodeMetric(data)
+}
+
+func (b *DiskBuffer) updateStats() {
+	var size int64
+	for _, segment := range b.segments {
+		size += segment.size
+	}
+	b.BufferBytes.Set(size)
+	b.BufferSize.Set(int64(len(b.entries)))
+}
+
+func fileSize(f *os.File) int64 {
+	stat, err := f.Stat()
+	if err != nil {
+		return -1
+	}
+	return stat.Size()
+}
+
+// Field value kinds used in the record encoding.
+const (
+	kindFloat byte = iota
+	kindInt
+	kindUint
+	kindString
+	kindBool
+)
+
+// encodeMetric serializes a metric into a self contained record payload that
+// preserves the field types and value type of the metric.
+func encodeMetric(m telegraf.Metric) []byte {
+	var buf bytes.Buffer
+	scratch := make([]byte, binary.MaxVarintLen64)
+
+	putString := func(s string) {
+		n := binary.PutUvarint(scratch, uint64(len(s)))
+		buf.Write(scratch[:n])
+		buf.WriteString(s)
+	}
+	putUvarint := func(v uint64) {
+		n := binary.PutUvarint(scratch, v)
+		buf.Write(scratch[:n])
+	}
+	putVarint := func(v int64) {
+		n := binary.PutVarint(scratch, v)
+		buf.Write(scratch[:n])
+	}
+
+	putString(m.Name())
+	putVarint(m.Time().UnixNano())
+	buf.WriteByte(byte(m.Type()))
+
+	tags := m.TagList()
+	putUvarint(uint64(len(tags)))
+	for _, tag := range tags {
+		putString(tag.Key)
+		putString(tag.Value)
+	}
+
+	fields := m.FieldList()
+	putUvarint(uint64(len(fields)))
+	for _, field := range fields {
+		putString(field.Key)
+		switch v := field.Value.(type) {
+		case float64:
+			buf.WriteByte(kindFloat)
+			binary.BigEndian.PutUint64(scratch, math.Float64bits(v))
+			buf.Write(scratch[:8])
+		case int64:
+			buf.WriteByte(kindInt)
+			putVarint(v)
+		case uint64:
+			buf.WriteByte(kindUint)
+			putUvarint(v)
+		case string:
+			buf.WriteByte(kindString)
+			putString(v)
+		case bool:
+			buf.WriteByte(kindBool)
+			if v {
+				buf.WriteByte(1)
+			} else {
+				buf.WriteByte(0)
+			}
+		}
+	}
+	return buf.Bytes()
+}
+
+func decodeMetric(data []byte) (telegraf.Metric, error) {
+	r := bytes.NewReader(data)
+
+	getString := func() (string, error) {
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return "", err
+		}
+		if n > uint64(r.Len()) {
+			return "", errCorruptRecord
+		}
+		s := make([]byte, n)
+		_, err = io.ReadFull(r, s)
+		return string(s), err
+	}
+
+	name, err := getString()
+	if err != nil {
+		return nil, err
+	}
+	ts, err := binary.ReadVarint(r)
+	if err != nil {
+		return nil, err
+	}
+	tp, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	ntags, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[string]string, ntags)
+	for i := uint64(0); i < ntags; i++ {
+		key, err := getString()
+		if err != nil {
+			return nil, err
+		}
+		value, err := getString()
+		if err != nil {
+			return nil, err
+		}
+		tags[key] = value
+	}
+
+	nfields, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	m, err := metric.New(name, tags, nil, time.Unix(0, ts), telegraf.ValueType(tp))
+	if err != nil {
+		return nil, err
+	}
+
+	for i := uint64(0); i < nfields; i++ {
+		key, err := getString()
+		if err != nil {
+			return nil, err
+		}
+		kind, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+
+		switch kind {
+		case kindFloat:
+			v := make([]byte, 8)
+			_, err := io.ReadFull(r, v)
+			if err != nil {
+				return nil, err
+			}
+			m.AddField(key, math.Float64frombits(binary.BigEndian.Uint64(v)))
+		case kindInt:
+			v, err := binary.ReadVarint(r)
+			if err != nil {
+				return nil, err
+			}
+			m.AddField(key, v)
+		case kindUint:
+			v, err := binary.ReadUvarint(r)
+			if err != nil {
+				return nil, err
+			}
+			m.AddField(key, v)
+		case kindString:
+			v, err := getString()
+			if err != nil {
+				return nil, err
+			}
+			m.AddField(key, v)
+		case kindBool:
+			v, err := r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			m.AddField(key, v != 0)
+		default:
+			return nil, errCorruptRecord
+		}
+	}
+
+	return m, nil
+}
@@ -0,0 +1,16 @@
+// +build !windows
+
+package models
+
+import "os"
+
+// syncDir flushes the entries of a directory, such as a renamed file, to
+// disk.
+func syncDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
@@ -0,0 +1,298 @@
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDiskBuffer(t *testing.T, dir string, capacity int) *DiskBuffer {
+	b, err := NewDiskBuffer("test", capacity, DiskBufferConfig{Directory: dir})
+	require.NoError(t, err)
+	b.MetricsAdded.Set(0)
+	b.MetricsWritten.Set(0)
+	b.MetricsDropped.Set(0)
+	return b
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "telegraf-buffer")
+	require.NoError(t, err)
+	return dir
+}
+
+func TestDiskBuffer_RoundTripTypes(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	m, err := metric.New(
+		"cpu",
+		map[string]string{"host": "localhost", "cpu": "cpu0"},
+		map[string]interface{}{
+			"float":  42.5,
+			"int":    int64(-42),
+			"uint":   uint64(18446744073709551615),
+			"string": "hello",
+			"bool":   true,
+		},
+		time.Unix(0, 1565000000123456789),
+		telegraf.Counter,
+	)
+	require.NoError(t, err)
+
+	b := newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+	b.Add(m)
+
+	batch := b.Batch(1)
+	require.Len(t, batch, 1)
+	testutil.RequireMetricEqual(t, m, batch[0])
+	require.Equal(t, telegraf.Counter, batch[0].Type())
+	require.Equal(t, m.FieldList(), batch[0].FieldList())
+}
+
+func TestDiskBuffer_BatchOldestFirst(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+
+	batch := b.Batch(2)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{MetricTime(1), MetricTime(2)}, batch)
+	require.Equal(t, 3, b.Len())
+}
+
+func TestDiskBuffer_AcceptRemovesBatch(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+
+	batch := b.Batch(2)
+	b.Accept(batch)
+	require.Equal(t, 1, b.Len())
+	require.Equal(t, int64(2), b.MetricsWritten.Get())
+
+	batch = b.Batch(2)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{MetricTime(3)}, batch)
+}
+
+func TestDiskBuffer_AcceptAll(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+
+	b.Accept(b.Batch(3))
+	require.Equal(t, 0, b.Len())
+	require.Equal(t, int64(0), b.pending)
+	require.Equal(t, 0, b.segments[len(b.segments)-1].live)
+
+	ack, err := b.readAck()
+	require.NoError(t, err)
+	require.Equal(t, b.nextID, ack)
+}
+
+func TestDiskBuffer_Sync(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	// By default the segment is synced when a batch is taken.
+	b := newTestDiskBuffer(t, dir, 5)
+	b.Add(MetricTime(1))
+	require.True(t, b.dirty)
+	b.Batch(1)
+	require.False(t, b.dirty)
+	require.NoError(t, b.Close())
+
+	b, err := NewDiskBuffer("test", 5, DiskBufferConfig{
+		Directory: dir,
+		Sync:      "always",
+	})
+	require.NoError(t, err)
+	defer b.Close()
+	b.Add(MetricTime(2))
+	require.False(t, b.dirty)
+}
+
+func TestDiskBuffer_InvalidSync(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	_, err := NewDiskBuffer("test", 5, DiskBufferConfig{
+		Directory: dir,
+		Sync:      "never",
+	})
+	require.Error(t, err)
+}
+
+func TestDiskBuffer_RejectKeepsBatch(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+	b.Add(MetricTime(1), MetricTime(2))
+
+	batch := b.Batch(2)
+	b.Reject(batch)
+	require.Equal(t, 2, b.Len())
+
+	batch = b.Batch(2)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{MetricTime(1), MetricTime(2)}, batch)
+}
+
+func TestDiskBuffer_AddDropsOldest(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 3)
+	defer b.Close()
+	dropped := b.Add(MetricTime(1), MetricTime(2), MetricTime(3), MetricTime(4))
+	require.Equal(t, 1, dropped)
+	require.Equal(t, int64(1), b.MetricsDropped.Get())
+
+	batch := b.Batch(3)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{MetricTime(2), MetricTime(3), MetricTime(4)}, batch)
+}
+
+func TestDiskBuffer_DropWhileBatchOut(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 2)
+	defer b.Close()
+	b.Add(MetricTime(1), MetricTime(2))
+
+	batch := b.Batch(2)
+	b.Add(MetricTime(3))
+	b.Accept(batch)
+
+	require.Equal(t, int64(1), b.MetricsWritten.Get())
+	require.Equal(t, int64(1), b.MetricsDropped.Get())
+
+	batch = b.Batch(2)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{MetricTime(3)}, batch)
+}
+
+func TestDiskBuffer_SizeLimit(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	size := int64(walHeaderSize + len(encodeMetric(MetricTime(1))))
+	b, err := NewDiskBuffer("test", 100, DiskBufferConfig{
+		Directory: dir,
+		SizeLimit: 2 * size,
+	})
+	require.NoError(t, err)
+	defer b.Close()
+
+	dropped := b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+	require.Equal(t, 1, dropped)
+	require.Equal(t, 2, b.Len())
+}
+
+func TestDiskBuffer_ReplayAfterRestart(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 10)
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+	b.Accept(b.Batch(1))
+
+	// A batch that is out when the agent stops must be replayed.
+	b.Batch(2)
+	require.NoError(t, b.Close())
+
+	b = newTestDiskBuffer(t, dir, 10)
+	defer b.Close()
+	require.Equal(t, 2, b.Len())
+
+	b.Add(MetricTime(4))
+	batch := b.Batch(10)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{MetricTime(2), MetricTime(3), MetricTime(4)}, batch)
+}
+
+func TestDiskBuffer_SegmentRotation(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b, err := NewDiskBuffer("test", 100, DiskBufferConfig{
+		Directory:   dir,
+		SegmentSize: 1,
+	})
+	require.NoError(t, err)
+	defer b.Close()
+
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+	segments, err := filepath.Glob(filepath.Join(dir, "*"+walSegmentExt))
+	require.NoError(t, err)
+	require.Len(t, segments, 3)
+
+	b.Accept(b.Batch(2))
+	segments, err = filepath.Glob(filepath.Join(dir, "*"+walSegmentExt))
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+}
+
+func TestDiskBuffer_TruncatedSegment(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 10)
+	b.Add(MetricTime(1), MetricTime(2))
+	require.NoError(t, b.Close())
+
+	// Simulate a crash in the middle of writing the last record.
+	path := b.segments[len(b.segments)-1].path
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(path, info.Size()-3))
+
+	b = newTestDiskBuffer(t, dir, 10)
+	defer b.Close()
+	require.Equal(t, 1, b.Len())
+
+	b.Add(MetricTime(3))
+	batch := b.Batch(10)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{MetricTime(1), MetricTime(3)}, batch)
+}
+
+func TestDiskBuffer_DropRemovesFromLog(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 5)
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+
+	batch := b.Batch(3)
+	b.Drop(batch, func(m telegraf.Metric, added time.Time) bool {
+		return m.Time().Unix() < 3
+	})
+	require.Equal(t, 1, b.Len())
+	require.Equal(t, int64(2), b.MetricsDropped.Get())
+	require.NoError(t, b.Close())
+
+	b = newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+	batch = b.Batch(3)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{MetricTime(3)}, batch)
+}
@@ -0,0 +1,9 @@
+// +build windows
+
+package models
+
+// syncDir is a no-op, directories cannot be synced on Windows and renames
+// are persisted by the file system.
+func syncDir(dir string) error {
+	return nil
+}
@@ -726,3 +726,54 @@ func TestBuffer_RejectEmptyBatch(t *testing.T) {
 		require.NotNil(t, m)
 	}
 }
+
+func TestBuffer_DropRemovesFromBatch(t *testing.T) {
+	b := setup(NewBuffer("test", 5))
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+	batch := b.Batch(3)
+	b.Drop(batch, func(m telegraf.Metric, added time.Time) bool {
+		return m.Time().Unix() < 3
+	})
+	require.Equal(t, 1, b.Len())
+	require.Equal(t, int64(2), b.MetricsDropped.Get())
+
+	b.Add(MetricTime(4))
+	batch = b.Batch(2)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{MetricTime(4), MetricTime(3)}, batch)
+}
+
+func TestBuffer_DropAll(t *testing.T) {
+	b := setup(NewBuffer("test", 5))
+	b.Add(MetricTime(1), MetricTime(2))
+	batch := b.Batch(2)
+	b.Drop(batch, func(m telegraf.Metric, added time.Time) bool {
+		return true
+	})
+	require.Equal(t, 0, b.Len())
+	require.Equal(t, int64(2), b.MetricsDropped.Get())
+}
+
+func TestBuffer_DropAddedTimeKeptOnReject(t *testing.T) {
+	b := setup(NewBuffer("test", 5))
+	now := time.Unix(100, 0)
+	b.now = func() time.Time { return now }
+	b.Add(MetricTime(1))
+	now = time.Unix(200, 0)
+	b.Add(MetricTime(2))
+
+	batch := b.Batch(2)
+	b.Reject(batch)
+
+	added := make(map[int64]time.Time)
+	batch = b.Batch(2)
+	b.Drop(batch, func(m telegraf.Metric, t time.Time) bool {
+		added[m.Time().Unix()] = t
+		return false
+	})
+	require.Equal(t, map[int64]time.Time{
+		1: time.Unix(100, 0),
+		2: time.Unix(200, 0),
+	}, added)
+	require.Equal(t, 2, b.Len())
+}
@@ -0,0 +1,142 @@
+package models
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/logger"
+	"github.com/influxdata/telegraf/selfstat"
+	"github.com/influxdata/wlog"
+)
+
+// Logger defines a logging structure for plugins.
+type Logger struct {
+	// Name is the name of the plugin, it is printed in the `[]` of each
+	// message.
+	Name string
+
+	// Level overrides the global log level for the plugin, if zero the
+	// global log level is used.
+	Level wlog.Level
+
+	// Errs counts the errors logged by the plugin.
+	Errs selfstat.Stat
+
+	// Status, if set, records the last error logged by the plugin.
+	Status *Status
+}
+
+// NewLogger returns a logger for a plugin.  The level is the log_level of the
+// plugin and must have been checked with ParseLogLevel.
+func NewLogger(name string, level string, errs selfstat.Stat) *Logger {
+	l, _ := ParseLogLevel(level)
+	return &Logger{
+		Name:  name,
+		Level: l,
+		Errs:  errs,
+	}
+}
+
+// ParseLogLevel parses a log_level setting.  An empty setting returns zero,
+// meaning the global log level is used.
+func ParseLogLevel(level string) (wlog.Level, error) {
+	if level == "" {
+		return 0, nil
+	}
+
+	l, ok := wlog.StringToLevel[strings.ToUpper(level)]
+	if !ok || l == wlog.OFF {
+		return 0, fmt.Errorf("invalid log_level %q, must be one of "+
+			"\"error\", \"warn\", \"info\" or \"debug\"", level)
+	}
+	return l, nil
+}
+
+// Errorf logs an error message, patterned after log.Printf.
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.error(fmt.Sprintf(format, args...))
+}
+
+// Error logs an error message, patterned after log.Print.
+func (l *Logger) Error(args ...interface{}) {
+	l.error(fmt.Sprint(args...))
+}
+
+func (l *Logger) error(msg string) {
+	l.Errs.Incr(1)
+	if l.Status != nil {
+		l.Status.Error(msg)
+	}
+	l.print(wlog.ERROR, msg)
+}
+
+// Debugf logs a debug message, patterned after log.Printf.
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.print(wlog.DEBUG, fmt.Sprintf(format, args...))
+}
+
+// Debug logs a debug message, patterned after log.Print.
+func (l *Logger) Debug(args ...interface{}) {
+	l.print(wlog.DEBUG, fmt.Sprint(args...))
+}
+
+// Warnf logs a warning message, patterned after log.Printf.
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.print(wlog.WARN, fmt.Sprintf(format, args...))
+}
+
+// Warn logs a warning message, patterned after log.Print.
+func (l *Logger) Warn(args ...interface{}) {
+	l.print(wlog.WARN, fmt.Sprint(args...))
+}
+
+// Infof logs an information message, patterned after log.Printf.
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.print(wlog.INFO, fmt.Sprintf(format, args...))
+}
+
+// Info logs an information message, patterned after log.Print.
+func (l *Logger) Info(args ...interface{}) {
+	l.print(wlog.INFO, fmt.Sprint(args...))
+}
+
+func (l *Logger) print(level wlog.Level, msg string) {
+	if l.Level == 0 {
+		log.Printf("%c! [%s] %s", wlog.ReverseLevels[level], l.Name, msg)
+		return
+	}
+
+	if level < l.Level {
+		return
+	}
+	logger.PrintUnfiltered(level, fmt.Sprintf("[%s] %s", l.Name, msg))
+}
+
+// logName returns the name of a plugin as used in log messages.
+func logName(pluginType, name, alias string) string {
+	if alias == "" {
+		return pluginType + "." + name
+	}
+	return pluginType + "." + name + "::" + alias
+}
+
+// SetLoggerOnPlugin sets the Log field of a plugin, if the plugin has a field
+// named Log of type telegraf.Logger.
+func SetLoggerOnPlugin(i interface{}, log telegraf.Logger) {
+	valI := reflect.ValueOf(i)
+	if valI.Kind() != reflect.Ptr || valI.Elem().Kind() != reflect.Struct {
+		return
+	}
+
+	field := valI.Elem().FieldByName("Log")
+	if !field.IsValid() || !field.CanSet() {
+		return
+	}
+
+	if field.Type() == reflect.TypeOf((*telegraf.Logger)(nil)).Elem() {
+		field.Set(reflect.ValueOf(log))
+	}
+}
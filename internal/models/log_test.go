@@ -0,0 +1,70 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/selfstat"
+	"github.com/influxdata/wlog"
+	"github.com/stretchr/testify/require"
+)
+
+type logPlugin struct {
+	Log telegraf.Logger `toml:"-"`
+}
+
+func (p *logPlugin) SampleConfig() string                  { return "" }
+func (p *logPlugin) Description() string                   { return "" }
+func (p *logPlugin) Gather(acc telegraf.Accumulator) error { return nil }
+
+func TestLogger_ErrorCounted(t *testing.T) {
+	stat := selfstat.Register("gather", "errors", map[string]string{"input": "log_test"})
+	stat.Set(0)
+
+	l := NewLogger("inputs.log_test", "", stat)
+	l.Errorf("error %d", 42)
+	l.Error("error")
+	l.Warn("warning")
+	require.Equal(t, int64(2), stat.Get())
+}
+
+func TestParseLogLevel(t *testing.T) {
+	level, err := ParseLogLevel("")
+	require.NoError(t, err)
+	require.Equal(t, wlog.Level(0), level)
+
+	level, err = ParseLogLevel("debug")
+	require.NoError(t, err)
+	require.Equal(t, wlog.DEBUG, level)
+
+	_, err = ParseLogLevel("off")
+	require.Error(t, err)
+
+	_, err = ParseLogLevel("verbose")
+	require.Error(t, err)
+}
+
+func TestRunningInput_InjectsLogger(t *testing.T) {
+	plugin := &logPlugin{}
+	ri := NewRunningInput(plugin, &InputConfig{
+		Name:     "log_test",
+		Alias:    "primary",
+		LogLevel: "debug",
+	})
+
+	require.NotNil(t, plugin.Log)
+	require.Equal(t, ri.Log(), plugin.Log)
+	require.Equal(t, "inputs.log_test::primary", ri.LogName())
+
+	l := plugin.Log.(*Logger)
+	require.Equal(t, "inputs.log_test::primary", l.Name)
+	require.Equal(t, wlog.DEBUG, l.Level)
+}
+
+func TestSetLoggerOnPlugin_WrongType(t *testing.T) {
+	plugin := &struct {
+		Log string
+	}{}
+	SetLoggerOnPlugin(plugin, &Logger{})
+	require.Equal(t, "", plugin.Log)
+}
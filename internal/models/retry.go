@@ -0,0 +1,177 @@
+package models
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+const (
+	// Default maximum delay between retries of a failed write.
+	DEFAULT_RETRY_MAX_INTERVAL = 5 * time.Minute
+
+	// Default time the circuit breaker stays open.
+	DEFAULT_CIRCUIT_BREAKER_COOLDOWN = time.Minute
+)
+
+// States of the circuit breaker as reported by the circuit_state field.
+const (
+	circuitClosed   = 0
+	circuitOpen     = 1
+	circuitHalfOpen = 2
+)
+
+var (
+	// ErrRetryBackoff is returned by RunningOutput.Write when a write is
+	// skipped because the previous write failed recently.
+	ErrRetryBackoff = errors.New("waiting to retry after failed write")
+
+	// ErrCircuitOpen is returned by RunningOutput.Write when a write is
+	// skipped because the circuit breaker of the output is open.
+	ErrCircuitOpen = errors.New("circuit breaker is open")
+)
+
+// retryPolicy decides when a failed write of an output is retried.
+//
+// After a failed write the next attempt is delayed, starting with
+// InitialInterval and doubling with each consecutive failure up to
+// MaxInterval.  When Threshold consecutive writes have failed the circuit
+// breaker opens and no writes are attempted for Cooldown.  After the cooldown
+// a single write is attempted, if it succeeds the breaker closes, otherwise it
+// opens again.
+type retryPolicy struct {
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	Threshold       int
+	Cooldown        time.Duration
+
+	CircuitState        selfstat.Stat
+	ConsecutiveFailures selfstat.Stat
+
+	log telegraf.Logger
+	now func() time.Time
+
+	sync.Mutex
+	failures  int
+	next      time.Time
+	state     int
+	openUntil time.Time
+}
+
+func newRetryPolicy(conf *OutputConfig, tags map[string]string, log telegraf.Logger) *retryPolicy {
+	r := &retryPolicy{
+		InitialInterval: conf.RetryInitialInterval,
+		MaxInterval:     conf.RetryMaxInterval,
+		Threshold:       conf.CircuitBreakerThreshold,
+		Cooldown:        conf.CircuitBreakerCooldown,
+		CircuitState: selfstat.Register(
+			"write",
+			"circuit_state",
+			tags,
+		),
+		ConsecutiveFailures: selfstat.Register(
+			"write",
+			"consecutive_failures",
+			tags,
+		),
+		log: log,
+		now: time.Now,
+	}
+	if r.MaxInterval == 0 {
+		r.MaxInterval = DEFAULT_RETRY_MAX_INTERVAL
+	}
+	if r.MaxInterval < r.InitialInterval {
+		r.MaxInterval = r.InitialInterval
+	}
+	if r.Cooldown == 0 {
+		r.Cooldown = DEFAULT_CIRCUIT_BREAKER_COOLDOWN
+	}
+	return r
+}
+
+// allow returns an error if no write should be attempted now.
+func (r *retryPolicy) allow() error {
+	r.Lock()
+	defer r.Unlock()
+
+	now := r.now()
+	switch r.state {
+	case circuitOpen:
+		if now.Before(r.openUntil) {
+			return ErrCircuitOpen
+		}
+		r.setState(circuitHalfOpen)
+		r.log.Infof("Circuit breaker half-open, attempting write")
+		return nil
+	case circuitHalfOpen:
+		return nil
+	}
+
+	if now.Before(r.next) {
+		return ErrRetryBackoff
+	}
+	return nil
+}
+
+// success records a successful write.
+func (r *retryPolicy) success() {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.state != circuitClosed {
+		r.log.Infof("Circuit breaker closed after successful write")
+	}
+	r.setState(circuitClosed)
+	r.failures = 0
+	r.next = time.Time{}
+	r.ConsecutiveFailures.Set(0)
+}
+
+// failure records a failed write and schedules the next attempt.
+func (r *retryPolicy) failure() {
+	r.Lock()
+	defer r.Unlock()
+
+	now := r.now()
+	r.failures++
+	r.ConsecutiveFailures.Set(int64(r.failures))
+
+	if r.Threshold > 0 && (r.state == circuitHalfOpen || r.failures >= r.Threshold) {
+		if r.state != circuitOpen {
+			r.log.Warnf("Circuit breaker opened after %d consecutive failed writes, "+
+				"next attempt in %s", r.failures, r.Cooldown)
+		}
+		r.setState(circuitOpen)
+		r.openUntil = now.Add(r.Cooldown)
+		return
+	}
+
+	if r.InitialInterval > 0 {
+		r.next = now.Add(r.backoff())
+	}
+}
+
+// backoff returns the delay before the next attempt, the delay is randomized
+// between half and the full exponential delay so that outputs failing at the
+// same time do not retry in lockstep.
+func (r *retryPolicy) backoff() time.Duration {
+	delay := r.MaxInterval
+	if r.failures < 32 {
+		d := r.InitialInterval << uint(r.failures-1)
+		if d > 0 && d < delay {
+			delay = d
+		}
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+func (r *retryPolicy) setState(state int) {
+	r.state = state
+	r.CircuitState.Set(int64(state))
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func (c *fakeClock) add(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+func newTestRetryPolicy(conf *OutputConfig) (*retryPolicy, *fakeClock) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	r := newRetryPolicy(conf, map[string]string{"output": "test"}, testutil.Logger{})
+	r.now = clock.now
+	return r, clock
+}
+
+func TestRetryPolicy_DisabledByDefault(t *testing.T) {
+	r, _ := newTestRetryPolicy(&OutputConfig{})
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, r.allow())
+		r.failure()
+	}
+	require.Equal(t, int64(10), r.ConsecutiveFailures.Get())
+	require.Equal(t, int64(circuitClosed), r.CircuitState.Get())
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	r, clock := newTestRetryPolicy(&OutputConfig{
+		RetryInitialInterval: 10 * time.Second,
+		RetryMaxInterval:     30 * time.Second,
+	})
+
+	require.NoError(t, r.allow())
+	r.failure()
+	require.Equal(t, ErrRetryBackoff, r.allow())
+
+	// First delay is between 5s and 10s.
+	clock.add(4 * time.Second)
+	require.Equal(t, ErrRetryBackoff, r.allow())
+	clock.add(6 * time.Second)
+	require.NoError(t, r.allow())
+
+	// Second delay is between 10s and 20s.
+	r.failure()
+	clock.add(9 * time.Second)
+	require.Equal(t, ErrRetryBackoff, r.allow())
+	clock.add(11 * time.Second)
+	require.NoError(t, r.allow())
+
+	// Delay is limited to 30s.
+	for i := 0; i < 40; i++ {
+		r.failure()
+	}
+	clock.add(30 * time.Second)
+	require.NoError(t, r.allow())
+
+	r.success()
+	require.NoError(t, r.allow())
+	require.Equal(t, int64(0), r.ConsecutiveFailures.Get())
+}
+
+func TestRetryPolicy_CircuitBreaker(t *testing.T) {
+	r, clock := newTestRetryPolicy(&OutputConfig{
+		CircuitBreakerThreshold: 3,
+		CircuitBreakerCooldown:  time.Minute,
+	})
+
+	r.failure()
+	r.failure()
+	require.NoError(t, r.allow())
+	r.failure()
+	require.Equal(t, ErrCircuitOpen, r.allow())
+	require.Equal(t, int64(circuitOpen), r.CircuitState.Get())
+
+	clock.add(59 * time.Second)
+	require.Equal(t, ErrCircuitOpen, r.allow())
+
+	// A failure while half-open opens the breaker again.
+	clock.add(time.Second)
+	require.NoError(t, r.allow())
+	require.Equal(t, int64(circuitHalfOpen), r.CircuitState.Get())
+	r.failure()
+	require.Equal(t, ErrCircuitOpen, r.allow())
+
+	// A success while half-open closes the breaker.
+	clock.add(time.Minute)
+	require.NoError(t, r.allow())
+	r.success()
+	require.Equal(t, int64(circuitClosed), r.CircuitState.Get())
+	r.failure()
+	require.NoError(t, r.allow())
+}
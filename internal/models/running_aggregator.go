@@ -1,7 +1,6 @@
 package models
 
 import (
-	"log"
 	"sync"
 	"time"
 
@@ -16,6 +15,8 @@ type RunningAggregator struct {
 	Config      *AggregatorConfig
 	periodStart time.Time
 	periodEnd   time.Time
+	log         telegraf.Logger
+	status      *Status
 
 	MetricsPushed   selfstat.Stat
 	MetricsFiltered selfstat.Stat
@@ -27,28 +28,41 @@ func NewRunningAggregator(
 	aggregator telegraf.Aggregator,
 	config *AggregatorConfig,
 ) *RunningAggregator {
+	tags := map[string]string{"aggregator": config.Name}
+	if config.Alias != "" {
+		tags["alias"] = config.Alias
+	}
+
+	status := NewStatus()
+	logger := NewLogger(logName("aggregators", config.Name, config.Alias),
+		config.LogLevel, selfstat.Register("aggregate", "errors", tags))
+	logger.Status = status
+	SetLoggerOnPlugin(aggregator, logger)
+
 	return &RunningAggregator{
 		Aggregator: aggregator,
 		Config:     config,
+		log:        logger,
+		status:     status,
 		MetricsPushed: selfstat.Register(
 			"aggregate",
 			"metrics_pushed",
-			map[string]string{"aggregator": config.Name},
+			tags,
 		),
 		MetricsFiltered: selfstat.Register(
 			"aggregate",
 			"metrics_filtered",
-			map[string]string{"aggregator": config.Name},
+			tags,
 		),
 		MetricsDropped: selfstat.Register(
 			"aggregate",
 			"metrics_dropped",
-			map[string]string{"aggregator": config.Name},
+			tags,
 		),
 		PushTime: selfstat.Register(
 			"aggregate",
 			"push_time_ns",
-			map[string]string{"aggregator": config.Name},
+			tags,
 		),
 	}
 }
@@ -56,6 +70,8 @@ func NewRunningAggregator(
 // AggregatorConfig is the common config for all aggregators.
 type AggregatorConfig struct {
 	Name         string
+	Alias        string
+	LogLevel     string
 	DropOriginal bool
 	Period       time.Duration
 	Delay        time.Duration
@@ -71,6 +87,26 @@ func (r *RunningAggregator) Name() string {
 	return "aggregators." + r.Config.Name
 }
 
+// LogName returns the name of the aggregator as used in log messages.
+func (r *RunningAggregator) LogName() string {
+	return logName("aggregators", r.Config.Name, r.Config.Alias)
+}
+
+// Log returns the logger of the aggregator.
+func (r *RunningAggregator) Log() telegraf.Logger {
+	return r.log
+}
+
+// Status returns the health status of the aggregator.
+func (r *RunningAggregator) Status() *Status {
+	return r.status
+}
+
+// PluginStatus returns a snapshot of the health status of the aggregator.
+func (r *RunningAggregator) PluginStatus() PluginStatus {
+	return r.status.Get(r.Config.Name, r.Config.Alias)
+}
+
 func (r *RunningAggregator) Init() error {
 	if p, ok := r.Aggregator.(telegraf.Initializer); ok {
 		err := p.Init()
@@ -92,7 +128,7 @@ func (r *RunningAggregator) EndPeriod() time.Time {
 func (r *RunningAggregator) UpdateWindow(start, until time.Time) {
 	r.periodStart = start
 	r.periodEnd = until
-	log.Printf("D! [%s] Updated aggregation range [%s, %s]", r.Name(), start, until)
+	r.log.Debugf("Updated aggregation range [%s, %s]", start, until)
 }
 
 func (r *RunningAggregator) MakeMetric(metric telegraf.Metric) telegraf.Metric {
@@ -136,8 +172,8 @@ func (r *RunningAggregator) Add(m telegraf.Metric) bool {
 	defer r.Unlock()
 
 	if m.Time().Before(r.periodStart) || m.Time().After(r.periodEnd.Add(r.Config.Delay)) {
-		log.Printf("D! [%s] metric is outside aggregation window; discarding. %s: m: %s e: %s",
-			r.Name(), m.Time(), r.periodStart, r.periodEnd)
+		r.log.Debugf("metric is outside aggregation window; discarding. %s: m: %s e: %s",
+			m.Time(), r.periodStart, r.periodEnd)
 		r.MetricsDropped.Incr(1)
 		return r.Config.DropOriginal
 	}
@@ -156,6 +192,7 @@ func (r *RunningAggregator) Push(acc telegraf.Accumulator) {
 
 	r.push(acc)
 	r.Aggregator.Reset()
+	r.status.Success()
 }
 
 func (r *RunningAggregator) push(acc telegraf.Accumulator) {
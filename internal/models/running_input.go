@@ -14,24 +14,39 @@ type RunningInput struct {
 	Config *InputConfig
 
 	defaultTags map[string]string
+	log         telegraf.Logger
+	status      *Status
 
 	MetricsGathered selfstat.Stat
 	GatherTime      selfstat.Stat
 }
 
 func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
+	tags := map[string]string{"input": config.Name}
+	if config.Alias != "" {
+		tags["alias"] = config.Alias
+	}
+
+	status := NewStatus()
+	logger := NewLogger(logName("inputs", config.Name, config.Alias),
+		config.LogLevel, selfstat.Register("gather", "errors", tags))
+	logger.Status = status
+	SetLoggerOnPlugin(input, logger)
+
 	return &RunningInput{
 		Input:  input,
 		Config: config,
+		log:    logger,
+		status: status,
 		MetricsGathered: selfstat.Register(
 			"gather",
 			"metrics_gathered",
-			map[string]string{"input": config.Name},
+			tags,
 		),
 		GatherTime: selfstat.RegisterTiming(
 			"gather",
 			"gather_time_ns",
-			map[string]string{"input": config.Name},
+			tags,
 		),
 	}
 }
@@ -39,7 +54,9 @@ func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
 // InputConfig is the common config for all inputs.
 type InputConfig struct {
 	Name     string
+	Alias    string
 	Interval time.Duration
+	LogLevel string
 
 	NameOverride      string
 	MeasurementPrefix string
@@ -52,6 +69,26 @@ func (r *RunningInput) Name() string {
 	return "inputs." + r.Config.Name
 }
 
+// LogName returns the name of the input as used in log messages.
+func (r *RunningInput) LogName() string {
+	return logName("inputs", r.Config.Name, r.Config.Alias)
+}
+
+// Log returns the logger of the input.
+func (r *RunningInput) Log() telegraf.Logger {
+	return r.log
+}
+
+// Status returns the health status of the input.
+func (r *RunningInput) Status() *Status {
+	return r.status
+}
+
+// PluginStatus returns a snapshot of the health status of the input.
+func (r *RunningInput) PluginStatus() PluginStatus {
+	return r.status.Get(r.Config.Name, r.Config.Alias)
+}
+
 func (r *RunningInput) metricFiltered(metric telegraf.Metric) {
 	metric.Drop()
 }
@@ -91,11 +128,21 @@ func (r *RunningInput) MakeMetric(metric telegraf.Metric) telegraf.Metric {
 	return m
 }
 
+// Gather runs the Gather function of the input.  The gather counts as failed
+// if it returns an error or the input logs an error during it.
 func (r *RunningInput) Gather(acc telegraf.Accumulator) error {
+	errs := r.status.errorCount()
+
 	start := time.Now()
 	err := r.Input.Gather(acc)
 	elapsed := time.Since(start)
 	r.GatherTime.Incr(elapsed.Nanoseconds())
+
+	if err != nil || r.status.errorCount() != errs {
+		r.status.Failure(err)
+	} else {
+		r.status.Success()
+	}
 	return err
 }
 
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -261,3 +262,48 @@ type testInput struct{}
 func (t *testInput) Description() string                   { return "" }
 func (t *testInput) SampleConfig() string                  { return "" }
 func (t *testInput) Gather(acc telegraf.Accumulator) error { return nil }
+
+type statusInput struct {
+	Log telegraf.Logger `toml:"-"`
+
+	err    error
+	logErr bool
+}
+
+func (t *statusInput) Description() string  { return "" }
+func (t *statusInput) SampleConfig() string { return "" }
+func (t *statusInput) Gather(acc telegraf.Accumulator) error {
+	if t.logErr {
+		t.Log.Errorf("connection refused")
+	}
+	return t.err
+}
+
+func TestRunningInputGatherStatus(t *testing.T) {
+	input := &statusInput{}
+	ri := NewRunningInput(input, &InputConfig{Name: "status"})
+	acc := &testutil.Accumulator{}
+
+	require.NoError(t, ri.Gather(acc))
+	s := ri.PluginStatus()
+	require.NotNil(t, s.LastSuccess)
+	require.Equal(t, int64(0), s.ConsecutiveFailures)
+
+	// An error logged during the gather is a failure.
+	input.logErr = true
+	require.NoError(t, ri.Gather(acc))
+	input.logErr = false
+	input.err = errors.New("timeout")
+	require.Error(t, ri.Gather(acc))
+	s = ri.PluginStatus()
+	require.Equal(t, int64(2), s.ConsecutiveFailures)
+	require.Equal(t, int64(1), s.Errors)
+	require.Equal(t, "timeout", s.LastError)
+	require.NotNil(t, s.LastErrorTime)
+
+	input.err = nil
+	require.NoError(t, ri.Gather(acc))
+	s = ri.PluginStatus()
+	require.Equal(t, int64(0), s.ConsecutiveFailures)
+	require.Equal(t, "timeout", s.LastError)
+}
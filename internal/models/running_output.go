@@ -1,7 +1,7 @@
 package models
 
 import (
-	"log"
+	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -20,12 +20,43 @@ const (
 
 // OutputConfig containing name and filter
 type OutputConfig struct {
-	Name   string
-	Filter Filter
+	Name     string
+	Alias    string
+	LogLevel string
+	Filter   Filter
 
 	FlushInterval     time.Duration
 	MetricBufferLimit int
 	MetricBatchSize   int
+
+	// BufferStrategy selects where unwritten metrics are stored, either
+	// "memory" or "disk".
+	BufferStrategy    string
+	BufferDirectory   string
+	BufferSizeLimit   int64
+	BufferSegmentSize int64
+	BufferSync        string
+
+	// WriteTimeout is the maximum duration of a write to the output, a write
+	// taking longer fails.  When zero writes have no time limit.
+	WriteTimeout time.Duration
+
+	// RetryInitialInterval is the delay before retrying a failed write, it
+	// doubles with each consecutive failure up to RetryMaxInterval.  When
+	// zero failed writes are retried on the next flush.
+	RetryInitialInterval time.Duration
+	RetryMaxInterval     time.Duration
+
+	// RetryMaxAge is the time after entering the buffer after which metrics
+	// of a failed write are dropped instead of retried.  When zero metrics are retried until they
+	// are dropped from the full buffer.
+	RetryMaxAge time.Duration
+
+	// CircuitBreakerThreshold is the number of consecutive failed writes
+	// after which no writes are attempted for CircuitBreakerCooldown.  When
+	// zero the circuit breaker is disabled.
+	CircuitBreakerThreshold int
+	CircuitBreakerCooldown  time.Duration
 }
 
 // RunningOutput contains the output configuration
@@ -41,11 +72,24 @@ type RunningOutput struct {
 	MetricBatchSize   int
 
 	MetricsFiltered selfstat.Stat
+	MetricsExpired  selfstat.Stat
 	WriteTime       selfstat.Stat
 
 	BatchReady chan time.Time
 
-	buffer *Buffer
+	log    telegraf.Logger
+	retry  *retryPolicy
+	status *Status
+
+	buffer MetricBuffer
+	// bufferShared is set while the disk buffer is shared with another
+	// output during a reload, the buffer is then neither created by Init nor
+	// closed by Close.
+	bufferShared bool
+
+	// inflight is closed when a write abandoned after WriteTimeout returns,
+	// no further writes are started until then.
+	inflight chan struct{}
 
 	aggMutex sync.Mutex
 }
@@ -69,6 +113,18 @@ func NewRunningOutput(
 	if batchSize == 0 {
 		batchSize = DEFAULT_METRIC_BATCH_SIZE
 	}
+
+	tags := map[string]string{"output": name}
+	if conf.Alias != "" {
+		tags["alias"] = conf.Alias
+	}
+
+	status := NewStatus()
+	logger := NewLogger(logName("outputs", name, conf.Alias),
+		conf.LogLevel, selfstat.Register("write", "errors", tags))
+	logger.Status = status
+	SetLoggerOnPlugin(output, logger)
+
 	ro := &RunningOutput{
 		Name:              name,
 		buffer:            NewBuffer(name, bufferLimit),
@@ -80,18 +136,51 @@ func NewRunningOutput(
 		MetricsFiltered: selfstat.Register(
 			"write",
 			"metrics_filtered",
-			map[string]string{"output": name},
+			tags,
+		),
+		MetricsExpired: selfstat.Register(
+			"write",
+			"metrics_expired",
+			tags,
 		),
 		WriteTime: selfstat.RegisterTiming(
 			"write",
 			"write_time_ns",
-			map[string]string{"output": name},
+			tags,
 		),
+		log:    logger,
+		retry:  newRetryPolicy(conf, tags, logger),
+		status: status,
 	}
 
 	return ro
 }
 
+// LogName returns the name of the output as used in log messages.
+func (ro *RunningOutput) LogName() string {
+	return logName("outputs", ro.Name, ro.Config.Alias)
+}
+
+// Log returns the logger of the output.
+func (ro *RunningOutput) Log() telegraf.Logger {
+	return ro.log
+}
+
+// Status returns the health status of the output.
+func (ro *RunningOutput) Status() *Status {
+	return ro.status
+}
+
+// PluginStatus returns a snapshot of the health status of the output,
+// including the fullness of its buffer.
+func (ro *RunningOutput) PluginStatus() PluginStatus {
+	ps := ro.status.Get(ro.Name, ro.Config.Alias)
+	size, limit := ro.buffer.Len(), ro.MetricBufferLimit
+	ps.BufferSize = &size
+	ps.BufferLimit = &limit
+	return ps
+}
+
 func (ro *RunningOutput) metricFiltered(metric telegraf.Metric) {
 	ro.MetricsFiltered.Incr(1)
 	metric.Drop()
@@ -104,6 +193,26 @@ func (ro *RunningOutput) Init() error {
 			return err
 		}
 	}
+
+	switch ro.Config.BufferStrategy {
+	case "", "memory":
+	case "disk":
+		if ro.bufferShared {
+			break
+		}
+		buffer, err := NewDiskBuffer(ro.Name, ro.MetricBufferLimit, DiskBufferConfig{
+			Directory:   ro.Config.BufferDirectory,
+			SizeLimit:   ro.Config.BufferSizeLimit,
+			SegmentSize: ro.Config.BufferSegmentSize,
+			Sync:        ro.Config.BufferSync,
+		})
+		if err != nil {
+			return err
+		}
+		ro.buffer = buffer
+	default:
+		return fmt.Errorf("invalid buffer_strategy %q", ro.Config.BufferStrategy)
+	}
 	return nil
 }
 
@@ -145,6 +254,17 @@ func (ro *RunningOutput) AddMetric(metric telegraf.Metric) {
 // Write writes all metrics to the output, stopping when all have been sent on
 // or error.
 func (ro *RunningOutput) Write() error {
+	return ro.writeAll(false)
+}
+
+// WriteFinal writes all metrics to the output like Write but ignores the
+// retry backoff and the circuit breaker, so that the final flush at shutdown
+// is attempted even while writes are suspended.
+func (ro *RunningOutput) WriteFinal() error {
+	return ro.writeAll(true)
+}
+
+func (ro *RunningOutput) writeAll(final bool) error {
 	if output, ok := ro.Output.(telegraf.AggregatingOutput); ok {
 		ro.aggMutex.Lock()
 		metrics := output.Push()
@@ -155,6 +275,13 @@ func (ro *RunningOutput) Write() error {
 
 	atomic.StoreInt64(&ro.newMetricsCount, 0)
 
+	if !final {
+		err := ro.retry.allow()
+		if err != nil {
+			return err
+		}
+	}
+
 	// Only process the metrics in the buffer now.  Metrics added while we are
 	// writing will be sent on the next call.
 	nBuffer := ro.buffer.Len()
@@ -167,7 +294,7 @@ func (ro *RunningOutput) Write() error {
 
 		err := ro.write(batch)
 		if err != nil {
-			ro.buffer.Reject(batch)
+			ro.reject(batch)
 			return err
 		}
 		ro.buffer.Accept(batch)
@@ -177,14 +304,19 @@ func (ro *RunningOutput) Write() error {
 
 // WriteBatch writes a single batch of metrics to the output.
 func (ro *RunningOutput) WriteBatch() error {
+	err := ro.retry.allow()
+	if err != nil {
+		return err
+	}
+
 	batch := ro.buffer.Batch(ro.MetricBatchSize)
 	if len(batch) == 0 {
 		return nil
 	}
 
-	err := ro.write(batch)
+	err = ro.write(batch)
 	if err != nil {
-		ro.buffer.Reject(batch)
+		ro.reject(batch)
 		return err
 	}
 	ro.buffer.Accept(batch)
@@ -192,35 +324,144 @@ func (ro *RunningOutput) WriteBatch() error {
 	return nil
 }
 
+// Connect connects the output.
+func (ro *RunningOutput) Connect() error {
+	err := ro.Output.Connect()
+	if err != nil {
+		ro.status.Failure(err)
+		return err
+	}
+	ro.status.SetRunning(true)
+	return nil
+}
+
 func (ro *RunningOutput) Close() {
+	ro.status.SetRunning(false)
+
 	err := ro.Output.Close()
 	if err != nil {
-		log.Printf("E! [outputs.%s] Error closing output: %v", ro.Name, err)
+		ro.log.Errorf("Error closing output: %v", err)
+	}
+
+	// Unlike the disk buffer, the memory buffer is lost on exit.
+	if ro.Config.BufferStrategy != "disk" {
+		if n := ro.buffer.Len(); n > 0 {
+			ro.log.Errorf("Dropping %d unwritten metrics from the buffer", n)
+		}
+	}
+
+	if ro.bufferShared {
+		return
+	}
+	err = ro.buffer.Close()
+	if err != nil {
+		ro.log.Errorf("Error closing buffer: %v", err)
 	}
 }
 
+// ShareBuffer makes the output use the disk buffer of prev, an output with
+// the same buffer directory that it replaces.  Metrics added to prev until it
+// is removed are kept in the buffer and neither output closes it until
+// ClaimBuffer is called.  It must be called before Init.
+func (ro *RunningOutput) ShareBuffer(prev *RunningOutput) {
+	ro.buffer = prev.buffer
+	ro.bufferShared = true
+	prev.bufferShared = true
+}
+
+// ClaimBuffer ends the sharing of the buffer started with ShareBuffer, the
+// output closes the buffer again on Close.
+func (ro *RunningOutput) ClaimBuffer() {
+	ro.bufferShared = false
+}
+
 func (ro *RunningOutput) write(metrics []telegraf.Metric) error {
 	dropped := atomic.LoadInt64(&ro.droppedMetrics)
 	if dropped > 0 {
-		log.Printf("W! [outputs.%s] Metric buffer overflow; %d metrics have been dropped",
-			ro.Name, dropped)
+		ro.log.Warnf("Metric buffer overflow; %d metrics have been dropped", dropped)
 		atomic.StoreInt64(&ro.droppedMetrics, 0)
 	}
 
 	start := time.Now()
-	err := ro.Output.Write(metrics)
+	err := ro.writeWithTimeout(metrics)
 	elapsed := time.Since(start)
 	ro.WriteTime.Incr(elapsed.Nanoseconds())
 
-	if err == nil {
-		log.Printf("D! [outputs.%s] wrote batch of %d metrics in %s\n",
-			ro.Name, len(metrics), elapsed)
+	if err != nil {
+		ro.retry.failure()
+		ro.status.Failure(err)
+		return err
+	}
+
+	ro.retry.success()
+	ro.status.Success()
+	ro.log.Debugf("Wrote batch of %d metrics in %s", len(metrics), elapsed)
+	return nil
+}
+
+// writeWithTimeout writes the metrics to the output, failing if the write
+// takes longer than WriteTimeout.  The write cannot be canceled, so a timed
+// out write keeps running and later writes fail until it returns.
+func (ro *RunningOutput) writeWithTimeout(metrics []telegraf.Metric) error {
+	timeout := ro.Config.WriteTimeout
+	if ro.inflight != nil {
+		select {
+		case <-ro.inflight:
+			ro.inflight = nil
+		default:
+			return fmt.Errorf("write timed out after %s is still in progress", timeout)
+		}
+	}
+
+	if timeout <= 0 {
+		return ro.Output.Write(metrics)
+	}
+
+	var err error
+	done := make(chan struct{})
+	go func() {
+		err = ro.Output.Write(metrics)
+		close(done)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return err
+	case <-timer.C:
+		ro.inflight = done
+		return fmt.Errorf("write timed out after %s", timeout)
+	}
+}
+
+// reject returns a batch that failed to write to the buffer, metrics older
+// than RetryMaxAge are dropped.  The age is the time since the metric was
+// added to the buffer.
+func (ro *RunningOutput) reject(batch []telegraf.Metric) {
+	if ro.Config.RetryMaxAge <= 0 {
+		ro.buffer.Reject(batch)
+		return
+	}
+
+	cutoff := ro.retry.now().Add(-ro.Config.RetryMaxAge)
+	var expired int
+	ro.buffer.Drop(batch, func(m telegraf.Metric, added time.Time) bool {
+		if added.Before(cutoff) {
+			expired++
+			return true
+		}
+		return false
+	})
+
+	if expired > 0 {
+		ro.MetricsExpired.Incr(int64(expired))
+		ro.log.Warnf("Dropped %d metrics older than retry_max_age of %s",
+			expired, ro.Config.RetryMaxAge)
 	}
-	return err
 }
 
 func (ro *RunningOutput) LogBufferStatus() {
 	nBuffer := ro.buffer.Len()
-	log.Printf("D! [outputs.%s] buffer fullness: %d / %d metrics. ",
-		ro.Name, nBuffer, ro.MetricBufferLimit)
+	ro.log.Debugf("Buffer fullness: %d / %d metrics", nBuffer, ro.MetricBufferLimit)
 }
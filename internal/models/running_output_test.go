@@ -2,8 +2,11 @@ package models
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/testutil"
@@ -412,6 +415,233 @@ func TestRunningOutputWriteFailOrder3(t *testing.T) {
 	assert.Equal(t, expected, m.Metrics())
 }
 
+// Verify that metrics not written before the output is closed are written
+// after a restart when using the disk buffer.
+func TestRunningOutputDiskBufferRestart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-buffer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	conf := &OutputConfig{
+		Filter:          Filter{},
+		BufferStrategy:  "disk",
+		BufferDirectory: dir,
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("test", m, conf, 5, 1000)
+	require.NoError(t, ro.Init())
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	err = ro.Write()
+	require.Error(t, err)
+	ro.Close()
+
+	m = &mockOutput{}
+	ro = NewRunningOutput("test", m, conf, 5, 1000)
+	require.NoError(t, ro.Init())
+	defer ro.Close()
+
+	ro.AddMetric(next5[0])
+	err = ro.Write()
+	require.NoError(t, err)
+
+	expected := append(append([]telegraf.Metric{}, first5...), next5[0])
+	testutil.RequireMetricsEqual(t, expected, m.Metrics())
+}
+
+func TestRunningOutputInvalidBufferStrategy(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:         Filter{},
+		BufferStrategy: "tape",
+	}
+
+	ro := NewRunningOutput("test", &mockOutput{}, conf, 5, 1000)
+	require.Error(t, ro.Init())
+}
+
+func TestRunningOutputRetryBackoff(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:               Filter{},
+		RetryInitialInterval: time.Hour,
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("test", m, conf, 5, 1000)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	err := ro.Write()
+	require.Error(t, err)
+
+	m.failWrite = false
+	err = ro.Write()
+	require.Equal(t, ErrRetryBackoff, err)
+	err = ro.WriteBatch()
+	require.Equal(t, ErrRetryBackoff, err)
+	require.Len(t, m.Metrics(), 0)
+
+	ro.retry.now = func() time.Time { return time.Now().Add(time.Hour) }
+	err = ro.Write()
+	require.NoError(t, err)
+	require.Len(t, m.Metrics(), 5)
+}
+
+func TestRunningOutputCircuitBreaker(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:                  Filter{},
+		CircuitBreakerThreshold: 2,
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("test", m, conf, 5, 1000)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	require.Error(t, ro.Write())
+	require.Error(t, ro.Write())
+
+	m.failWrite = false
+	err := ro.Write()
+	require.Equal(t, ErrCircuitOpen, err)
+	require.Len(t, m.Metrics(), 0)
+
+	ro.retry.now = func() time.Time { return time.Now().Add(time.Hour) }
+	err = ro.Write()
+	require.NoError(t, err)
+	require.Len(t, m.Metrics(), 5)
+}
+
+func TestRunningOutputWriteFinalIgnoresCircuitBreaker(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:                  Filter{},
+		CircuitBreakerThreshold: 1,
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("test", m, conf, 5, 1000)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	require.Error(t, ro.Write())
+
+	m.failWrite = false
+	require.Equal(t, ErrCircuitOpen, ro.Write())
+	require.NoError(t, ro.WriteFinal())
+	require.Len(t, m.Metrics(), 5)
+}
+
+func TestRunningOutputWriteTimeout(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:                  Filter{},
+		WriteTimeout:            10 * time.Millisecond,
+		CircuitBreakerThreshold: 2,
+	}
+
+	m := &blockingOutput{block: make(chan struct{})}
+	ro := NewRunningOutput("test", m, conf, 5, 1000)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	err := ro.Write()
+	require.Error(t, err)
+	require.Equal(t, int64(1), ro.retry.ConsecutiveFailures.Get())
+	require.Equal(t, 5, ro.buffer.Len())
+
+	// The blocked write has not returned yet.
+	err = ro.Write()
+	require.Error(t, err)
+	require.Equal(t, int64(2), ro.retry.ConsecutiveFailures.Get())
+	require.Equal(t, ErrCircuitOpen, ro.Write())
+
+	close(m.block)
+	<-ro.inflight
+	ro.retry.now = func() time.Time { return time.Now().Add(time.Hour) }
+	require.NoError(t, ro.Write())
+	require.Equal(t, int64(0), ro.retry.ConsecutiveFailures.Get())
+	require.Equal(t, 0, ro.buffer.Len())
+	require.Len(t, m.Metrics(), 10)
+}
+
+func TestRunningOutputRetryMaxAge(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:      Filter{},
+		RetryMaxAge: time.Minute,
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("test", m, conf, 5, 1000)
+	ro.MetricsExpired.Set(0)
+
+	// The age is measured from when the metric entered the buffer, not from
+	// the timestamp of the metric.
+	now := time.Now()
+	buffer := ro.buffer.(*Buffer)
+	buffer.now = func() time.Time { return now.Add(-2 * time.Minute) }
+	ro.AddMetric(testutil.MustMetric("cpu", map[string]string{},
+		map[string]interface{}{"value": 1}, now))
+	buffer.now = func() time.Time { return now }
+	ro.AddMetric(testutil.MustMetric("cpu", map[string]string{},
+		map[string]interface{}{"value": 2}, now.Add(-time.Hour)))
+
+	require.Error(t, ro.Write())
+	require.Equal(t, int64(1), ro.MetricsExpired.Get())
+
+	m.failWrite = false
+	require.NoError(t, ro.Write())
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{},
+			map[string]interface{}{"value": 2}, now.Add(-time.Hour)),
+	}
+	testutil.RequireMetricsEqual(t, expected, m.Metrics())
+}
+
+func TestRunningOutputStatus(t *testing.T) {
+	m := &mockOutput{}
+	ro := NewRunningOutput("test", m, &OutputConfig{}, 4, 12)
+
+	s := ro.PluginStatus()
+	require.False(t, s.Running)
+	require.Equal(t, 0, *s.BufferSize)
+	require.Equal(t, 12, *s.BufferLimit)
+
+	require.NoError(t, ro.Connect())
+	require.True(t, ro.PluginStatus().Running)
+
+	m.failWrite = true
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	require.Error(t, ro.Write())
+	require.Error(t, ro.Write())
+	s = ro.PluginStatus()
+	require.Equal(t, int64(2), s.ConsecutiveFailures)
+	require.Equal(t, "Failed Write!", s.LastError)
+	require.Equal(t, 5, *s.BufferSize)
+	require.Nil(t, s.LastSuccess)
+
+	m.failWrite = false
+	require.NoError(t, ro.Write())
+	s = ro.PluginStatus()
+	require.Equal(t, int64(0), s.ConsecutiveFailures)
+	require.Equal(t, 0, *s.BufferSize)
+	require.NotNil(t, s.LastSuccess)
+
+	ro.Close()
+	require.False(t, ro.PluginStatus().Running)
+}
+
 type mockOutput struct {
 	sync.Mutex
 
@@ -460,6 +690,18 @@ func (m *mockOutput) Metrics() []telegraf.Metric {
 	return m.metrics
 }
 
+// blockingOutput is a mockOutput whose writes block until block is closed.
+type blockingOutput struct {
+	mockOutput
+
+	block chan struct{}
+}
+
+func (m *blockingOutput) Write(metrics []telegraf.Metric) error {
+	<-m.block
+	return m.mockOutput.Write(metrics)
+}
+
 type perfOutput struct {
 	// if true, mock a write failure
 	failWrite bool
@@ -1,9 +1,11 @@
 package models
 
 import (
+	"fmt"
 	"sync"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/selfstat"
 )
 
 type RunningProcessor struct {
@@ -12,6 +14,8 @@ type RunningProcessor struct {
 	sync.Mutex
 	Processor telegraf.Processor
 	Config    *ProcessorConfig
+	log       telegraf.Logger
+	status    *Status
 }
 
 type RunningProcessors []*RunningProcessor
@@ -20,11 +24,73 @@ func (rp RunningProcessors) Len() int           { return len(rp) }
 func (rp RunningProcessors) Swap(i, j int)      { rp[i], rp[j] = rp[j], rp[i] }
 func (rp RunningProcessors) Less(i, j int) bool { return rp[i].Config.Order < rp[j].Config.Order }
 
+// Stages of the pipeline a processor can be applied to.
+const (
+	// ProcessorStageBoth applies the processor to the metrics of the inputs
+	// and again to the metrics emitted by the aggregators.
+	ProcessorStageBoth = "both"
+
+	// ProcessorStagePre applies the processor only to the metrics of the
+	// inputs, before they are aggregated.
+	ProcessorStagePre = "pre"
+
+	// ProcessorStagePost applies the processor only to the metrics emitted
+	// by the aggregators.
+	ProcessorStagePost = "post"
+)
+
 // FilterConfig containing a name and filter
 type ProcessorConfig struct {
-	Name   string
-	Order  int64
-	Filter Filter
+	Name     string
+	Alias    string
+	Order    int64
+	LogLevel string
+	Filter   Filter
+
+	// Stage selects the metrics the processor is applied to, one of the
+	// ProcessorStage constants.  When empty ProcessorStageBoth is used.
+	Stage string
+}
+
+func NewRunningProcessor(processor telegraf.Processor, config *ProcessorConfig) *RunningProcessor {
+	tags := map[string]string{"processor": config.Name}
+	if config.Alias != "" {
+		tags["alias"] = config.Alias
+	}
+
+	status := NewStatus()
+	logger := NewLogger(logName("processors", config.Name, config.Alias),
+		config.LogLevel, selfstat.Register("process", "errors", tags))
+	logger.Status = status
+	SetLoggerOnPlugin(processor, logger)
+
+	return &RunningProcessor{
+		Name:      config.Name,
+		Processor: processor,
+		Config:    config,
+		log:       logger,
+		status:    status,
+	}
+}
+
+// LogName returns the name of the processor as used in log messages.
+func (rp *RunningProcessor) LogName() string {
+	return logName("processors", rp.Config.Name, rp.Config.Alias)
+}
+
+// Log returns the logger of the processor.
+func (rp *RunningProcessor) Log() telegraf.Logger {
+	return rp.log
+}
+
+// Status returns the health status of the processor.
+func (rp *RunningProcessor) Status() *Status {
+	return rp.status
+}
+
+// PluginStatus returns a snapshot of the health status of the processor.
+func (rp *RunningProcessor) PluginStatus() PluginStatus {
+	return rp.status.Get(rp.Config.Name, rp.Config.Alias)
 }
 
 func (rp *RunningProcessor) metricFiltered(metric telegraf.Metric) {
@@ -41,6 +107,11 @@ func containsMetric(item telegraf.Metric, metrics []telegraf.Metric) bool {
 }
 
 func (rp *RunningProcessor) Init() error {
+	if rp.IsStreaming() && rp.Config.Stage == ProcessorStagePost {
+		return fmt.Errorf("streaming processors can not use stage %q",
+			ProcessorStagePost)
+	}
+
 	if p, ok := rp.Processor.(telegraf.Initializer); ok {
 		err := p.Init()
 		if err != nil {
@@ -77,3 +148,51 @@ func (rp *RunningProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
 
 	return ret
 }
+
+// MakeMetric returns the metric unchanged, metrics emitted by streaming
+// processors are not modified.
+func (rp *RunningProcessor) MakeMetric(metric telegraf.Metric) telegraf.Metric {
+	return metric
+}
+
+// Stage returns the stage of the pipeline the processor is applied to.
+func (rp *RunningProcessor) Stage() string {
+	if rp.Config.Stage == "" {
+		return ProcessorStageBoth
+	}
+	return rp.Config.Stage
+}
+
+// IsPreAggregation returns true if the processor is applied to the metrics
+// of the inputs.
+func (rp *RunningProcessor) IsPreAggregation() bool {
+	return rp.Stage() != ProcessorStagePost
+}
+
+// IsPostAggregation returns true if the processor is applied to the metrics
+// emitted by the aggregators.  Streaming processors only process the metrics
+// of the inputs.
+func (rp *RunningProcessor) IsPostAggregation() bool {
+	return rp.Stage() != ProcessorStagePre && !rp.IsStreaming()
+}
+
+// IsStreaming returns true if the processor emits metrics outside of Apply.
+func (rp *RunningProcessor) IsStreaming() bool {
+	_, ok := rp.Processor.(telegraf.StreamingProcessor)
+	return ok
+}
+
+// Start starts the processor if it is a streaming processor.
+func (rp *RunningProcessor) Start(acc telegraf.Accumulator) error {
+	if p, ok := rp.Processor.(telegraf.StreamingProcessor); ok {
+		return p.Start(acc)
+	}
+	return nil
+}
+
+// Stop stops the processor if it is a streaming processor.
+func (rp *RunningProcessor) Stop() {
+	if p, ok := rp.Processor.(telegraf.StreamingProcessor); ok {
+		p.Stop()
+	}
+}
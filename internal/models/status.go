@@ -0,0 +1,117 @@
+package models
+
+import (
+	"sync"
+	"time"
+)
+
+// Status tracks the health of a running plugin.  It is safe for concurrent
+// use.
+type Status struct {
+	mu sync.Mutex
+
+	running             bool
+	lastSuccess         time.Time
+	lastError           string
+	lastErrorTime       time.Time
+	consecutiveFailures int64
+	errors              int64
+
+	now func() time.Time
+}
+
+// NewStatus returns the Status of a plugin that is not running yet.
+func NewStatus() *Status {
+	return &Status{now: time.Now}
+}
+
+// PluginStatus is a snapshot of the Status of a plugin.
+type PluginStatus struct {
+	Name  string `json:"name"`
+	Alias string `json:"alias,omitempty"`
+
+	// Running is true for inputs that are gathering, service inputs whose
+	// service started, outputs that are connected and processors and
+	// aggregators that are part of the pipeline.
+	Running bool `json:"running"`
+
+	LastSuccess         *time.Time `json:"last_success,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastErrorTime       *time.Time `json:"last_error_time,omitempty"`
+	ConsecutiveFailures int64      `json:"consecutive_failures"`
+	Errors              int64      `json:"errors"`
+
+	// BufferSize and BufferLimit are only set for outputs.
+	BufferSize  *int `json:"buffer_size,omitempty"`
+	BufferLimit *int `json:"buffer_limit,omitempty"`
+}
+
+// SetRunning records whether the plugin is running.
+func (s *Status) SetRunning(running bool) {
+	s.mu.Lock()
+	s.running = running
+	s.mu.Unlock()
+}
+
+// Success records a successful gather, write or push.
+func (s *Status) Success() {
+	s.mu.Lock()
+	s.lastSuccess = s.now()
+	s.consecutiveFailures = 0
+	s.mu.Unlock()
+}
+
+// Failure records a failed gather, write or push.
+func (s *Status) Failure(err error) {
+	s.mu.Lock()
+	if err != nil {
+		s.setError(err.Error())
+	}
+	s.consecutiveFailures++
+	s.mu.Unlock()
+}
+
+// Error records an error logged by the plugin, it does not count as a
+// failure by itself.
+func (s *Status) Error(msg string) {
+	s.mu.Lock()
+	s.setError(msg)
+	s.errors++
+	s.mu.Unlock()
+}
+
+func (s *Status) setError(msg string) {
+	s.lastError = msg
+	s.lastErrorTime = s.now()
+}
+
+// errorCount returns the number of errors logged by the plugin.
+func (s *Status) errorCount() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.errors
+}
+
+// Get returns a snapshot of the status.
+func (s *Status) Get(name, alias string) PluginStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ps := PluginStatus{
+		Name:                name,
+		Alias:               alias,
+		Running:             s.running,
+		LastError:           s.lastError,
+		ConsecutiveFailures: s.consecutiveFailures,
+		Errors:              s.errors,
+	}
+	if !s.lastSuccess.IsZero() {
+		t := s.lastSuccess
+		ps.LastSuccess = &t
+	}
+	if !s.lastErrorTime.IsZero() {
+		t := s.lastErrorTime
+		ps.LastErrorTime = &t
+	}
+	return ps
+}
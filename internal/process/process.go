@@ -0,0 +1,264 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+const (
+	// DefaultRestartDelay is the delay before the first restart of a process.
+	DefaultRestartDelay = 10 * time.Second
+
+	// MaxRestartDelay is the maximum delay between restarts of a process
+	// that keeps exiting.
+	MaxRestartDelay = 5 * time.Minute
+
+	stopTimeout = 5 * time.Second
+)
+
+// ErrNotRunning is returned when writing to or signaling a process that is
+// not running.
+var ErrNotRunning = errors.New("process is not running")
+
+// Process is a long lived child process.  The process is restarted when it
+// exits, with a delay that doubles each time the process exits shortly after
+// being started.
+type Process struct {
+	// ReadStdoutFn and ReadStderrFn are called with the output of each run of
+	// the process.  They must read until EOF.
+	ReadStdoutFn func(io.Reader)
+	ReadStderrFn func(io.Reader)
+
+	RestartDelay time.Duration
+	Log          telegraf.Logger
+
+	name string
+	args []string
+
+	mu        sync.Mutex
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	exited    chan struct{}
+	startTime time.Time
+	stopping  bool
+
+	cancel context.CancelFunc
+	loopWg sync.WaitGroup
+	wg     sync.WaitGroup
+}
+
+// New creates a new process from a command and its arguments.
+func New(command []string) (*Process, error) {
+	if len(command) == 0 {
+		return nil, errors.New("no command specified")
+	}
+
+	return &Process{
+		RestartDelay: DefaultRestartDelay,
+		name:         command[0],
+		args:         command[1:],
+	}, nil
+}
+
+// Start starts the process and restarts it whenever it exits until Stop is
+// called.
+func (p *Process) Start() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	err := p.cmdStart()
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	p.loopWg.Add(1)
+	go func() {
+		defer p.loopWg.Done()
+		p.cmdLoop(ctx)
+	}()
+	return nil
+}
+
+// Stop stops the process.  Stdin of the process is closed first, if it does
+// not exit on its own it is terminated.  Stop returns after all output of the
+// process has been read.
+func (p *Process) Stop() {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	// No restarts are attempted once the loop has returned.
+	p.loopWg.Wait()
+
+	p.mu.Lock()
+	p.stopping = true
+	cmd, stdin, exited := p.cmd, p.stdin, p.exited
+	p.mu.Unlock()
+
+	if cmd != nil {
+		stdin.Close()
+		gracefulStop(cmd, exited, stopTimeout)
+	}
+	p.wg.Wait()
+}
+
+// Write writes to stdin of the process.
+func (p *Process) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	stdin := p.stdin
+	p.mu.Unlock()
+
+	if stdin == nil {
+		return 0, ErrNotRunning
+	}
+	return stdin.Write(b)
+}
+
+// Signal sends a signal to the process.
+func (p *Process) Signal(sig os.Signal) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cmd == nil {
+		return ErrNotRunning
+	}
+	return p.cmd.Process.Signal(sig)
+}
+
+func (p *Process) cmdStart() error {
+	cmd := exec.Command(p.name, p.args...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("error opening stdin pipe: %v", err)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("error opening stdout pipe: %v", err)
+	}
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("error opening stderr pipe: %v", err)
+	}
+
+	p.Log.Infof("Starting process: %s %s", p.name, p.args)
+
+	err = cmd.Start()
+	if err != nil {
+		return fmt.Errorf("error starting process: %v", err)
+	}
+
+	exited := make(chan struct{})
+
+	p.mu.Lock()
+	p.cmd = cmd
+	p.stdin = stdin
+	p.exited = exited
+	p.startTime = time.Now()
+	p.mu.Unlock()
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer close(exited)
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			p.readOutput(p.ReadStdoutFn, stdout)
+		}()
+		go func() {
+			defer wg.Done()
+			p.readOutput(p.ReadStderrFn, stderr)
+		}()
+
+		// The output must be read completely before calling Wait.
+		wg.Wait()
+		err := cmd.Wait()
+
+		p.mu.Lock()
+		p.cmd = nil
+		p.stdin = nil
+		stopping := p.stopping
+		p.mu.Unlock()
+
+		if stopping {
+			p.Log.Debugf("Process %s stopped", p.name)
+		} else if err != nil {
+			p.Log.Errorf("Process %s exited: %v", p.name, err)
+		} else {
+			p.Log.Infof("Process %s exited", p.name)
+		}
+	}()
+	return nil
+}
+
+func (p *Process) readOutput(fn func(io.Reader), r io.Reader) {
+	if fn == nil {
+		io.Copy(ioutil.Discard, r)
+		return
+	}
+	fn(r)
+	// Drain anything that fn did not read, so that Wait does not block.
+	io.Copy(ioutil.Discard, r)
+}
+
+// cmdLoop restarts the process each time it exits until the context is done.
+func (p *Process) cmdLoop(ctx context.Context) {
+	delay := p.RestartDelay
+	for {
+		p.mu.Lock()
+		exited := p.exited
+		started := p.startTime
+		p.mu.Unlock()
+
+		select {
+		case <-exited:
+		case <-ctx.Done():
+			return
+		}
+
+		maxDelay := MaxRestartDelay
+		if p.RestartDelay > maxDelay {
+			maxDelay = p.RestartDelay
+		}
+
+		// Back off while the process keeps exiting shortly after it
+		// started.
+		if time.Since(started) >= maxDelay {
+			delay = p.RestartDelay
+		}
+
+		for {
+			p.Log.Infof("Restarting in %s...", delay)
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return
+			}
+
+			delay *= 2
+			if delay > maxDelay {
+				delay = maxDelay
+			}
+
+			err := p.cmdStart()
+			if err == nil {
+				break
+			}
+			p.Log.Errorf("Error restarting process: %v", err)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+// +build !windows
+
+package process
+
+import (
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// gracefulStop asks the process to terminate and kills it if it has not
+// exited after the timeout.
+func gracefulStop(cmd *exec.Cmd, exited <-chan struct{}, timeout time.Duration) {
+	select {
+	case <-exited:
+		return
+	case <-time.After(timeout):
+		cmd.Process.Signal(syscall.SIGTERM)
+	}
+
+	select {
+	case <-exited:
+	case <-time.After(timeout):
+		cmd.Process.Kill()
+	}
+}
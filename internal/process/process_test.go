@@ -0,0 +1,85 @@
+// +build !windows
+
+package process
+
+import (
+	"bufio"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcess_WriteAndRead(t *testing.T) {
+	p, err := New([]string{"cat"})
+	require.NoError(t, err)
+	p.Log = testutil.Logger{}
+
+	var mu sync.Mutex
+	var lines []string
+	p.ReadStdoutFn = func(r io.Reader) {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			mu.Lock()
+			lines = append(lines, scanner.Text())
+			mu.Unlock()
+		}
+	}
+
+	require.NoError(t, p.Start())
+	_, err = p.Write([]byte("hello\nworld\n"))
+	require.NoError(t, err)
+	p.Stop()
+
+	// Stop returns only after the output has been read.
+	require.Equal(t, []string{"hello", "world"}, lines)
+}
+
+func TestProcess_RestartOnExit(t *testing.T) {
+	p, err := New([]string{"sh", "-c", "echo started"})
+	require.NoError(t, err)
+	p.Log = testutil.Logger{}
+	p.RestartDelay = 10 * time.Millisecond
+
+	starts := make(chan struct{}, 10)
+	p.ReadStdoutFn = func(r io.Reader) {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			starts <- struct{}{}
+		}
+	}
+
+	require.NoError(t, p.Start())
+	defer p.Stop()
+
+	for i := 0; i < 3; i++ {
+		select {
+		case <-starts:
+		case <-time.After(5 * time.Second):
+			t.Fatal("process was not restarted")
+		}
+	}
+}
+
+func TestProcess_StopUnresponsive(t *testing.T) {
+	// The process ignores stdin being closed, Stop terminates it.
+	p, err := New([]string{"sleep", "60"})
+	require.NoError(t, err)
+	p.Log = testutil.Logger{}
+
+	require.NoError(t, p.Start())
+	start := time.Now()
+	p.Stop()
+	require.True(t, time.Since(start) < 30*time.Second)
+
+	_, err = p.Write([]byte("x"))
+	require.Equal(t, ErrNotRunning, err)
+}
+
+func TestProcess_NoCommand(t *testing.T) {
+	_, err := New(nil)
+	require.Error(t, err)
+}
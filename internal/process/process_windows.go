@@ -0,0 +1,17 @@
+// +build windows
+
+package process
+
+import (
+	"os/exec"
+	"time"
+)
+
+// gracefulStop kills the process if it has not exited after the timeout.
+func gracefulStop(cmd *exec.Cmd, exited <-chan struct{}, timeout time.Duration) {
+	select {
+	case <-exited:
+	case <-time.After(timeout):
+		cmd.Process.Kill()
+	}
+}
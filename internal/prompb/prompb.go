@@ -0,0 +1,80 @@
+// Package prompb holds the protocol buffer messages of the Prometheus remote
+// write protocol, and the snappy compressed encoding of the requests.
+//
+// The messages follow remote.proto and types.proto of Prometheus, only the
+// fields needed to write samples are declared.
+package prompb
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/snappy"
+)
+
+// WriteRequest is the body of a remote write request.
+type WriteRequest struct {
+	Timeseries []*TimeSeries `protobuf:"bytes,1,rep,name=timeseries,proto3" json:"timeseries,omitempty"`
+}
+
+func (m *WriteRequest) Reset()         { *m = WriteRequest{} }
+func (m *WriteRequest) String() string { return proto.CompactTextString(m) }
+func (*WriteRequest) ProtoMessage()    {}
+
+// TimeSeries is a series identified by its labels and its samples.  The
+// metric name is the value of the "__name__" label.
+type TimeSeries struct {
+	Labels  []*Label  `protobuf:"bytes,1,rep,name=labels,proto3" json:"labels,omitempty"`
+	Samples []*Sample `protobuf:"bytes,2,rep,name=samples,proto3" json:"samples,omitempty"`
+}
+
+func (m *TimeSeries) Reset()         { *m = TimeSeries{} }
+func (m *TimeSeries) String() string { return proto.CompactTextString(m) }
+func (*TimeSeries) ProtoMessage()    {}
+
+// Label is a name and value pair of a series.
+type Label struct {
+	Name  string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Value string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (m *Label) Reset()         { *m = Label{} }
+func (m *Label) String() string { return proto.CompactTextString(m) }
+func (*Label) ProtoMessage()    {}
+
+// Sample is a value with its timestamp in milliseconds since the epoch.
+type Sample struct {
+	Value     float64 `protobuf:"fixed64,1,opt,name=value,proto3" json:"value,omitempty"`
+	Timestamp int64   `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+}
+
+func (m *Sample) Reset()         { *m = Sample{} }
+func (m *Sample) String() string { return proto.CompactTextString(m) }
+func (*Sample) ProtoMessage()    {}
+
+// MetricNameLabel is the label holding the metric name of a series.
+const MetricNameLabel = "__name__"
+
+// Decode decodes a snappy compressed write request.
+func Decode(buf []byte) (*WriteRequest, error) {
+	data, err := snappy.Decode(nil, buf)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing write request failed: %s", err)
+	}
+
+	var req WriteRequest
+	err = proto.Unmarshal(data, &req)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshaling write request failed: %s", err)
+	}
+	return &req, nil
+}
+
+// Encode encodes the write request with snappy compression.
+func Encode(req *WriteRequest) ([]byte, error) {
+	data, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	return snappy.Encode(nil, data), nil
+}
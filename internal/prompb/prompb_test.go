@@ -0,0 +1,36 @@
+package prompb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	req := &WriteRequest{
+		Timeseries: []*TimeSeries{
+			{
+				Labels: []*Label{
+					{Name: MetricNameLabel, Value: "up"},
+					{Name: "job", Value: "node"},
+				},
+				Samples: []*Sample{
+					{Value: 1, Timestamp: 1257894000000},
+					{Value: 0.5, Timestamp: 1257894001000},
+				},
+			},
+		},
+	}
+
+	buf, err := Encode(req)
+	require.NoError(t, err)
+
+	actual, err := Decode(buf)
+	require.NoError(t, err)
+	require.Equal(t, req, actual)
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	_, err := Decode([]byte("not snappy"))
+	require.Error(t, err)
+}
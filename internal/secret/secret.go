@@ -0,0 +1,156 @@
+// Package secret implements configuration values that may reference secrets
+// held in a secret store.
+package secret
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// refRe matches a reference to a secret in the form @{store:key}.
+var refRe = regexp.MustCompile(`@\{(\w+):([^{}]+)\}`)
+
+// Resolver looks up the current value of a secret in a store.
+type Resolver interface {
+	Resolve(store, key string) ([]byte, error)
+}
+
+// ResolverFunc is an adapter to use an ordinary function as a Resolver.
+type ResolverFunc func(store, key string) ([]byte, error)
+
+// Resolve calls f(store, key).
+func (f ResolverFunc) Resolve(store, key string) ([]byte, error) {
+	return f(store, key)
+}
+
+// Reference identifies a secret referenced by a Secret.
+type Reference struct {
+	Store string
+	Key   string
+}
+
+// Secret is a configuration value, such as a password, that may contain
+// references to secrets in the form @{store:key}.  References are resolved
+// each time Get is called, so that rotated secrets are picked up without a
+// restart.
+//
+// The value is never included in formatted output, use Get to retrieve it.
+type Secret struct {
+	value    []byte
+	resolver Resolver
+}
+
+// New returns a Secret with the given value.
+func New(value string) Secret {
+	return Secret{value: []byte(value)}
+}
+
+// UnmarshalTOML parses the secret from a TOML string.
+func (s *Secret) UnmarshalTOML(b []byte) error {
+	switch {
+	case len(b) >= 2 && b[0] == '\'' && b[len(b)-1] == '\'':
+		s.value = append([]byte(nil), b[1:len(b)-1]...)
+	case len(b) >= 2 && b[0] == '"' && b[len(b)-1] == '"':
+		v, err := strconv.Unquote(string(b))
+		if err != nil {
+			return fmt.Errorf("invalid secret: %v", err)
+		}
+		s.value = []byte(v)
+	default:
+		return errors.New("invalid secret: value must be a string")
+	}
+	return nil
+}
+
+// Link sets the Resolver used to look up the referenced secrets.
+func (s *Secret) Link(r Resolver) {
+	s.resolver = r
+}
+
+// Empty returns true if the secret has no value.
+func (s *Secret) Empty() bool {
+	return len(s.value) == 0
+}
+
+// HasReference returns true if s contains a reference to a secret.
+func HasReference(s string) bool {
+	return refRe.MatchString(s)
+}
+
+// References returns the secrets referenced by the value.
+func (s *Secret) References() []Reference {
+	var refs []Reference
+	for _, m := range refRe.FindAllSubmatch(s.value, -1) {
+		refs = append(refs, Reference{Store: string(m[1]), Key: string(m[2])})
+	}
+	return refs
+}
+
+// Get returns the value with all references replaced by the current value of
+// the secret.  The returned slice is owned by the caller, which should Zero
+// it once it is no longer needed.
+func (s *Secret) Get() ([]byte, error) {
+	matches := refRe.FindAllSubmatchIndex(s.value, -1)
+	if len(matches) == 0 {
+		return append([]byte(nil), s.value...), nil
+	}
+	if s.resolver == nil {
+		return nil, errors.New("secret references a secret store but no stores are available")
+	}
+
+	parts := make([][]byte, len(matches))
+	defer func() {
+		for _, part := range parts {
+			Zero(part)
+		}
+	}()
+
+	size := len(s.value)
+	for i, m := range matches {
+		store := string(s.value[m[2]:m[3]])
+		key := string(s.value[m[4]:m[5]])
+		part, err := s.resolver.Resolve(store, key)
+		if err != nil {
+			return nil, fmt.Errorf("resolving secret %q of store %q: %v", key, store, err)
+		}
+		parts[i] = part
+		size += len(part) - (m[1] - m[0])
+	}
+
+	// Allocate the result once so that no partial copies are left behind.
+	out := bytes.NewBuffer(make([]byte, 0, size))
+	last := 0
+	for i, m := range matches {
+		out.Write(s.value[last:m[0]])
+		out.Write(parts[i])
+		last = m[1]
+	}
+	out.Write(s.value[last:])
+	return out.Bytes(), nil
+}
+
+// Destroy overwrites the value of the secret.
+func (s *Secret) Destroy() {
+	Zero(s.value)
+	s.value = nil
+}
+
+// String implements fmt.Stringer and never returns the value.
+func (s Secret) String() string {
+	return "<secret>"
+}
+
+// GoString implements fmt.GoStringer and never returns the value.
+func (s Secret) GoString() string {
+	return "secret.Secret{<secret>}"
+}
+
+// Zero overwrites b with zeros.
+func Zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,109 @@
+package secret
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecret_UnmarshalTOML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		err      bool
+	}{
+		{
+			name:     "basic string",
+			input:    `"pa\"ss"`,
+			expected: `pa"ss`,
+		},
+		{
+			name:     "literal string",
+			input:    `'pa\ss'`,
+			expected: `pa\ss`,
+		},
+		{
+			name:  "integer",
+			input: `42`,
+			err:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s Secret
+			err := s.UnmarshalTOML([]byte(tt.input))
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			v, err := s.Get()
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, string(v))
+		})
+	}
+}
+
+func TestSecret_Resolve(t *testing.T) {
+	stores := map[string]map[string]string{
+		"vault": {"token": "abc", "db/password": "secret"},
+	}
+	resolver := ResolverFunc(func(store, key string) ([]byte, error) {
+		s, ok := stores[store]
+		if !ok {
+			return nil, errors.New("unknown store")
+		}
+		v, ok := s[key]
+		if !ok {
+			return nil, errors.New("unknown key")
+		}
+		return []byte(v), nil
+	})
+
+	s := New("Bearer @{vault:token} @{vault:db/password}!")
+	s.Link(resolver)
+	require.Equal(t, []Reference{
+		{Store: "vault", Key: "token"},
+		{Store: "vault", Key: "db/password"},
+	}, s.References())
+
+	v, err := s.Get()
+	require.NoError(t, err)
+	require.Equal(t, "Bearer abc secret!", string(v))
+
+	// Rotated secrets are picked up by the next call.
+	stores["vault"]["token"] = "def"
+	v, err = s.Get()
+	require.NoError(t, err)
+	require.Equal(t, "Bearer def secret!", string(v))
+
+	s = New("@{vault:missing}")
+	s.Link(resolver)
+	_, err = s.Get()
+	require.Error(t, err)
+}
+
+func TestSecret_Unlinked(t *testing.T) {
+	s := New("@{vault:token}")
+	_, err := s.Get()
+	require.Error(t, err)
+}
+
+func TestSecret_Format(t *testing.T) {
+	plugin := struct {
+		Password Secret
+	}{
+		Password: New("hunter2"),
+	}
+	require.NotContains(t, fmt.Sprintf("%v %+v %#v %s", plugin, plugin, plugin, plugin.Password), "hunter2")
+}
+
+func TestSecret_Destroy(t *testing.T) {
+	s := New("hunter2")
+	s.Destroy()
+	require.True(t, s.Empty())
+}
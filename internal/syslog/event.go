@@ -0,0 +1,36 @@
+package syslog
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AddHeaderTags adds the hostname and appname of the BSD syslog header
+// preceding an event, such as a CEF or LEEF event, to the tags.  A header
+// that cannot be parsed is ignored.
+func AddHeaderTags(tags map[string]string, header string, now time.Time) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return
+	}
+	msg, err := ParseRFC3164(header, now)
+	if err != nil {
+		return
+	}
+	if msg.Hostname != "" {
+		tags["hostname"] = msg.Hostname
+	}
+	if msg.Appname != "" {
+		tags["appname"] = msg.Appname
+	}
+}
+
+// EventFieldValue returns the value of an event attribute as int64 if it is
+// an integer and as string otherwise.
+func EventFieldValue(s string) interface{} {
+	if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return v
+	}
+	return s
+}
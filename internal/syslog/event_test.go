@@ -0,0 +1,28 @@
+package syslog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddHeaderTags(t *testing.T) {
+	now := time.Date(2019, time.May, 7, 16, 0, 0, 0, time.UTC)
+
+	tags := map[string]string{}
+	AddHeaderTags(tags, "<13>Sep 19 08:26:10 fw01 agent[12]: ", now)
+	require.Equal(t, map[string]string{"hostname": "fw01", "appname": "agent"}, tags)
+
+	tags = map[string]string{}
+	AddHeaderTags(tags, "  ", now)
+	AddHeaderTags(tags, "not a header", now)
+	require.Empty(t, tags)
+}
+
+func TestEventFieldValue(t *testing.T) {
+	require.Equal(t, int64(42), EventFieldValue("42"))
+	require.Equal(t, int64(-1), EventFieldValue("-1"))
+	require.Equal(t, "4.2", EventFieldValue("4.2"))
+	require.Equal(t, "", EventFieldValue(""))
+}
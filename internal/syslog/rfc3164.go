@@ -0,0 +1,152 @@
+package syslog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// severityLevels are the short names of the severities, as used by the
+// RFC5424 parser.
+var severityLevels = []string{
+	"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug",
+}
+
+// facilityLevels are the names of the facilities, as used by the RFC5424
+// parser.
+var facilityLevels = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console",
+	"solaris-cron", "local0", "local1", "local2", "local3", "local4",
+	"local5", "local6", "local7",
+}
+
+// SeverityLevel returns the short name of the severity.
+func SeverityLevel(severity uint8) string {
+	if int(severity) < len(severityLevels) {
+		return severityLevels[severity]
+	}
+	return ""
+}
+
+// FacilityLevel returns the name of the facility.
+func FacilityLevel(facility uint8) string {
+	if int(facility) < len(facilityLevels) {
+		return facilityLevels[facility]
+	}
+	return ""
+}
+
+// BSDMessage is a message in the BSD syslog format of RFC3164.
+type BSDMessage struct {
+	Facility uint8
+	Severity uint8
+
+	// Timestamp is nil if the message has no valid timestamp.
+	Timestamp *time.Time
+
+	Hostname string
+
+	// Appname and ProcID are the TAG of the message and the process ID
+	// following it in square brackets, if any.
+	Appname string
+	ProcID  string
+
+	Message string
+}
+
+// ParseRFC3164 parses a message in the BSD syslog format:
+//
+//	<PRI>Mmm dd hh:mm:ss HOSTNAME TAG[PID]: MESSAGE
+//
+// Timestamps in RFC3339 format are accepted as well.  Timestamps without a
+// year are assumed to be in the last year, in UTC.  If the message has no
+// valid timestamp, the hostname is not set and the rest of the message is
+// parsed as the tag and message.
+func ParseRFC3164(line string, now time.Time) (*BSDMessage, error) {
+	if !strings.HasPrefix(line, "<") {
+		return nil, fmt.Errorf("expecting a priority value within angle brackets")
+	}
+	end := strings.IndexByte(line, '>')
+	if end < 2 || end > 4 {
+		return nil, fmt.Errorf("expecting a priority value within angle brackets")
+	}
+	prio, err := strconv.ParseUint(line[1:end], 10, 8)
+	if err != nil || prio > 191 {
+		return nil, fmt.Errorf("expecting a priority value in the range 0-191, got %q", line[1:end])
+	}
+	msg := &BSDMessage{
+		Facility: uint8(prio / 8),
+		Severity: uint8(prio % 8),
+	}
+	rest := line[end+1:]
+
+	if ts, n, ok := parseBSDTimestamp(rest, now); ok {
+		msg.Timestamp = &ts
+		rest = strings.TrimLeft(rest[n:], " ")
+
+		i := strings.IndexByte(rest, ' ')
+		if i < 0 {
+			msg.Hostname = rest
+			return msg, nil
+		}
+		msg.Hostname = rest[:i]
+		rest = rest[i+1:]
+	}
+
+	msg.Appname, msg.ProcID, msg.Message = parseBSDTag(rest)
+	return msg, nil
+}
+
+// parseBSDTimestamp parses the timestamp at the start of s, returning its
+// length.
+func parseBSDTimestamp(s string, now time.Time) (time.Time, int, bool) {
+	if len(s) >= len(time.Stamp) {
+		ts, err := time.Parse(time.Stamp, s[:len(time.Stamp)])
+		if err == nil {
+			now = now.UTC()
+			ts = ts.AddDate(now.Year(), 0, 0)
+			// Messages from the end of last year received early this year.
+			if ts.After(now.AddDate(0, 0, 1)) {
+				ts = ts.AddDate(-1, 0, 0)
+			}
+			return ts, len(time.Stamp), true
+		}
+	}
+
+	n := strings.IndexByte(s, ' ')
+	if n < 0 {
+		n = len(s)
+	}
+	ts, err := time.Parse(time.RFC3339Nano, s[:n])
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	return ts, n, true
+}
+
+// parseBSDTag splits the tag and process ID from the content.  The tag is
+// a word of up to 32 characters followed by a colon or a process ID in
+// square brackets, otherwise the whole content is the message.
+func parseBSDTag(s string) (string, string, string) {
+	i := strings.IndexAny(s, ":[ ")
+	if i <= 0 || i > 32 || s[i] == ' ' {
+		return "", "", s
+	}
+	tag := s[:i]
+	rest := s[i:]
+
+	var pid string
+	if rest[0] == '[' {
+		j := strings.IndexByte(rest, ']')
+		if j < 0 {
+			return "", "", s
+		}
+		pid = rest[1:j]
+		rest = rest[j+1:]
+	}
+	rest = strings.TrimPrefix(rest, ":")
+	rest = strings.TrimPrefix(rest, " ")
+	return tag, pid, rest
+}
@@ -0,0 +1,102 @@
+package syslog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRFC3164(t *testing.T) {
+	now := time.Date(2019, time.March, 1, 0, 0, 0, 0, time.UTC)
+	ts := func(year int, month time.Month, day, hour, min, sec int) *time.Time {
+		t := time.Date(year, month, day, hour, min, sec, 0, time.UTC)
+		return &t
+	}
+
+	tests := []struct {
+		name     string
+		line     string
+		expected *BSDMessage
+	}{
+		{
+			name: "complete",
+			line: "<34>Feb  5 22:14:15 mymachine su[123]: 'su root' failed for lonvick on /dev/pts/8",
+			expected: &BSDMessage{
+				Facility:  4,
+				Severity:  2,
+				Timestamp: ts(2019, time.February, 5, 22, 14, 15),
+				Hostname:  "mymachine",
+				Appname:   "su",
+				ProcID:    "123",
+				Message:   "'su root' failed for lonvick on /dev/pts/8",
+			},
+		},
+		{
+			name: "last year",
+			line: "<13>Dec 31 23:59:59 host app: message",
+			expected: &BSDMessage{
+				Facility:  1,
+				Severity:  5,
+				Timestamp: ts(2018, time.December, 31, 23, 59, 59),
+				Hostname:  "host",
+				Appname:   "app",
+				Message:   "message",
+			},
+		},
+		{
+			name: "rfc3339 timestamp",
+			line: "<13>2019-02-05T22:14:15Z host app: message",
+			expected: &BSDMessage{
+				Facility:  1,
+				Severity:  5,
+				Timestamp: ts(2019, time.February, 5, 22, 14, 15),
+				Hostname:  "host",
+				Appname:   "app",
+				Message:   "message",
+			},
+		},
+		{
+			name: "no timestamp",
+			line: "<13>app: message",
+			expected: &BSDMessage{
+				Facility: 1,
+				Severity: 5,
+				Appname:  "app",
+				Message:  "message",
+			},
+		},
+		{
+			name: "no tag",
+			line: "<13>Feb  5 22:14:15 host just a message",
+			expected: &BSDMessage{
+				Facility:  1,
+				Severity:  5,
+				Timestamp: ts(2019, time.February, 5, 22, 14, 15),
+				Hostname:  "host",
+				Message:   "just a message",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := ParseRFC3164(tt.line, now)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, msg)
+		})
+	}
+}
+
+func TestParseRFC3164Invalid(t *testing.T) {
+	for _, line := range []string{"", "no priority", "<192>message", "<a>message", "<13"} {
+		_, err := ParseRFC3164(line, time.Now())
+		require.Error(t, err, line)
+	}
+}
+
+func TestLevels(t *testing.T) {
+	require.Equal(t, "notice", SeverityLevel(5))
+	require.Equal(t, "daemon", FacilityLevel(3))
+	require.Equal(t, "local7", FacilityLevel(23))
+	require.Equal(t, "", FacilityLevel(24))
+}
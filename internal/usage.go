@@ -11,6 +11,8 @@ Usage:
 The commands & flags are:
 
   config              print out full sample configuration to stdout
+  secret set <store> <key>
+                      store the secret read from stdin in a secret store
   version             print the version to stdout
 
   --aggregator-filter <filter>   filter the aggregators to enable, separator is :
@@ -19,6 +21,9 @@ The commands & flags are:
   --debug                        turn on debug logging
   --input-filter <filter>        filter the inputs to enable, separator is :
   --input-list                   print available input plugins.
+  --once                         gather metrics once, write them to the outputs,
+                                 and exit; the exit status is non-zero if any
+                                 output failed to write
   --output-filter <filter>       filter the outputs to enable, separator is :
   --output-list                  print available output plugins.
   --pidfile <file>               file to write our pid to
@@ -29,11 +34,13 @@ The commands & flags are:
                                  Valid values are 'agent', 'global_tags', 'outputs',
                                  'processors', 'aggregators' and 'inputs'
   --sample-config                print out full sample configuration
-  --test                         gather metrics, print them out, and exit;
-                                 processors, aggregators, and outputs are not run
+  --test                         gather metrics, run them through the processors
+                                 and aggregators, print them out, and exit;
+                                 outputs are not run
   --test-wait                    wait up to this many seconds for service
-                                 inputs to complete in test mode
-  --usage <plugin>               print usage for a plugin, ie, 'telegraf --usage mysql'
+                                 inputs to complete in test or once mode
+  --usage <plugin>               print usage for a plugin or the options of a
+                                 data format, ie, 'telegraf --usage mysql'
   --version                      display the version and exit
 
 Examples:
@@ -44,9 +51,15 @@ Examples:
   # generate config with only cpu input & influxdb output plugins defined
   telegraf --input-filter cpu --output-filter influxdb config
 
+  # store a password in the secret store with id "vault"
+  telegraf --config telegraf.conf secret set vault db_password < password.txt
+
   # run a single telegraf collection, outputing metrics to stdout
   telegraf --config telegraf.conf --test
 
+  # run a single telegraf collection, writing metrics to the outputs
+  telegraf --config telegraf.conf --once
+
   # run telegraf with all plugins defined in config file
   telegraf --config telegraf.conf
 
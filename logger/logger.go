@@ -2,10 +2,12 @@ package logger
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"log"
 	"os"
 	"regexp"
+	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf/internal"
@@ -15,6 +17,11 @@ import (
 
 var prefixRegex = regexp.MustCompile("^[DIWE]!")
 
+var (
+	mu      sync.Mutex
+	current *telegrafLog
+)
+
 // newTelegrafWriter returns a logging-wrapped writer.
 func newTelegrafWriter(w io.Writer) io.Writer {
 	return &telegrafLog{
@@ -56,6 +63,12 @@ func (t *telegrafLog) Write(b []byte) (n int, err error) {
 	return t.writer.Write(line)
 }
 
+// writeUnfiltered writes a line without applying the log level.
+func (t *telegrafLog) writeUnfiltered(b []byte) (n int, err error) {
+	line := append([]byte(time.Now().UTC().Format(time.RFC3339)+" "), b...)
+	return t.internalWriter.Write(line)
+}
+
 func (t *telegrafLog) Close() error {
 	closer, isCloser := t.internalWriter.(io.Closer)
 	if !isCloser {
@@ -89,7 +102,26 @@ func newLogWriter(config LogConfig) io.Writer {
 		writer = os.Stderr
 	}
 
-	telegrafLog := newTelegrafWriter(writer)
-	log.SetOutput(telegrafLog)
-	return telegrafLog
+	w := newTelegrafWriter(writer)
+	log.SetOutput(w)
+
+	mu.Lock()
+	current = w.(*telegrafLog)
+	mu.Unlock()
+	return w
+}
+
+// PrintUnfiltered logs a message at the given level even if the level is
+// below the global log level.  It is used by plugins that override the log
+// level.
+func PrintUnfiltered(level wlog.Level, msg string) {
+	line := fmt.Sprintf("%c! %s\n", wlog.ReverseLevels[level], msg)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if current == nil {
+		log.Print(line)
+		return
+	}
+	current.writeUnfiltered([]byte(line))
 }
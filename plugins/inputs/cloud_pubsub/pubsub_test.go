@@ -3,7 +3,7 @@ package cloud_pubsub
 import (
 	"encoding/base64"
 	"errors"
-	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -17,7 +17,7 @@ const (
 func TestRunParse(t *testing.T) {
 	subId := "sub-run-parse"
 
-	testParser, _ := parsers.NewInfluxParser()
+	testParser := influx.NewParser(influx.NewMetricHandler())
 
 	sub := &stubSub{
 		id:       subId,
@@ -60,7 +60,7 @@ func TestRunParse(t *testing.T) {
 func TestRunBase64(t *testing.T) {
 	subId := "sub-run-base64"
 
-	testParser, _ := parsers.NewInfluxParser()
+	testParser := influx.NewParser(influx.NewMetricHandler())
 
 	sub := &stubSub{
 		id:       subId,
@@ -103,7 +103,7 @@ func TestRunBase64(t *testing.T) {
 func TestRunInvalidMessages(t *testing.T) {
 	subId := "sub-invalid-messages"
 
-	testParser, _ := parsers.NewInfluxParser()
+	testParser := influx.NewParser(influx.NewMetricHandler())
 
 	sub := &stubSub{
 		id:       subId,
@@ -149,7 +149,7 @@ func TestRunOverlongMessages(t *testing.T) {
 
 	acc := &testutil.Accumulator{}
 
-	testParser, _ := parsers.NewInfluxParser()
+	testParser := influx.NewParser(influx.NewMetricHandler())
 
 	sub := &stubSub{
 		id:       subId,
@@ -195,7 +195,7 @@ func TestRunErrorInSubscriber(t *testing.T) {
 
 	acc := &testutil.Accumulator{}
 
-	testParser, _ := parsers.NewInfluxParser()
+	testParser := influx.NewParser(influx.NewMetricHandler())
 
 	sub := &stubSub{
 		id:       subId,
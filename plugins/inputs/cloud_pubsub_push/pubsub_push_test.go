@@ -17,7 +17,8 @@ import (
 	"github.com/influxdata/telegraf/agent"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/models"
-	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
+	"github.com/influxdata/telegraf/testutil"
 )
 
 func TestServeHTTP(t *testing.T) {
@@ -135,10 +136,7 @@ func TestServeHTTP(t *testing.T) {
 			pubPush.sem <- struct{}{}
 		}
 
-		p, _ := parsers.NewParser(&parsers.Config{
-			MetricName: "cloud_pubsub_push",
-			DataFormat: "influx",
-		})
+		p := influx.NewParser(influx.NewMetricHandler())
 		pubPush.SetParser(p)
 
 		dst := make(chan telegraf.Metric, 1)
@@ -183,10 +181,18 @@ func (tm *testMetricMaker) Name() string {
 	return "TestPlugin"
 }
 
+func (tm *testMetricMaker) LogName() string {
+	return tm.Name()
+}
+
 func (tm *testMetricMaker) MakeMetric(metric telegraf.Metric) telegraf.Metric {
 	return metric
 }
 
+func (tm *testMetricMaker) Log() telegraf.Logger {
+	return testutil.Logger{}
+}
+
 type testOutput struct {
 	// if true, mock a write failure
 	failWrite bool
@@ -0,0 +1,37 @@
+// +build !windows
+
+package execd
+
+import (
+	"fmt"
+	"syscall"
+
+	"github.com/influxdata/telegraf/internal/process"
+)
+
+func checkSignal(signal string) error {
+	switch signal {
+	case "none", "STDIN", "SIGHUP", "SIGUSR1", "SIGUSR2":
+		return nil
+	default:
+		return fmt.Errorf("invalid signal: %s", signal)
+	}
+}
+
+func (e *Execd) signal() error {
+	switch e.Signal {
+	case "SIGHUP":
+		return e.process.Signal(syscall.SIGHUP)
+	case "SIGUSR1":
+		return e.process.Signal(syscall.SIGUSR1)
+	case "SIGUSR2":
+		return e.process.Signal(syscall.SIGUSR2)
+	case "STDIN":
+		_, err := e.process.Write([]byte{'\n'})
+		if err != nil && err != process.ErrNotRunning {
+			return fmt.Errorf("error writing to stdin: %v", err)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+//go:build !windows
+// +build !windows
+
+package execd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestExecd(t *testing.T, signal string, command ...string) *Execd {
+	parser := influx.NewParser(influx.NewMetricHandler())
+
+	e := &Execd{
+		Command:      command,
+		Signal:       signal,
+		RestartDelay: internal.Duration{Duration: 10 * time.Millisecond},
+		Log:          testutil.Logger{},
+	}
+	e.SetParser(parser)
+	require.NoError(t, e.Init())
+	return e
+}
+
+func TestExecd_SignalStdin(t *testing.T) {
+	e := newTestExecd(t, "STDIN", "sh", "-c",
+		`while read line; do echo "cpu value=42i 1000000000"; done`)
+
+	var acc testutil.Accumulator
+	require.NoError(t, e.Start(&acc))
+	defer e.Stop()
+
+	require.NoError(t, e.Gather(&acc))
+	acc.Wait(1)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"cpu",
+			map[string]string{},
+			map[string]interface{}{"value": int64(42)},
+			time.Unix(1, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestExecd_SignalNone(t *testing.T) {
+	e := newTestExecd(t, "none", "sh", "-c",
+		`echo "cpu value=1i 1000000000"; echo "cpu value=2i 2000000000"; cat`)
+
+	var acc testutil.Accumulator
+	require.NoError(t, e.Start(&acc))
+	defer e.Stop()
+
+	require.NoError(t, e.Gather(&acc))
+	acc.Wait(2)
+	require.Len(t, acc.GetTelegrafMetrics(), 2)
+}
+
+func TestExecd_ParseError(t *testing.T) {
+	e := newTestExecd(t, "none", "sh", "-c", `echo "not valid line protocol"; cat`)
+
+	var acc testutil.Accumulator
+	require.NoError(t, e.Start(&acc))
+	defer e.Stop()
+
+	acc.WaitError(1)
+}
+
+func TestExecd_InvalidSignal(t *testing.T) {
+	e := &Execd{Command: []string{"cat"}, Signal: "SIGKILL"}
+	require.Error(t, e.Init())
+}
+
+func TestExecd_NoCommand(t *testing.T) {
+	e := &Execd{Signal: "none"}
+	require.Error(t, e.Init())
+}
+
+func TestExecd_InvalidRestartDelay(t *testing.T) {
+	e := &Execd{Command: []string{"cat"}, Signal: "none"}
+	require.Error(t, e.Init())
+}
@@ -0,0 +1,30 @@
+// +build windows
+
+package execd
+
+import (
+	"fmt"
+
+	"github.com/influxdata/telegraf/internal/process"
+)
+
+func checkSignal(signal string) error {
+	switch signal {
+	case "none", "STDIN":
+		return nil
+	default:
+		return fmt.Errorf("invalid signal %s, only \"none\" and \"STDIN\" are supported on Windows", signal)
+	}
+}
+
+func (e *Execd) signal() error {
+	switch e.Signal {
+	case "STDIN":
+		_, err := e.process.Write([]byte{'\n'})
+		if err != nil && err != process.ErrNotRunning {
+			return fmt.Errorf("error writing to stdin: %v", err)
+		}
+		return err
+	}
+	return nil
+}
@@ -5,7 +5,8 @@ import (
 	"path/filepath"
 	"testing"
 
-	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/parsers/grok"
+	"github.com/influxdata/telegraf/plugins/parsers/json"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -27,13 +28,9 @@ func TestJSONParserCompile(t *testing.T) {
 	r := File{
 		Files: []string{filepath.Join(wd, "dev/testfiles/json_a.log")},
 	}
-	parserConfig := parsers.Config{
-		DataFormat: "json",
-		TagKeys:    []string{"parent_ignored_child"},
+	r.parser = &json.JSONParser{
+		TagKeys: []string{"parent_ignored_child"},
 	}
-	nParser, err := parsers.NewParser(&parserConfig)
-	assert.NoError(t, err)
-	r.parser = nParser
 
 	r.Gather(&acc)
 	assert.Equal(t, map[string]string{"parent_ignored_child": "hi"}, acc.Metrics[0].Tags)
@@ -47,13 +44,11 @@ func TestGrokParser(t *testing.T) {
 		Files: []string{filepath.Join(wd, "dev/testfiles/grok_a.log")},
 	}
 
-	parserConfig := parsers.Config{
-		DataFormat:   "grok",
-		GrokPatterns: []string{"%{COMMON_LOG_FORMAT}"},
+	parser := &grok.Parser{
+		Patterns: []string{"%{COMMON_LOG_FORMAT}"},
 	}
-
-	nParser, err := parsers.NewParser(&parserConfig)
-	r.parser = nParser
+	err := parser.Compile()
+	r.parser = parser
 	assert.NoError(t, err)
 
 	err = r.Gather(&acc)
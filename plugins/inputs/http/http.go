@@ -11,6 +11,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/secret"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
@@ -25,8 +26,8 @@ type HTTP struct {
 	Headers map[string]string `toml:"headers"`
 
 	// HTTP Basic Auth Credentials
-	Username string `toml:"username"`
-	Password string `toml:"password"`
+	Username secret.Secret `toml:"username"`
+	Password secret.Secret `toml:"password"`
 	tls.ClientConfig
 
 	Timeout internal.Duration `toml:"timeout"`
@@ -50,7 +51,8 @@ var sampleConfig = `
   ## Optional HTTP headers
   # headers = {"X-Special-Header" = "Special-Value"}
 
-  ## Optional HTTP Basic Auth Credentials
+  ## Optional HTTP Basic Auth Credentials, may reference a secret store
+  ## as in "@{store:key}".
   # username = "username"
   # password = "pa$$word"
 
@@ -161,8 +163,20 @@ func (h *HTTP) gatherURL(
 		}
 	}
 
-	if h.Username != "" || h.Password != "" {
-		request.SetBasicAuth(h.Username, h.Password)
+	if !h.Username.Empty() || !h.Password.Empty() {
+		username, err := h.Username.Get()
+		if err != nil {
+			return err
+		}
+		defer secret.Zero(username)
+
+		password, err := h.Password.Get()
+		if err != nil {
+			return err
+		}
+		defer secret.Zero(password)
+
+		request.SetBasicAuth(string(username), string(password))
 	}
 
 	resp, err := h.client.Do(request)
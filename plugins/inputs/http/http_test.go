@@ -9,7 +9,8 @@ import (
 	"testing"
 
 	plugin "github.com/influxdata/telegraf/plugins/inputs/http"
-	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
+	"github.com/influxdata/telegraf/plugins/parsers/json"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -30,10 +31,7 @@ func TestHTTPwithJSONFormat(t *testing.T) {
 	}
 	metricName := "metricName"
 
-	p, _ := parsers.NewParser(&parsers.Config{
-		DataFormat: "json",
-		MetricName: "metricName",
-	})
+	p := &json.JSONParser{MetricName: "metricName"}
 	plugin.SetParser(p)
 
 	var acc testutil.Accumulator
@@ -72,10 +70,7 @@ func TestHTTPHeaders(t *testing.T) {
 		Headers: map[string]string{header: headerValue},
 	}
 
-	p, _ := parsers.NewParser(&parsers.Config{
-		DataFormat: "json",
-		MetricName: "metricName",
-	})
+	p := &json.JSONParser{MetricName: "metricName"}
 	plugin.SetParser(p)
 
 	var acc testutil.Accumulator
@@ -95,10 +90,7 @@ func TestInvalidStatusCode(t *testing.T) {
 	}
 
 	metricName := "metricName"
-	p, _ := parsers.NewParser(&parsers.Config{
-		DataFormat: "json",
-		MetricName: metricName,
-	})
+	p := &json.JSONParser{MetricName: metricName}
 	plugin.SetParser(p)
 
 	var acc testutil.Accumulator
@@ -121,10 +113,7 @@ func TestMethod(t *testing.T) {
 		Method: "POST",
 	}
 
-	p, _ := parsers.NewParser(&parsers.Config{
-		DataFormat: "json",
-		MetricName: "metricName",
-	})
+	p := &json.JSONParser{MetricName: "metricName"}
 	plugin.SetParser(p)
 
 	var acc testutil.Accumulator
@@ -216,14 +205,12 @@ func TestBodyAndContentEncoding(t *testing.T) {
 				tt.queryHandlerFunc(t, w, r)
 			})
 
-			parser, err := parsers.NewParser(&parsers.Config{DataFormat: "influx"})
-			require.NoError(t, err)
-
+			parser := influx.NewParser(influx.NewMetricHandler())
 			tt.plugin.SetParser(parser)
 
 			var acc testutil.Accumulator
 			tt.plugin.Init()
-			err = tt.plugin.Gather(&acc)
+			err := tt.plugin.Gather(&acc)
 			require.NoError(t, err)
 		})
 	}
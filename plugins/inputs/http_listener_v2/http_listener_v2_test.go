@@ -14,7 +14,8 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf/internal"
-	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/parsers/form_urlencoded"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -43,7 +44,7 @@ var (
 )
 
 func newTestHTTPListenerV2() *HTTPListenerV2 {
-	parser, _ := parsers.NewInfluxParser()
+	parser := influx.NewParser(influx.NewMetricHandler())
 
 	listener := &HTTPListenerV2{
 		ServiceAddress: "localhost:0",
@@ -65,7 +66,7 @@ func newTestHTTPAuthListener() *HTTPListenerV2 {
 }
 
 func newTestHTTPSListenerV2() *HTTPListenerV2 {
-	parser, _ := parsers.NewInfluxParser()
+	parser := influx.NewParser(influx.NewMetricHandler())
 
 	listener := &HTTPListenerV2{
 		ServiceAddress: "localhost:0",
@@ -228,7 +229,7 @@ func TestWriteHTTPNoNewline(t *testing.T) {
 }
 
 func TestWriteHTTPExactMaxBodySize(t *testing.T) {
-	parser, _ := parsers.NewInfluxParser()
+	parser := influx.NewParser(influx.NewMetricHandler())
 
 	listener := &HTTPListenerV2{
 		ServiceAddress: "localhost:0",
@@ -250,7 +251,7 @@ func TestWriteHTTPExactMaxBodySize(t *testing.T) {
 }
 
 func TestWriteHTTPVerySmallMaxBody(t *testing.T) {
-	parser, _ := parsers.NewInfluxParser()
+	parser := influx.NewParser(influx.NewMetricHandler())
 
 	listener := &HTTPListenerV2{
 		ServiceAddress: "localhost:0",
@@ -378,7 +379,7 @@ func TestWriteHTTPEmpty(t *testing.T) {
 }
 
 func TestWriteHTTPQueryParams(t *testing.T) {
-	parser, _ := parsers.NewFormUrlencodedParser("query_measurement", nil, []string{"tagKey"})
+	parser := &form_urlencoded.Parser{MetricName: "query_measurement", TagKeys: []string{"tagKey"}}
 	listener := newTestHTTPListenerV2()
 	listener.DataSource = "query"
 	listener.Parser = parser
@@ -400,7 +401,7 @@ func TestWriteHTTPQueryParams(t *testing.T) {
 }
 
 func TestWriteHTTPFormData(t *testing.T) {
-	parser, _ := parsers.NewFormUrlencodedParser("query_measurement", nil, []string{"tagKey"})
+	parser := &form_urlencoded.Parser{MetricName: "query_measurement", TagKeys: []string{"tagKey"}}
 	listener := newTestHTTPListenerV2()
 	listener.Parser = parser
 
@@ -14,7 +14,7 @@ import (
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
-	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/parsers/json"
 )
 
 var (
@@ -181,14 +181,10 @@ func (h *HttpJson) gatherServer(
 		"server": serverURL,
 	}
 
-	parser, err := parsers.NewParser(&parsers.Config{
-		DataFormat:  "json",
+	parser := &json.JSONParser{
 		MetricName:  msrmnt_name,
 		TagKeys:     h.TagKeys,
 		DefaultTags: tags,
-	})
-	if err != nil {
-		return err
 	}
 
 	metrics, err := parser.Parse([]byte(resp))
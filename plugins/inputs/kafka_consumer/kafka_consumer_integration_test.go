@@ -6,11 +6,10 @@ import (
 	"time"
 
 	"github.com/Shopify/sarama"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-
-	"github.com/influxdata/telegraf/plugins/parsers"
 )
 
 func TestReadsMetricsFromKafka(t *testing.T) {
@@ -40,7 +39,7 @@ func TestReadsMetricsFromKafka(t *testing.T) {
 		Brokers:       brokerPeers,
 		Offset:        "oldest",
 	}
-	p, _ := parsers.NewInfluxParser()
+	p := influx.NewParser(influx.NewMetricHandler())
 	k.SetParser(p)
 
 	// Verify that we can now gather the sent message
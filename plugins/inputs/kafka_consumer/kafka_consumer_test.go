@@ -7,7 +7,9 @@ import (
 
 	"github.com/Shopify/sarama"
 	"github.com/influxdata/telegraf"
-	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/parsers/graphite"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
+	"github.com/influxdata/telegraf/plugins/parsers/json"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
 )
@@ -86,7 +88,7 @@ func TestRunParser(t *testing.T) {
 	acc := testutil.Accumulator{}
 	ctx := context.Background()
 
-	k.parser, _ = parsers.NewInfluxParser()
+	k.parser = influx.NewParser(influx.NewMetricHandler())
 	go k.receiver(ctx, &acc)
 	consumer.Inject(saramaMsg(testMsg))
 	acc.Wait(1)
@@ -101,7 +103,7 @@ func TestRunParserWithTopic(t *testing.T) {
 	acc := testutil.Accumulator{}
 	ctx := context.Background()
 
-	k.parser, _ = parsers.NewInfluxParser()
+	k.parser = influx.NewParser(influx.NewMetricHandler())
 	go k.receiver(ctx, &acc)
 	consumer.Inject(saramaMsgWithTopic(testMsg, "test_topic"))
 	acc.Wait(1)
@@ -116,7 +118,7 @@ func TestRunParserInvalidMsg(t *testing.T) {
 	acc := testutil.Accumulator{}
 	ctx := context.Background()
 
-	k.parser, _ = parsers.NewInfluxParser()
+	k.parser = influx.NewParser(influx.NewMetricHandler())
 	go k.receiver(ctx, &acc)
 	consumer.Inject(saramaMsg(invalidMsg))
 	acc.WaitError(1)
@@ -146,7 +148,7 @@ func TestRunParserAndGather(t *testing.T) {
 	acc := testutil.Accumulator{}
 	ctx := context.Background()
 
-	k.parser, _ = parsers.NewInfluxParser()
+	k.parser = influx.NewParser(influx.NewMetricHandler())
 	go k.receiver(ctx, &acc)
 	consumer.Inject(saramaMsg(testMsg))
 	acc.Wait(1)
@@ -164,7 +166,7 @@ func TestRunParserAndGatherGraphite(t *testing.T) {
 	acc := testutil.Accumulator{}
 	ctx := context.Background()
 
-	k.parser, _ = parsers.NewGraphiteParser("_", []string{}, nil)
+	k.parser, _ = graphite.NewGraphiteParser("_", []string{}, nil)
 	go k.receiver(ctx, &acc)
 	consumer.Inject(saramaMsg(testMsgGraphite))
 	acc.Wait(1)
@@ -182,10 +184,7 @@ func TestRunParserAndGatherJSON(t *testing.T) {
 	acc := testutil.Accumulator{}
 	ctx := context.Background()
 
-	k.parser, _ = parsers.NewParser(&parsers.Config{
-		DataFormat: "json",
-		MetricName: "kafka_json_test",
-	})
+	k.parser = &json.JSONParser{MetricName: "kafka_json_test"}
 	go k.receiver(ctx, &acc)
 	consumer.Inject(saramaMsg(testMsgJSON))
 	acc.Wait(1)
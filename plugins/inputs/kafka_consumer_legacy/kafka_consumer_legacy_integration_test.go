@@ -6,11 +6,10 @@ import (
 	"time"
 
 	"github.com/Shopify/sarama"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-
-	"github.com/influxdata/telegraf/plugins/parsers"
 )
 
 func TestReadsMetricsFromKafka(t *testing.T) {
@@ -43,7 +42,7 @@ func TestReadsMetricsFromKafka(t *testing.T) {
 		PointBuffer:    100000,
 		Offset:         "oldest",
 	}
-	p, _ := parsers.NewInfluxParser()
+	p := influx.NewParser(influx.NewMetricHandler())
 	k.SetParser(p)
 
 	// Verify that we can now gather the sent message
@@ -12,6 +12,7 @@ import (
 	"github.com/influxdata/telegraf/internal/globpath"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/parsers/grok"
 	// Parsers
 )
 
@@ -141,22 +142,19 @@ func (l *LogParserPlugin) Start(acc telegraf.Accumulator) error {
 	}
 
 	// Looks for fields which implement LogParser interface
-	config := &parsers.Config{
-		MetricName:             mName,
-		GrokPatterns:           l.GrokConfig.Patterns,
-		GrokNamedPatterns:      l.GrokConfig.NamedPatterns,
-		GrokCustomPatterns:     l.GrokConfig.CustomPatterns,
-		GrokCustomPatternFiles: l.GrokConfig.CustomPatternFiles,
-		GrokTimezone:           l.GrokConfig.Timezone,
-		GrokUniqueTimestamp:    l.GrokConfig.UniqueTimestamp,
-		DataFormat:             "grok",
+	parser := &grok.Parser{
+		Measurement:        mName,
+		Patterns:           l.GrokConfig.Patterns,
+		NamedPatterns:      l.GrokConfig.NamedPatterns,
+		CustomPatterns:     l.GrokConfig.CustomPatterns,
+		CustomPatternFiles: l.GrokConfig.CustomPatternFiles,
+		Timezone:           l.GrokConfig.Timezone,
+		UniqueTimestamp:    l.GrokConfig.UniqueTimestamp,
 	}
-
-	var err error
-	l.GrokParser, err = parsers.NewParser(config)
-	if err != nil {
+	if err := parser.Compile(); err != nil {
 		return err
 	}
+	l.GrokParser = parser
 
 	l.wg.Add(1)
 	go l.parser()
@@ -18,6 +18,7 @@ import (
 	tlsint "github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
 )
 
 type setReadBufferer interface {
@@ -105,6 +106,9 @@ func (ssl *streamSocketListener) read(c net.Conn) {
 	defer c.Close()
 
 	scnr := bufio.NewScanner(c)
+	if s, ok := ssl.Parser.(parsers.Splitter); ok {
+		scnr.Split(s.Split)
+	}
 	for {
 		if ssl.ReadTimeout != nil && ssl.ReadTimeout.Duration > 0 {
 			c.SetReadDeadline(time.Now().Add(ssl.ReadTimeout.Duration))
@@ -381,7 +385,7 @@ func (sl *SocketListener) Stop() {
 }
 
 func newSocketListener() *SocketListener {
-	parser, _ := parsers.NewInfluxParser()
+	parser := influx.NewParser(influx.NewMetricHandler())
 
 	return &SocketListener{
 		Parser: parser,
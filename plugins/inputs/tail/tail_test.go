@@ -7,12 +7,17 @@ import (
 	"testing"
 
 	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/testutil"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+func newInfluxParser() (parsers.Parser, error) {
+	return influx.NewParser(influx.NewMetricHandler()), nil
+}
+
 func TestTailFromBeginning(t *testing.T) {
 	if os.Getenv("CIRCLE_PROJECT_REPONAME") != "" {
 		t.Skip("Skipping CI testing due to race conditions")
@@ -27,7 +32,7 @@ func TestTailFromBeginning(t *testing.T) {
 	tt := NewTail()
 	tt.FromBeginning = true
 	tt.Files = []string{tmpfile.Name()}
-	tt.SetParserFunc(parsers.NewInfluxParser)
+	tt.SetParserFunc(newInfluxParser)
 	defer tt.Stop()
 	defer tmpfile.Close()
 
@@ -59,7 +64,7 @@ func TestTailFromEnd(t *testing.T) {
 
 	tt := NewTail()
 	tt.Files = []string{tmpfile.Name()}
-	tt.SetParserFunc(parsers.NewInfluxParser)
+	tt.SetParserFunc(newInfluxParser)
 	defer tt.Stop()
 	defer tmpfile.Close()
 
@@ -96,7 +101,7 @@ func TestTailBadLine(t *testing.T) {
 	tt := NewTail()
 	tt.FromBeginning = true
 	tt.Files = []string{tmpfile.Name()}
-	tt.SetParserFunc(parsers.NewInfluxParser)
+	tt.SetParserFunc(newInfluxParser)
 	defer tt.Stop()
 	defer tmpfile.Close()
 
@@ -121,7 +126,7 @@ func TestTailDosLineendings(t *testing.T) {
 	tt := NewTail()
 	tt.FromBeginning = true
 	tt.Files = []string{tmpfile.Name()}
-	tt.SetParserFunc(parsers.NewInfluxParser)
+	tt.SetParserFunc(newInfluxParser)
 	defer tt.Stop()
 	defer tmpfile.Close()
 
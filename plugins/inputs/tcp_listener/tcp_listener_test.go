@@ -11,7 +11,9 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/parsers/graphite"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
+	"github.com/influxdata/telegraf/plugins/parsers/json"
 	"github.com/influxdata/telegraf/testutil"
 
 	"github.com/stretchr/testify/assert"
@@ -49,7 +51,7 @@ func BenchmarkTCP(b *testing.B) {
 		AllowedPendingMessages: 100000,
 		MaxTCPConnections:      250,
 	}
-	listener.parser, _ = parsers.NewInfluxParser()
+	listener.parser = influx.NewParser(influx.NewMetricHandler())
 	acc := &testutil.Accumulator{Discard: true}
 
 	// send multiple messages to socket
@@ -80,7 +82,7 @@ func TestHighTrafficTCP(t *testing.T) {
 		AllowedPendingMessages: 100000,
 		MaxTCPConnections:      250,
 	}
-	listener.parser, _ = parsers.NewInfluxParser()
+	listener.parser = influx.NewParser(influx.NewMetricHandler())
 	acc := &testutil.Accumulator{}
 
 	// send multiple messages to socket
@@ -107,7 +109,7 @@ func TestConnectTCP(t *testing.T) {
 		AllowedPendingMessages: 10000,
 		MaxTCPConnections:      250,
 	}
-	listener.parser, _ = parsers.NewInfluxParser()
+	listener.parser = influx.NewParser(influx.NewMetricHandler())
 
 	acc := &testutil.Accumulator{}
 	require.NoError(t, listener.Start(acc))
@@ -144,7 +146,7 @@ func TestConcurrentConns(t *testing.T) {
 		AllowedPendingMessages: 10000,
 		MaxTCPConnections:      2,
 	}
-	listener.parser, _ = parsers.NewInfluxParser()
+	listener.parser = influx.NewParser(influx.NewMetricHandler())
 
 	acc := &testutil.Accumulator{}
 	require.NoError(t, listener.Start(acc))
@@ -179,7 +181,7 @@ func TestConcurrentConns1(t *testing.T) {
 		AllowedPendingMessages: 10000,
 		MaxTCPConnections:      1,
 	}
-	listener.parser, _ = parsers.NewInfluxParser()
+	listener.parser = influx.NewParser(influx.NewMetricHandler())
 
 	acc := &testutil.Accumulator{}
 	require.NoError(t, listener.Start(acc))
@@ -212,7 +214,7 @@ func TestCloseConcurrentConns(t *testing.T) {
 		AllowedPendingMessages: 10000,
 		MaxTCPConnections:      2,
 	}
-	listener.parser, _ = parsers.NewInfluxParser()
+	listener.parser = influx.NewParser(influx.NewMetricHandler())
 
 	acc := &testutil.Accumulator{}
 	require.NoError(t, listener.Start(acc))
@@ -233,7 +235,7 @@ func TestRunParser(t *testing.T) {
 	listener.acc = &acc
 	defer close(listener.done)
 
-	listener.parser, _ = parsers.NewInfluxParser()
+	listener.parser = influx.NewParser(influx.NewMetricHandler())
 	listener.wg.Add(1)
 	go listener.tcpParser()
 
@@ -255,7 +257,7 @@ func TestRunParserInvalidMsg(t *testing.T) {
 	listener.acc = &acc
 	defer close(listener.done)
 
-	listener.parser, _ = parsers.NewInfluxParser()
+	listener.parser = influx.NewParser(influx.NewMetricHandler())
 	listener.wg.Add(1)
 	go listener.tcpParser()
 
@@ -280,7 +282,7 @@ func TestRunParserGraphiteMsg(t *testing.T) {
 	listener.acc = &acc
 	defer close(listener.done)
 
-	listener.parser, _ = parsers.NewGraphiteParser("_", []string{}, nil)
+	listener.parser, _ = graphite.NewGraphiteParser("_", []string{}, nil)
 	listener.wg.Add(1)
 	go listener.tcpParser()
 
@@ -300,10 +302,7 @@ func TestRunParserJSONMsg(t *testing.T) {
 	listener.acc = &acc
 	defer close(listener.done)
 
-	listener.parser, _ = parsers.NewParser(&parsers.Config{
-		DataFormat: "json",
-		MetricName: "udp_json_test",
-	})
+	listener.parser = &json.JSONParser{MetricName: "udp_json_test"}
 	listener.wg.Add(1)
 	go listener.tcpParser()
 
@@ -12,7 +12,9 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/parsers/graphite"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
+	"github.com/influxdata/telegraf/plugins/parsers/json"
 	"github.com/influxdata/telegraf/testutil"
 
 	"github.com/stretchr/testify/assert"
@@ -48,8 +50,7 @@ func TestHighTrafficUDP(t *testing.T) {
 		AllowedPendingMessages: 100000,
 	}
 	var err error
-	listener.parser, err = parsers.NewInfluxParser()
-	require.NoError(t, err)
+	listener.parser = influx.NewParser(influx.NewMetricHandler())
 	acc := &testutil.Accumulator{}
 
 	// send multiple messages to socket
@@ -84,7 +85,7 @@ func TestConnectUDP(t *testing.T) {
 		ServiceAddress:         ":8127",
 		AllowedPendingMessages: 10000,
 	}
-	listener.parser, _ = parsers.NewInfluxParser()
+	listener.parser = influx.NewParser(influx.NewMetricHandler())
 
 	acc := &testutil.Accumulator{}
 	require.NoError(t, listener.Start(acc))
@@ -123,7 +124,7 @@ func TestRunParser(t *testing.T) {
 	listener.acc = &acc
 	defer close(listener.done)
 
-	listener.parser, _ = parsers.NewInfluxParser()
+	listener.parser = influx.NewParser(influx.NewMetricHandler())
 	listener.wg.Add(1)
 	go listener.udpParser()
 
@@ -146,7 +147,7 @@ func TestRunParserInvalidMsg(t *testing.T) {
 	listener.acc = &acc
 	defer close(listener.done)
 
-	listener.parser, _ = parsers.NewInfluxParser()
+	listener.parser = influx.NewParser(influx.NewMetricHandler())
 	listener.wg.Add(1)
 	go listener.udpParser()
 
@@ -172,7 +173,7 @@ func TestRunParserGraphiteMsg(t *testing.T) {
 	listener.acc = &acc
 	defer close(listener.done)
 
-	listener.parser, _ = parsers.NewGraphiteParser("_", []string{}, nil)
+	listener.parser, _ = graphite.NewGraphiteParser("_", []string{}, nil)
 	listener.wg.Add(1)
 	go listener.udpParser()
 
@@ -193,10 +194,7 @@ func TestRunParserJSONMsg(t *testing.T) {
 	listener.acc = &acc
 	defer close(listener.done)
 
-	listener.parser, _ = parsers.NewParser(&parsers.Config{
-		DataFormat: "json",
-		MetricName: "udp_json_test",
-	})
+	listener.parser = &json.JSONParser{MetricName: "udp_json_test"}
 	listener.wg.Add(1)
 	go listener.udpParser()
 
@@ -6,7 +6,7 @@ import (
 	"cloud.google.com/go/pubsub"
 	"encoding/base64"
 	"github.com/influxdata/telegraf"
-	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
 )
@@ -169,7 +169,7 @@ func verifyRawMetricPublished(t *testing.T, m telegraf.Metric, published map[str
 }
 
 func verifyMetricPublished(t *testing.T, m telegraf.Metric, published map[string]*pubsub.Message, base64Encoded bool) *pubsub.Message {
-	p, _ := parsers.NewInfluxParser()
+	p := influx.NewParser(influx.NewMetricHandler())
 
 	v, _ := m.GetField("value")
 	psMsg, ok := published[v.(string)]
@@ -0,0 +1,130 @@
+package execd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/process"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+const sampleConfig = `
+  ## Program to run as daemon
+  command = ["my-telegraf-output", "--some-flag", "value"]
+
+  ## Delay before the process is restarted after an unexpected termination,
+  ## the delay doubles if the process keeps exiting.
+  restart_delay = "10s"
+
+  ## Data format to export.
+  ## Each data format has its own unique set of configuration options, read
+  ## more about them here:
+  ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_OUTPUT.md
+  data_format = "influx"
+`
+
+type Execd struct {
+	Command      []string          `toml:"command"`
+	RestartDelay internal.Duration `toml:"restart_delay"`
+	Log          telegraf.Logger   `toml:"-"`
+
+	process    *process.Process
+	serializer serializers.Serializer
+}
+
+func (e *Execd) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *Execd) Description() string {
+	return "Run executable as long-running output plugin"
+}
+
+func (e *Execd) SetSerializer(s serializers.Serializer) {
+	e.serializer = s
+}
+
+func (e *Execd) Init() error {
+	if len(e.Command) == 0 {
+		return fmt.Errorf("no command specified")
+	}
+	if e.RestartDelay.Duration <= 0 {
+		return fmt.Errorf("restart_delay must be positive")
+	}
+	return nil
+}
+
+func (e *Execd) Connect() error {
+	var err error
+	e.process, err = process.New(e.Command)
+	if err != nil {
+		return fmt.Errorf("error creating process %s: %v", e.Command, err)
+	}
+	e.process.Log = e.Log
+	e.process.RestartDelay = e.RestartDelay.Duration
+	e.process.ReadStdoutFn = e.cmdReadOut
+	e.process.ReadStderrFn = e.cmdReadErr
+
+	err = e.process.Start()
+	if err != nil {
+		return fmt.Errorf("failed to start process %s: %v", e.Command, err)
+	}
+	return nil
+}
+
+func (e *Execd) Close() error {
+	e.process.Stop()
+	return nil
+}
+
+func (e *Execd) Write(metrics []telegraf.Metric) error {
+	for _, m := range metrics {
+		b, err := e.serializer.Serialize(m)
+		if err != nil {
+			e.Log.Debugf("Could not serialize metric: %v", err)
+			continue
+		}
+
+		_, err = e.process.Write(b)
+		if err != nil {
+			// The metrics are retried when the process has restarted.
+			return fmt.Errorf("error writing to process stdin: %v", err)
+		}
+	}
+	return nil
+}
+
+func (e *Execd) cmdReadErr(out io.Reader) {
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		e.Log.Errorf("stderr: %q", scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		e.Log.Errorf("Error reading stderr: %v", err)
+	}
+}
+
+func (e *Execd) cmdReadOut(out io.Reader) {
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		e.Log.Debugf("stdout: %q", scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		e.Log.Errorf("Error reading stdout: %v", err)
+	}
+}
+
+func init() {
+	outputs.Add("execd", func() telegraf.Output {
+		return &Execd{
+			RestartDelay: internal.Duration{Duration: 10 * time.Second},
+		}
+	})
+}
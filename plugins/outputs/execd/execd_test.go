@@ -0,0 +1,61 @@
+// +build !windows
+
+package execd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecd_Write(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-execd")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "out")
+
+	e := &Execd{
+		Command:      []string{"sh", "-c", "cat > " + path},
+		RestartDelay: internal.Duration{Duration: 10 * time.Millisecond},
+		Log:          testutil.Logger{},
+	}
+	e.SetSerializer(influx.NewSerializer())
+	require.NoError(t, e.Init())
+	require.NoError(t, e.Connect())
+
+	metrics := []telegraf.Metric{
+		testutil.MustMetric(
+			"cpu",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"value": 42.0},
+			time.Unix(1, 0),
+		),
+	}
+	require.NoError(t, e.Write(metrics))
+
+	// Close waits for the process to exit, after which all output is in
+	// the file.
+	require.NoError(t, e.Close())
+
+	b, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "cpu,host=a value=42 1000000000\n", string(b))
+}
+
+func TestExecd_NoCommand(t *testing.T) {
+	e := &Execd{}
+	require.Error(t, e.Init())
+}
+
+func TestExecd_InvalidRestartDelay(t *testing.T) {
+	e := &Execd{Command: []string{"cat"}}
+	require.Error(t, e.Init())
+}
@@ -1,10 +1,13 @@
 package file
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
@@ -18,9 +21,12 @@ type File struct {
 	RotationInterval    internal.Duration `toml:"rotation_interval"`
 	RotationMaxSize     internal.Size     `toml:"rotation_max_size"`
 	RotationMaxArchives int               `toml:"rotation_max_archives"`
+	UseBatchFormat      bool              `toml:"use_batch_format"`
+	Overwrite           bool              `toml:"overwrite"`
 
 	writer     io.Writer
 	closers    []io.Closer
+	replace    []string
 	serializer serializers.Serializer
 }
 
@@ -40,6 +46,17 @@ var sampleConfig = `
   ## If set to -1, no archives are removed.
   # rotation_max_archives = 5
 
+  ## Use batch serialization format instead of line based delimiting.  The
+  ## batch format allows for the production of non line based output formats and
+  ## may more efficiently encode metric groups.
+  # use_batch_format = false
+
+  ## Replace the contents of the files on each write instead of appending.
+  ## The files are replaced atomically, so that readers such as the
+  ## node_exporter textfile collector never see a partial file.  Each write
+  ## holds at most metric_batch_size metrics.  Rotation does not apply.
+  # overwrite = false
+
   ## Data format to output.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
@@ -61,6 +78,8 @@ func (f *File) Connect() error {
 	for _, file := range f.Files {
 		if file == "stdout" {
 			writers = append(writers, os.Stdout)
+		} else if f.Overwrite {
+			f.replace = append(f.replace, file)
 		} else {
 			of, err := rotate.NewFileWriter(
 				file, f.RotationInterval.Duration, f.RotationMaxSize.Size, f.RotationMaxArchives)
@@ -98,6 +117,24 @@ func (f *File) Description() string {
 func (f *File) Write(metrics []telegraf.Metric) error {
 	var writeErr error = nil
 
+	if f.Overwrite {
+		return f.overwrite(metrics)
+	}
+
+	if f.UseBatchFormat {
+		octets, err := f.serializer.SerializeBatch(metrics)
+		if err != nil {
+			log.Printf("E! [outputs.file] Could not serialize metrics: %v", err)
+			return nil
+		}
+
+		_, err = f.writer.Write(octets)
+		if err != nil {
+			return fmt.Errorf("E! [outputs.file] failed to write message: %v", err)
+		}
+		return nil
+	}
+
 	for _, metric := range metrics {
 		b, err := f.serializer.Serialize(metric)
 		if err != nil {
@@ -113,6 +150,65 @@ func (f *File) Write(metrics []telegraf.Metric) error {
 	return writeErr
 }
 
+// overwrite replaces the contents of the files with the metrics.
+func (f *File) overwrite(metrics []telegraf.Metric) error {
+	var octets []byte
+	if f.UseBatchFormat {
+		var err error
+		octets, err = f.serializer.SerializeBatch(metrics)
+		if err != nil {
+			log.Printf("E! [outputs.file] Could not serialize metrics: %v", err)
+			return nil
+		}
+	} else {
+		var buf bytes.Buffer
+		for _, metric := range metrics {
+			b, err := f.serializer.Serialize(metric)
+			if err != nil {
+				log.Printf("D! [outputs.file] Could not serialize metric: %v", err)
+				continue
+			}
+			buf.Write(b)
+		}
+		octets = buf.Bytes()
+	}
+
+	_, err := f.writer.Write(octets)
+	if err != nil {
+		return fmt.Errorf("E! [outputs.file] failed to write message: %v", err)
+	}
+
+	for _, file := range f.replace {
+		err := replaceFile(file, octets)
+		if err != nil {
+			return fmt.Errorf("E! [outputs.file] failed to replace %s: %v", file, err)
+		}
+	}
+	return nil
+}
+
+// replaceFile writes the data to a temporary file in the directory of the
+// file and renames it over the file.
+func replaceFile(file string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(file), "."+filepath.Base(file)+".")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Chmod(rotate.FilePerm)
+	}
+	if errClose := tmp.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
 func init() {
 	outputs.Add("file", func() telegraf.Output {
 		return &File{}
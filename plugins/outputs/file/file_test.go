@@ -10,7 +10,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/influxdata/telegraf/internal"
-	"github.com/influxdata/telegraf/plugins/serializers"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
 	"github.com/influxdata/telegraf/testutil"
 )
 
@@ -23,7 +23,7 @@ const (
 func TestFileExistingFile(t *testing.T) {
 	fh := createFile()
 	defer os.Remove(fh.Name())
-	s, _ := serializers.NewInfluxSerializer()
+	s := influx.NewSerializer()
 	f := File{
 		Files:      []string{fh.Name()},
 		serializer: s,
@@ -42,7 +42,7 @@ func TestFileExistingFile(t *testing.T) {
 }
 
 func TestFileNewFile(t *testing.T) {
-	s, _ := serializers.NewInfluxSerializer()
+	s := influx.NewSerializer()
 	fh := tmpFile()
 	defer os.Remove(fh)
 	f := File{
@@ -62,6 +62,53 @@ func TestFileNewFile(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestFileBatchFormat(t *testing.T) {
+	s := influx.NewSerializer()
+	fh := tmpFile()
+	defer os.Remove(fh)
+	f := File{
+		Files:          []string{fh},
+		UseBatchFormat: true,
+		serializer:     s,
+	}
+
+	err := f.Connect()
+	assert.NoError(t, err)
+
+	err = f.Write(testutil.MockMetrics())
+	assert.NoError(t, err)
+
+	validateFile(fh, expNewFile, t)
+
+	err = f.Close()
+	assert.NoError(t, err)
+}
+
+func TestFileOverwrite(t *testing.T) {
+	fh := createFile()
+	defer os.Remove(fh.Name())
+	s := influx.NewSerializer()
+	f := File{
+		Files:      []string{fh.Name()},
+		Overwrite:  true,
+		serializer: s,
+	}
+
+	err := f.Connect()
+	assert.NoError(t, err)
+
+	err = f.Write(testutil.MockMetrics())
+	assert.NoError(t, err)
+	validateFile(fh.Name(), expNewFile, t)
+
+	err = f.Write(testutil.MockMetrics())
+	assert.NoError(t, err)
+	validateFile(fh.Name(), expNewFile, t)
+
+	err = f.Close()
+	assert.NoError(t, err)
+}
+
 func TestFileExistingFiles(t *testing.T) {
 	fh1 := createFile()
 	defer os.Remove(fh1.Name())
@@ -70,7 +117,7 @@ func TestFileExistingFiles(t *testing.T) {
 	fh3 := createFile()
 	defer os.Remove(fh3.Name())
 
-	s, _ := serializers.NewInfluxSerializer()
+	s := influx.NewSerializer()
 	f := File{
 		Files:      []string{fh1.Name(), fh2.Name(), fh3.Name()},
 		serializer: s,
@@ -91,7 +138,7 @@ func TestFileExistingFiles(t *testing.T) {
 }
 
 func TestFileNewFiles(t *testing.T) {
-	s, _ := serializers.NewInfluxSerializer()
+	s := influx.NewSerializer()
 	fh1 := tmpFile()
 	defer os.Remove(fh1)
 	fh2 := tmpFile()
@@ -123,7 +170,7 @@ func TestFileBoth(t *testing.T) {
 	fh2 := tmpFile()
 	defer os.Remove(fh2)
 
-	s, _ := serializers.NewInfluxSerializer()
+	s := influx.NewSerializer()
 	f := File{
 		Files:      []string{fh1.Name(), fh2},
 		serializer: s,
@@ -148,7 +195,7 @@ func TestFileStdout(t *testing.T) {
 	r, w, _ := os.Pipe()
 	os.Stdout = w
 
-	s, _ := serializers.NewInfluxSerializer()
+	s := influx.NewSerializer()
 	f := File{
 		Files:      []string{"stdout"},
 		serializer: s,
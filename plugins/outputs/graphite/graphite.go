@@ -12,7 +12,7 @@ import (
 	"github.com/influxdata/telegraf"
 	tlsint "github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
-	"github.com/influxdata/telegraf/plugins/serializers"
+	serializer "github.com/influxdata/telegraf/plugins/serializers/graphite"
 )
 
 type Graphite struct {
@@ -134,9 +134,10 @@ func checkEOF(conn net.Conn) {
 func (g *Graphite) Write(metrics []telegraf.Metric) error {
 	// Prepare data
 	var batch []byte
-	s, err := serializers.NewGraphiteSerializer(g.Prefix, g.Template, g.GraphiteTagSupport)
-	if err != nil {
-		return err
+	s := &serializer.GraphiteSerializer{
+		Prefix:     g.Prefix,
+		Template:   g.Template,
+		TagSupport: g.GraphiteTagSupport,
 	}
 
 	for _, metric := range metrics {
@@ -147,7 +148,7 @@ func (g *Graphite) Write(metrics []telegraf.Metric) error {
 		batch = append(batch, buf...)
 	}
 
-	err = g.send(batch)
+	err := g.send(batch)
 
 	// try to reconnect and retry to send
 	if err != nil {
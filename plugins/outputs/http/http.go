@@ -12,6 +12,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/secret"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers"
@@ -33,11 +34,13 @@ var sampleConfig = `
   ## HTTP method, one of: "POST" or "PUT"
   # method = "POST"
 
-  ## HTTP Basic Auth credentials
+  ## HTTP Basic Auth credentials, may reference a secret store as in
+  ## "@{store:key}".
   # username = "username"
   # password = "pa$$word"
 
-  ## OAuth2 Client Credentials Grant
+  ## OAuth2 Client Credentials Grant, the client secret may reference a
+  ## secret store.
   # client_id = "clientid"
   # client_secret = "secret"
   # token_url = "https://indentityprovider/oauth2/v1/token"
@@ -76,11 +79,11 @@ type HTTP struct {
 	URL             string            `toml:"url"`
 	Timeout         internal.Duration `toml:"timeout"`
 	Method          string            `toml:"method"`
-	Username        string            `toml:"username"`
-	Password        string            `toml:"password"`
+	Username        secret.Secret     `toml:"username"`
+	Password        secret.Secret     `toml:"password"`
 	Headers         map[string]string `toml:"headers"`
 	ClientID        string            `toml:"client_id"`
-	ClientSecret    string            `toml:"client_secret"`
+	ClientSecret    secret.Secret     `toml:"client_secret"`
 	TokenURL        string            `toml:"token_url"`
 	Scopes          []string          `toml:"scopes"`
 	ContentEncoding string            `toml:"content_encoding"`
@@ -108,10 +111,16 @@ func (h *HTTP) createClient(ctx context.Context) (*http.Client, error) {
 		Timeout: h.Timeout.Duration,
 	}
 
-	if h.ClientID != "" && h.ClientSecret != "" && h.TokenURL != "" {
+	if h.ClientID != "" && !h.ClientSecret.Empty() && h.TokenURL != "" {
+		clientSecret, err := h.ClientSecret.Get()
+		if err != nil {
+			return nil, err
+		}
+		defer secret.Zero(clientSecret)
+
 		oauthConfig := clientcredentials.Config{
 			ClientID:     h.ClientID,
-			ClientSecret: h.ClientSecret,
+			ClientSecret: string(clientSecret),
 			TokenURL:     h.TokenURL,
 			Scopes:       h.Scopes,
 		}
@@ -187,8 +196,20 @@ func (h *HTTP) write(reqBody []byte) error {
 		return err
 	}
 
-	if h.Username != "" || h.Password != "" {
-		req.SetBasicAuth(h.Username, h.Password)
+	if !h.Username.Empty() || !h.Password.Empty() {
+		username, err := h.Username.Get()
+		if err != nil {
+			return err
+		}
+		defer secret.Zero(username)
+
+		password, err := h.Password.Get()
+		if err != nil {
+			return err
+		}
+		defer secret.Zero(password)
+
+		req.SetBasicAuth(string(username), string(password))
 	}
 
 	req.Header.Set("User-Agent", "Telegraf/"+internal.Version())
@@ -12,6 +12,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/secret"
 	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
 	"github.com/stretchr/testify/require"
@@ -298,8 +299,10 @@ func TestBasicAuth(t *testing.T) {
 	require.NoError(t, err)
 
 	tests := []struct {
-		name   string
-		plugin *HTTP
+		name     string
+		plugin   *HTTP
+		username string
+		password string
 	}{
 		{
 			name: "default",
@@ -311,23 +314,27 @@ func TestBasicAuth(t *testing.T) {
 			name: "username only",
 			plugin: &HTTP{
 				URL:      u.String(),
-				Username: "username",
+				Username: secret.New("username"),
 			},
+			username: "username",
 		},
 		{
 			name: "password only",
 			plugin: &HTTP{
 				URL:      u.String(),
-				Password: "pa$$word",
+				Password: secret.New("pa$$word"),
 			},
+			password: "pa$$word",
 		},
 		{
 			name: "username and password",
 			plugin: &HTTP{
 				URL:      u.String(),
-				Username: "username",
-				Password: "pa$$word",
+				Username: secret.New("username"),
+				Password: secret.New("pa$$word"),
 			},
+			username: "username",
+			password: "pa$$word",
 		},
 	}
 
@@ -335,8 +342,8 @@ func TestBasicAuth(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			ts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				username, password, _ := r.BasicAuth()
-				require.Equal(t, tt.plugin.Username, username)
-				require.Equal(t, tt.plugin.Password, password)
+				require.Equal(t, tt.username, username)
+				require.Equal(t, tt.password, password)
 				w.WriteHeader(http.StatusOK)
 			})
 
@@ -383,7 +390,7 @@ func TestOAuthClientCredentialsGrant(t *testing.T) {
 			plugin: &HTTP{
 				URL:          u.String() + "/write",
 				ClientID:     "howdy",
-				ClientSecret: "secret",
+				ClientSecret: secret.New("secret"),
 				TokenURL:     u.String() + "/token",
 				Scopes:       []string{"urn:opc:idm:__myscopes__"},
 			},
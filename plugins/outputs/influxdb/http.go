@@ -16,6 +16,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/secret"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
 )
 
@@ -86,8 +87,8 @@ type HTTPConfig struct {
 	URL                  *url.URL
 	UserAgent            string
 	Timeout              time.Duration
-	Username             string
-	Password             string
+	Username             secret.Secret
+	Password             secret.Secret
 	TLSConfig            *tls.Config
 	Proxy                *url.URL
 	Headers              map[string]string
@@ -368,7 +369,10 @@ func (c *httpClient) makeQueryRequest(query string) (*http.Request, error) {
 	}
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	c.addHeaders(req)
+	err = c.addHeaders(req)
+	if err != nil {
+		return nil, err
+	}
 
 	return req, nil
 }
@@ -388,7 +392,10 @@ func (c *httpClient) makeWriteRequest(url string, body io.Reader) (*http.Request
 	}
 
 	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
-	c.addHeaders(req)
+	err = c.addHeaders(req)
+	if err != nil {
+		return nil, err
+	}
 
 	if c.config.ContentEncoding == "gzip" {
 		req.Header.Set("Content-Encoding", "gzip")
@@ -397,14 +404,27 @@ func (c *httpClient) makeWriteRequest(url string, body io.Reader) (*http.Request
 	return req, nil
 }
 
-func (c *httpClient) addHeaders(req *http.Request) {
-	if c.config.Username != "" || c.config.Password != "" {
-		req.SetBasicAuth(c.config.Username, c.config.Password)
+func (c *httpClient) addHeaders(req *http.Request) error {
+	if !c.config.Username.Empty() || !c.config.Password.Empty() {
+		username, err := c.config.Username.Get()
+		if err != nil {
+			return err
+		}
+		defer secret.Zero(username)
+
+		password, err := c.config.Password.Get()
+		if err != nil {
+			return err
+		}
+		defer secret.Zero(password)
+
+		req.SetBasicAuth(string(username), string(password))
 	}
 
 	for header, value := range c.config.Headers {
 		req.Header.Set(header, value)
 	}
+	return nil
 }
 
 func makeWriteURL(loc *url.URL, db, rp, consistency string) (string, error) {
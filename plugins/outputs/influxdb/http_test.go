@@ -19,6 +19,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/secret"
 	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/outputs/influxdb"
 	"github.com/stretchr/testify/require"
@@ -90,8 +91,8 @@ func TestHTTP_CreateDatabase(t *testing.T) {
 			name: "send basic auth",
 			config: influxdb.HTTPConfig{
 				URL:      u,
-				Username: "guy",
-				Password: "smiley",
+				Username: secret.New("guy"),
+				Password: secret.New("smiley"),
 				Database: "telegraf",
 			},
 			database: "telegraf",
@@ -275,8 +276,8 @@ func TestHTTP_Write(t *testing.T) {
 			config: influxdb.HTTPConfig{
 				URL:      u,
 				Database: "telegraf",
-				Username: "guy",
-				Password: "smiley",
+				Username: secret.New("guy"),
+				Password: secret.New("smiley"),
 			},
 			queryHandlerFunc: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
 				username, password, ok := r.BasicAuth()
@@ -11,6 +11,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/secret"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
@@ -34,8 +35,8 @@ type Client interface {
 type InfluxDB struct {
 	URL                  string   // url deprecated in 0.1.9; use urls
 	URLs                 []string `toml:"urls"`
-	Username             string
-	Password             string
+	Username             secret.Secret
+	Password             secret.Secret
 	Database             string
 	DatabaseTag          string `toml:"database_tag"`
 	UserAgent            string
@@ -93,7 +94,7 @@ var sampleConfig = `
   ## Timeout for HTTP messages.
   # timeout = "5s"
 
-  ## HTTP Basic Auth
+  ## HTTP Basic Auth, may reference a secret store as in "@{store:key}".
   # username = "telegraf"
   # password = "metricsmetricsmetricsmetrics"
 
@@ -8,6 +8,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/secret"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/outputs/influxdb"
@@ -106,8 +107,8 @@ func TestConnectHTTPConfig(t *testing.T) {
 		RetentionPolicy:  "default",
 		WriteConsistency: "any",
 		Timeout:          internal.Duration{Duration: 5 * time.Second},
-		Username:         "guy",
-		Password:         "smiley",
+		Username:         secret.New("guy"),
+		Password:         secret.New("smiley"),
 		UserAgent:        "telegraf",
 		HTTPProxy:        "http://localhost:8086",
 		HTTPHeaders: map[string]string{
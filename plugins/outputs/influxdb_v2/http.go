@@ -17,6 +17,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/secret"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
 )
 
@@ -41,7 +42,7 @@ const (
 
 type HTTPConfig struct {
 	URL             *url.URL
-	Token           string
+	Token           secret.Secret
 	Organization    string
 	Bucket          string
 	BucketTag       string
@@ -59,6 +60,7 @@ type httpClient struct {
 	ContentEncoding string
 	Timeout         time.Duration
 	Headers         map[string]string
+	Token           secret.Secret
 	Organization    string
 	Bucket          string
 	BucketTag       string
@@ -84,9 +86,8 @@ func NewHTTPClient(config *HTTPConfig) (*httpClient, error) {
 		userAgent = "Telegraf/" + internal.Version()
 	}
 
-	var headers = make(map[string]string, len(config.Headers)+2)
+	var headers = make(map[string]string, len(config.Headers)+1)
 	headers["User-Agent"] = userAgent
-	headers["Authorization"] = "Token " + config.Token
 	for k, v := range config.Headers {
 		headers[k] = v
 	}
@@ -134,6 +135,7 @@ func NewHTTPClient(config *HTTPConfig) (*httpClient, error) {
 		ContentEncoding: config.ContentEncoding,
 		Timeout:         timeout,
 		Headers:         headers,
+		Token:           config.Token,
 		Organization:    config.Organization,
 		Bucket:          config.Bucket,
 		BucketTag:       config.BucketTag,
@@ -274,7 +276,10 @@ func (c *httpClient) makeWriteRequest(url string, body io.Reader) (*http.Request
 	}
 
 	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
-	c.addHeaders(req)
+	err = c.addHeaders(req)
+	if err != nil {
+		return nil, err
+	}
 
 	if c.ContentEncoding == "gzip" {
 		req.Header.Set("Content-Encoding", "gzip")
@@ -283,10 +288,18 @@ func (c *httpClient) makeWriteRequest(url string, body io.Reader) (*http.Request
 	return req, nil
 }
 
-func (c *httpClient) addHeaders(req *http.Request) {
+func (c *httpClient) addHeaders(req *http.Request) error {
+	token, err := c.Token.Get()
+	if err != nil {
+		return err
+	}
+	defer secret.Zero(token)
+	req.Header.Set("Authorization", "Token "+string(token))
+
 	for header, value := range c.Headers {
 		req.Header.Set(header, value)
 	}
+	return nil
 }
 
 func makeWriteURL(loc url.URL, org, bucket string) (string, error) {
@@ -11,6 +11,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/secret"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
@@ -29,7 +30,8 @@ var sampleConfig = `
   ## urls will be written to each interval.
   urls = ["http://127.0.0.1:9999"]
 
-  ## Token for authentication.
+  ## Token for authentication, may reference a secret store as in
+  ## "@{store:key}".
   token = ""
 
   ## Organization is the name of the organization you wish to write to; must exist.
@@ -79,7 +81,7 @@ type Client interface {
 
 type InfluxDB struct {
 	URLs            []string          `toml:"urls"`
-	Token           string            `toml:"token"`
+	Token           secret.Secret     `toml:"token"`
 	Organization    string            `toml:"organization"`
 	Bucket          string            `toml:"bucket"`
 	BucketTag       string            `toml:"bucket_tag"`
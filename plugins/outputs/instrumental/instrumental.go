@@ -12,7 +12,6 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/plugins/outputs"
-	"github.com/influxdata/telegraf/plugins/serializers"
 	"github.com/influxdata/telegraf/plugins/serializers/graphite"
 )
 
@@ -85,9 +84,9 @@ func (i *Instrumental) Write(metrics []telegraf.Metric) error {
 		}
 	}
 
-	s, err := serializers.NewGraphiteSerializer(i.Prefix, i.Template, false)
-	if err != nil {
-		return err
+	s := &graphite.GraphiteSerializer{
+		Prefix:   i.Prefix,
+		Template: i.Template,
 	}
 
 	var points []string
@@ -147,7 +146,7 @@ func (i *Instrumental) Write(metrics []telegraf.Metric) error {
 	}
 
 	allPoints := strings.Join(points, "")
-	_, err = fmt.Fprintf(i.conn, allPoints)
+	_, err := fmt.Fprintf(i.conn, allPoints)
 
 	if err != nil {
 		if err == io.EOF {
@@ -6,7 +6,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
-	"github.com/influxdata/telegraf/plugins/serializers"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -22,7 +22,7 @@ func TestConnectAndWrite(t *testing.T) {
 	}
 
 	brokers := []string{testutil.GetLocalHost() + ":9092"}
-	s, _ := serializers.NewInfluxSerializer()
+	s := influx.NewSerializer()
 	k := &Kafka{
 		Brokers:    brokers,
 		Topic:      "Test",
@@ -3,7 +3,7 @@ package mqtt
 import (
 	"testing"
 
-	"github.com/influxdata/telegraf/plugins/serializers"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
 	"github.com/influxdata/telegraf/testutil"
 
 	"github.com/stretchr/testify/require"
@@ -15,7 +15,7 @@ func TestConnectAndWrite(t *testing.T) {
 	}
 
 	var url = testutil.GetLocalHost() + ":1883"
-	s, _ := serializers.NewInfluxSerializer()
+	s := influx.NewSerializer()
 	m := &MQTT{
 		Servers:    []string{url},
 		serializer: s,
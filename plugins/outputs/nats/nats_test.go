@@ -3,7 +3,7 @@ package nats
 import (
 	"testing"
 
-	"github.com/influxdata/telegraf/plugins/serializers"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -14,7 +14,7 @@ func TestConnectAndWrite(t *testing.T) {
 	}
 
 	server := []string{"nats://" + testutil.GetLocalHost() + ":4222"}
-	s, _ := serializers.NewInfluxSerializer()
+	s := influx.NewSerializer()
 	n := &NATS{
 		Servers:    server,
 		Subject:    "telegraf",
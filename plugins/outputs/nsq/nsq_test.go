@@ -3,7 +3,7 @@ package nsq
 import (
 	"testing"
 
-	"github.com/influxdata/telegraf/plugins/serializers"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -14,7 +14,7 @@ func TestConnectAndWrite(t *testing.T) {
 	}
 
 	server := []string{testutil.GetLocalHost() + ":4150"}
-	s, _ := serializers.NewInfluxSerializer()
+	s := influx.NewSerializer()
 	n := &NSQ{
 		Server:     server[0],
 		Topic:      "telegraf",
@@ -0,0 +1,227 @@
+package avro
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/fieldmap"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+// Parser parses Avro binary encoded records into metrics.  Nested values are
+// flattened, with their keys joined by the FieldSeparator.
+type Parser struct {
+	// MetricName is used as the metric name if Measurement is empty.
+	MetricName string
+
+	// Measurement, Tags, Fields, Timestamp and TimestampFormat select the
+	// metric name, tags, fields and time from the flattened values, see
+	// fieldmap.Mapping.
+	Measurement     string   `toml:"avro_measurement"`
+	Tags            []string `toml:"avro_tags"`
+	Fields          []string `toml:"avro_fields"`
+	Timestamp       string   `toml:"avro_timestamp"`
+	TimestampFormat string   `toml:"avro_timestamp_format"`
+
+	// SchemaFile is the file holding the schema of the records.
+	SchemaFile string `toml:"avro_schema_file"`
+
+	// SchemaRegistry is the URL of a schema registry, each record is
+	// expected to be prefixed with the magic byte and the id of its schema.
+	SchemaRegistry string `toml:"avro_schema_registry"`
+
+	FieldSeparator string `toml:"avro_field_separator"`
+
+	DefaultTags map[string]string
+	TimeFunc    func() time.Time
+
+	schema   *schema
+	registry *schemaRegistry
+}
+
+// Init loads the schema and checks the configuration.
+func (p *Parser) Init() error {
+	switch {
+	case p.SchemaFile != "" && p.SchemaRegistry != "":
+		return fmt.Errorf("only one of avro_schema_file and avro_schema_registry can be set")
+	case p.SchemaFile != "":
+		spec, err := ioutil.ReadFile(p.SchemaFile)
+		if err != nil {
+			return err
+		}
+		p.schema, err = newSchema(string(spec))
+		if err != nil {
+			return fmt.Errorf("invalid schema %s: %v", p.SchemaFile, err)
+		}
+	case p.SchemaRegistry != "":
+		p.registry = newSchemaRegistry(p.SchemaRegistry)
+	default:
+		return fmt.Errorf("one of avro_schema_file or avro_schema_registry must be set")
+	}
+
+	if p.FieldSeparator == "" {
+		p.FieldSeparator = "_"
+	}
+	return nil
+}
+
+func (p *Parser) Description() string {
+	return "Parse Avro binary encoded records"
+}
+
+func (p *Parser) SampleConfig() string {
+	return `
+  ## Schema of the records, exactly one of the schema file or the URL of a
+  ## schema registry must be set.  When using a schema registry each message
+  ## must start with the magic byte 0x00 and the 4 byte schema id.
+  avro_schema_file = "/etc/telegraf/cpu.avsc"
+  # avro_schema_registry = "http://localhost:8081"
+
+  ## Key of the value used as the measurement name, if unset the name of the
+  ## plugin is used.
+  # avro_measurement = ""
+
+  ## Keys of the values used as tags.
+  # avro_tags = []
+
+  ## Keys of the values used as fields, if empty all values not used as
+  ## measurement, tag or timestamp are added as fields.
+  # avro_fields = []
+
+  ## Key of the value used as the metric time and its format, either a Go
+  ## time layout or one of "unix", "unix_ms", "unix_us" or "unix_ns".  Values
+  ## with a timestamp logical type don't need a format.  If unset the current
+  ## time is used.
+  # avro_timestamp = ""
+  # avro_timestamp_format = "unix"
+
+  ## Separator joining the keys of nested values.
+  # avro_field_separator = "_"
+`
+}
+
+// Parse parses one or more consecutive records.
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	metrics := make([]telegraf.Metric, 0)
+	for len(buf) > 0 {
+		s := p.schema
+		if p.registry != nil {
+			id, payload, err := splitHeader(buf)
+			if err != nil {
+				return nil, err
+			}
+			s, err = p.registry.get(id)
+			if err != nil {
+				return nil, err
+			}
+			buf = payload
+		}
+
+		native, rest, err := s.codec.NativeFromBinary(buf)
+		if err != nil {
+			return nil, err
+		}
+		buf = rest
+
+		record, ok := native.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected a record, got %T", native)
+		}
+
+		values := make(map[string]interface{})
+		p.flatten(s, values, "", record)
+		m, err := p.mapping().Metric(values)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(metrics) {
+	case 0:
+		return nil, nil
+	case 1:
+		return metrics[0], nil
+	default:
+		return nil, fmt.Errorf("cannot parse line with multiple (%d) metrics", len(metrics))
+	}
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+func (p *Parser) mapping() *fieldmap.Mapping {
+	return &fieldmap.Mapping{
+		MetricName:      p.MetricName,
+		Measurement:     p.Measurement,
+		Tags:            p.Tags,
+		Fields:          p.Fields,
+		Timestamp:       p.Timestamp,
+		TimestampFormat: p.TimestampFormat,
+		DefaultTags:     p.DefaultTags,
+		TimeFunc:        p.TimeFunc,
+	}
+}
+
+// flatten adds the values of records, maps and arrays with their keys joined
+// by the separator.  Union values, which are wrapped in a map keyed by their
+// type, are unwrapped.
+func (p *Parser) flatten(s *schema, values map[string]interface{}, key string, value interface{}) {
+	switch v := value.(type) {
+	case nil:
+	case map[string]interface{}:
+		if len(v) == 1 {
+			for typ, inner := range v {
+				if s.isUnionType(typ) {
+					p.flatten(s, values, key, inner)
+					return
+				}
+			}
+		}
+		for k, inner := range v {
+			p.flatten(s, values, p.join(key, k), inner)
+		}
+	case []interface{}:
+		for i, inner := range v {
+			p.flatten(s, values, p.join(key, strconv.Itoa(i)), inner)
+		}
+	case bool, int64, float64, string, time.Time:
+		values[key] = v
+	case int32:
+		values[key] = int64(v)
+	case float32:
+		values[key] = float64(v)
+	case []byte:
+		values[key] = string(v)
+	case time.Duration:
+		values[key] = int64(v)
+	case *big.Rat:
+		values[key], _ = v.Float64()
+	}
+}
+
+func (p *Parser) join(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + p.FieldSeparator + key
+}
+
+func init() {
+	parsers.Add("avro", func(defaultMetricName string) parsers.Parser {
+		return &Parser{MetricName: defaultMetricName}
+	})
+}
@@ -0,0 +1,253 @@
+package avro
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/linkedin/goavro.v2"
+)
+
+const schemaFile = "testdata/cpu.avsc"
+
+var exptime = time.Date(2020, time.August, 1, 15, 4, 3, 0, time.UTC)
+
+func record() map[string]interface{} {
+	return map[string]interface{}{
+		"measurement": "cpu",
+		"time":        exptime,
+		"host":        "server01",
+		"state":       "BUSY",
+		"usage":       goavro.Union("double", 42.5),
+		"cores":       int32(4),
+		"load":        []interface{}{float32(0.5), float32(1.5)},
+		"temperature": map[string]interface{}{"core0": int64(50)},
+		"cache": goavro.Union("com.example.Cache", map[string]interface{}{
+			"hits":   int64(10),
+			"misses": int64(2),
+		}),
+	}
+}
+
+func encode(t *testing.T, records ...map[string]interface{}) []byte {
+	spec, err := ioutil.ReadFile(schemaFile)
+	require.NoError(t, err)
+	codec, err := goavro.NewCodec(string(spec))
+	require.NoError(t, err)
+
+	var buf []byte
+	for _, r := range records {
+		buf, err = codec.BinaryFromNative(buf, r)
+		require.NoError(t, err)
+	}
+	return buf
+}
+
+func withHeader(id uint32, payload []byte) []byte {
+	buf := make([]byte, 5, 5+len(payload))
+	binary.BigEndian.PutUint32(buf[1:], id)
+	return append(buf, payload...)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		parser   *Parser
+		records  []map[string]interface{}
+		expected []telegraf.Metric
+	}{
+		{
+			name: "all values as fields",
+			parser: &Parser{
+				MetricName: "avro",
+				TimeFunc:   func() time.Time { return exptime },
+			},
+			records: []map[string]interface{}{record()},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("avro",
+					map[string]string{},
+					map[string]interface{}{
+						"measurement":       "cpu",
+						"time":              exptime.UnixNano(),
+						"host":              "server01",
+						"state":             "BUSY",
+						"usage":             42.5,
+						"cores":             int64(4),
+						"load_0":            0.5,
+						"load_1":            1.5,
+						"temperature_core0": int64(50),
+						"cache_hits":        int64(10),
+						"cache_misses":      int64(2),
+					},
+					exptime),
+			},
+		},
+		{
+			name: "mapping",
+			parser: &Parser{
+				Measurement:    "measurement",
+				Tags:           []string{"host", "state"},
+				Fields:         []string{"usage", "cache.hits"},
+				Timestamp:      "time",
+				FieldSeparator: ".",
+			},
+			records: []map[string]interface{}{record()},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{
+						"host":  "server01",
+						"state": "BUSY",
+					},
+					map[string]interface{}{
+						"usage":      42.5,
+						"cache.hits": int64(10),
+					},
+					exptime),
+			},
+		},
+		{
+			name: "null union",
+			parser: &Parser{
+				Measurement: "measurement",
+				Tags:        []string{"host"},
+				Fields:      []string{"usage", "cores"},
+				Timestamp:   "time",
+			},
+			records: []map[string]interface{}{
+				func() map[string]interface{} {
+					r := record()
+					r["usage"] = nil
+					return r
+				}(),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "server01"},
+					map[string]interface{}{"cores": int64(4)},
+					exptime),
+			},
+		},
+		{
+			name: "multiple records",
+			parser: &Parser{
+				Measurement: "measurement",
+				Tags:        []string{"host"},
+				Fields:      []string{"cores"},
+				Timestamp:   "time",
+			},
+			records: []map[string]interface{}{
+				record(),
+				func() map[string]interface{} {
+					r := record()
+					r["host"] = "server02"
+					r["cores"] = int32(8)
+					return r
+				}(),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "server01"},
+					map[string]interface{}{"cores": int64(4)},
+					exptime),
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "server02"},
+					map[string]interface{}{"cores": int64(8)},
+					exptime),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.parser.SchemaFile = schemaFile
+			require.NoError(t, tt.parser.Init())
+
+			metrics, err := tt.parser.Parse(encode(t, tt.records...))
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, tt.expected, metrics)
+		})
+	}
+}
+
+func TestParseLine(t *testing.T) {
+	parser := &Parser{
+		Measurement: "measurement",
+		Fields:      []string{"cores"},
+		Timestamp:   "time",
+		SchemaFile:  schemaFile,
+	}
+	require.NoError(t, parser.Init())
+
+	m, err := parser.ParseLine(string(encode(t, record())))
+	require.NoError(t, err)
+	testutil.RequireMetricEqual(t,
+		testutil.MustMetric("cpu",
+			map[string]string{},
+			map[string]interface{}{"cores": int64(4)},
+			exptime),
+		m)
+
+	_, err = parser.ParseLine(string(encode(t, record(), record())))
+	require.Error(t, err)
+}
+
+func TestSchemaRegistry(t *testing.T) {
+	spec, err := ioutil.ReadFile(schemaFile)
+	require.NoError(t, err)
+
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/schemas/ids/7" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"schema": %s}`, strconv.Quote(string(spec)))
+	}))
+	defer ts.Close()
+
+	parser := &Parser{
+		Measurement:    "measurement",
+		Tags:           []string{"host"},
+		Fields:         []string{"cores"},
+		Timestamp:      "time",
+		SchemaRegistry: ts.URL,
+	}
+	require.NoError(t, parser.Init())
+
+	buf := append(withHeader(7, encode(t, record())), withHeader(7, encode(t, record()))...)
+	metrics, err := parser.Parse(buf)
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+	require.Equal(t, 1, requests)
+
+	_, err = parser.Parse(withHeader(8, encode(t, record())))
+	require.Error(t, err)
+
+	_, err = parser.Parse(encode(t, record()))
+	require.Error(t, err)
+}
+
+func TestInit(t *testing.T) {
+	require.Error(t, (&Parser{}).Init())
+	require.Error(t, (&Parser{SchemaFile: schemaFile, SchemaRegistry: "http://localhost:8081"}).Init())
+	require.Error(t, (&Parser{SchemaFile: "testdata/missing.avsc"}).Init())
+}
+
+func TestMissingTimestamp(t *testing.T) {
+	parser := &Parser{
+		Timestamp:  "created",
+		SchemaFile: schemaFile,
+	}
+	require.NoError(t, parser.Init())
+
+	_, err := parser.Parse(encode(t, record()))
+	require.Error(t, err)
+}
@@ -0,0 +1,157 @@
+package avro
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/linkedin/goavro.v2"
+)
+
+// magicByte starts messages framed with the schema id of a schema registry.
+const magicByte = 0
+
+// primitiveTypes are the unnamed types used as keys of decoded union values.
+var primitiveTypes = map[string]bool{
+	"null":    true,
+	"boolean": true,
+	"int":     true,
+	"long":    true,
+	"float":   true,
+	"double":  true,
+	"bytes":   true,
+	"string":  true,
+	"array":   true,
+	"map":     true,
+}
+
+type schema struct {
+	codec *goavro.Codec
+
+	// names holds the full names of the named types of the schema.
+	names map[string]bool
+}
+
+func newSchema(spec string) (*schema, error) {
+	codec, err := goavro.NewCodec(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(spec), &parsed); err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool)
+	collectNames(parsed, "", names)
+
+	return &schema{codec: codec, names: names}, nil
+}
+
+// isUnionType returns true if name is the name of a type, as used for the key
+// of decoded union values.
+func (s *schema) isUnionType(name string) bool {
+	// Logical types are named after their underlying type, such as
+	// long.timestamp-millis.
+	if i := strings.Index(name, "."); i >= 0 && primitiveTypes[name[:i]] {
+		return true
+	}
+	return primitiveTypes[name] || s.names[name]
+}
+
+func collectNames(schema interface{}, namespace string, names map[string]bool) {
+	switch s := schema.(type) {
+	case []interface{}:
+		for _, member := range s {
+			collectNames(member, namespace, names)
+		}
+	case map[string]interface{}:
+		switch s["type"] {
+		case "record", "error", "enum", "fixed":
+			if name, ok := s["name"].(string); ok {
+				if ns, ok := s["namespace"].(string); ok {
+					namespace = ns
+				}
+				if i := strings.LastIndex(name, "."); i >= 0 {
+					namespace = name[:i]
+				} else if namespace != "" {
+					name = namespace + "." + name
+				}
+				names[name] = true
+			}
+		}
+
+		collectNames(s["type"], namespace, names)
+		collectNames(s["items"], namespace, names)
+		collectNames(s["values"], namespace, names)
+		if fields, ok := s["fields"].([]interface{}); ok {
+			for _, field := range fields {
+				collectNames(field, namespace, names)
+			}
+		}
+	}
+}
+
+// schemaRegistry looks up schemas by id from a Confluent compatible schema
+// registry.
+type schemaRegistry struct {
+	url    string
+	client *http.Client
+
+	mu      sync.Mutex
+	schemas map[uint32]*schema
+}
+
+func newSchemaRegistry(url string) *schemaRegistry {
+	return &schemaRegistry{
+		url:     strings.TrimSuffix(url, "/"),
+		client:  &http.Client{Timeout: 10 * time.Second},
+		schemas: make(map[uint32]*schema),
+	}
+}
+
+// splitHeader returns the schema id and the payload of a message framed by
+// a magic byte and the schema id.
+func splitHeader(buf []byte) (uint32, []byte, error) {
+	if len(buf) < 5 || buf[0] != magicByte {
+		return 0, nil, fmt.Errorf("message does not start with a schema registry header")
+	}
+	return binary.BigEndian.Uint32(buf[1:5]), buf[5:], nil
+}
+
+func (r *schemaRegistry) get(id uint32) (*schema, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if s, ok := r.schemas[id]; ok {
+		return s, nil
+	}
+
+	resp, err := r.client.Get(fmt.Sprintf("%s/schemas/ids/%d", r.url, id))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("schema registry returned status %s for schema id %d", resp.Status, id)
+	}
+
+	var body struct {
+		Schema string `json:"schema"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("invalid schema registry response: %v", err)
+	}
+
+	s, err := newSchema(body.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema id %d: %v", id, err)
+	}
+	r.schemas[id] = s
+	return s, nil
+}
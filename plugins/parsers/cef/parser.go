@@ -0,0 +1,173 @@
+package cef
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/syslog"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+// headerFields are the tags and fields of the header, following the version.
+var headerFields = []string{
+	"device_vendor", "device_product", "device_version", "signature_id",
+	"name", "severity",
+}
+
+// Parser parses ArcSight Common Event Format (CEF) events, one per line.
+// The events may be preceded by a BSD syslog header.
+type Parser struct {
+	DefaultTags map[string]string
+
+	now func() time.Time
+}
+
+func (p *Parser) Description() string {
+	return "Parse ArcSight Common Event Format (CEF) events"
+}
+
+func (p *Parser) SampleConfig() string {
+	return ""
+}
+
+// Parse parses the events of each non-empty line.
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	metrics := make([]telegraf.Metric, 0)
+	for _, line := range bytes.Split(buf, []byte("\n")) {
+		line = bytes.TrimRight(line, "\r")
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		m, err := p.ParseLine(string(line))
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+// ParseLine parses a single event.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	now := time.Now
+	if p.now != nil {
+		now = p.now
+	}
+	t := now()
+
+	start := strings.Index(line, "CEF:")
+	if start < 0 {
+		return nil, fmt.Errorf("not a CEF event, missing the CEF: prefix")
+	}
+
+	tags := make(map[string]string)
+	fields := make(map[string]interface{})
+
+	syslog.AddHeaderTags(tags, line[:start], t)
+
+	parts := splitHeader(line[start+len("CEF:"):], len(headerFields)+1)
+	if len(parts) != len(headerFields)+2 {
+		return nil, fmt.Errorf("expecting %d header fields, got %d", len(headerFields)+1, len(parts)-1)
+	}
+
+	if _, err := strconv.ParseInt(parts[0], 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid CEF version %q", parts[0])
+	}
+	fields["version"] = parts[0]
+
+	for i, key := range headerFields {
+		value := unescapeHeader(parts[i+1])
+		if key == "name" {
+			fields[key] = value
+		} else if value != "" {
+			tags[key] = value
+		}
+	}
+
+	for key, value := range parseExtension(parts[len(parts)-1]) {
+		fields[key] = syslog.EventFieldValue(value)
+	}
+
+	for k, v := range p.DefaultTags {
+		if _, ok := tags[k]; !ok {
+			tags[k] = v
+		}
+	}
+	return metric.New("cef", tags, fields, t)
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+// splitHeader splits the header on unescaped pipes into n values followed by
+// the extension.
+func splitHeader(s string, n int) []string {
+	parts := make([]string, 0, n+1)
+	begin := 0
+	for i := 0; i < len(s) && len(parts) < n; i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '|':
+			parts = append(parts, s[begin:i])
+			begin = i + 1
+		}
+	}
+	if len(parts) < n {
+		return parts
+	}
+	return append(parts, s[begin:])
+}
+
+func unescapeHeader(s string) string {
+	return strings.NewReplacer(`\|`, `|`, `\\`, `\`).Replace(s)
+}
+
+// parseExtension parses the space separated key=value pairs of the
+// extension.  Values may contain spaces, each value ends at the last space
+// before the next key.
+func parseExtension(s string) map[string]string {
+	// Start of the keys and positions of their equal signs.  Equal signs
+	// without a space since the previous key belong to its value.
+	var starts, eqs []int
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '=':
+			start := strings.LastIndexByte(s[:i], ' ') + 1
+			if start == i || (len(eqs) > 0 && start <= eqs[len(eqs)-1]) {
+				continue
+			}
+			starts = append(starts, start)
+			eqs = append(eqs, i)
+		}
+	}
+
+	result := make(map[string]string, len(eqs))
+	for n, eq := range eqs {
+		end := len(s)
+		if n+1 < len(starts) {
+			end = starts[n+1]
+		}
+		key := s[starts[n]:eq]
+		result[key] = unescapeValue(strings.TrimSpace(s[eq+1 : end]))
+	}
+	return result
+}
+
+func unescapeValue(s string) string {
+	return strings.NewReplacer(`\=`, `=`, `\\`, `\`, `\n`, "\n", `\r`, "\r").Replace(s)
+}
+
+func init() {
+	parsers.Add("cef", func(defaultMetricName string) parsers.Parser {
+		return &Parser{}
+	})
+}
@@ -0,0 +1,89 @@
+package cef
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var defaultTime = time.Unix(1557248400, 0)
+
+func TestParse(t *testing.T) {
+	parser := &Parser{
+		now: func() time.Time {
+			return defaultTime
+		},
+	}
+	parser.SetDefaultTags(map[string]string{"source": "firewall"})
+
+	metrics, err := parser.Parse([]byte(
+		`<134>Feb  5 22:14:15 fw01 CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|src=10.0.0.1 dst=2.1.2.2 spt=1232 msg=Detected a \= sign in a message with spaces act=blocked a \| pipe` + "\n" +
+			`CEF:0|Vendor \| Inc|Product|2.0|sig\\1|name|Low|` + "\n"))
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"cef",
+			map[string]string{
+				"hostname":       "fw01",
+				"device_vendor":  "Security",
+				"device_product": "threatmanager",
+				"device_version": "1.0",
+				"signature_id":   "100",
+				"severity":       "10",
+				"source":         "firewall",
+			},
+			map[string]interface{}{
+				"version": "0",
+				"name":    "worm successfully stopped",
+				"src":     "10.0.0.1",
+				"dst":     "2.1.2.2",
+				"spt":     int64(1232),
+				"msg":     "Detected a = sign in a message with spaces",
+				"act":     `blocked a \| pipe`,
+			},
+			defaultTime,
+		),
+		testutil.MustMetric(
+			"cef",
+			map[string]string{
+				"device_vendor":  "Vendor | Inc",
+				"device_product": "Product",
+				"device_version": "2.0",
+				"signature_id":   `sig\1`,
+				"severity":       "Low",
+				"source":         "firewall",
+			},
+			map[string]interface{}{
+				"version": "0",
+				"name":    "name",
+			},
+			defaultTime,
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestParseExtension(t *testing.T) {
+	require.Equal(t, map[string]string{
+		"a": "b=c",
+		"d": "e f",
+		"g": "line\nbreak",
+	}, parseExtension(`a=b=c d=e f g=line\nbreak`))
+	require.Equal(t, map[string]string{}, parseExtension(""))
+}
+
+func TestParseInvalid(t *testing.T) {
+	parser := &Parser{}
+	for _, line := range []string{
+		"not cef",
+		"CEF:0|Vendor|Product|1.0|100|name",
+		"CEF:x|Vendor|Product|1.0|100|name|1|",
+	} {
+		_, err := parser.ParseLine(line)
+		require.Error(t, err, line)
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers"
 )
 
 const (
@@ -18,12 +19,19 @@ const (
 )
 
 type CollectdParser struct {
+	// Authentication file for cryptographic security levels
+	AuthFile string `toml:"collectd_auth_file"`
+	// One of none (default), sign, or encrypt
+	SecurityLevel string `toml:"collectd_security_level"`
+	// Paths of the TypesDB specifications
+	TypesDB []string `toml:"collectd_typesdb"`
+
 	// DefaultTags will be added to every parsed metric
 	DefaultTags map[string]string
 
 	//whether or not to split multi value metric into multiple metrics
 	//default value is split
-	ParseMultiValue string
+	ParseMultiValue string `toml:"collectd_parse_multivalue"`
 	popts           network.ParseOpts
 }
 
@@ -37,9 +45,23 @@ func NewCollectdParser(
 	typesDB []string,
 	split string,
 ) (*CollectdParser, error) {
+	parser := &CollectdParser{
+		AuthFile:        authFile,
+		SecurityLevel:   securityLevel,
+		TypesDB:         typesDB,
+		ParseMultiValue: split,
+	}
+	if err := parser.Init(); err != nil {
+		return nil, err
+	}
+	return parser, nil
+}
+
+// Init sets the security level and loads the types databases.
+func (p *CollectdParser) Init() error {
 	popts := network.ParseOpts{}
 
-	switch securityLevel {
+	switch p.SecurityLevel {
 	case "none":
 		popts.SecurityLevel = network.None
 	case "sign":
@@ -50,15 +72,16 @@ func NewCollectdParser(
 		popts.SecurityLevel = network.None
 	}
 
+	authFile := p.AuthFile
 	if authFile == "" {
 		authFile = DefaultAuthFile
 	}
 	popts.PasswordLookup = network.NewAuthFile(authFile)
 
-	for _, path := range typesDB {
+	for _, path := range p.TypesDB {
 		db, err := LoadTypesDB(path)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		if popts.TypesDB != nil {
@@ -68,9 +91,28 @@ func NewCollectdParser(
 		}
 	}
 
-	parser := CollectdParser{popts: popts,
-		ParseMultiValue: split}
-	return &parser, nil
+	p.popts = popts
+	return nil
+}
+
+func (p *CollectdParser) Description() string {
+	return "Parse the collectd binary network protocol"
+}
+
+func (p *CollectdParser) SampleConfig() string {
+	return `
+  ## Authentication file for cryptographic security levels
+  # collectd_auth_file = "/etc/collectd/auth_file"
+  ## One of none (default), sign, or encrypt
+  # collectd_security_level = "none"
+  ## Path of to TypesDB specifications
+  # collectd_typesdb = ["/usr/share/collectd/types.db"]
+
+  ## Multi-value plugins can be handled two ways.
+  ## "split" will parse and store the multi-value plugin data into separate measurements
+  ## "join" will parse and store the multi-value plugin as a single multi-value measurement.
+  # collectd_parse_multivalue = "split"
+`
 }
 
 func (p *CollectdParser) Parse(buf []byte) ([]telegraf.Metric, error) {
@@ -212,3 +254,9 @@ func LoadTypesDB(path string) (*api.TypesDB, error) {
 	}
 	return api.NewTypesDB(reader)
 }
+
+func init() {
+	parsers.Add("collectd", func(defaultMetricName string) parsers.Parser {
+		return &CollectdParser{}
+	})
+}
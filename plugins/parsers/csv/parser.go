@@ -11,26 +11,116 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers"
 )
 
 type Parser struct {
 	MetricName        string
-	HeaderRowCount    int
-	SkipRows          int
-	SkipColumns       int
-	Delimiter         string
-	Comment           string
-	TrimSpace         bool
-	ColumnNames       []string
-	ColumnTypes       []string
-	TagColumns        []string
-	MeasurementColumn string
-	TimestampColumn   string
-	TimestampFormat   string
+	HeaderRowCount    int      `toml:"csv_header_row_count"`
+	SkipRows          int      `toml:"csv_skip_rows"`
+	SkipColumns       int      `toml:"csv_skip_columns"`
+	Delimiter         string   `toml:"csv_delimiter"`
+	Comment           string   `toml:"csv_comment"`
+	TrimSpace         bool     `toml:"csv_trim_space"`
+	ColumnNames       []string `toml:"csv_column_names"`
+	ColumnTypes       []string `toml:"csv_column_types"`
+	TagColumns        []string `toml:"csv_tag_columns"`
+	MeasurementColumn string   `toml:"csv_measurement_column"`
+	TimestampColumn   string   `toml:"csv_timestamp_column"`
+	TimestampFormat   string   `toml:"csv_timestamp_format"`
 	DefaultTags       map[string]string
 	TimeFunc          func() time.Time
 }
 
+// Init validates the options.
+func (p *Parser) Init() error {
+	if p.HeaderRowCount == 0 && len(p.ColumnNames) == 0 {
+		return fmt.Errorf("`csv_header_row_count` must be defined if `csv_column_names` is not specified")
+	}
+
+	if p.Delimiter != "" {
+		runeStr := []rune(p.Delimiter)
+		if len(runeStr) > 1 {
+			return fmt.Errorf("csv_delimiter must be a single character, got: %s", p.Delimiter)
+		}
+	}
+
+	if p.Comment != "" {
+		runeStr := []rune(p.Comment)
+		if len(runeStr) > 1 {
+			return fmt.Errorf("csv_delimiter must be a single character, got: %s", p.Comment)
+		}
+	}
+
+	if len(p.ColumnNames) > 0 && len(p.ColumnTypes) > 0 && len(p.ColumnNames) != len(p.ColumnTypes) {
+		return fmt.Errorf("csv_column_names field count doesn't match with csv_column_types")
+	}
+
+	if p.TimeFunc == nil {
+		p.TimeFunc = time.Now
+	}
+	return nil
+}
+
+func (p *Parser) Description() string {
+	return "Parse comma separated values"
+}
+
+func (p *Parser) SampleConfig() string {
+	return `
+  ## Indicates how many rows to treat as a header. By default, the parser assumes
+  ## there is no header and will parse the first row as data. If set to anything more
+  ## than 1, column names will be concatenated with the name listed in the next header row.
+  ## If "csv_column_names" is specified, the column names in header will be overridden.
+  csv_header_row_count = 0
+
+  ## For assigning custom names to columns
+  ## If this is specified, all columns should have a name
+  ## Unnamed columns will be ignored by the parser.
+  ## If "csv_header_row_count" is set to 0, this config must be used
+  csv_column_names = []
+
+  ## For assigning explicit data types to columns.
+  ## Supported types: "int", "float", "bool", "string".
+  ## If this is not specified, type conversion will be done on the types above.
+  # csv_column_types = []
+
+  ## Indicates the number of rows to skip before looking for header information.
+  # csv_skip_rows = 0
+
+  ## Indicates the number of columns to skip before looking for data to parse.
+  ## These columns will be skipped in the header as well.
+  # csv_skip_columns = 0
+
+  ## The seperator between csv fields
+  ## By default, the parser assumes a comma (",")
+  # csv_delimiter = ","
+
+  ## The character reserved for marking a row as a comment row
+  ## Commented rows are skipped and not parsed
+  # csv_comment = ""
+
+  ## If set to true, the parser will remove leading whitespace from fields
+  ## By default, this is false
+  # csv_trim_space = false
+
+  ## Columns listed here will be added as tags. Any other columns
+  ## will be added as fields.
+  # csv_tag_columns = []
+
+  ## The column to extract the name of the metric from
+  # csv_measurement_column = ""
+
+  ## The column to extract time information for the metric
+  ## "csv_timestamp_format" must be specified if this is used
+  # csv_timestamp_column = ""
+
+  ## The format of time data extracted from "csv_timestamp_column"
+  ## this must be specified if "csv_timestamp_column" is specified
+  # csv_timestamp_format = ""
+`
+}
+
 func (p *Parser) SetTimeFunc(fn metric.TimeFunc) {
 	p.TimeFunc = fn
 }
@@ -250,3 +340,9 @@ func parseTimestamp(timeFunc func() time.Time, recordFields map[string]interface
 func (p *Parser) SetDefaultTags(tags map[string]string) {
 	p.DefaultTags = tags
 }
+
+func init() {
+	parsers.Add("csv", func(defaultMetricName string) parsers.Parser {
+		return &Parser{MetricName: defaultMetricName}
+	})
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal/templating"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers"
 	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/tidwall/gjson"
 )
@@ -24,28 +25,31 @@ type parser struct {
 
 	// an optional json path containing the metric registry object
 	// if left empty, the whole json object is parsed as a metric registry
-	MetricRegistryPath string
+	MetricRegistryPath string `toml:"dropwizard_metric_registry_path"`
 
 	// an optional json path containing the default time of the metrics
 	// if left empty, or if cannot be parsed the current processing time is used as the time of the metrics
-	TimePath string
+	TimePath string `toml:"dropwizard_time_path"`
 
 	// time format to use for parsing the time field
 	// defaults to time.RFC3339
-	TimeFormat string
+	TimeFormat string `toml:"dropwizard_time_format"`
 
 	// an optional json path pointing to a json object with tag key/value pairs
 	// takes precedence over TagPathsMap
-	TagsPath string
+	TagsPath string `toml:"dropwizard_tags_path"`
 
 	// an optional map containing tag names as keys and json paths to retrieve the tag values from as values
 	// used if TagsPath is empty or doesn't return any tags
-	TagPathsMap map[string]string
+	TagPathsMap map[string]string `toml:"dropwizard_tag_paths"`
+
+	// the separator and templates used to parse the metric names
+	Separator string   `toml:"separator"`
+	Templates []string `toml:"templates"`
 
 	// an optional map of default tags to use for metrics
 	DefaultTags map[string]string
 
-	separator      string
 	templateEngine *templating.Engine
 
 	timeFunc metric.TimeFunc
@@ -65,6 +69,47 @@ func NewParser() *parser {
 	return parser
 }
 
+// Init sets up the templates.
+func (p *parser) Init() error {
+	return p.SetTemplates(p.Separator, p.Templates)
+}
+
+func (p *parser) Description() string {
+	return "Parse JSON documents of dropwizard metrics"
+}
+
+func (p *parser) SampleConfig() string {
+	return `
+  ## Used by the templating engine to join matched values when cardinality is > 1
+  separator = "_"
+
+  ## Each template line requires a template pattern. It can have an optional
+  ## filter before the template and separated by spaces. It can also have optional extra
+  ## tags following the template. Multiple tags should be separated by commas and no spaces
+  ## similar to the line protocol format. There can be only one default template.
+  ## By providing an empty template array, templating is disabled and measurements are parsed as influxdb line protocol keys (measurement<,tag_set>)
+  templates = []
+
+  ## You may use an appropriate [gjson path](https://github.com/tidwall/gjson#path-syntax)
+  ## to locate the metric registry within the JSON document
+  # dropwizard_metric_registry_path = "metrics"
+
+  ## You may use an appropriate [gjson path](https://github.com/tidwall/gjson#path-syntax)
+  ## to locate the default time of the measurements within the JSON document
+  # dropwizard_time_path = "time"
+  # dropwizard_time_format = "2006-01-02T15:04:05Z07:00"
+
+  ## You may use an appropriate [gjson path](https://github.com/tidwall/gjson#path-syntax)
+  ## to locate the tags map within the JSON document
+  # dropwizard_tags_path = "tags"
+
+  ## You may even use tag paths per tag
+  # [inputs.file.dropwizard_tag_paths]
+  #   tag1 = "tags.tag1"
+  #   tag2 = "tags.tag2"
+`
+}
+
 // Parse parses the input bytes to an array of metrics
 func (p *parser) Parse(buf []byte) ([]telegraf.Metric, error) {
 
@@ -114,6 +159,8 @@ func (p *parser) Parse(buf []byte) ([]telegraf.Metric, error) {
 }
 
 func (p *parser) SetTemplates(separator string, templates []string) error {
+	p.Separator = separator
+	p.Templates = templates
 	if len(templates) == 0 {
 		p.templateEngine = nil
 		return nil
@@ -129,7 +176,6 @@ func (p *parser) SetTemplates(separator string, templates []string) error {
 		return err
 	}
 
-	p.separator = separator
 	p.templateEngine = templateEngine
 	return nil
 }
@@ -227,7 +273,7 @@ func (p *parser) readDWMetrics(metricType string, dwms interface{}, metrics []te
 			if p.templateEngine != nil {
 				measurementName, tags, fieldPrefix, _ = p.templateEngine.Apply(dwmName)
 				if len(fieldPrefix) > 0 {
-					fieldPrefix = fmt.Sprintf("%s%s", fieldPrefix, p.separator)
+					fieldPrefix = fmt.Sprintf("%s%s", fieldPrefix, p.Separator)
 				}
 			}
 
@@ -270,3 +316,9 @@ func (p *parser) readDWMetrics(metricType string, dwms interface{}, metrics []te
 func (p *parser) SetTimeFunc(f metric.TimeFunc) {
 	p.timeFunc = f
 }
+
+func init() {
+	parsers.Add("dropwizard", func(defaultMetricName string) parsers.Parser {
+		return NewParser()
+	})
+}
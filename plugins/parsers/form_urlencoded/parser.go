@@ -9,6 +9,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers"
 )
 
 var (
@@ -20,10 +21,22 @@ var (
 type Parser struct {
 	MetricName  string
 	DefaultTags map[string]string
-	TagKeys     []string
+	TagKeys     []string `toml:"form_urlencoded_tag_keys"`
 	AllowedKeys []string
 }
 
+func (p *Parser) Description() string {
+	return "Parse application/x-www-form-urlencoded data"
+}
+
+func (p *Parser) SampleConfig() string {
+	return `
+  ## Array of key names which should be collected as tags.
+  ## By default, keys with string value are ignored if not marked as tags.
+  # form_urlencoded_tag_keys = []
+`
+}
+
 // Parse converts a slice of bytes in "application/x-www-form-urlencoded" format into metrics
 func (p Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
 	buf = bytes.TrimSpace(buf)
@@ -123,3 +136,9 @@ func (p Parser) parseFields(values url.Values) map[string]interface{} {
 
 	return fields
 }
+
+func init() {
+	parsers.Add("form_urlencoded", func(defaultMetricName string) parsers.Parser {
+		return &Parser{MetricName: defaultMetricName}
+	})
+}
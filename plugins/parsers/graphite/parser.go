@@ -13,6 +13,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers"
 )
 
 // Minimum and maximum supported dates for timestamps.
@@ -23,8 +24,8 @@ var (
 
 // Parser encapsulates a Graphite Parser.
 type GraphiteParser struct {
-	Separator      string
-	Templates      []string
+	Separator      string   `toml:"separator"`
+	Templates      []string `toml:"templates"`
 	DefaultTags    map[string]string
 	templateEngine *templating.Engine
 }
@@ -38,11 +39,6 @@ func NewGraphiteParser(
 	templates []string,
 	defaultTags map[string]string,
 ) (*GraphiteParser, error) {
-	var err error
-
-	if separator == "" {
-		separator = DefaultSeparator
-	}
 	p := &GraphiteParser{
 		Separator: separator,
 		Templates: templates,
@@ -51,13 +47,50 @@ func NewGraphiteParser(
 	if defaultTags != nil {
 		p.DefaultTags = defaultTags
 	}
+	return p, p.Init()
+}
+
+// Init sets up the templates.
+func (p *GraphiteParser) Init() error {
+	if p.Separator == "" {
+		p.Separator = DefaultSeparator
+	}
+
+	var err error
 	defaultTemplate, _ := templating.NewDefaultTemplateWithPattern("measurement*")
 	p.templateEngine, err = templating.NewEngine(p.Separator, defaultTemplate, p.Templates)
 
 	if err != nil {
-		return p, fmt.Errorf("exec input parser config is error: %s ", err.Error())
+		return fmt.Errorf("exec input parser config is error: %s ", err.Error())
 	}
-	return p, nil
+	return nil
+}
+
+func (p *GraphiteParser) Description() string {
+	return "Parse the Graphite plaintext protocol"
+}
+
+func (p *GraphiteParser) SampleConfig() string {
+	return `
+  ## This string will be used to join the matched values.
+  # separator = "."
+
+  ## Each template line requires a template pattern. It can have an optional
+  ## filter before the template and separated by spaces. It can also have optional extra
+  ## tags following the template. Multiple tags should be separated by commas and no spaces
+  ## similar to the line protocol format. There can be only one default template.
+  ## Templates support below format:
+  ## 1. filter + template
+  ## 2. filter + template + extra tag(s)
+  ## 3. filter + template with field key
+  ## 4. default template
+  # templates = [
+  #   "*.app env.service.resource.measurement",
+  #   "stats.* .host.measurement* region=eu-east,agent=sensu",
+  #   "stats2.* .host.measurement.field",
+  #   "measurement*"
+  # ]
+`
 }
 
 func (p *GraphiteParser) Parse(buf []byte) ([]telegraf.Metric, error) {
@@ -182,3 +215,9 @@ func (p *GraphiteParser) ApplyTemplate(line string) (string, map[string]string,
 
 	return name, tags, field, err
 }
+
+func init() {
+	parsers.Add("graphite", func(defaultMetricName string) parsers.Parser {
+		return &GraphiteParser{}
+	})
+}
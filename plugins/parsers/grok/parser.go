@@ -13,6 +13,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers"
 	"github.com/vjeantet/grok"
 )
 
@@ -64,14 +65,14 @@ var (
 
 // Parser is the primary struct to handle and grok-patterns defined in the config toml
 type Parser struct {
-	Patterns []string
+	Patterns []string `toml:"grok_patterns"`
 	// namedPatterns is a list of internally-assigned names to the patterns
 	// specified by the user in Patterns.
 	// They will look like:
 	//   GROK_INTERNAL_PATTERN_0, GROK_INTERNAL_PATTERN_1, etc.
-	NamedPatterns      []string
-	CustomPatterns     string
-	CustomPatternFiles []string
+	NamedPatterns      []string `toml:"grok_named_patterns"`
+	CustomPatterns     string   `toml:"grok_custom_patterns"`
+	CustomPatternFiles []string `toml:"grok_custom_pattern_files"`
 	Measurement        string
 	DefaultTags        map[string]string
 
@@ -82,11 +83,11 @@ type Parser struct {
 	// 1. Local             -- interpret based on machine localtime
 	// 2. "America/Chicago" -- Unix TZ values like those found in https://en.wikipedia.org/wiki/List_of_tz_database_time_zones
 	// 3. UTC               -- or blank/unspecified, will return timestamp in UTC
-	Timezone string
+	Timezone string `toml:"grok_timezone"`
 	loc      *time.Location
 
 	// UniqueTimestamp when set to "disable", timestamp will not incremented if there is a duplicate.
-	UniqueTimestamp string
+	UniqueTimestamp string `toml:"grok_unique_timestamp"`
 
 	// typeMap is a map of patterns -> capture name -> modifier,
 	//   ie, {
@@ -124,6 +125,48 @@ type Parser struct {
 	tsModder *tsModder
 }
 
+// Init compiles the patterns.
+func (p *Parser) Init() error {
+	return p.Compile()
+}
+
+func (p *Parser) Description() string {
+	return "Parse lines of text with grok patterns"
+}
+
+func (p *Parser) SampleConfig() string {
+	return `
+  ## This is a list of patterns to check the given log file(s) for.
+  ## Note that adding patterns here increases processing time. The most
+  ## efficient configuration is to have one pattern.
+  ## Other common built-in patterns are:
+  ##   %{COMMON_LOG_FORMAT}   (plain apache & nginx access logs)
+  ##   %{COMBINED_LOG_FORMAT} (access logs + referrer & agent)
+  grok_patterns = ["%{COMBINED_LOG_FORMAT}"]
+
+  ## Full path(s) to custom pattern files.
+  # grok_custom_pattern_files = []
+
+  ## Custom patterns can also be defined here. Put one pattern per line.
+  # grok_custom_patterns = ""
+
+  ## Timezone allows you to provide an override for timestamps that
+  ## don't already include an offset
+  ## e.g. 04/06/2016 12:41:45 data one two 5.43µs
+  ##
+  ## Default: "" which renders UTC
+  ## Options are as follows:
+  ##   1. Local             -- interpret based on machine localtime
+  ##   2. "Canada/Eastern"  -- Unix TZ values like those found in https://en.wikipedia.org/wiki/List_of_tz_database_time_zones
+  ##   3. UTC               -- or blank/unspecified, will return timestamp in UTC
+  # grok_timezone = ""
+
+  ## When set to "disable" timestamp will not incremented if there is a
+  ## duplicate.
+  # grok_unique_timestamp = "auto"
+`
+}
+
 // Compile is a bound method to Parser which will process the options for our parser
 func (p *Parser) Compile() error {
 	p.typeMap = make(map[string]map[string]string)
@@ -549,3 +592,9 @@ func (t *tsModder) tsMod(ts time.Time) time.Time {
 	}
 	return ts.Add(t.incr*t.incrn + t.rollover)
 }
+
+func init() {
+	parsers.Add("grok", func(defaultMetricName string) parsers.Parser {
+		return &Parser{Measurement: defaultMetricName}
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"sync"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/parsers"
 )
 
 const (
@@ -120,6 +121,14 @@ func (p *Parser) SetDefaultTags(tags map[string]string) {
 	p.DefaultTags = tags
 }
 
+func (p *Parser) Description() string {
+	return "Parse InfluxDB line protocol"
+}
+
+func (p *Parser) SampleConfig() string {
+	return ""
+}
+
 func (p *Parser) applyDefaultTags(metrics []telegraf.Metric) {
 	if len(p.DefaultTags) == 0 {
 		return
@@ -133,3 +142,9 @@ func (p *Parser) applyDefaultTags(metrics []telegraf.Metric) {
 		}
 	}
 }
+
+func init() {
+	parsers.Add("influx", func(defaultMetricName string) parsers.Parser {
+		return NewParser(NewMetricHandler())
+	})
+}
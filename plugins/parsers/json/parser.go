@@ -14,27 +14,195 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers"
 )
 
 var (
 	utf8BOM = []byte("\xef\xbb\xbf")
 )
 
+// Array modes, how arrays nested in an object are converted.
+const (
+	// ArrayModeIndex adds the elements as fields keyed by their index.
+	ArrayModeIndex = "index"
+
+	// ArrayModeExpand creates a metric for each element, with the tags of
+	// the object holding the array.
+	ArrayModeExpand = "expand"
+)
+
+// Object selects JSON objects to convert to metrics, with their own name,
+// tag, field and time keys.
+type Object struct {
+	// Query is the GJSON path of the object or array of objects, defaults
+	// to the whole document.
+	Query string `toml:"query"`
+
+	// MetricName is the name of the metrics, defaults to the name of the
+	// plugin.
+	MetricName string `toml:"metric_name"`
+
+	// NameKey is the key of the metric name, overriding the MetricName.
+	NameKey string `toml:"name_key"`
+
+	// TagKeys are the keys added as tags.
+	TagKeys []string `toml:"tag_keys"`
+
+	// StringFields are the keys of strings and booleans added as fields,
+	// all others are ignored.
+	StringFields []string `toml:"string_fields"`
+
+	// TimeKey is the key of the metric time, if empty the current time is
+	// used.
+	TimeKey string `toml:"time_key"`
+
+	// TimeFormat is the format of the TimeKey, either a Go time layout or
+	// one of unix, unix_ms, unix_us or unix_ns.
+	TimeFormat string `toml:"time_format"`
+
+	// Timezone is the location of times without an offset, defaults to UTC.
+	Timezone string `toml:"timezone"`
+}
+
 type JSONParser struct {
 	MetricName     string
-	TagKeys        []string
-	StringFields   []string
-	JSONNameKey    string
-	JSONQuery      string
-	JSONTimeKey    string
-	JSONTimeFormat string
-	JSONTimezone   string
-	DefaultTags    map[string]string
+	TagKeys        []string `toml:"tag_keys"`
+	StringFields   []string `toml:"json_string_fields"`
+	JSONNameKey    string   `toml:"json_name_key"`
+	JSONQuery      string   `toml:"json_query"`
+	JSONTimeKey    string   `toml:"json_time_key"`
+	JSONTimeFormat string   `toml:"json_time_format"`
+	JSONTimezone   string   `toml:"json_timezone"`
+
+	// Separator joins the keys of nested objects and arrays, defaults to an
+	// underscore.
+	Separator string `toml:"json_separator"`
+
+	// MaxDepth is the number of nested objects and arrays flattened into
+	// fields, values nested deeper are ignored.  Zero is unlimited.
+	MaxDepth int `toml:"json_max_depth"`
+
+	// ArrayMode is how nested arrays are converted, ArrayModeIndex or
+	// ArrayModeExpand.  Defaults to ArrayModeIndex.
+	ArrayMode string `toml:"json_array_mode"`
+
+	// Objects select the objects to convert to metrics, when set the
+	// json_query, json_name_key, tag_keys, json_string_fields and json_time
+	// options are not used.
+	Objects []Object `toml:"json_object"`
+
+	DefaultTags map[string]string
 }
 
-func (p *JSONParser) parseArray(buf []byte) ([]telegraf.Metric, error) {
-	metrics := make([]telegraf.Metric, 0)
+func (p *JSONParser) Description() string {
+	return "Parse JSON objects and arrays of objects"
+}
+
+func (p *JSONParser) SampleConfig() string {
+	return `
+  ## Query is a GJSON path that specifies a specific chunk of JSON to be
+  ## parsed, if not specified the whole document will be parsed.
+  ##
+  ## GJSON query paths are described here:
+  ##   https://github.com/tidwall/gjson#path-syntax
+  # json_query = ""
+
+  ## Tag keys is an array of keys that should be added as tags.
+  # tag_keys = []
+
+  ## String fields is an array of keys that should be added as string fields.
+  # json_string_fields = []
+
+  ## Name key is the key to use as the measurement name.
+  # json_name_key = ""
+
+  ## Time key is the key containing the time that should be used to create the
+  ## metric.
+  # json_time_key = ""
+
+  ## Time format is the time layout that should be used to interprete the json_time_key.
+  ## The time must be "unix", "unix_ms", "unix_us", "unix_ns", or a time in the
+  ## "reference time".
+  ##   ex: json_time_format = "2006-01-02T15:04:05Z07:00"
+  # json_time_format = ""
+
+  ## Timezone allows you to provide an override for timestamps that
+  ## don't already include an offset, one of "Local", "UTC" or a Unix TZ
+  ## value such as "America/New_York".  Default: "" which renders UTC.
+  # json_timezone = ""
+
+  ## Separator joining the keys of nested objects and arrays.
+  # json_separator = "_"
+
+  ## Number of nested objects and arrays to flatten into fields, values
+  ## nested deeper are ignored.  Default: 0 which is unlimited.
+  # json_max_depth = 0
+
+  ## How arrays nested in an object are converted, either "index" to add the
+  ## elements as fields keyed by their index, or "expand" to create a metric
+  ## for each element with the tags of the object holding the array.
+  # json_array_mode = "index"
+
+  ## Multiple objects can be selected from the same document, each with its
+  ## own name, tag, field and time keys.  When set the options above
+  ## selecting the object are not used.
+  # [[inputs.file.json_object]]
+  #   query = ""
+  #   metric_name = ""
+  #   name_key = ""
+  #   tag_keys = []
+  #   string_fields = []
+  #   time_key = ""
+  #   time_format = ""
+  #   timezone = ""
+`
+}
+
+// Init checks the options.
+func (p *JSONParser) Init() error {
+	switch p.ArrayMode {
+	case "", ArrayModeIndex, ArrayModeExpand:
+	default:
+		return fmt.Errorf("invalid json_array_mode %q, must be %q or %q",
+			p.ArrayMode, ArrayModeIndex, ArrayModeExpand)
+	}
+	if p.MaxDepth < 0 {
+		return fmt.Errorf("invalid json_max_depth %d", p.MaxDepth)
+	}
+	return nil
+}
+
+// objects returns the selected objects, or the object of the top level
+// options if none are set.
+func (p *JSONParser) objects() []Object {
+	if len(p.Objects) > 0 {
+		return p.Objects
+	}
+	return []Object{{
+		Query:        p.JSONQuery,
+		NameKey:      p.JSONNameKey,
+		TagKeys:      p.TagKeys,
+		StringFields: p.StringFields,
+		TimeKey:      p.JSONTimeKey,
+		TimeFormat:   p.JSONTimeFormat,
+		Timezone:     p.JSONTimezone,
+	}}
+}
+
+func (p *JSONParser) newFlattener() *flattener {
+	separator := p.Separator
+	if separator == "" {
+		separator = "_"
+	}
+	return &flattener{
+		separator: separator,
+		maxDepth:  p.MaxDepth,
+		expand:    p.ArrayMode == ArrayModeExpand,
+		fields:    make(map[string]interface{}),
+	}
+}
 
+func (p *JSONParser) parseArray(metrics []telegraf.Metric, obj Object, buf []byte) ([]telegraf.Metric, error) {
 	var jsonOut []map[string]interface{}
 	err := json.Unmarshal(buf, &jsonOut)
 	if err != nil {
@@ -42,7 +210,7 @@ func (p *JSONParser) parseArray(buf []byte) ([]telegraf.Metric, error) {
 		return nil, err
 	}
 	for _, item := range jsonOut {
-		metrics, err = p.parseObject(metrics, item)
+		metrics, err = p.parseObject(metrics, obj, item)
 		if err != nil {
 			return nil, err
 		}
@@ -50,45 +218,50 @@ func (p *JSONParser) parseArray(buf []byte) ([]telegraf.Metric, error) {
 	return metrics, nil
 }
 
-func (p *JSONParser) parseObject(metrics []telegraf.Metric, jsonOut map[string]interface{}) ([]telegraf.Metric, error) {
+func (p *JSONParser) parseObject(metrics []telegraf.Metric, obj Object, jsonOut map[string]interface{}) ([]telegraf.Metric, error) {
 	tags := make(map[string]string)
 	for k, v := range p.DefaultTags {
 		tags[k] = v
 	}
 
-	f := JSONFlattener{}
-	err := f.FullFlattenJSON("", jsonOut, true, true)
+	f := p.newFlattener()
+	err := f.flatten("", jsonOut, 0)
 	if err != nil {
 		return nil, err
 	}
 
+	name := obj.MetricName
+	if name == "" {
+		name = p.MetricName
+	}
+
 	//checks if json_name_key is set
-	if p.JSONNameKey != "" {
-		switch field := f.Fields[p.JSONNameKey].(type) {
+	if obj.NameKey != "" {
+		switch field := f.fields[obj.NameKey].(type) {
 		case string:
-			p.MetricName = field
+			name = field
 		}
 	}
 
 	//if time key is specified, set it to nTime
 	nTime := time.Now().UTC()
-	if p.JSONTimeKey != "" {
-		if p.JSONTimeFormat == "" {
+	if obj.TimeKey != "" {
+		if obj.TimeFormat == "" {
 			err := fmt.Errorf("use of 'json_time_key' requires 'json_time_format'")
 			return nil, err
 		}
 
-		if f.Fields[p.JSONTimeKey] == nil {
+		if f.fields[obj.TimeKey] == nil {
 			err := fmt.Errorf("JSON time key could not be found")
 			return nil, err
 		}
 
-		nTime, err = internal.ParseTimestampWithLocation(f.Fields[p.JSONTimeKey], p.JSONTimeFormat, p.JSONTimezone)
+		nTime, err = internal.ParseTimestampWithLocation(f.fields[obj.TimeKey], obj.TimeFormat, obj.Timezone)
 		if err != nil {
 			return nil, err
 		}
 
-		delete(f.Fields, p.JSONTimeKey)
+		delete(f.fields, obj.TimeKey)
 
 		//if the year is 0, set to current year
 		if nTime.Year() == 0 {
@@ -96,20 +269,56 @@ func (p *JSONParser) parseObject(metrics []telegraf.Metric, jsonOut map[string]i
 		}
 	}
 
-	tags, nFields := p.switchFieldToTag(tags, f.Fields)
-	metric, err := metric.New(p.MetricName, tags, nFields, nTime)
-	if err != nil {
-		return nil, err
+	return p.appendMetrics(metrics, obj, name, tags, nTime, f)
+}
+
+// appendMetrics appends the metric of the flattened fields followed by the
+// metrics of the elements of the expanded arrays, which inherit the tags of
+// the metric.  The metric is left out if it has no fields but the arrays do.
+func (p *JSONParser) appendMetrics(
+	metrics []telegraf.Metric,
+	obj Object,
+	name string,
+	tags map[string]string,
+	tm time.Time,
+	f *flattener,
+) ([]telegraf.Metric, error) {
+	tags, nFields := p.switchFieldToTag(obj, tags, f.fields)
+	if len(nFields) > 0 || len(f.arrays) == 0 {
+		metric, err := metric.New(name, tags, nFields, tm)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, metric)
+	}
+
+	for _, array := range f.arrays {
+		for _, value := range array.values {
+			elem := p.newFlattener()
+			err := elem.flatten(array.key, value, array.depth+1)
+			if err != nil {
+				return nil, err
+			}
+
+			elemTags := make(map[string]string, len(tags))
+			for k, v := range tags {
+				elemTags[k] = v
+			}
+			metrics, err = p.appendMetrics(metrics, obj, name, elemTags, tm, elem)
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
-	return append(metrics, metric), nil
+	return metrics, nil
 }
 
 //will take in field map with strings and bools,
 //search for TagKeys that match fieldnames and add them to tags
 //will delete any strings/bools that shouldn't be fields
 //assumes that any non-numeric values in TagKeys should be displayed as tags
-func (p *JSONParser) switchFieldToTag(tags map[string]string, fields map[string]interface{}) (map[string]string, map[string]interface{}) {
-	for _, name := range p.TagKeys {
+func (p *JSONParser) switchFieldToTag(obj Object, tags map[string]string, fields map[string]interface{}) (map[string]string, map[string]interface{}) {
+	for _, name := range obj.TagKeys {
 		//switch any fields in tagkeys into tags
 		if fields[name] == nil {
 			continue
@@ -133,7 +342,7 @@ func (p *JSONParser) switchFieldToTag(tags map[string]string, fields map[string]
 	for k := range fields {
 		//check if field is in StringFields
 		sField := false
-		for _, v := range p.StringFields {
+		for _, v := range obj.StringFields {
 			if v == k {
 				sField = true
 			}
@@ -153,8 +362,22 @@ func (p *JSONParser) switchFieldToTag(tags map[string]string, fields map[string]
 }
 
 func (p *JSONParser) Parse(buf []byte) ([]telegraf.Metric, error) {
-	if p.JSONQuery != "" {
-		result := gjson.GetBytes(buf, p.JSONQuery)
+	metrics := make([]telegraf.Metric, 0)
+	for _, obj := range p.objects() {
+		var err error
+		metrics, err = p.parseSelection(metrics, obj, buf)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return metrics, nil
+}
+
+// parseSelection appends the metrics of the objects selected by the query of
+// the object.
+func (p *JSONParser) parseSelection(metrics []telegraf.Metric, obj Object, buf []byte) ([]telegraf.Metric, error) {
+	if obj.Query != "" {
+		result := gjson.GetBytes(buf, obj.Query)
 		buf = []byte(result.Raw)
 		if !result.IsArray() && !result.IsObject() {
 			err := fmt.Errorf("E! Query path must lead to a JSON object or array of objects, but lead to: %v", result.Type)
@@ -165,20 +388,19 @@ func (p *JSONParser) Parse(buf []byte) ([]telegraf.Metric, error) {
 	buf = bytes.TrimSpace(buf)
 	buf = bytes.TrimPrefix(buf, utf8BOM)
 	if len(buf) == 0 {
-		return make([]telegraf.Metric, 0), nil
+		return metrics, nil
 	}
 
 	if !isarray(buf) {
-		metrics := make([]telegraf.Metric, 0)
 		var jsonOut map[string]interface{}
 		err := json.Unmarshal(buf, &jsonOut)
 		if err != nil {
 			err = fmt.Errorf("unable to parse out as JSON, %s", err)
 			return nil, err
 		}
-		return p.parseObject(metrics, jsonOut)
+		return p.parseObject(metrics, obj, jsonOut)
 	}
-	return p.parseArray(buf)
+	return p.parseArray(metrics, obj, buf)
 }
 
 func (p *JSONParser) ParseLine(line string) (telegraf.Metric, error) {
@@ -264,6 +486,72 @@ func (f *JSONFlattener) FullFlattenJSON(
 	return nil
 }
 
+// flattener flattens the values of an object into fields, keyed by the path
+// of each value.  Arrays are either flattened by the index of the elements
+// or collected to be expanded into metrics of their own.
+type flattener struct {
+	separator string
+	maxDepth  int
+	expand    bool
+
+	fields map[string]interface{}
+	arrays []array
+}
+
+// array is a nested array collected for expansion.
+type array struct {
+	key    string
+	depth  int
+	values []interface{}
+}
+
+// join appends the key to the prefix, separators at the ends of the keys are
+// trimmed so keys such as "_id" keep their name.
+func (f *flattener) join(prefix, key string) string {
+	if prefix == "" {
+		return strings.Trim(key, f.separator)
+	}
+	return strings.Trim(prefix+f.separator+key, f.separator)
+}
+
+// flatten adds the value with the key, depth is the number of objects and
+// arrays the value is nested in.
+func (f *flattener) flatten(key string, v interface{}, depth int) error {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if f.maxDepth > 0 && depth > f.maxDepth {
+			return nil
+		}
+		for k, v := range t {
+			err := f.flatten(f.join(key, k), v, depth+1)
+			if err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if f.maxDepth > 0 && depth > f.maxDepth {
+			return nil
+		}
+		if f.expand {
+			f.arrays = append(f.arrays, array{key: key, depth: depth, values: t})
+			return nil
+		}
+		for i, v := range t {
+			err := f.flatten(f.join(key, strconv.Itoa(i)), v, depth+1)
+			if err != nil {
+				return err
+			}
+		}
+	case float64, string, bool:
+		f.fields[key] = t
+	case nil:
+	default:
+		return fmt.Errorf("JSON Flattener: got unexpected type %T with value %v (%s)",
+			t, t, key)
+	}
+	return nil
+}
+
 func isarray(buf []byte) bool {
 	ia := bytes.IndexByte(buf, '[')
 	ib := bytes.IndexByte(buf, '{')
@@ -273,3 +561,9 @@ func isarray(buf []byte) bool {
 		return false
 	}
 }
+
+func init() {
+	parsers.Add("json", func(defaultMetricName string) parsers.Parser {
+		return &JSONParser{MetricName: defaultMetricName}
+	})
+}
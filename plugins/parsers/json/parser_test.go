@@ -768,3 +768,138 @@ func TestTimeKeyDelete(t *testing.T) {
 
 	testutil.RequireMetricsEqual(t, expected, metrics)
 }
+
+func TestSeparator(t *testing.T) {
+	parser := JSONParser{
+		MetricName: "json",
+		Separator:  ".",
+	}
+
+	metrics, err := parser.Parse([]byte(`{"a": {"b": 1, "c": [2, 3]}}`))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	require.Equal(t, map[string]interface{}{
+		"a.b":   1.0,
+		"a.c.0": 2.0,
+		"a.c.1": 3.0,
+	}, metrics[0].Fields())
+}
+
+func TestMaxDepth(t *testing.T) {
+	parser := JSONParser{
+		MetricName: "json",
+		MaxDepth:   1,
+	}
+
+	metrics, err := parser.Parse([]byte(`{"a": 1, "b": {"c": 2, "d": {"e": 3}, "f": [4]}}`))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	require.Equal(t, map[string]interface{}{
+		"a":   1.0,
+		"b_c": 2.0,
+	}, metrics[0].Fields())
+}
+
+func TestInvalidArrayMode(t *testing.T) {
+	parser := JSONParser{
+		MetricName: "json",
+		ArrayMode:  "explode",
+	}
+	require.Error(t, parser.Init())
+}
+
+func TestMultipleObjects(t *testing.T) {
+	data := `{
+		"host": {"name": "server01", "uptime": 1234},
+		"disks": [
+			{"path": "/", "used": 10, "ts": 1541183052},
+			{"path": "/home", "used": 20, "ts": 1541183052}
+		]
+	}`
+
+	parser := JSONParser{
+		MetricName: "json",
+		Objects: []Object{
+			{
+				Query:   "host",
+				TagKeys: []string{"name"},
+			},
+			{
+				Query:      "disks",
+				MetricName: "disk",
+				TagKeys:    []string{"path"},
+				TimeKey:    "ts",
+				TimeFormat: "unix",
+			},
+		},
+	}
+
+	metrics, err := parser.Parse([]byte(data))
+	require.NoError(t, err)
+	require.Len(t, metrics, 3)
+
+	require.Equal(t, "json", metrics[0].Name())
+	require.Equal(t, map[string]string{"name": "server01"}, metrics[0].Tags())
+	require.Equal(t, map[string]interface{}{"uptime": 1234.0}, metrics[0].Fields())
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("disk",
+			map[string]string{"path": "/"},
+			map[string]interface{}{"used": 10.0},
+			time.Unix(1541183052, 0)),
+		testutil.MustMetric("disk",
+			map[string]string{"path": "/home"},
+			map[string]interface{}{"used": 20.0},
+			time.Unix(1541183052, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics[1:])
+}
+
+func TestArrayExpand(t *testing.T) {
+	data := `{
+		"name": "cluster01",
+		"nodes": [
+			{
+				"id": "node01",
+				"load": 0.5,
+				"volumes": [{"id": "vol01", "free": 100}, {"id": "vol02", "free": 200}]
+			},
+			{
+				"id": "node02",
+				"load": 0.25,
+				"volumes": []
+			}
+		]
+	}`
+
+	parser := JSONParser{
+		MetricName: "json",
+		TagKeys:    []string{"name", "nodes_id", "nodes_volumes_id"},
+		ArrayMode:  ArrayModeExpand,
+	}
+	require.NoError(t, parser.Init())
+
+	metrics, err := parser.Parse([]byte(data))
+	require.NoError(t, err)
+
+	now := time.Unix(0, 0)
+	expected := []telegraf.Metric{
+		testutil.MustMetric("json",
+			map[string]string{"name": "cluster01", "nodes_id": "node01"},
+			map[string]interface{}{"nodes_load": 0.5},
+			now),
+		testutil.MustMetric("json",
+			map[string]string{"name": "cluster01", "nodes_id": "node01", "nodes_volumes_id": "vol01"},
+			map[string]interface{}{"nodes_volumes_free": 100.0},
+			now),
+		testutil.MustMetric("json",
+			map[string]string{"name": "cluster01", "nodes_id": "node01", "nodes_volumes_id": "vol02"},
+			map[string]interface{}{"nodes_volumes_free": 200.0},
+			now),
+		testutil.MustMetric("json",
+			map[string]string{"name": "cluster01", "nodes_id": "node02"},
+			map[string]interface{}{"nodes_load": 0.25},
+			now),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics, testutil.IgnoreTime())
+}
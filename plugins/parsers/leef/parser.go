@@ -0,0 +1,154 @@
+package leef
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/syslog"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+// headerTags are the tags of the header, following the version.
+var headerTags = []string{
+	"device_vendor", "device_product", "device_version", "event_id",
+}
+
+// Parser parses IBM QRadar Log Event Extended Format (LEEF) events, one per
+// line.  The events may be preceded by a BSD syslog header.
+type Parser struct {
+	DefaultTags map[string]string
+
+	now func() time.Time
+}
+
+func (p *Parser) Description() string {
+	return "Parse Log Event Extended Format (LEEF) events"
+}
+
+func (p *Parser) SampleConfig() string {
+	return ""
+}
+
+// Parse parses the events of each non-empty line.
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	metrics := make([]telegraf.Metric, 0)
+	for _, line := range bytes.Split(buf, []byte("\n")) {
+		line = bytes.TrimRight(line, "\r")
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		m, err := p.ParseLine(string(line))
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+// ParseLine parses a single event.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	now := time.Now
+	if p.now != nil {
+		now = p.now
+	}
+	t := now()
+
+	start := strings.Index(line, "LEEF:")
+	if start < 0 {
+		return nil, fmt.Errorf("not a LEEF event, missing the LEEF: prefix")
+	}
+
+	tags := make(map[string]string)
+	fields := make(map[string]interface{})
+
+	syslog.AddHeaderTags(tags, line[:start], t)
+
+	event := line[start+len("LEEF:"):]
+	i := strings.IndexByte(event, '|')
+	if i < 0 {
+		return nil, fmt.Errorf("expecting %d header fields, got 0", len(headerTags)+1)
+	}
+	version := event[:i]
+	fields["version"] = version
+
+	// Version 2 has the delimiter of the attributes in the header.
+	n := len(headerTags) + 1
+	if strings.HasPrefix(version, "2") {
+		n++
+	}
+	parts := strings.SplitN(event[i+1:], "|", n)
+	if len(parts) != n {
+		return nil, fmt.Errorf("expecting %d header fields, got %d", n, len(parts)+1)
+	}
+
+	for i, key := range headerTags {
+		if parts[i] != "" {
+			tags[key] = parts[i]
+		}
+	}
+
+	delimiter := "\t"
+	if n > len(headerTags)+1 {
+		d, err := parseDelimiter(parts[len(headerTags)])
+		if err != nil {
+			return nil, err
+		}
+		delimiter = d
+	}
+
+	for _, attr := range strings.Split(parts[len(parts)-1], delimiter) {
+		kv := strings.SplitN(attr, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			continue
+		}
+		key, value := strings.TrimSpace(kv[0]), kv[1]
+		if key == "sev" {
+			tags["severity"] = value
+			continue
+		}
+		fields[key] = syslog.EventFieldValue(value)
+	}
+
+	for k, v := range p.DefaultTags {
+		if _, ok := tags[k]; !ok {
+			tags[k] = v
+		}
+	}
+	return metric.New("leef", tags, fields, t)
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+// parseDelimiter returns the attribute delimiter of a version 2 header,
+// either a single character or its hex code prefixed by "x" or "0x".  An
+// empty delimiter is a tab.
+func parseDelimiter(s string) (string, error) {
+	switch {
+	case s == "":
+		return "\t", nil
+	case len(s) == 1:
+		return s, nil
+	}
+
+	lower := strings.ToLower(s)
+	hex := strings.TrimPrefix(strings.TrimPrefix(lower, "0x"), "x")
+	code, err := strconv.ParseUint(hex, 16, 8)
+	if err != nil || hex == lower {
+		return "", fmt.Errorf("invalid LEEF delimiter %q", s)
+	}
+	return string(rune(code)), nil
+}
+
+func init() {
+	parsers.Add("leef", func(defaultMetricName string) parsers.Parser {
+		return &Parser{}
+	})
+}
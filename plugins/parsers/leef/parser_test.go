@@ -0,0 +1,92 @@
+package leef
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var defaultTime = time.Unix(1557248400, 0)
+
+func TestParse(t *testing.T) {
+	parser := &Parser{
+		now: func() time.Time {
+			return defaultTime
+		},
+	}
+
+	metrics, err := parser.Parse([]byte(
+		"<13>Feb  5 22:14:15 fw01 LEEF:1.0|Microsoft|MSExchange|4.0 SP1|15345|src=192.0.2.0\tdst=172.50.123.1\tsev=5\tcat=anomaly\tdstPort=443\n" +
+			"LEEF:2.0|Lancope|StealthWatch|1.0|41|^|src=10.0.1.8^dst=10.0.0.5^proto=6\n" +
+			"LEEF:2.0|Vendor|Product|1.0|42|x7C|a=1|b=c\n"))
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"leef",
+			map[string]string{
+				"hostname":       "fw01",
+				"device_vendor":  "Microsoft",
+				"device_product": "MSExchange",
+				"device_version": "4.0 SP1",
+				"event_id":       "15345",
+				"severity":       "5",
+			},
+			map[string]interface{}{
+				"version": "1.0",
+				"src":     "192.0.2.0",
+				"dst":     "172.50.123.1",
+				"cat":     "anomaly",
+				"dstPort": int64(443),
+			},
+			defaultTime,
+		),
+		testutil.MustMetric(
+			"leef",
+			map[string]string{
+				"device_vendor":  "Lancope",
+				"device_product": "StealthWatch",
+				"device_version": "1.0",
+				"event_id":       "41",
+			},
+			map[string]interface{}{
+				"version": "2.0",
+				"src":     "10.0.1.8",
+				"dst":     "10.0.0.5",
+				"proto":   int64(6),
+			},
+			defaultTime,
+		),
+		testutil.MustMetric(
+			"leef",
+			map[string]string{
+				"device_vendor":  "Vendor",
+				"device_product": "Product",
+				"device_version": "1.0",
+				"event_id":       "42",
+			},
+			map[string]interface{}{
+				"version": "2.0",
+				"a":       int64(1),
+				"b":       "c",
+			},
+			defaultTime,
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestParseInvalid(t *testing.T) {
+	parser := &Parser{}
+	for _, line := range []string{
+		"not leef",
+		"LEEF:1.0|Vendor|Product",
+		"LEEF:2.0|Vendor|Product|1.0|42|09|a=1",
+	} {
+		_, err := parser.ParseLine(line)
+		require.Error(t, err, line)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/go-logfmt/logfmt"
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers"
 )
 
 var (
@@ -31,6 +32,14 @@ func NewParser(metricName string, defaultTags map[string]string) *Parser {
 	}
 }
 
+func (p *Parser) Description() string {
+	return "Parse logfmt key=value pairs"
+}
+
+func (p *Parser) SampleConfig() string {
+	return ""
+}
+
 // Parse converts a slice of bytes in logfmt format to metrics.
 func (p *Parser) Parse(b []byte) ([]telegraf.Metric, error) {
 	reader := bytes.NewReader(b)
@@ -109,3 +118,9 @@ func (p *Parser) applyDefaultTags(metrics []telegraf.Metric) {
 		}
 	}
 }
+
+func init() {
+	parsers.Add("logfmt", func(defaultMetricName string) parsers.Parser {
+		return NewParser(defaultMetricName, nil)
+	})
+}
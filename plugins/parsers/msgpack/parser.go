@@ -0,0 +1,252 @@
+package msgpack
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/tinylib/msgp/msgp"
+)
+
+// timestampExtension is the MessagePack extension type of timestamps.
+const timestampExtension = -1
+
+// Parser parses metrics serialized by the msgpack serializer, each a map
+// holding the name, time, tags, fields and value type of the metric.
+type Parser struct {
+	DefaultTags map[string]string
+}
+
+// Parse parses one or more consecutive metrics.
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	metrics := make([]telegraf.Metric, 0)
+	for len(buf) > 0 {
+		var m telegraf.Metric
+		var err error
+		m, buf, err = p.readMetric(buf)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(metrics) {
+	case 0:
+		return nil, nil
+	case 1:
+		return metrics[0], nil
+	default:
+		return nil, fmt.Errorf("cannot parse line with multiple (%d) metrics", len(metrics))
+	}
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+func (p *Parser) Description() string {
+	return "Parse metrics serialized by the msgpack serializer"
+}
+
+func (p *Parser) SampleConfig() string {
+	return ""
+}
+
+// Split is a bufio.SplitFunc returning one serialized metric at a time, used
+// to read metrics from streams as they are not delimited by newlines.
+func (p *Parser) Split(data []byte, atEOF bool) (int, []byte, error) {
+	if len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	rest, err := msgp.Skip(data)
+	if err == msgp.ErrShortBytes && !atEOF {
+		return 0, nil, nil
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	n := len(data) - len(rest)
+	return n, data[:n], nil
+}
+
+func (p *Parser) readMetric(buf []byte) (telegraf.Metric, []byte, error) {
+	size, buf, err := msgp.ReadMapHeaderBytes(buf)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var name string
+	var tm time.Time
+	tags := make(map[string]string)
+	fields := make(map[string]interface{})
+	tp := telegraf.Untyped
+	for i := uint32(0); i < size; i++ {
+		var key string
+		key, buf, err = msgp.ReadStringBytes(buf)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch key {
+		case "name":
+			name, buf, err = msgp.ReadStringBytes(buf)
+		case "time":
+			tm, buf, err = readTime(buf)
+		case "tags":
+			buf, err = readTags(buf, tags)
+		case "fields":
+			buf, err = readFields(buf, fields)
+		case "type":
+			var s string
+			s, buf, err = msgp.ReadStringBytes(buf)
+			if err == nil {
+				tp, err = valueType(s)
+			}
+		default:
+			buf, err = msgp.Skip(buf)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid %s: %v", key, err)
+		}
+	}
+
+	if name == "" {
+		return nil, nil, fmt.Errorf("metric has no name")
+	}
+	if tm.IsZero() {
+		tm = time.Now()
+	}
+	for k, v := range p.DefaultTags {
+		if _, ok := tags[k]; !ok {
+			tags[k] = v
+		}
+	}
+
+	m, err := metric.New(name, tags, fields, tm, tp)
+	if err != nil {
+		return nil, nil, err
+	}
+	return m, buf, nil
+}
+
+// readTime reads a timestamp extension in any of its 32, 64 or 96 bit
+// formats.
+func readTime(buf []byte) (time.Time, []byte, error) {
+	ext := &msgp.RawExtension{Type: timestampExtension}
+	buf, err := msgp.ReadExtensionBytes(buf, ext)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+
+	b := ext.Data
+	switch len(b) {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(b)), 0), buf, nil
+	case 8:
+		v := binary.BigEndian.Uint64(b)
+		return time.Unix(int64(v&(1<<34-1)), int64(v>>34)), buf, nil
+	case 12:
+		nanos := binary.BigEndian.Uint32(b[0:4])
+		seconds := binary.BigEndian.Uint64(b[4:12])
+		return time.Unix(int64(seconds), int64(nanos)), buf, nil
+	default:
+		return time.Time{}, nil, fmt.Errorf("invalid timestamp length %d", len(b))
+	}
+}
+
+func readTags(buf []byte, tags map[string]string) ([]byte, error) {
+	size, buf, err := msgp.ReadMapHeaderBytes(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := uint32(0); i < size; i++ {
+		var key, value string
+		key, buf, err = msgp.ReadStringBytes(buf)
+		if err != nil {
+			return nil, err
+		}
+		value, buf, err = msgp.ReadStringBytes(buf)
+		if err != nil {
+			return nil, err
+		}
+		tags[key] = value
+	}
+	return buf, nil
+}
+
+func readFields(buf []byte, fields map[string]interface{}) ([]byte, error) {
+	size, buf, err := msgp.ReadMapHeaderBytes(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := uint32(0); i < size; i++ {
+		var key string
+		key, buf, err = msgp.ReadStringBytes(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		var value interface{}
+		switch t := msgp.NextType(buf); t {
+		case msgp.IntType:
+			value, buf, err = msgp.ReadInt64Bytes(buf)
+		case msgp.UintType:
+			value, buf, err = msgp.ReadUint64Bytes(buf)
+		case msgp.Float64Type:
+			value, buf, err = msgp.ReadFloat64Bytes(buf)
+		case msgp.Float32Type:
+			var f float32
+			f, buf, err = msgp.ReadFloat32Bytes(buf)
+			value = float64(f)
+		case msgp.BoolType:
+			value, buf, err = msgp.ReadBoolBytes(buf)
+		case msgp.StrType:
+			value, buf, err = msgp.ReadStringBytes(buf)
+		default:
+			return nil, fmt.Errorf("unsupported type %s of field %q", t, key)
+		}
+		if err != nil {
+			return nil, err
+		}
+		fields[key] = value
+	}
+	return buf, nil
+}
+
+func valueType(name string) (telegraf.ValueType, error) {
+	switch name {
+	case "counter":
+		return telegraf.Counter, nil
+	case "gauge":
+		return telegraf.Gauge, nil
+	case "summary":
+		return telegraf.Summary, nil
+	case "histogram":
+		return telegraf.Histogram, nil
+	case "untyped":
+		return telegraf.Untyped, nil
+	default:
+		return telegraf.Untyped, fmt.Errorf("unknown value type %q", name)
+	}
+}
+
+func init() {
+	parsers.Add("msgpack", func(defaultMetricName string) parsers.Parser {
+		return &Parser{}
+	})
+}
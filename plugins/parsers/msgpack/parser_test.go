@@ -0,0 +1,188 @@
+package msgpack
+
+import (
+	"bufio"
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/serializers/msgpack"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestRoundTrip(t *testing.T) {
+	metrics := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "server01", "cpu": "cpu0"},
+			map[string]interface{}{
+				"int":      int64(-5),
+				"small":    int64(5),
+				"uint":     uint64(5),
+				"uint_max": uint64(math.MaxUint64),
+				"float":    1.5,
+				"bool":     true,
+				"string":   "foo\nbar",
+			},
+			time.Unix(1596294243, 123456789)),
+		testutil.MustMetric("requests",
+			map[string]string{},
+			map[string]interface{}{"count": uint64(10)},
+			time.Unix(-1, 5),
+			telegraf.Counter),
+		testutil.MustMetric("latency",
+			map[string]string{},
+			map[string]interface{}{"p50": 0.25},
+			time.Unix(0, 0),
+			telegraf.Summary),
+	}
+
+	buf, err := msgpack.NewSerializer().SerializeBatch(metrics)
+	require.NoError(t, err)
+
+	parser := &Parser{}
+	actual, err := parser.Parse(buf)
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t, metrics, actual)
+}
+
+func TestDefaultTags(t *testing.T) {
+	m := testutil.MustMetric("cpu",
+		map[string]string{"host": "server01"},
+		map[string]interface{}{"value": 42.0},
+		time.Unix(0, 0))
+	buf, err := msgpack.NewSerializer().Serialize(m)
+	require.NoError(t, err)
+
+	parser := &Parser{}
+	parser.SetDefaultTags(map[string]string{"host": "default", "dc": "us-east-1"})
+	actual, err := parser.ParseLine(string(buf))
+	require.NoError(t, err)
+	testutil.RequireMetricEqual(t,
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "server01", "dc": "us-east-1"},
+			map[string]interface{}{"value": 42.0},
+			time.Unix(0, 0)),
+		actual)
+}
+
+func TestTimestampFormats(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		expected time.Time
+	}{
+		{
+			name:     "32 bit",
+			data:     []byte{0, 0, 0, 10},
+			expected: time.Unix(10, 0),
+		},
+		{
+			name:     "64 bit",
+			data:     []byte{0, 0, 0, 4, 0, 0, 0, 10},
+			expected: time.Unix(10, 1),
+		},
+		{
+			name:     "96 bit",
+			data:     []byte{0, 0, 0, 1, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+			expected: time.Unix(-1, 1),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := msgp.AppendMapHeader(nil, 3)
+			buf = msgp.AppendString(buf, "name")
+			buf = msgp.AppendString(buf, "test")
+			buf = msgp.AppendString(buf, "time")
+			buf, err := msgp.AppendExtension(buf, &msgp.RawExtension{Type: -1, Data: tt.data})
+			require.NoError(t, err)
+			buf = msgp.AppendString(buf, "fields")
+			buf = msgp.AppendMapHeader(buf, 1)
+			buf = msgp.AppendString(buf, "value")
+			buf = msgp.AppendFloat32(buf, 1.5)
+
+			m, err := (&Parser{}).ParseLine(string(buf))
+			require.NoError(t, err)
+			require.True(t, tt.expected.Equal(m.Time()), "got %v", m.Time())
+			require.Equal(t, map[string]interface{}{"value": 1.5}, m.Fields())
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	valid, err := msgpack.NewSerializer().Serialize(testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{"value": 42.0},
+		time.Unix(0, 0)))
+	require.NoError(t, err)
+
+	noName := msgp.AppendMapHeader(nil, 1)
+	noName = msgp.AppendString(noName, "fields")
+	noName = msgp.AppendMapHeader(noName, 1)
+	noName = msgp.AppendString(noName, "value")
+	noName = msgp.AppendFloat64(noName, 1)
+
+	badType := msgp.AppendMapHeader(nil, 2)
+	badType = msgp.AppendString(badType, "name")
+	badType = msgp.AppendString(badType, "cpu")
+	badType = msgp.AppendString(badType, "type")
+	badType = msgp.AppendString(badType, "meter")
+
+	badField := msgp.AppendMapHeader(nil, 2)
+	badField = msgp.AppendString(badField, "name")
+	badField = msgp.AppendString(badField, "cpu")
+	badField = msgp.AppendString(badField, "fields")
+	badField = msgp.AppendMapHeader(badField, 1)
+	badField = msgp.AppendString(badField, "value")
+	badField = msgp.AppendNil(badField)
+
+	for name, buf := range map[string][]byte{
+		"truncated":  valid[:len(valid)-1],
+		"not a map":  msgp.AppendString(nil, "cpu"),
+		"no name":    noName,
+		"value type": badType,
+		"field type": badField,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := (&Parser{}).Parse(buf)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	metrics := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{},
+			map[string]interface{}{"value": int64('\n')},
+			time.Unix(0, 0)),
+		testutil.MustMetric("mem",
+			map[string]string{},
+			map[string]interface{}{"value": "\n"},
+			time.Unix(0, 0)),
+	}
+	buf, err := msgpack.NewSerializer().SerializeBatch(metrics)
+	require.NoError(t, err)
+
+	parser := &Parser{}
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	scanner.Split(parser.Split)
+
+	var actual []telegraf.Metric
+	for scanner.Scan() {
+		m, err := parser.ParseLine(scanner.Text())
+		require.NoError(t, err)
+		actual = append(actual, m)
+	}
+	require.NoError(t, scanner.Err())
+	testutil.RequireMetricsEqual(t, metrics, actual)
+
+	scanner = bufio.NewScanner(bytes.NewReader(buf[:len(buf)-1]))
+	scanner.Split(parser.Split)
+	require.True(t, scanner.Scan())
+	require.False(t, scanner.Scan())
+	require.Error(t, scanner.Err())
+}
@@ -15,6 +15,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers"
 )
 
 // getExitCode get the exit code from an error value which is the result
@@ -94,6 +95,14 @@ func (p *NagiosParser) SetDefaultTags(tags map[string]string) {
 	p.DefaultTags = tags
 }
 
+func (p *NagiosParser) Description() string {
+	return "Parse the output of Nagios plugins"
+}
+
+func (p *NagiosParser) SampleConfig() string {
+	return ""
+}
+
 func (p *NagiosParser) Parse(buf []byte) ([]telegraf.Metric, error) {
 	ts := time.Now().UTC()
 
@@ -301,3 +310,9 @@ func parseThreshold(threshold string) (min float64, max float64, err error) {
 
 	return
 }
+
+func init() {
+	parsers.Add("nagios", func(defaultMetricName string) parsers.Parser {
+		return &NagiosParser{MetricName: defaultMetricName}
+	})
+}
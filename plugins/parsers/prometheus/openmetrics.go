@@ -0,0 +1,385 @@
+package prometheus
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// openMetricsType is the content type of the OpenMetrics text format.
+const openMetricsType = "application/openmetrics-text"
+
+var eofMarker = []byte("# EOF")
+
+// isOpenMetrics returns true if the body ends with the "# EOF" marker that
+// terminates OpenMetrics exposition.
+func isOpenMetrics(buf []byte) bool {
+	return bytes.HasSuffix(bytes.TrimRight(buf, " \r\n"), eofMarker)
+}
+
+// omFamily is a metric family declared by a "# TYPE" line.
+type omFamily struct {
+	name string
+	typ  string
+}
+
+// omGroup collects the samples of a family with the same labels and time
+// into the fields of one metric.
+type omGroup struct {
+	name   string
+	typ    telegraf.ValueType
+	tags   map[string]string
+	fields map[string]interface{}
+	time   time.Time
+}
+
+// openMetricsParser parses the OpenMetrics text format.  Samples are grouped
+// into the same metrics the Prometheus text format produces, the "_created"
+// samples are added as a "created" field and exemplars and units are
+// ignored.
+type openMetricsParser struct {
+	defaultTags map[string]string
+	now         time.Time
+
+	families map[string]*omFamily
+	groups   []*omGroup
+	index    map[string]*omGroup
+}
+
+func (p *Parser) parseOpenMetrics(buf []byte) ([]telegraf.Metric, error) {
+	op := &openMetricsParser{
+		defaultTags: p.DefaultTags,
+		now:         time.Now(),
+		families:    make(map[string]*omFamily),
+		index:       make(map[string]*omGroup),
+	}
+
+	eof := false
+	for i, b := range bytes.Split(buf, []byte("\n")) {
+		lineno := i + 1
+		line := strings.TrimRight(string(b), "\r")
+		if eof {
+			if strings.TrimSpace(line) != "" {
+				return nil, fmt.Errorf("line %d: unexpected data after # EOF", lineno)
+			}
+			continue
+		}
+
+		var err error
+		switch {
+		case line == "# EOF":
+			eof = true
+		case strings.HasPrefix(line, "#"):
+			err = op.parseDescriptor(line)
+		case strings.TrimSpace(line) == "":
+		default:
+			err = op.parseSample(line)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", lineno, err)
+		}
+	}
+
+	metrics := make([]telegraf.Metric, 0, len(op.groups))
+	for _, g := range op.groups {
+		if len(g.fields) == 0 {
+			continue
+		}
+		m, err := metric.New(g.name, g.tags, g.fields, g.time, g.typ)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+// parseDescriptor parses the "# TYPE", "# HELP" and "# UNIT" lines, only the
+// types are used.
+func (op *openMetricsParser) parseDescriptor(line string) error {
+	parts := strings.SplitN(line, " ", 4)
+	if len(parts) < 3 || parts[0] != "#" {
+		return nil
+	}
+
+	switch parts[1] {
+	case "TYPE":
+		if len(parts) != 4 {
+			return fmt.Errorf("invalid TYPE line %q", line)
+		}
+		name, typ := parts[2], parts[3]
+		switch typ {
+		case "counter", "gauge", "histogram", "gaugehistogram", "summary",
+			"stateset", "info", "unknown":
+		default:
+			return fmt.Errorf("unknown metric type %q", typ)
+		}
+		op.families[name] = &omFamily{name: name, typ: typ}
+	}
+	return nil
+}
+
+// familySuffixes are the suffixes of the sample names of each type.
+var familySuffixes = map[string][]string{
+	"counter":        {"_total", "_created"},
+	"histogram":      {"_bucket", "_count", "_sum", "_created"},
+	"gaugehistogram": {"_gbucket", "_gcount", "_gsum"},
+	"summary":        {"_count", "_sum", "_created"},
+	"info":           {"_info"},
+}
+
+// family returns the family of the sample name and the suffix of the sample
+// name, samples without a declared family are unknown.
+func (op *openMetricsParser) family(name string) (*omFamily, string) {
+	if fam, ok := op.families[name]; ok {
+		return fam, ""
+	}
+	for i := strings.LastIndexByte(name, '_'); i > 0; i = strings.LastIndexByte(name[:i], '_') {
+		fam, ok := op.families[name[:i]]
+		if !ok {
+			continue
+		}
+		for _, suffix := range familySuffixes[fam.typ] {
+			if suffix == name[i:] {
+				return fam, suffix
+			}
+		}
+	}
+	return &omFamily{name: name, typ: "unknown"}, ""
+}
+
+func (op *openMetricsParser) parseSample(line string) error {
+	name, labels, value, ts, err := parseSampleLine(line)
+	if err != nil {
+		return err
+	}
+
+	fam, suffix := op.family(name)
+
+	// Field key of the sample and the name and type of the metric.
+	var key string
+	metricName := fam.name
+	var typ telegraf.ValueType
+	switch fam.typ {
+	case "counter":
+		metricName = fam.name + "_total"
+		typ = telegraf.Counter
+		key = "counter"
+		if suffix == "_created" {
+			key = "created"
+		}
+	case "gauge", "stateset":
+		typ = telegraf.Gauge
+		key = "gauge"
+	case "info":
+		metricName = name
+		typ = telegraf.Gauge
+		key = "gauge"
+	case "histogram", "gaugehistogram":
+		typ = telegraf.Histogram
+		switch suffix {
+		case "_bucket", "_gbucket":
+			le, ok := labels["le"]
+			if !ok {
+				return fmt.Errorf("bucket %q without le label", name)
+			}
+			bound, err := strconv.ParseFloat(le, 64)
+			if err != nil {
+				return fmt.Errorf("invalid le label %q", le)
+			}
+			delete(labels, "le")
+			key = fmt.Sprint(bound)
+		case "_count", "_gcount":
+			key = "count"
+		case "_sum", "_gsum":
+			key = "sum"
+		case "_created":
+			key = "created"
+		}
+	case "summary":
+		typ = telegraf.Summary
+		switch suffix {
+		case "":
+			q, ok := labels["quantile"]
+			if !ok {
+				return fmt.Errorf("summary %q without quantile label", name)
+			}
+			quantile, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				return fmt.Errorf("invalid quantile label %q", q)
+			}
+			delete(labels, "quantile")
+			key = fmt.Sprint(quantile)
+		case "_count":
+			key = "count"
+		case "_sum":
+			key = "sum"
+		case "_created":
+			key = "created"
+		}
+	default:
+		typ = telegraf.Untyped
+		key = "value"
+	}
+
+	t := op.now
+	if ts != nil {
+		t = *ts
+	}
+	g := op.group(metricName, typ, labels, t)
+	if !math.IsNaN(value) {
+		g.fields[key] = value
+	}
+	return nil
+}
+
+// group returns the group of the metric name, labels and time.
+func (op *openMetricsParser) group(name string, typ telegraf.ValueType, labels map[string]string, t time.Time) *omGroup {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(name)
+	for _, k := range keys {
+		b.WriteByte(0)
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(labels[k])
+	}
+	b.WriteByte(0)
+	b.WriteString(strconv.FormatInt(t.UnixNano(), 10))
+
+	key := b.String()
+	if g, ok := op.index[key]; ok {
+		return g
+	}
+
+	for k, v := range op.defaultTags {
+		if _, ok := labels[k]; !ok {
+			labels[k] = v
+		}
+	}
+	g := &omGroup{
+		name:   name,
+		typ:    typ,
+		tags:   labels,
+		fields: make(map[string]interface{}),
+		time:   t,
+	}
+	op.index[key] = g
+	op.groups = append(op.groups, g)
+	return g
+}
+
+// parseSampleLine parses a sample line of the form:
+//
+//	name{label="value",...} value [timestamp] [# {label="value"} value [timestamp]]
+//
+// The timestamp is in seconds, the exemplar is ignored.
+func parseSampleLine(line string) (string, map[string]string, float64, *time.Time, error) {
+	i := strings.IndexAny(line, "{ ")
+	if i <= 0 {
+		return "", nil, 0, nil, fmt.Errorf("invalid sample %q", line)
+	}
+	name := line[:i]
+	rest := line[i:]
+
+	labels := make(map[string]string)
+	if rest[0] == '{' {
+		var err error
+		rest, err = parseLabels(rest[1:], labels)
+		if err != nil {
+			return "", nil, 0, nil, err
+		}
+	}
+
+	if !strings.HasPrefix(rest, " ") {
+		return "", nil, 0, nil, fmt.Errorf("missing value of sample %q", name)
+	}
+	rest = rest[1:]
+	if i := strings.Index(rest, " # "); i >= 0 {
+		rest = rest[:i]
+	}
+
+	parts := strings.Fields(rest)
+	if len(parts) == 0 || len(parts) > 2 {
+		return "", nil, 0, nil, fmt.Errorf("invalid value of sample %q", name)
+	}
+	value, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return "", nil, 0, nil, fmt.Errorf("invalid value %q of sample %q", parts[0], name)
+	}
+
+	var ts *time.Time
+	if len(parts) == 2 {
+		sec, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return "", nil, 0, nil, fmt.Errorf("invalid timestamp %q of sample %q", parts[1], name)
+		}
+		t := time.Unix(0, int64(sec*float64(time.Second)))
+		ts = &t
+	}
+	return name, labels, value, ts, nil
+}
+
+// parseLabels parses the labels following the opening brace into the map,
+// returning the rest of the line after the closing brace.
+func parseLabels(s string, labels map[string]string) (string, error) {
+	for {
+		if strings.HasPrefix(s, "}") {
+			return s[1:], nil
+		}
+
+		i := strings.Index(s, "=\"")
+		if i <= 0 {
+			return "", fmt.Errorf("invalid labels")
+		}
+		name := s[:i]
+		s = s[i+2:]
+
+		var value strings.Builder
+		closed := false
+		for i = 0; i < len(s) && !closed; i++ {
+			switch s[i] {
+			case '\\':
+				i++
+				if i >= len(s) {
+					return "", fmt.Errorf("invalid escape in label %q", name)
+				}
+				switch s[i] {
+				case 'n':
+					value.WriteByte('\n')
+				case '\\', '"':
+					value.WriteByte(s[i])
+				default:
+					return "", fmt.Errorf("invalid escape in label %q", name)
+				}
+			case '"':
+				closed = true
+			default:
+				value.WriteByte(s[i])
+			}
+		}
+		if !closed {
+			return "", fmt.Errorf("unterminated value of label %q", name)
+		}
+		labels[name] = value.String()
+
+		s = s[i:]
+		if strings.HasPrefix(s, ",") {
+			s = s[1:]
+		} else if !strings.HasPrefix(s, "}") {
+			return "", fmt.Errorf("invalid labels")
+		}
+	}
+}
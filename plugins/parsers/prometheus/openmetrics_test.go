@@ -0,0 +1,85 @@
+package prometheus
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const validOpenMetrics = `# TYPE http_requests counter
+# UNIT http_requests requests
+# HELP http_requests The total number of HTTP requests.
+http_requests_total{code="200"} 1027 1257894000.5 # {trace_id="abc"} 1 1257893999
+http_requests_created{code="200"} 1257890000 1257894000.5
+# TYPE request_latency_seconds histogram
+request_latency_seconds_bucket{le="0.5"} 10 1257894000
+request_latency_seconds_bucket{le="+Inf"} 12 1257894000
+request_latency_seconds_count 12 1257894000
+request_latency_seconds_sum 4.5 1257894000
+# TYPE build info
+build_info{version="1.0 \"beta\""} 1 1257894000
+# TYPE temperature gauge
+temperature NaN 1257894000
+# EOF
+`
+
+func TestParseOpenMetrics(t *testing.T) {
+	parser := &Parser{}
+	metrics, err := parser.Parse([]byte(validOpenMetrics))
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"http_requests_total",
+			map[string]string{"code": "200"},
+			map[string]interface{}{"counter": 1027.0, "created": 1257890000.0},
+			time.Unix(1257894000, 500000000),
+			telegraf.Counter,
+		),
+		testutil.MustMetric(
+			"request_latency_seconds",
+			map[string]string{},
+			map[string]interface{}{"0.5": 10.0, "+Inf": 12.0, "count": 12.0, "sum": 4.5},
+			time.Unix(1257894000, 0),
+			telegraf.Histogram,
+		),
+		testutil.MustMetric(
+			"build_info",
+			map[string]string{"version": `1.0 "beta"`},
+			map[string]interface{}{"gauge": 1.0},
+			time.Unix(1257894000, 0),
+			telegraf.Gauge,
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestParseOpenMetricsContentType(t *testing.T) {
+	parser := &Parser{
+		Header: http.Header{
+			"Content-Type": []string{"application/openmetrics-text; version=1.0.0; charset=utf-8"},
+		},
+	}
+	metrics, err := parser.Parse([]byte("# TYPE up gauge\nup 1 1257894000\n"))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	require.Equal(t, exptime, metrics[0].Time().UTC())
+}
+
+func TestParseOpenMetricsDataAfterEOF(t *testing.T) {
+	parser := &Parser{
+		Header: http.Header{"Content-Type": []string{"application/openmetrics-text"}},
+	}
+	_, err := parser.Parse([]byte("up 1\n# EOF\nup 2\n"))
+	require.Error(t, err)
+}
+
+func TestParseOpenMetricsInvalid(t *testing.T) {
+	parser := &Parser{}
+	_, err := parser.Parse([]byte("up{job=node} 1\n# EOF\n"))
+	require.Error(t, err)
+}
@@ -15,15 +15,28 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers"
 
 	"github.com/matttproud/golang_protobuf_extensions/pbutil"
 	dto "github.com/prometheus/client_model/go"
 	"github.com/prometheus/common/expfmt"
 )
 
+// Parser parses metrics in the Prometheus text exposition format.  If the
+// Header indicates a delimited protocol buffer body it is parsed as such,
+// OpenMetrics is parsed if indicated by the Header or by the "# EOF" marker
+// at the end of the body.
+type Parser struct {
+	DefaultTags map[string]string
+
+	// Header is the header of the HTTP response the data was read from, if
+	// any.
+	Header http.Header
+}
+
 // Parse returns a slice of Metrics from a text representation of a
 // metrics
-func Parse(buf []byte, header http.Header) ([]telegraf.Metric, error) {
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
 	var metrics []telegraf.Metric
 	var parser expfmt.TextParser
 	// parse even if the buffer begins with a newline
@@ -32,7 +45,11 @@ func Parse(buf []byte, header http.Header) ([]telegraf.Metric, error) {
 	buffer := bytes.NewBuffer(buf)
 	reader := bufio.NewReader(buffer)
 
-	mediatype, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	mediatype, params, err := mime.ParseMediaType(p.Header.Get("Content-Type"))
+	if (err == nil && mediatype == openMetricsType) || isOpenMetrics(buf) {
+		return p.parseOpenMetrics(buf)
+	}
+
 	// Prepare output
 	metricFamilies := make(map[string]*dto.MetricFamily)
 
@@ -86,6 +103,11 @@ func Parse(buf []byte, header http.Header) ([]telegraf.Metric, error) {
 				} else {
 					t = time.Now()
 				}
+				for k, v := range p.DefaultTags {
+					if _, ok := tags[k]; !ok {
+						tags[k] = v
+					}
+				}
 				metric, err := metric.New(metricName, tags, fields, t, valueType(mf.GetType()))
 				if err == nil {
 					metrics = append(metrics, metric)
@@ -97,6 +119,39 @@ func Parse(buf []byte, header http.Header) ([]telegraf.Metric, error) {
 	return metrics, err
 }
 
+// ParseLine parses a single sample, nil is returned for comments and empty
+// lines.  Histograms and summaries span multiple lines and can not be parsed
+// with ParseLine.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line + "\n"))
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(metrics) {
+	case 0:
+		return nil, nil
+	case 1:
+		return metrics[0], nil
+	default:
+		return nil, fmt.Errorf("line contains %d metrics, expected 1", len(metrics))
+	}
+}
+
+// SetDefaultTags sets the tags added to the metrics that do not have a label
+// of the same name.
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+func (p *Parser) Description() string {
+	return "Parse the Prometheus text exposition format"
+}
+
+func (p *Parser) SampleConfig() string {
+	return ""
+}
+
 func valueType(mt dto.MetricType) telegraf.ValueType {
 	switch mt {
 	case dto.MetricType_COUNTER:
@@ -159,3 +214,9 @@ func getNameAndValue(m *dto.Metric) map[string]interface{} {
 	}
 	return fields
 }
+
+func init() {
+	parsers.Add("prometheus", func(defaultMetricName string) parsers.Parser {
+		return &Parser{}
+	})
+}
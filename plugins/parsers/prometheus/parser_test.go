@@ -1,11 +1,12 @@
 package prometheus
 
 import (
-	"net/http"
 	"testing"
 	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var exptime = time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
@@ -102,8 +103,10 @@ cpu,host=foo,datacenter=us-east usage_idle=99,usage_busy=1
 `
 
 func TestParseValidPrometheus(t *testing.T) {
+	parser := &Parser{}
+
 	// Gauge value
-	metrics, err := Parse([]byte(validUniqueGauge), http.Header{})
+	metrics, err := parser.Parse([]byte(validUniqueGauge))
 	assert.NoError(t, err)
 	assert.Len(t, metrics, 1)
 	assert.Equal(t, "cadvisor_version_info", metrics[0].Name())
@@ -119,7 +122,7 @@ func TestParseValidPrometheus(t *testing.T) {
 	}, metrics[0].Tags())
 
 	// Counter value
-	metrics, err = Parse([]byte(validUniqueCounter), http.Header{})
+	metrics, err = parser.Parse([]byte(validUniqueCounter))
 	assert.NoError(t, err)
 	assert.Len(t, metrics, 1)
 	assert.Equal(t, "get_token_fail_count", metrics[0].Name())
@@ -130,7 +133,7 @@ func TestParseValidPrometheus(t *testing.T) {
 
 	// Summary data
 	//SetDefaultTags(map[string]string{})
-	metrics, err = Parse([]byte(validUniqueSummary), http.Header{})
+	metrics, err = parser.Parse([]byte(validUniqueSummary))
 	assert.NoError(t, err)
 	assert.Len(t, metrics, 1)
 	assert.Equal(t, "http_request_duration_microseconds", metrics[0].Name())
@@ -144,7 +147,7 @@ func TestParseValidPrometheus(t *testing.T) {
 	assert.Equal(t, map[string]string{"handler": "prometheus"}, metrics[0].Tags())
 
 	// histogram data
-	metrics, err = Parse([]byte(validUniqueHistogram), http.Header{})
+	metrics, err = parser.Parse([]byte(validUniqueHistogram))
 	assert.NoError(t, err)
 	assert.Len(t, metrics, 1)
 	assert.Equal(t, "apiserver_request_latencies", metrics[0].Name())
@@ -165,3 +168,43 @@ func TestParseValidPrometheus(t *testing.T) {
 		metrics[0].Tags())
 
 }
+
+func TestParseDefaultTags(t *testing.T) {
+	parser := &Parser{}
+	parser.SetDefaultTags(map[string]string{"osVersion": "unknown", "source": "file"})
+
+	metrics, err := parser.Parse([]byte(validUniqueGauge))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	require.Equal(t, "CentOS Linux 7 (Core)", metrics[0].Tags()["osVersion"])
+	require.Equal(t, "file", metrics[0].Tags()["source"])
+}
+
+func TestParseTimestamp(t *testing.T) {
+	parser := &Parser{}
+
+	metrics, err := parser.Parse([]byte("# TYPE requests_total counter\n" +
+		"requests_total{code=\"200\"} 1027 1257894000000\n"))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	require.Equal(t, exptime, metrics[0].Time().UTC())
+	require.Equal(t, telegraf.Counter, metrics[0].Type())
+}
+
+func TestParseLine(t *testing.T) {
+	parser := &Parser{}
+
+	m, err := parser.ParseLine(`http_requests_total{method="post",code="200"} 1027`)
+	require.NoError(t, err)
+	require.Equal(t, "http_requests_total", m.Name())
+	require.Equal(t, map[string]string{"method": "post", "code": "200"}, m.Tags())
+	require.Equal(t, map[string]interface{}{"value": 1027.0}, m.Fields())
+	require.Equal(t, telegraf.Untyped, m.Type())
+
+	m, err = parser.ParseLine("# HELP http_requests_total The total number of HTTP requests.")
+	require.NoError(t, err)
+	require.Nil(t, m)
+
+	_, err = parser.ParseLine("http_requests_total{method=post} 1027")
+	require.Error(t, err)
+}
@@ -0,0 +1,88 @@
+package prometheusremotewrite
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/prompb"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+// Parser parses the snappy compressed protocol buffer body of Prometheus
+// remote write requests.
+type Parser struct {
+	DefaultTags map[string]string
+}
+
+// Parse returns a metric for each sample of the write request.
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	req, err := prompb.Decode(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := make([]telegraf.Metric, 0)
+	for _, ts := range req.Timeseries {
+		var name string
+		tags := make(map[string]string, len(ts.Labels)+len(p.DefaultTags))
+		for _, label := range ts.Labels {
+			if label.Name == prompb.MetricNameLabel {
+				name = label.Value
+				continue
+			}
+			tags[label.Name] = label.Value
+		}
+		if name == "" {
+			return nil, fmt.Errorf("series without %q label", prompb.MetricNameLabel)
+		}
+		for k, v := range p.DefaultTags {
+			if _, ok := tags[k]; !ok {
+				tags[k] = v
+			}
+		}
+
+		for _, s := range ts.Samples {
+			// NaN values mark stale series and have no data.
+			if math.IsNaN(s.Value) {
+				continue
+			}
+
+			fields := map[string]interface{}{"value": s.Value}
+			t := time.Unix(0, s.Timestamp*int64(time.Millisecond))
+			m, err := metric.New(name, tags, fields, t, telegraf.Untyped)
+			if err != nil {
+				return nil, err
+			}
+			metrics = append(metrics, m)
+		}
+	}
+	return metrics, nil
+}
+
+// ParseLine is not supported, write requests are binary.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	return nil, fmt.Errorf("parsing a line is not supported by the prometheusremotewrite format")
+}
+
+// SetDefaultTags sets the tags added to the metrics that do not have a label
+// of the same name.
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+func (p *Parser) Description() string {
+	return "Parse Prometheus remote write requests"
+}
+
+func (p *Parser) SampleConfig() string {
+	return ""
+}
+
+func init() {
+	parsers.Add("prometheusremotewrite", func(defaultMetricName string) parsers.Parser {
+		return &Parser{}
+	})
+}
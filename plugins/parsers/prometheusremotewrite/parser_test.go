@@ -0,0 +1,74 @@
+package prometheusremotewrite
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/prompb"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	buf, err := prompb.Encode(&prompb.WriteRequest{
+		Timeseries: []*prompb.TimeSeries{
+			{
+				Labels: []*prompb.Label{
+					{Name: "__name__", Value: "go_goroutines"},
+					{Name: "job", Value: "prometheus"},
+				},
+				Samples: []*prompb.Sample{
+					{Value: 42, Timestamp: 1257894000000},
+					{Value: math.NaN(), Timestamp: 1257894001000},
+					{Value: 43, Timestamp: 1257894002000},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	parser := &Parser{}
+	parser.SetDefaultTags(map[string]string{"job": "default", "source": "remote"})
+	metrics, err := parser.Parse(buf)
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"go_goroutines",
+			map[string]string{"job": "prometheus", "source": "remote"},
+			map[string]interface{}{"value": 42.0},
+			time.Unix(1257894000, 0),
+		),
+		testutil.MustMetric(
+			"go_goroutines",
+			map[string]string{"job": "prometheus", "source": "remote"},
+			map[string]interface{}{"value": 43.0},
+			time.Unix(1257894002, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestParseMissingName(t *testing.T) {
+	buf, err := prompb.Encode(&prompb.WriteRequest{
+		Timeseries: []*prompb.TimeSeries{
+			{
+				Labels:  []*prompb.Label{{Name: "job", Value: "prometheus"}},
+				Samples: []*prompb.Sample{{Value: 1, Timestamp: 1257894000000}},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	parser := &Parser{}
+	_, err = parser.Parse(buf)
+	require.Error(t, err)
+}
+
+func TestParseInvalid(t *testing.T) {
+	parser := &Parser{}
+	_, err := parser.Parse([]byte("up 1\n"))
+	require.Error(t, err)
+}
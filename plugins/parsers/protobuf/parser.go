@@ -0,0 +1,305 @@
+package protobuf
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/dynamic"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/fieldmap"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+const timestampType = "google.protobuf.Timestamp"
+
+// Parser parses Protocol Buffers messages into metrics using the message
+// descriptor of a .proto file.  Nested values are flattened, with their keys
+// joined by the FieldSeparator.
+type Parser struct {
+	// MetricName is used as the metric name if Measurement is empty.
+	MetricName string
+
+	// Measurement, Tags, Fields, Timestamp and TimestampFormat select the
+	// metric name, tags, fields and time from the flattened values, see
+	// fieldmap.Mapping.
+	Measurement     string   `toml:"protobuf_measurement"`
+	Tags            []string `toml:"protobuf_tags"`
+	Fields          []string `toml:"protobuf_fields"`
+	Timestamp       string   `toml:"protobuf_timestamp"`
+	TimestampFormat string   `toml:"protobuf_timestamp_format"`
+
+	// ProtoFile is the .proto file defining the MessageType.
+	ProtoFile string `toml:"protobuf_file"`
+
+	// ImportPaths are searched for the imports of the ProtoFile in addition
+	// to its own directory.
+	ImportPaths []string `toml:"protobuf_import_paths"`
+
+	// MessageType is the fully qualified name of the message.
+	MessageType string `toml:"protobuf_message_type"`
+
+	// Delimited is set if each message is prefixed with its length encoded
+	// as a varint, allowing multiple messages to be parsed at once.
+	Delimited bool `toml:"protobuf_delimited"`
+
+	FieldSeparator string `toml:"protobuf_field_separator"`
+
+	DefaultTags map[string]string
+	TimeFunc    func() time.Time
+
+	message *desc.MessageDescriptor
+}
+
+// Init loads the message descriptor from the .proto file.
+func (p *Parser) Init() error {
+	var err error
+	p.message, err = LoadMessage(p.ProtoFile, p.ImportPaths, p.MessageType)
+	if err != nil {
+		return err
+	}
+
+	if p.FieldSeparator == "" {
+		p.FieldSeparator = "_"
+	}
+	return nil
+}
+
+func (p *Parser) Description() string {
+	return "Parse Protocol Buffers messages"
+}
+
+func (p *Parser) SampleConfig() string {
+	return `
+  ## The .proto file and the fully qualified name of the message type.
+  protobuf_file = "/etc/telegraf/cpu.proto"
+  protobuf_message_type = "example.CPU"
+
+  ## Directories searched for the imports of the .proto file, in addition to
+  ## the directory of the file.
+  # protobuf_import_paths = []
+
+  ## Parse multiple messages, each prefixed with its length encoded as a
+  ## varint.  When false the input is a single message.
+  # protobuf_delimited = false
+
+  ## Key of the value used as the measurement name, if unset the name of the
+  ## plugin is used.
+  # protobuf_measurement = ""
+
+  ## Keys of the values used as tags.
+  # protobuf_tags = []
+
+  ## Keys of the values used as fields, if empty all values not used as
+  ## measurement, tag or timestamp are added as fields.
+  # protobuf_fields = []
+
+  ## Key of the value used as the metric time and its format, either a Go
+  ## time layout or one of "unix", "unix_ms", "unix_us" or "unix_ns".
+  ## google.protobuf.Timestamp values don't need a format.  If unset the
+  ## current time is used.
+  # protobuf_timestamp = ""
+  # protobuf_timestamp_format = "unix"
+
+  ## Separator joining the keys of nested values.
+  # protobuf_field_separator = "_"
+`
+}
+
+// LoadMessage returns the descriptor of the message type defined in the
+// .proto file, imports are searched in the directory of the file and the
+// import paths.
+func LoadMessage(file string, importPaths []string, messageType string) (*desc.MessageDescriptor, error) {
+	if file == "" {
+		return nil, fmt.Errorf("protobuf_file must be set")
+	}
+	if messageType == "" {
+		return nil, fmt.Errorf("protobuf_message_type must be set")
+	}
+
+	parser := protoparse.Parser{
+		ImportPaths: append([]string{filepath.Dir(file)}, importPaths...),
+	}
+	fds, err := parser.ParseFiles(filepath.Base(file))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s: %v", file, err)
+	}
+
+	md := fds[0].FindMessage(messageType)
+	if md == nil {
+		return nil, fmt.Errorf("message type %q not found in %s", messageType, file)
+	}
+	return md, nil
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	if !p.Delimited {
+		m, err := p.parseMessage(buf)
+		if err != nil {
+			return nil, err
+		}
+		return []telegraf.Metric{m}, nil
+	}
+
+	metrics := make([]telegraf.Metric, 0)
+	for len(buf) > 0 {
+		size, n := proto.DecodeVarint(buf)
+		if n == 0 || uint64(len(buf)-n) < size {
+			return nil, fmt.Errorf("invalid message length")
+		}
+		buf = buf[n:]
+
+		m, err := p.parseMessage(buf[:size])
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+		buf = buf[size:]
+	}
+	return metrics, nil
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(metrics) {
+	case 0:
+		return nil, nil
+	case 1:
+		return metrics[0], nil
+	default:
+		return nil, fmt.Errorf("cannot parse line with multiple (%d) metrics", len(metrics))
+	}
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+func (p *Parser) mapping() *fieldmap.Mapping {
+	return &fieldmap.Mapping{
+		MetricName:      p.MetricName,
+		Measurement:     p.Measurement,
+		Tags:            p.Tags,
+		Fields:          p.Fields,
+		Timestamp:       p.Timestamp,
+		TimestampFormat: p.TimestampFormat,
+		DefaultTags:     p.DefaultTags,
+		TimeFunc:        p.TimeFunc,
+	}
+}
+
+func (p *Parser) parseMessage(buf []byte) (telegraf.Metric, error) {
+	msg := dynamic.NewMessage(p.message)
+	if err := msg.Unmarshal(buf); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{})
+	if err := p.flatten(values, "", msg); err != nil {
+		return nil, err
+	}
+	return p.mapping().Metric(values)
+}
+
+// flatten adds the values of the message fields, nested messages, repeated
+// fields and maps have their keys joined by the separator.
+func (p *Parser) flatten(values map[string]interface{}, key string, msg *dynamic.Message) error {
+	for _, fd := range msg.GetMessageDescriptor().GetFields() {
+		if !msg.HasField(fd) && !hasImplicitPresence(fd) {
+			continue
+		}
+
+		name := p.join(key, fd.GetName())
+		value := msg.GetField(fd)
+		switch {
+		case fd.IsMap():
+			for k, v := range value.(map[interface{}]interface{}) {
+				err := p.flattenValue(values, p.join(name, fmt.Sprintf("%v", k)), fd.GetMapValueType(), v)
+				if err != nil {
+					return err
+				}
+			}
+		case fd.IsRepeated():
+			for i, v := range value.([]interface{}) {
+				err := p.flattenValue(values, p.join(name, strconv.Itoa(i)), fd, v)
+				if err != nil {
+					return err
+				}
+			}
+		default:
+			if err := p.flattenValue(values, name, fd, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (p *Parser) flattenValue(values map[string]interface{}, key string, fd *desc.FieldDescriptor, value interface{}) error {
+	if md := fd.GetMessageType(); md != nil {
+		msg, err := dynamic.AsDynamicMessage(value.(proto.Message))
+		if err != nil {
+			return err
+		}
+		if md.GetFullyQualifiedName() == timestampType {
+			seconds := msg.GetFieldByName("seconds").(int64)
+			nanos := msg.GetFieldByName("nanos").(int32)
+			values[key] = time.Unix(seconds, int64(nanos)).UTC()
+			return nil
+		}
+		return p.flatten(values, key, msg)
+	}
+
+	if enum := fd.GetEnumType(); enum != nil {
+		if ev := enum.FindValueByNumber(value.(int32)); ev != nil {
+			values[key] = ev.GetName()
+			return nil
+		}
+	}
+
+	switch v := value.(type) {
+	case bool, int64, uint64, float64, string:
+		values[key] = v
+	case int32:
+		values[key] = int64(v)
+	case uint32:
+		values[key] = uint64(v)
+	case float32:
+		values[key] = float64(v)
+	case []byte:
+		values[key] = string(v)
+	}
+	return nil
+}
+
+// hasImplicitPresence returns true for fields that are set when holding the
+// zero value, which are the singular scalar fields of proto3 outside of a
+// oneof.
+func hasImplicitPresence(fd *desc.FieldDescriptor) bool {
+	return fd.GetFile().IsProto3() &&
+		!fd.IsRepeated() &&
+		fd.GetMessageType() == nil &&
+		fd.GetOneOf() == nil
+}
+
+func (p *Parser) join(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + p.FieldSeparator + key
+}
+
+func init() {
+	parsers.Add("protobuf", func(defaultMetricName string) parsers.Parser {
+		return &Parser{MetricName: defaultMetricName}
+	})
+}
@@ -0,0 +1,170 @@
+package protobuf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/jhump/protoreflect/dynamic"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+const protoFile = "testdata/cpu.proto"
+
+var exptime = time.Date(2020, time.August, 1, 15, 4, 3, 0, time.UTC)
+
+func encode(t *testing.T, host string, cores int32) []byte {
+	md, err := LoadMessage(protoFile, nil, "example.CPU")
+	require.NoError(t, err)
+
+	ts := dynamic.NewMessage(md.FindFieldByName("time").GetMessageType())
+	ts.SetFieldByName("seconds", exptime.Unix())
+
+	cache := dynamic.NewMessage(md.FindFieldByName("cache").GetMessageType())
+	cache.SetFieldByName("hits", int64(10))
+	cache.SetFieldByName("misses", int64(2))
+
+	msg := dynamic.NewMessage(md)
+	msg.SetFieldByName("measurement", "cpu")
+	msg.SetFieldByName("time", ts)
+	msg.SetFieldByName("host", host)
+	msg.SetFieldByName("state", int32(1))
+	msg.SetFieldByName("usage", 42.5)
+	msg.SetFieldByName("cores", cores)
+	msg.SetFieldByName("load", []float32{0.5, 1.5})
+	msg.SetFieldByName("temperature", map[string]int64{"core0": 50})
+	msg.SetFieldByName("cache", cache)
+
+	buf, err := msg.Marshal()
+	require.NoError(t, err)
+	return buf
+}
+
+func delimited(messages ...[]byte) []byte {
+	var buf []byte
+	for _, msg := range messages {
+		buf = append(buf, proto.EncodeVarint(uint64(len(msg)))...)
+		buf = append(buf, msg...)
+	}
+	return buf
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		parser   *Parser
+		input    []byte
+		expected []telegraf.Metric
+	}{
+		{
+			name: "all values as fields",
+			parser: &Parser{
+				MetricName: "protobuf",
+				TimeFunc:   func() time.Time { return exptime },
+			},
+			input: encode(t, "server01", 4),
+			expected: []telegraf.Metric{
+				testutil.MustMetric("protobuf",
+					map[string]string{},
+					map[string]interface{}{
+						"measurement":       "cpu",
+						"time":              exptime.UnixNano(),
+						"host":              "server01",
+						"state":             "BUSY",
+						"usage":             42.5,
+						"cores":             int64(4),
+						"load_0":            0.5,
+						"load_1":            1.5,
+						"temperature_core0": int64(50),
+						"cache_hits":        int64(10),
+						"cache_misses":      int64(2),
+						"uptime":            uint64(0),
+						"serial":            "",
+					},
+					exptime),
+			},
+		},
+		{
+			name: "mapping",
+			parser: &Parser{
+				Measurement:    "measurement",
+				Tags:           []string{"host", "state"},
+				Fields:         []string{"usage", "cache.hits"},
+				Timestamp:      "time",
+				FieldSeparator: ".",
+			},
+			input: encode(t, "server01", 4),
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{
+						"host":  "server01",
+						"state": "BUSY",
+					},
+					map[string]interface{}{
+						"usage":      42.5,
+						"cache.hits": int64(10),
+					},
+					exptime),
+			},
+		},
+		{
+			name: "delimited",
+			parser: &Parser{
+				Measurement: "measurement",
+				Tags:        []string{"host"},
+				Fields:      []string{"cores"},
+				Timestamp:   "time",
+				Delimited:   true,
+			},
+			input: delimited(encode(t, "server01", 4), encode(t, "server02", 8)),
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "server01"},
+					map[string]interface{}{"cores": int64(4)},
+					exptime),
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "server02"},
+					map[string]interface{}{"cores": int64(8)},
+					exptime),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.parser.ProtoFile = protoFile
+			tt.parser.MessageType = "example.CPU"
+			require.NoError(t, tt.parser.Init())
+
+			metrics, err := tt.parser.Parse(tt.input)
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, tt.expected, metrics)
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	parser := &Parser{
+		ProtoFile:   protoFile,
+		MessageType: "example.CPU",
+		Delimited:   true,
+	}
+	require.NoError(t, parser.Init())
+
+	msg := delimited(encode(t, "server01", 4))
+	_, err := parser.Parse(msg[:len(msg)-1])
+	require.Error(t, err)
+
+	parser.Delimited = false
+	_, err = parser.Parse([]byte{0xff, 0xff})
+	require.Error(t, err)
+}
+
+func TestInit(t *testing.T) {
+	require.Error(t, (&Parser{MessageType: "example.CPU"}).Init())
+	require.Error(t, (&Parser{ProtoFile: protoFile}).Init())
+	require.Error(t, (&Parser{ProtoFile: protoFile, MessageType: "example.Memory"}).Init())
+	require.Error(t, (&Parser{ProtoFile: "testdata/missing.proto", MessageType: "example.CPU"}).Init())
+}
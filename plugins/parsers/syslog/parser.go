@@ -0,0 +1,289 @@
+package syslog
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/influxdata/go-syslog"
+	"github.com/influxdata/go-syslog/rfc5424"
+	"github.com/influxdata/telegraf"
+	bsd "github.com/influxdata/telegraf/internal/syslog"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+// Message formats.
+const (
+	// FormatAuto detects the format of each message.
+	FormatAuto = "auto"
+
+	// FormatRFC5424 is the syslog protocol of RFC5424.
+	FormatRFC5424 = "rfc5424"
+
+	// FormatRFC3164 is the BSD syslog format of RFC3164.
+	FormatRFC3164 = "rfc3164"
+)
+
+// Parser parses syslog messages, one per line, into metrics like those of the
+// syslog input.
+type Parser struct {
+	// Format is the message format, one of FormatAuto, FormatRFC5424 or
+	// FormatRFC3164.  When empty FormatAuto is used.
+	Format string `toml:"syslog_format"`
+
+	// BestEffort parses partially valid RFC5424 messages.
+	BestEffort bool `toml:"syslog_best_effort"`
+
+	// Separator joins the SD-ID and the names of the structured data
+	// parameters in the field names, defaults to an underscore.
+	Separator string `toml:"syslog_sdparam_separator"`
+
+	DefaultTags map[string]string
+
+	now func() time.Time
+
+	// mu guards lastTime, as a parser may be shared by several goroutines.
+	mu       sync.Mutex
+	lastTime time.Time
+}
+
+func (p *Parser) Description() string {
+	return "Parse RFC5424 and RFC3164 syslog messages"
+}
+
+func (p *Parser) SampleConfig() string {
+	return `
+  ## Format of the messages, "rfc5424", "rfc3164" or "auto" to detect the
+  ## format of each message.
+  # syslog_format = "auto"
+
+  ## Parse partially valid RFC5424 messages.
+  # syslog_best_effort = false
+
+  ## Separator joining the SD-ID and the parameter names of structured data
+  ## in the field names.
+  # syslog_sdparam_separator = "_"
+`
+}
+
+// Init checks the options.
+func (p *Parser) Init() error {
+	switch p.Format {
+	case "", FormatAuto, FormatRFC5424, FormatRFC3164:
+	default:
+		return fmt.Errorf("invalid syslog_format %q, must be %q, %q or %q",
+			p.Format, FormatAuto, FormatRFC5424, FormatRFC3164)
+	}
+	return nil
+}
+
+// Parse parses the messages of each non-empty line.
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	metrics := make([]telegraf.Metric, 0)
+	for _, line := range bytes.Split(buf, []byte("\n")) {
+		line = bytes.TrimRight(line, "\r")
+		if len(line) == 0 {
+			continue
+		}
+		m, err := p.ParseLine(string(line))
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+// ParseLine parses a single message.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	var tags map[string]string
+	var fields map[string]interface{}
+	t := p.time()
+
+	format := p.Format
+	if format == "" || format == FormatAuto {
+		format = detectFormat(line)
+	}
+	switch format {
+	case FormatRFC5424:
+		var machine syslog.Machine
+		if p.BestEffort {
+			machine = rfc5424.NewParser(rfc5424.WithBestEffort())
+		} else {
+			machine = rfc5424.NewParser()
+		}
+		msg, err := machine.Parse([]byte(line))
+		if err != nil && (msg == nil || !p.BestEffort) {
+			return nil, err
+		}
+		separator := p.Separator
+		if separator == "" {
+			separator = "_"
+		}
+		tags, fields = Tags(msg), Fields(msg, separator)
+	default:
+		msg, err := bsd.ParseRFC3164(line, t)
+		if err != nil {
+			return nil, err
+		}
+		tags, fields = bsdTags(msg), bsdFields(msg)
+	}
+
+	for k, v := range p.DefaultTags {
+		if _, ok := tags[k]; !ok {
+			tags[k] = v
+		}
+	}
+	return metric.New("syslog", tags, fields, t)
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+// time returns the current time, unique for each call so messages received
+// at once are kept as separate points.
+func (p *Parser) time() time.Time {
+	now := p.now
+	if now == nil {
+		now = time.Now
+	}
+	t := now()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !t.After(p.lastTime) {
+		t = p.lastTime.Add(time.Nanosecond)
+	}
+	p.lastTime = t
+	return t
+}
+
+// detectFormat returns FormatRFC5424 if the priority is followed by a
+// version, otherwise FormatRFC3164.
+func detectFormat(line string) string {
+	end := strings.IndexByte(line, '>')
+	if !strings.HasPrefix(line, "<") || end < 0 {
+		return FormatRFC3164
+	}
+	rest := line[end+1:]
+	i := 0
+	for i < len(rest) && i < 3 && rest[i] >= '0' && rest[i] <= '9' {
+		i++
+	}
+	if i > 0 && rest[0] != '0' && i < len(rest) && rest[i] == ' ' {
+		return FormatRFC5424
+	}
+	return FormatRFC3164
+}
+
+// Tags returns the tags of an RFC5424 message.
+func Tags(msg syslog.Message) map[string]string {
+	ts := map[string]string{}
+
+	// Not checking assuming a minimally valid message
+	ts["severity"] = *msg.SeverityShortLevel()
+	ts["facility"] = *msg.FacilityLevel()
+
+	if msg.Hostname() != nil {
+		ts["hostname"] = *msg.Hostname()
+	}
+
+	if msg.Appname() != nil {
+		ts["appname"] = *msg.Appname()
+	}
+
+	return ts
+}
+
+// Fields returns the fields of an RFC5424 message, the separator joins the
+// SD-ID and the parameter names of the structured data.
+func Fields(msg syslog.Message, separator string) map[string]interface{} {
+	// Not checking assuming a minimally valid message
+	flds := map[string]interface{}{
+		"version": msg.Version(),
+	}
+	flds["severity_code"] = int(*msg.Severity())
+	flds["facility_code"] = int(*msg.Facility())
+
+	if msg.Timestamp() != nil {
+		flds["timestamp"] = (*msg.Timestamp()).UnixNano()
+	}
+
+	if msg.ProcID() != nil {
+		flds["procid"] = *msg.ProcID()
+	}
+
+	if msg.MsgID() != nil {
+		flds["msgid"] = *msg.MsgID()
+	}
+
+	if msg.Message() != nil {
+		flds["message"] = trimMessage(*msg.Message())
+	}
+
+	if msg.StructuredData() != nil {
+		for sdid, sdparams := range *msg.StructuredData() {
+			if len(sdparams) == 0 {
+				// When SD-ID does not have params we indicate its presence with a bool
+				flds[sdid] = true
+				continue
+			}
+			for name, value := range sdparams {
+				// Using whitespace as separator since it is not allowed by the grammar within SDID
+				flds[sdid+separator+name] = value
+			}
+		}
+	}
+
+	return flds
+}
+
+// bsdTags returns the tags of an RFC3164 message.
+func bsdTags(msg *bsd.BSDMessage) map[string]string {
+	ts := map[string]string{
+		"severity": bsd.SeverityLevel(msg.Severity),
+		"facility": bsd.FacilityLevel(msg.Facility),
+	}
+	if msg.Hostname != "" {
+		ts["hostname"] = msg.Hostname
+	}
+	if msg.Appname != "" {
+		ts["appname"] = msg.Appname
+	}
+	return ts
+}
+
+// bsdFields returns the fields of an RFC3164 message.
+func bsdFields(msg *bsd.BSDMessage) map[string]interface{} {
+	flds := map[string]interface{}{
+		"severity_code": int(msg.Severity),
+		"facility_code": int(msg.Facility),
+	}
+	if msg.Timestamp != nil {
+		flds["timestamp"] = msg.Timestamp.UnixNano()
+	}
+	if msg.ProcID != "" {
+		flds["procid"] = msg.ProcID
+	}
+	if msg.Message != "" {
+		flds["message"] = trimMessage(msg.Message)
+	}
+	return flds
+}
+
+func trimMessage(s string) string {
+	return strings.TrimRightFunc(s, func(r rune) bool {
+		return unicode.IsSpace(r)
+	})
+}
+
+func init() {
+	parsers.Add("syslog", func(defaultMetricName string) parsers.Parser {
+		return &Parser{}
+	})
+}
@@ -0,0 +1,141 @@
+package syslog
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var defaultTime = time.Unix(1557248400, 0)
+
+func newParser() *Parser {
+	return &Parser{
+		now: func() time.Time {
+			return defaultTime
+		},
+	}
+}
+
+func TestParseRFC5424(t *testing.T) {
+	parser := newParser()
+	metrics, err := parser.Parse([]byte(`<29>1 2016-02-21T04:32:57+00:00 web1 someservice 2341 2 [origin][meta sequence="14125553" service="someservice"] "GET /v1/ok HTTP/1.1" 200 145 "-" "hacheck 0.9.0" 24306 127.0.0.1:40124 575` + "\n"))
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"syslog",
+			map[string]string{
+				"severity": "notice",
+				"facility": "daemon",
+				"hostname": "web1",
+				"appname":  "someservice",
+			},
+			map[string]interface{}{
+				"version":       uint16(1),
+				"timestamp":     time.Unix(1456029177, 0).UnixNano(),
+				"procid":        "2341",
+				"msgid":         "2",
+				"message":       `"GET /v1/ok HTTP/1.1" 200 145 "-" "hacheck 0.9.0" 24306 127.0.0.1:40124 575`,
+				"origin":        true,
+				"meta_sequence": "14125553",
+				"meta_service":  "someservice",
+				"severity_code": 5,
+				"facility_code": 3,
+			},
+			defaultTime,
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestParseRFC3164(t *testing.T) {
+	parser := newParser()
+	metrics, err := parser.Parse([]byte("<34>Feb  5 22:14:15 mymachine su[123]: 'su root' failed\n" +
+		"\n" +
+		"<13>kernel: message \r\n"))
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"syslog",
+			map[string]string{
+				"severity": "crit",
+				"facility": "auth",
+				"hostname": "mymachine",
+				"appname":  "su",
+			},
+			map[string]interface{}{
+				"timestamp":     time.Date(2019, time.February, 5, 22, 14, 15, 0, time.UTC).UnixNano(),
+				"procid":        "123",
+				"message":       "'su root' failed",
+				"severity_code": 2,
+				"facility_code": 4,
+			},
+			defaultTime,
+		),
+		testutil.MustMetric(
+			"syslog",
+			map[string]string{
+				"severity": "notice",
+				"facility": "user",
+				"appname":  "kernel",
+			},
+			map[string]interface{}{
+				"message":       "message",
+				"severity_code": 5,
+				"facility_code": 1,
+			},
+			defaultTime.Add(time.Nanosecond),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestParseConcurrent(t *testing.T) {
+	parser := newParser()
+
+	var wg sync.WaitGroup
+	times := make(chan time.Time, 40)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				m, err := parser.ParseLine("<13>kernel: message")
+				require.NoError(t, err)
+				times <- m.Time()
+			}
+		}()
+	}
+	wg.Wait()
+	close(times)
+
+	// Each metric gets a distinct time.
+	seen := make(map[time.Time]bool)
+	for tm := range times {
+		require.False(t, seen[tm])
+		seen[tm] = true
+	}
+	require.Len(t, seen, 40)
+}
+
+func TestDetectFormat(t *testing.T) {
+	require.Equal(t, FormatRFC5424, detectFormat("<1>1 - - - - - - A"))
+	require.Equal(t, FormatRFC5424, detectFormat("<165>12 - - - - - - A"))
+	require.Equal(t, FormatRFC3164, detectFormat("<13>Feb  5 22:14:15 host app: A"))
+	require.Equal(t, FormatRFC3164, detectFormat("<13>1a: A"))
+	require.Equal(t, FormatRFC3164, detectFormat("no priority"))
+}
+
+func TestParseInvalid(t *testing.T) {
+	parser := newParser()
+	_, err := parser.Parse([]byte("no priority\n"))
+	require.Error(t, err)
+
+	parser = &Parser{Format: "rfc1234"}
+	require.Error(t, parser.Init())
+}
@@ -9,14 +9,27 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers"
 )
 
 type ValueParser struct {
 	MetricName  string
-	DataType    string
+	DataType    string `toml:"data_type"`
 	DefaultTags map[string]string
 }
 
+func (v *ValueParser) Description() string {
+	return "Parse a single value"
+}
+
+func (v *ValueParser) SampleConfig() string {
+	return `
+  ## Data type of the value, one of "integer", "float", "long", "string" or
+  ## "boolean".
+  data_type = "integer"
+`
+}
+
 func (v *ValueParser) Parse(buf []byte) ([]telegraf.Metric, error) {
 	vStr := string(bytes.TrimSpace(bytes.Trim(buf, "\x00")))
 
@@ -73,3 +86,9 @@ func (v *ValueParser) ParseLine(line string) (telegraf.Metric, error) {
 func (v *ValueParser) SetDefaultTags(tags map[string]string) {
 	v.DefaultTags = tags
 }
+
+func init() {
+	parsers.Add("value", func(defaultMetricName string) parsers.Parser {
+		return &ValueParser{MetricName: defaultMetricName}
+	})
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers"
 )
 
 const MAX_BUFFER_SIZE = 2
@@ -63,6 +64,14 @@ func NewWavefrontParser(defaultTags map[string]string) *WavefrontParser {
 	return wp
 }
 
+func (p *WavefrontParser) Description() string {
+	return "Parse the Wavefront data format"
+}
+
+func (p *WavefrontParser) SampleConfig() string {
+	return ""
+}
+
 func NewPointParser(parent *WavefrontParser) *PointParser {
 	elements := NewWavefrontElements()
 	return &PointParser{Elements: elements, parent: parent}
@@ -224,3 +233,9 @@ func (p *PointParser) reset(buf []byte) {
 	}
 	p.buf.n = 0
 }
+
+func init() {
+	parsers.Add("wavefront", func(defaultMetricName string) parsers.Parser {
+		return NewWavefrontParser(nil)
+	})
+}
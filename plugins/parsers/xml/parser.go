@@ -0,0 +1,429 @@
+package xml
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/antchfx/xpath"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+// Config defines how metrics are extracted from the document.  Each node
+// matched by Selection is converted to a metric, all other queries are
+// relative to the selected node.
+type Config struct {
+	// Selection selects the nodes to convert to metrics, defaults to the
+	// document root.
+	Selection string `toml:"metric_selection"`
+
+	// MetricQuery is the query for the metric name, if empty the name of the
+	// plugin is used.
+	MetricQuery string `toml:"metric_name"`
+
+	// Timestamp is the query for the metric time, if empty the current time
+	// is used.
+	Timestamp string `toml:"timestamp"`
+
+	// TimestampFmt is the format of the Timestamp, either a Go time layout or
+	// one of unix, unix_ms, unix_us or unix_ns.  Defaults to RFC3339.
+	TimestampFmt string `toml:"timestamp_format"`
+
+	// Tags maps tag keys to queries.
+	Tags map[string]string `toml:"tags"`
+
+	// Fields maps field keys to queries.  The field type follows the type of
+	// the query result: number, boolean or string.
+	Fields map[string]string `toml:"fields"`
+
+	// FieldsInt maps field keys to queries converted to integers.
+	FieldsInt map[string]string `toml:"fields_int"`
+
+	// FieldSelection selects nodes, relative to the metric node, which are
+	// each added as a field using FieldNameQuery and FieldValueQuery.
+	FieldSelection string `toml:"field_selection"`
+
+	// FieldNameQuery is the query for the field key relative to each node
+	// of the FieldSelection, defaults to the node name.
+	FieldNameQuery string `toml:"field_name"`
+
+	// FieldValueQuery is the query for the field value relative to each node
+	// of the FieldSelection, defaults to the node text.
+	FieldValueQuery string `toml:"field_value"`
+
+	// FieldNameExpand prefixes the field keys of the FieldSelection with the
+	// names of their parents below the metric node.
+	FieldNameExpand bool `toml:"field_name_expansion"`
+}
+
+// Parser parses XML documents into metrics using XPath queries.
+type Parser struct {
+	MetricName  string
+	Configs     []Config `toml:"xml"`
+	DefaultTags map[string]string
+	TimeFunc    func() time.Time
+}
+
+// NewParser returns a parser for the given configs, all queries are checked
+// for syntax errors.
+func NewParser(metricName string, configs []Config, defaultTags map[string]string) (*Parser, error) {
+	p := &Parser{
+		MetricName:  metricName,
+		Configs:     configs,
+		DefaultTags: defaultTags,
+	}
+	if err := p.Init(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Init checks all queries for syntax errors.
+func (p *Parser) Init() error {
+	if len(p.Configs) == 0 {
+		return fmt.Errorf("no xml configuration provided")
+	}
+
+	for _, config := range p.Configs {
+		queries := []string{
+			config.Selection,
+			config.MetricQuery,
+			config.Timestamp,
+			config.FieldSelection,
+			config.FieldNameQuery,
+			config.FieldValueQuery,
+		}
+		for _, query := range config.Tags {
+			queries = append(queries, query)
+		}
+		for _, query := range config.Fields {
+			queries = append(queries, query)
+		}
+		for _, query := range config.FieldsInt {
+			queries = append(queries, query)
+		}
+
+		for _, query := range queries {
+			if query == "" {
+				continue
+			}
+			if _, err := xpath.Compile(query); err != nil {
+				return fmt.Errorf("invalid xml query %q: %v", query, err)
+			}
+		}
+	}
+
+	if p.TimeFunc == nil {
+		p.TimeFunc = time.Now
+	}
+	return nil
+}
+
+func (p *Parser) Description() string {
+	return "Parse XML documents with XPath queries"
+}
+
+func (p *Parser) SampleConfig() string {
+	return `
+  ## Multiple parsing sections are allowed, each creates metrics from the
+  ## same document.
+  [[inputs.file.xml]]
+    ## Select the nodes to convert to metrics, one metric is created for each
+    ## node.  All other queries are relative to the selected node.  Defaults
+    ## to the document root.
+    # metric_selection = "/"
+
+    ## Query for the metric name, if unset the name of the plugin is used.
+    ## Use a string literal, such as "'sensor'", for a fixed name.
+    # metric_name = "name(.)"
+
+    ## Query for the metric time and its format, either a Go time layout or
+    ## one of "unix", "unix_ms", "unix_us" or "unix_ns".  If unset the current
+    ## time is used.
+    # timestamp = "/Gateway/Timestamp"
+    # timestamp_format = "2006-01-02T15:04:05Z07:00"
+
+    ## Tags to add, mapping the tag key to a query.
+    [inputs.file.xml.tags]
+      # name = "substring-after(@name, ' ')"
+
+    ## Fields to add, mapping the field key to a query.  The field type
+    ## follows the type of the query result, use the number(), boolean() or
+    ## string() functions to convert it.
+    [inputs.file.xml.fields]
+      # temperature = "number(Variable/@temperature)"
+
+    ## Integer fields to add, mapping the field key to a query.
+    [inputs.file.xml.fields_int]
+      # seqnr = "/Gateway/Sequence"
+
+    ## Select nodes, relative to the metric node, that are each added as a
+    ## field.  The key and value of the field are queries relative to each
+    ## selected node, and default to the node name and its text.
+    # field_selection = "child::*"
+    # field_name = "name()"
+    # field_value = "."
+
+    ## Prefix the field keys of the field selection with the names of their
+    ## parents below the metric node, joined by an underscore.
+    # field_name_expansion = false
+`
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	doc, err := xmlquery.Parse(bytes.NewReader(buf))
+	if err != nil {
+		return nil, err
+	}
+	root := xmlquery.CreateXPathNavigator(doc)
+
+	now := p.TimeFunc()
+	metrics := make([]telegraf.Metric, 0)
+	for _, config := range p.Configs {
+		selection := config.Selection
+		if selection == "" {
+			selection = "/"
+		}
+
+		nodes, err := selectNodes(root, selection)
+		if err != nil {
+			return nil, err
+		}
+		if len(nodes) == 0 {
+			log.Printf("D! [parsers.xml] No nodes found for metric_selection %q", selection)
+		}
+
+		for _, node := range nodes {
+			m, err := p.parseNode(node, config, now)
+			if err != nil {
+				return nil, err
+			}
+			metrics = append(metrics, m)
+		}
+	}
+	return metrics, nil
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(metrics) {
+	case 0:
+		return nil, nil
+	case 1:
+		return metrics[0], nil
+	default:
+		return nil, fmt.Errorf("cannot parse line with multiple (%d) metrics", len(metrics))
+	}
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+func (p *Parser) parseNode(node *xmlquery.NodeNavigator, config Config, now time.Time) (telegraf.Metric, error) {
+	name := p.MetricName
+	if config.MetricQuery != "" {
+		v, err := executeQuery(node, config.MetricQuery)
+		if err != nil {
+			return nil, err
+		}
+		s, ok := v.(string)
+		if !ok || s == "" {
+			return nil, fmt.Errorf("metric_name query %q did not return a string", config.MetricQuery)
+		}
+		name = s
+	}
+
+	timestamp := now
+	if config.Timestamp != "" {
+		v, err := executeQuery(node, config.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			return nil, fmt.Errorf("timestamp query %q did not match", config.Timestamp)
+		}
+
+		format := config.TimestampFmt
+		if format == "" {
+			format = time.RFC3339
+		}
+		timestamp, err = internal.ParseTimestamp(v, format)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tags := make(map[string]string)
+	for key, query := range config.Tags {
+		v, err := executeQuery(node, query)
+		if err != nil {
+			return nil, err
+		}
+
+		switch v := v.(type) {
+		case nil:
+			continue
+		case string:
+			tags[key] = v
+		case float64:
+			tags[key] = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			tags[key] = strconv.FormatBool(v)
+		}
+	}
+	for key, value := range p.DefaultTags {
+		if _, ok := tags[key]; !ok {
+			tags[key] = value
+		}
+	}
+
+	fields := make(map[string]interface{})
+	if config.FieldSelection != "" {
+		err := p.parseFieldSelection(fields, node, config)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for key, query := range config.Fields {
+		v, err := executeQuery(node, query)
+		if err != nil {
+			return nil, err
+		}
+		if v != nil {
+			fields[key] = v
+		}
+	}
+
+	for key, query := range config.FieldsInt {
+		v, err := executeQuery(node, query)
+		if err != nil {
+			return nil, err
+		}
+
+		switch v := v.(type) {
+		case nil:
+			continue
+		case string:
+			n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %v", key, err)
+			}
+			fields[key] = n
+		case float64:
+			fields[key] = int64(v)
+		case bool:
+			if v {
+				fields[key] = int64(1)
+			} else {
+				fields[key] = int64(0)
+			}
+		}
+	}
+
+	return metric.New(name, tags, fields, timestamp)
+}
+
+// parseFieldSelection adds a field for each node matched by the field
+// selection.
+func (p *Parser) parseFieldSelection(fields map[string]interface{}, node *xmlquery.NodeNavigator, config Config) error {
+	nameQuery := config.FieldNameQuery
+	if nameQuery == "" {
+		nameQuery = "name()"
+	}
+	valueQuery := config.FieldValueQuery
+	if valueQuery == "" {
+		valueQuery = "."
+	}
+
+	selected, err := selectNodes(node, config.FieldSelection)
+	if err != nil {
+		return err
+	}
+	for _, fieldNode := range selected {
+		v, err := executeQuery(fieldNode, nameQuery)
+		if err != nil {
+			return err
+		}
+		key, ok := v.(string)
+		if !ok || key == "" {
+			return fmt.Errorf("field_name query %q did not return a string", nameQuery)
+		}
+
+		if config.FieldNameExpand {
+			// The current node of an attribute is the element it belongs to.
+			parent := fieldNode.Current()
+			if fieldNode.NodeType() != xpath.AttributeNode {
+				parent = parent.Parent
+			}
+			for ; parent != node.Current() && parent.Type == xmlquery.ElementNode; parent = parent.Parent {
+				key = parent.Data + "_" + key
+			}
+		}
+
+		v, err = executeQuery(fieldNode, valueQuery)
+		if err != nil {
+			return err
+		}
+		if v != nil {
+			fields[key] = v
+		}
+	}
+	return nil
+}
+
+// selectNodes returns the nodes matched by the query relative to the node.
+func selectNodes(node *xmlquery.NodeNavigator, query string) ([]*xmlquery.NodeNavigator, error) {
+	expr, err := xpath.Compile(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid xml query %q: %v", query, err)
+	}
+
+	var nodes []*xmlquery.NodeNavigator
+	iter := expr.Select(node.Copy())
+	for iter.MoveNext() {
+		nodes = append(nodes, iter.Current().Copy().(*xmlquery.NodeNavigator))
+	}
+	return nodes, nil
+}
+
+// executeQuery evaluates the query relative to the node.  The result is a
+// float64, bool or string; a node set is converted to the text of its first
+// node, or nil if empty.
+func executeQuery(node *xmlquery.NodeNavigator, query string) (interface{}, error) {
+	expr, err := xpath.Compile(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid xml query %q: %v", query, err)
+	}
+
+	switch v := expr.Evaluate(node.Copy()).(type) {
+	case *xpath.NodeIterator:
+		if v.MoveNext() {
+			return v.Current().Value(), nil
+		}
+		return nil, nil
+	case float64, bool, string:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("unsupported result type %T of xml query %q", v, query)
+	}
+}
+
+func init() {
+	parsers.Add("xml", func(defaultMetricName string) parsers.Parser {
+		return &Parser{MetricName: defaultMetricName}
+	})
+}
@@ -0,0 +1,369 @@
+package xml
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const gatewayXML = `<?xml version="1.0"?>
+<Gateway>
+  <Name>Main Gateway</Name>
+  <Timestamp>2020-08-01T15:04:03Z</Timestamp>
+  <Sequence>12</Sequence>
+  <Status>ok</Status>
+  <Bus>
+    <Sensor name="Sensor Facility A">
+      <Variable temperature="20.0"/>
+      <Variable power="123.4"/>
+      <Variable frequency="49.78"/>
+      <Mode>busy</Mode>
+    </Sensor>
+    <Sensor name="Sensor Facility B">
+      <Variable temperature="23.1"/>
+      <Variable power="14.3"/>
+      <Variable frequency="49.78"/>
+      <Mode>standby</Mode>
+    </Sensor>
+  </Bus>
+</Gateway>
+`
+
+const deviceXML = `<?xml version="1.0"?>
+<Device>
+  <Stats>
+    <Uptime>3600</Uptime>
+    <Load>
+      <Short>0.5</Short>
+      <Long>0.25</Long>
+    </Load>
+  </Stats>
+</Device>
+`
+
+var defaultTime = time.Unix(0, 0)
+
+func newParser(t *testing.T, configs ...Config) *Parser {
+	p, err := NewParser("xml", configs, nil)
+	require.NoError(t, err)
+	p.TimeFunc = func() time.Time { return defaultTime }
+	return p
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		configs  []Config
+		input    string
+		expected []telegraf.Metric
+	}{
+		{
+			name: "document root",
+			configs: []Config{
+				{
+					Fields: map[string]string{
+						"seqnr": "/Gateway/Sequence",
+					},
+				},
+			},
+			input: gatewayXML,
+			expected: []telegraf.Metric{
+				testutil.MustMetric(
+					"xml",
+					map[string]string{},
+					map[string]interface{}{
+						"seqnr": "12",
+					},
+					defaultTime,
+				),
+			},
+		},
+		{
+			name: "typed fields",
+			configs: []Config{
+				{
+					MetricQuery: "name(/*[1])",
+					Timestamp:   "/Gateway/Timestamp",
+					Tags: map[string]string{
+						"gateway": "/Gateway/Name",
+					},
+					Fields: map[string]string{
+						"seqnr":   "number(/Gateway/Sequence)",
+						"ok":      "/Gateway/Status = 'ok'",
+						"status":  "/Gateway/Status",
+						"sensors": "count(/Gateway/Bus/Sensor)",
+					},
+					FieldsInt: map[string]string{
+						"seqnr_int": "/Gateway/Sequence",
+					},
+				},
+			},
+			input: gatewayXML,
+			expected: []telegraf.Metric{
+				testutil.MustMetric(
+					"Gateway",
+					map[string]string{
+						"gateway": "Main Gateway",
+					},
+					map[string]interface{}{
+						"seqnr":     12.0,
+						"ok":        true,
+						"status":    "ok",
+						"sensors":   2.0,
+						"seqnr_int": int64(12),
+					},
+					time.Date(2020, 8, 1, 15, 4, 3, 0, time.UTC),
+				),
+			},
+		},
+		{
+			name: "metric selection",
+			configs: []Config{
+				{
+					Selection:   "/Gateway/Bus/Sensor",
+					MetricQuery: "string('sensor')",
+					Tags: map[string]string{
+						"name":    "substring-after(@name, 'Facility ')",
+						"gateway": "/Gateway/Name",
+					},
+					Fields: map[string]string{
+						"temperature": "number(Variable/@temperature)",
+						"mode":        "Mode",
+						"busy":        "Mode = 'busy'",
+					},
+				},
+			},
+			input: gatewayXML,
+			expected: []telegraf.Metric{
+				testutil.MustMetric(
+					"sensor",
+					map[string]string{
+						"name":    "A",
+						"gateway": "Main Gateway",
+					},
+					map[string]interface{}{
+						"temperature": 20.0,
+						"mode":        "busy",
+						"busy":        true,
+					},
+					defaultTime,
+				),
+				testutil.MustMetric(
+					"sensor",
+					map[string]string{
+						"name":    "B",
+						"gateway": "Main Gateway",
+					},
+					map[string]interface{}{
+						"temperature": 23.1,
+						"mode":        "standby",
+						"busy":        false,
+					},
+					defaultTime,
+				),
+			},
+		},
+		{
+			name: "field selection",
+			configs: []Config{
+				{
+					Selection:       "/Gateway/Bus/Sensor",
+					Tags:            map[string]string{"name": "@name"},
+					FieldSelection:  "Variable/@*",
+					FieldNameQuery:  "name()",
+					FieldValueQuery: "number(.)",
+				},
+			},
+			input: gatewayXML,
+			expected: []telegraf.Metric{
+				testutil.MustMetric(
+					"xml",
+					map[string]string{"name": "Sensor Facility A"},
+					map[string]interface{}{
+						"temperature": 20.0,
+						"power":       123.4,
+						"frequency":   49.78,
+					},
+					defaultTime,
+				),
+				testutil.MustMetric(
+					"xml",
+					map[string]string{"name": "Sensor Facility B"},
+					map[string]interface{}{
+						"temperature": 23.1,
+						"power":       14.3,
+						"frequency":   49.78,
+					},
+					defaultTime,
+				),
+			},
+		},
+		{
+			name: "field name expansion",
+			configs: []Config{
+				{
+					Selection:       "/Device",
+					FieldSelection:  "descendant::*[not(*)]",
+					FieldValueQuery: "number(.)",
+					FieldNameExpand: true,
+				},
+			},
+			input: deviceXML,
+			expected: []telegraf.Metric{
+				testutil.MustMetric(
+					"xml",
+					map[string]string{},
+					map[string]interface{}{
+						"Stats_Uptime":     3600.0,
+						"Stats_Load_Short": 0.5,
+						"Stats_Load_Long":  0.25,
+					},
+					defaultTime,
+				),
+			},
+		},
+		{
+			name: "multiple configs",
+			configs: []Config{
+				{
+					MetricQuery: "string('gateway')",
+					FieldsInt:   map[string]string{"seqnr": "/Gateway/Sequence"},
+				},
+				{
+					Selection:   "/Gateway/Bus/Sensor[1]",
+					MetricQuery: "string('sensor')",
+					Fields:      map[string]string{"mode": "Mode"},
+				},
+				{
+					Selection: "/Gateway/Missing",
+					Fields:    map[string]string{"value": "."},
+				},
+			},
+			input: gatewayXML,
+			expected: []telegraf.Metric{
+				testutil.MustMetric(
+					"gateway",
+					map[string]string{},
+					map[string]interface{}{"seqnr": int64(12)},
+					defaultTime,
+				),
+				testutil.MustMetric(
+					"sensor",
+					map[string]string{},
+					map[string]interface{}{"mode": "busy"},
+					defaultTime,
+				),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newParser(t, tt.configs...)
+			actual, err := p.Parse([]byte(tt.input))
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestParseTimestampFormat(t *testing.T) {
+	p := newParser(t, Config{
+		Timestamp:    "/Data/@time",
+		TimestampFmt: "unix_ms",
+		Fields:       map[string]string{"value": "number(/Data)"},
+	})
+
+	actual, err := p.Parse([]byte(`<Data time="1596294243123">42</Data>`))
+	require.NoError(t, err)
+	require.Len(t, actual, 1)
+	require.Equal(t, time.Unix(1596294243, 123000000).UTC(), actual[0].Time())
+}
+
+func TestParseDefaultTags(t *testing.T) {
+	p := newParser(t, Config{
+		Tags:   map[string]string{"host": "/Data/@host"},
+		Fields: map[string]string{"value": "number(/Data)"},
+	})
+	p.SetDefaultTags(map[string]string{"host": "default", "region": "east"})
+
+	actual, err := p.Parse([]byte(`<Data host="example.org">42</Data>`))
+	require.NoError(t, err)
+	require.Len(t, actual, 1)
+	require.Equal(t, map[string]string{
+		"host":   "example.org",
+		"region": "east",
+	}, actual[0].Tags())
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		input  string
+	}{
+		{
+			name:   "invalid document",
+			config: Config{Fields: map[string]string{"value": "."}},
+			input:  `<Data>42</Dat`,
+		},
+		{
+			name:   "invalid integer",
+			config: Config{FieldsInt: map[string]string{"value": "/Data"}},
+			input:  `<Data>forty-two</Data>`,
+		},
+		{
+			name: "invalid timestamp",
+			config: Config{
+				Timestamp: "/Data/@time",
+				Fields:    map[string]string{"value": "/Data"},
+			},
+			input: `<Data time="yesterday">42</Data>`,
+		},
+		{
+			name: "metric name not a string",
+			config: Config{
+				MetricQuery: "count(/Data)",
+				Fields:      map[string]string{"value": "/Data"},
+			},
+			input: `<Data>42</Data>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newParser(t, tt.config)
+			_, err := p.Parse([]byte(tt.input))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestParseLine(t *testing.T) {
+	p := newParser(t, Config{
+		Selection: "/Data/Value",
+		Fields:    map[string]string{"value": "number(.)"},
+	})
+
+	m, err := p.ParseLine(`<Data><Value>42</Value></Data>`)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"value": 42.0}, m.Fields())
+
+	m, err = p.ParseLine(`<Data></Data>`)
+	require.NoError(t, err)
+	require.Nil(t, m)
+
+	_, err = p.ParseLine(`<Data><Value>1</Value><Value>2</Value></Data>`)
+	require.Error(t, err)
+}
+
+func TestNewParserInvalidQuery(t *testing.T) {
+	_, err := NewParser("xml", []Config{
+		{Fields: map[string]string{"value": "/Data["}},
+	}, nil)
+	require.Error(t, err)
+
+	_, err = NewParser("xml", nil, nil)
+	require.Error(t, err)
+}
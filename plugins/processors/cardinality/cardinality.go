@@ -0,0 +1,304 @@
+package cardinality
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/processors"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+const sampleConfig = `
+  ## Values and series not seen for the window are forgotten.
+  # window = "1h"
+
+  ## Maximum number of values of each tag of a measurement, 0 for no limit.
+  # max_tag_values = 1000
+
+  ## Maximum number of series of each measurement, 0 for no limit.  Metrics
+  ## of new series over the limit are dropped.
+  # max_series = 100000
+
+  ## Action on a tag value over the limit, "drop_tag" to remove the tag,
+  ## "overflow" to replace the value with the overflow_value or "drop_metric"
+  ## to drop the metric.
+  # action = "overflow"
+
+  ## Value of the tags over the limit with the "overflow" action.
+  # overflow_value = "overflow"
+`
+
+// Actions on the tag values over the limit.
+const (
+	actionDropTag    = "drop_tag"
+	actionOverflow   = "overflow"
+	actionDropMetric = "drop_metric"
+)
+
+// Cardinality limits the number of values of the tags and the number of
+// series of each measurement.
+type Cardinality struct {
+	Window        internal.Duration `toml:"window"`
+	MaxTagValues  int               `toml:"max_tag_values"`
+	MaxSeries     int               `toml:"max_series"`
+	Action        string            `toml:"action"`
+	OverflowValue string            `toml:"overflow_value"`
+	Log           telegraf.Logger   `toml:"-"`
+
+	measurements map[string]*measurement
+	offenders    map[offenderKey]*offender
+	lastSweep    time.Time
+	now          func() time.Time
+}
+
+// measurement holds the tag values and series seen of a measurement.
+type measurement struct {
+	tags   map[string]*tracker
+	series *tracker
+}
+
+// tracker holds the hashes of the values seen and when they were last seen.
+type tracker struct {
+	seen map[uint64]time.Time
+}
+
+// offenderKey is a measurement and a tag key, empty for the series of the
+// measurement.
+type offenderKey struct {
+	measurement string
+	tag         string
+}
+
+// offender holds the statistics of a tag or series over the limit.
+type offender struct {
+	count   selfstat.Stat
+	limited selfstat.Stat
+}
+
+func New() *Cardinality {
+	return &Cardinality{
+		Window:        internal.Duration{Duration: time.Hour},
+		MaxTagValues:  1000,
+		MaxSeries:     100000,
+		Action:        actionOverflow,
+		OverflowValue: "overflow",
+	}
+}
+
+func (c *Cardinality) SampleConfig() string {
+	return sampleConfig
+}
+
+func (c *Cardinality) Description() string {
+	return "Limit the number of tag values and series of measurements"
+}
+
+func (c *Cardinality) Init() error {
+	switch c.Action {
+	case actionDropTag, actionOverflow, actionDropMetric:
+	default:
+		return fmt.Errorf("invalid action %q, must be %q, %q or %q",
+			c.Action, actionDropTag, actionOverflow, actionDropMetric)
+	}
+	if c.Action == actionOverflow && c.OverflowValue == "" {
+		return fmt.Errorf("overflow_value must not be empty")
+	}
+	if c.MaxTagValues < 0 || c.MaxSeries < 0 {
+		return fmt.Errorf("max_tag_values and max_series must not be negative")
+	}
+	if c.Window.Duration <= 0 {
+		return fmt.Errorf("window must be positive")
+	}
+
+	c.measurements = make(map[string]*measurement)
+	c.offenders = make(map[offenderKey]*offender)
+	if c.now == nil {
+		c.now = time.Now
+	}
+	c.lastSweep = c.now()
+	return nil
+}
+
+func (c *Cardinality) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	now := c.now()
+	if now.Sub(c.lastSweep) >= c.Window.Duration/10 {
+		c.sweep(now)
+		c.lastSweep = now
+	}
+
+	out := in[:0]
+	for _, m := range in {
+		if c.apply(m, now) {
+			out = append(out, m)
+		} else {
+			m.Drop()
+		}
+	}
+	return out
+}
+
+// apply enforces the limits on the metric, returning false if the metric is
+// to be dropped.  The values and series of a dropped metric are not tracked.
+func (c *Cardinality) apply(m telegraf.Metric, now time.Time) bool {
+	meas, ok := c.measurements[m.Name()]
+	if !ok {
+		meas = &measurement{
+			tags:   make(map[string]*tracker),
+			series: newTracker(),
+		}
+	}
+
+	// The tags are copied as the actions modify the tag list.
+	var tags []telegraf.Tag
+	if c.MaxTagValues > 0 {
+		tags = make([]telegraf.Tag, 0, len(m.TagList()))
+		for _, tag := range m.TagList() {
+			tags = append(tags, *tag)
+		}
+	}
+
+	// All tags are checked before applying the action, so that a metric
+	// dropped for one tag does not count towards the limits of the others.
+	var kept []telegraf.Tag
+	var over []string
+	for _, tag := range tags {
+		t, ok := meas.tags[tag.Key]
+		if !ok || t.fits(hash(tag.Value), c.MaxTagValues) {
+			kept = append(kept, tag)
+			continue
+		}
+		c.limited(offenderKey{measurement: m.Name(), tag: tag.Key}, len(t.seen))
+		over = append(over, tag.Key)
+	}
+
+	for _, key := range over {
+		switch c.Action {
+		case actionDropTag:
+			m.RemoveTag(key)
+		case actionOverflow:
+			m.AddTag(key, c.OverflowValue)
+		case actionDropMetric:
+			return false
+		}
+	}
+
+	id := m.HashID()
+	if c.MaxSeries > 0 && !meas.series.fits(id, c.MaxSeries) {
+		c.limited(offenderKey{measurement: m.Name()}, len(meas.series.seen))
+		return false
+	}
+
+	c.measurements[m.Name()] = meas
+	for _, tag := range kept {
+		t, ok := meas.tags[tag.Key]
+		if !ok {
+			t = newTracker()
+			meas.tags[tag.Key] = t
+		}
+		t.add(hash(tag.Value), now)
+	}
+	if c.MaxSeries > 0 {
+		meas.series.add(id, now)
+	}
+	return true
+}
+
+// limited counts a metric over the limit of the offender, logging a warning
+// the first time.
+func (c *Cardinality) limited(key offenderKey, count int) {
+	o, ok := c.offenders[key]
+	if !ok {
+		if key.tag != "" {
+			c.Log.Warnf("Tag %q of measurement %q exceeds %d values, applying %s",
+				key.tag, key.measurement, c.MaxTagValues, c.Action)
+			tags := map[string]string{"measurement": key.measurement, "tag": key.tag}
+			o = &offender{
+				count:   selfstat.Register("cardinality", "tag_values", tags),
+				limited: selfstat.Register("cardinality", "metrics_limited", tags),
+			}
+		} else {
+			c.Log.Warnf("Measurement %q exceeds %d series, dropping new series",
+				key.measurement, c.MaxSeries)
+			tags := map[string]string{"measurement": key.measurement}
+			o = &offender{
+				count:   selfstat.Register("cardinality", "series", tags),
+				limited: selfstat.Register("cardinality", "metrics_limited", tags),
+			}
+		}
+		c.offenders[key] = o
+	}
+	o.count.Set(int64(count))
+	o.limited.Incr(1)
+}
+
+// sweep forgets the values and series not seen for the window.
+func (c *Cardinality) sweep(now time.Time) {
+	expiry := now.Add(-c.Window.Duration)
+	for name, meas := range c.measurements {
+		for key, t := range meas.tags {
+			t.expire(expiry)
+			if len(t.seen) == 0 {
+				delete(meas.tags, key)
+			}
+		}
+		meas.series.expire(expiry)
+		if len(meas.series.seen) == 0 && len(meas.tags) == 0 {
+			delete(c.measurements, name)
+		}
+	}
+	for key, o := range c.offenders {
+		meas, ok := c.measurements[key.measurement]
+		switch {
+		case !ok:
+			o.count.Set(0)
+		case key.tag == "":
+			o.count.Set(int64(len(meas.series.seen)))
+		case meas.tags[key.tag] != nil:
+			o.count.Set(int64(len(meas.tags[key.tag].seen)))
+		default:
+			o.count.Set(0)
+		}
+	}
+}
+
+func newTracker() *tracker {
+	return &tracker{seen: make(map[uint64]time.Time)}
+}
+
+// fits returns false if the value is new and the tracker already holds limit
+// values.  A limit of 0 is no limit.
+func (t *tracker) fits(v uint64, limit int) bool {
+	if _, ok := t.seen[v]; ok {
+		return true
+	}
+	return limit <= 0 || len(t.seen) < limit
+}
+
+// add marks the value as seen.
+func (t *tracker) add(v uint64, now time.Time) {
+	t.seen[v] = now
+}
+
+// expire forgets the values last seen before the expiry.
+func (t *tracker) expire(expiry time.Time) {
+	for v, seen := range t.seen {
+		if seen.Before(expiry) {
+			delete(t.seen, v)
+		}
+	}
+}
+
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+func init() {
+	processors.Add("cardinality", func() telegraf.Processor {
+		return New()
+	})
+}
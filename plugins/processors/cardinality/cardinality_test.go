@@ -0,0 +1,396 @@
+package cardinality
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/selfstat"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCardinality(t *testing.T) {
+	now := time.Unix(0, 0)
+	tests := []struct {
+		name        string
+		cardinality *Cardinality
+		metrics     []telegraf.Metric
+		expected    []telegraf.Metric
+	}{
+		{
+			name: "overflow",
+			cardinality: &Cardinality{
+				Window:        internal.Duration{Duration: time.Hour},
+				MaxTagValues:  2,
+				Action:        "overflow",
+				OverflowValue: "overflow",
+			},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric("http",
+					map[string]string{"host": "a", "request_id": "1"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("http",
+					map[string]string{"host": "a", "request_id": "2"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("http",
+					map[string]string{"host": "a", "request_id": "3"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("http",
+					map[string]string{"host": "a", "request_id": "1"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("http",
+					map[string]string{"host": "a", "request_id": "1"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("http",
+					map[string]string{"host": "a", "request_id": "2"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("http",
+					map[string]string{"host": "a", "request_id": "overflow"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("http",
+					map[string]string{"host": "a", "request_id": "1"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+			},
+		},
+		{
+			name: "drop tag",
+			cardinality: &Cardinality{
+				Window:       internal.Duration{Duration: time.Hour},
+				MaxTagValues: 2,
+				Action:       "drop_tag",
+			},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric("http",
+					map[string]string{"a": "1", "b": "1", "c": "1"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("http",
+					map[string]string{"a": "2", "b": "2", "c": "1"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("http",
+					map[string]string{"a": "3", "b": "3", "c": "1"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("http",
+					map[string]string{"a": "1", "b": "1", "c": "1"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("http",
+					map[string]string{"a": "2", "b": "2", "c": "1"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("http",
+					map[string]string{"c": "1"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+			},
+		},
+		{
+			name: "drop metric",
+			cardinality: &Cardinality{
+				Window:       internal.Duration{Duration: time.Hour},
+				MaxTagValues: 2,
+				Action:       "drop_metric",
+			},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric("http",
+					map[string]string{"host": "a", "request_id": "1"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("http",
+					map[string]string{"host": "a", "request_id": "2"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("http",
+					map[string]string{"host": "a", "request_id": "3"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("http",
+					map[string]string{"host": "a", "request_id": "2"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("http",
+					map[string]string{"host": "a", "request_id": "1"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("http",
+					map[string]string{"host": "a", "request_id": "2"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("http",
+					map[string]string{"host": "a", "request_id": "2"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+			},
+		},
+		{
+			name: "values of dropped metrics are not tracked",
+			cardinality: &Cardinality{
+				Window:       internal.Duration{Duration: time.Hour},
+				MaxTagValues: 2,
+				Action:       "drop_metric",
+			},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric("http",
+					map[string]string{"a": "1", "b": "1"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("http",
+					map[string]string{"a": "1", "b": "2"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				// Dropped for b, the new value of a is not tracked.
+				testutil.MustMetric("http",
+					map[string]string{"a": "2", "b": "3"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("http",
+					map[string]string{"a": "3", "b": "1"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("http",
+					map[string]string{"a": "1", "b": "1"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("http",
+					map[string]string{"a": "1", "b": "2"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("http",
+					map[string]string{"a": "3", "b": "1"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+			},
+		},
+		{
+			name: "measurements are separate",
+			cardinality: &Cardinality{
+				Window:       internal.Duration{Duration: time.Hour},
+				MaxTagValues: 2,
+				Action:       "drop_metric",
+			},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric("http",
+					map[string]string{"host": "a", "request_id": "1"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("http",
+					map[string]string{"host": "a", "request_id": "2"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("grpc",
+					map[string]string{"host": "a", "request_id": "3"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("http",
+					map[string]string{"host": "a", "request_id": "1"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("http",
+					map[string]string{"host": "a", "request_id": "2"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("grpc",
+					map[string]string{"host": "a", "request_id": "3"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+			},
+		},
+		{
+			name: "max series",
+			cardinality: &Cardinality{
+				Window:    internal.Duration{Duration: time.Hour},
+				MaxSeries: 3,
+				Action:    "drop_metric",
+			},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric("http",
+					map[string]string{"host": "a", "port": "80"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("http",
+					map[string]string{"host": "a", "port": "443"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("http",
+					map[string]string{"host": "b", "port": "80"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("http",
+					map[string]string{"host": "b", "port": "443"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				// Known series are passed.
+				testutil.MustMetric("http",
+					map[string]string{"host": "a", "port": "80"},
+					map[string]interface{}{"value": 2.0},
+					now,
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("http",
+					map[string]string{"host": "a", "port": "80"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("http",
+					map[string]string{"host": "a", "port": "443"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("http",
+					map[string]string{"host": "b", "port": "80"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("http",
+					map[string]string{"host": "a", "port": "80"},
+					map[string]interface{}{"value": 2.0},
+					now,
+				),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cardinality.Log = testutil.Logger{}
+			require.NoError(t, tt.cardinality.Init())
+
+			actual := tt.cardinality.Apply(tt.metrics...)
+			testutil.RequireMetricsEqual(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestWindow(t *testing.T) {
+	now := time.Unix(0, 0)
+	c := &Cardinality{
+		Window:       internal.Duration{Duration: 10 * time.Minute},
+		MaxTagValues: 2,
+		Action:       "drop_metric",
+		Log:          testutil.Logger{},
+		now:          func() time.Time { return now },
+	}
+	require.NoError(t, c.Init())
+
+	steps := []struct {
+		advance  time.Duration
+		ids      []string
+		expected []string
+	}{
+		{ids: []string{"1", "2", "3"}, expected: []string{"1", "2"}},
+		{advance: 6 * time.Minute, ids: []string{"2", "3"}, expected: []string{"2"}},
+		// 1 has not been seen for the window and is forgotten.
+		{advance: 5 * time.Minute, ids: []string{"3"}, expected: []string{"3"}},
+		{ids: []string{"2", "3", "1"}, expected: []string{"2", "3"}},
+	}
+	for _, step := range steps {
+		now = now.Add(step.advance)
+
+		var metrics, expected []telegraf.Metric
+		for _, id := range step.ids {
+			metrics = append(metrics, testutil.MustMetric("http",
+				map[string]string{"request_id": id},
+				map[string]interface{}{"value": 1.0},
+				time.Unix(0, 0),
+			))
+		}
+		for _, id := range step.expected {
+			expected = append(expected, testutil.MustMetric("http",
+				map[string]string{"request_id": id},
+				map[string]interface{}{"value": 1.0},
+				time.Unix(0, 0),
+			))
+		}
+		actual := c.Apply(metrics...)
+		testutil.RequireMetricsEqual(t, expected, actual)
+	}
+}
+
+func TestStats(t *testing.T) {
+	c := &Cardinality{
+		Window:        internal.Duration{Duration: time.Hour},
+		MaxTagValues:  2,
+		Action:        "overflow",
+		OverflowValue: "overflow",
+		Log:           testutil.Logger{},
+	}
+	require.NoError(t, c.Init())
+
+	c.Apply(testutil.MustMetric("http",
+		map[string]string{"user": "x@example.com"},
+		map[string]interface{}{"value": 1.0},
+		time.Unix(0, 0),
+	))
+	for i := 0; i < 5; i++ {
+		c.Apply(testutil.MustMetric("http",
+			map[string]string{"user": fmt.Sprintf("%d@example.com", i)},
+			map[string]interface{}{"value": 1.0},
+			time.Unix(0, 0),
+		))
+	}
+
+	tags := map[string]string{"measurement": "http", "tag": "user"}
+	require.Equal(t, int64(2), selfstat.Register("cardinality", "tag_values", tags).Get())
+	require.Equal(t, int64(4), selfstat.Register("cardinality", "metrics_limited", tags).Get())
+}
@@ -0,0 +1,222 @@
+package dedup
+
+import (
+	"container/list"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const sampleConfig = `
+  ## Maximum time to suppress unchanged values, a metric is passed at least
+  ## once per interval as a heartbeat.
+  dedup_interval = "10m"
+
+  ## Numeric values differing by at most the tolerance from the last value
+  ## passed are unchanged.
+  # tolerance = 0.0
+
+  ## Remove only the unchanged fields of a metric, the metric is dropped if
+  ## all of its fields are unchanged.  Otherwise a metric is passed with all
+  ## of its fields if any of them has changed.
+  # per_field = false
+
+  ## Maximum number of series to remember, the least recently seen series
+  ## are forgotten first.
+  # cache_size = 100000
+`
+
+// Dedup drops metrics, or their fields, that did not change since the last
+// metric of the series was passed.
+type Dedup struct {
+	DedupInterval internal.Duration `toml:"dedup_interval"`
+	Tolerance     float64           `toml:"tolerance"`
+	PerField      bool              `toml:"per_field"`
+	CacheSize     int               `toml:"cache_size"`
+
+	cache map[uint64]*list.Element
+	lru   *list.List
+}
+
+// entry holds the values last passed of a series.
+type entry struct {
+	id     uint64
+	fields map[string]value
+}
+
+// value is a field value and the time it was passed.
+type value struct {
+	value interface{}
+	time  time.Time
+}
+
+func New() *Dedup {
+	return &Dedup{
+		DedupInterval: internal.Duration{Duration: 10 * time.Minute},
+		CacheSize:     100000,
+	}
+}
+
+func (d *Dedup) SampleConfig() string {
+	return sampleConfig
+}
+
+func (d *Dedup) Description() string {
+	return "Filter metrics with repeating field values"
+}
+
+func (d *Dedup) Init() error {
+	if d.CacheSize <= 0 {
+		return fmt.Errorf("cache_size must be positive")
+	}
+	if d.Tolerance < 0 {
+		return fmt.Errorf("tolerance must not be negative")
+	}
+	d.cache = make(map[uint64]*list.Element)
+	d.lru = list.New()
+	return nil
+}
+
+func (d *Dedup) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	out := in[:0]
+	for _, m := range in {
+		var keep bool
+		if d.PerField {
+			keep = d.applyFields(m)
+		} else {
+			keep = d.applyMetric(m)
+		}
+
+		if keep {
+			out = append(out, m)
+		} else {
+			m.Drop()
+		}
+	}
+	return out
+}
+
+// applyMetric returns true if the metric has changed or its heartbeat is
+// due, the cache is updated with the fields of passed metrics.
+func (d *Dedup) applyMetric(m telegraf.Metric) bool {
+	e := d.lookup(m.HashID())
+
+	fields := m.FieldList()
+	changed := len(fields) != len(e.fields)
+	for _, field := range fields {
+		if changed {
+			break
+		}
+		last, ok := e.fields[field.Key]
+		changed = !ok || d.changed(last, field.Value, m.Time())
+	}
+	if !changed {
+		return false
+	}
+
+	e.fields = make(map[string]value, len(fields))
+	for _, field := range fields {
+		e.fields[field.Key] = value{value: field.Value, time: m.Time()}
+	}
+	return true
+}
+
+// applyFields removes the fields that have not changed and whose heartbeat
+// is not due, returning true if any field remains.
+func (d *Dedup) applyFields(m telegraf.Metric) bool {
+	e := d.lookup(m.HashID())
+
+	var unchanged []string
+	for _, field := range m.FieldList() {
+		last, ok := e.fields[field.Key]
+		if ok && !d.changed(last, field.Value, m.Time()) {
+			unchanged = append(unchanged, field.Key)
+			continue
+		}
+		e.fields[field.Key] = value{value: field.Value, time: m.Time()}
+	}
+
+	if len(unchanged) == len(m.FieldList()) {
+		return false
+	}
+	for _, key := range unchanged {
+		m.RemoveField(key)
+	}
+	return true
+}
+
+// changed returns true if the value differs from the last value passed, or
+// if the last value was passed an interval before t.
+func (d *Dedup) changed(last value, v interface{}, t time.Time) bool {
+	if t.Sub(last.time) >= d.DedupInterval.Duration {
+		return true
+	}
+
+	a, aok := toFloat(last.value)
+	b, bok := toFloat(v)
+	if aok && bok && sameKind(last.value, v) {
+		return math.Abs(a-b) > d.Tolerance
+	}
+	return last.value != v
+}
+
+// lookup returns the cache entry of the series, creating it if needed and
+// evicting the least recently seen series if the cache is full.
+func (d *Dedup) lookup(id uint64) *entry {
+	if d.cache == nil {
+		d.cache = make(map[uint64]*list.Element)
+		d.lru = list.New()
+	}
+
+	if elem, ok := d.cache[id]; ok {
+		d.lru.MoveToFront(elem)
+		return elem.Value.(*entry)
+	}
+
+	for d.CacheSize > 0 && d.lru.Len() >= d.CacheSize {
+		oldest := d.lru.Back()
+		d.lru.Remove(oldest)
+		delete(d.cache, oldest.Value.(*entry).id)
+	}
+
+	e := &entry{id: id, fields: make(map[string]value)}
+	d.cache[id] = d.lru.PushFront(e)
+	return e
+}
+
+func sameKind(a, b interface{}) bool {
+	switch a.(type) {
+	case int64:
+		_, ok := b.(int64)
+		return ok
+	case uint64:
+		_, ok := b.(uint64)
+		return ok
+	case float64:
+		_, ok := b.(float64)
+		return ok
+	}
+	return false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+func init() {
+	processors.Add("dedup", func() telegraf.Processor {
+		return New()
+	})
+}
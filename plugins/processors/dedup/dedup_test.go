@@ -0,0 +1,312 @@
+package dedup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDedup(t *testing.T) {
+	now := time.Unix(0, 0)
+	tests := []struct {
+		name     string
+		dedup    *Dedup
+		metrics  []telegraf.Metric
+		expected []telegraf.Metric
+	}{
+		{
+			name: "drop unchanged",
+			dedup: &Dedup{
+				DedupInterval: internal.Duration{Duration: 10 * time.Minute},
+				CacheSize:     10,
+			},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"value": 1.0, "state": "up"},
+					now,
+				),
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"value": 1.0, "state": "up"},
+					now.Add(time.Minute),
+				),
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"value": 1.0, "state": "down"},
+					now.Add(2*time.Minute),
+				),
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"value": 1.0, "state": "down", "extra": int64(1)},
+					now.Add(3*time.Minute),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"value": 1.0, "state": "up"},
+					now,
+				),
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"value": 1.0, "state": "down"},
+					now.Add(2*time.Minute),
+				),
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"value": 1.0, "state": "down", "extra": int64(1)},
+					now.Add(3*time.Minute),
+				),
+			},
+		},
+		{
+			name: "series are separate",
+			dedup: &Dedup{
+				DedupInterval: internal.Duration{Duration: 10 * time.Minute},
+				CacheSize:     10,
+			},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "b"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "b"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+			},
+		},
+		{
+			name: "heartbeat",
+			dedup: &Dedup{
+				DedupInterval: internal.Duration{Duration: 10 * time.Minute},
+				CacheSize:     10,
+			},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"value": int64(42)},
+					now,
+				),
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"value": int64(42)},
+					now.Add(5*time.Minute),
+				),
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"value": int64(42)},
+					now.Add(10*time.Minute),
+				),
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"value": int64(42)},
+					now.Add(15*time.Minute),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"value": int64(42)},
+					now,
+				),
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"value": int64(42)},
+					now.Add(10*time.Minute),
+				),
+			},
+		},
+		{
+			name: "tolerance",
+			dedup: &Dedup{
+				DedupInterval: internal.Duration{Duration: 10 * time.Minute},
+				Tolerance:     0.5,
+				CacheSize:     10,
+			},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"value": 10.0},
+					now,
+				),
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"value": 10.3},
+					now.Add(time.Second),
+				),
+				// Compared with the last value passed, so slow drift is
+				// detected.
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"value": 10.6},
+					now.Add(2*time.Second),
+				),
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"value": 10.2},
+					now.Add(3*time.Second),
+				),
+				// A type change is a change.
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"value": int64(10)},
+					now.Add(4*time.Second),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"value": 10.0},
+					now,
+				),
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"value": 10.6},
+					now.Add(2*time.Second),
+				),
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"value": int64(10)},
+					now.Add(4*time.Second),
+				),
+			},
+		},
+		{
+			name: "per field",
+			dedup: &Dedup{
+				DedupInterval: internal.Duration{Duration: 10 * time.Minute},
+				PerField:      true,
+				CacheSize:     10,
+			},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"a": int64(1), "b": int64(2)},
+					now,
+				),
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"a": int64(1), "b": int64(3)},
+					now.Add(time.Minute),
+				),
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"a": int64(1), "b": int64(3)},
+					now.Add(2*time.Minute),
+				),
+				// The heartbeat of a is due before the one of b.
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"a": int64(1), "b": int64(3)},
+					now.Add(10*time.Minute),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"a": int64(1), "b": int64(2)},
+					now,
+				),
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"b": int64(3)},
+					now.Add(time.Minute),
+				),
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"a": int64(1)},
+					now.Add(10*time.Minute),
+				),
+			},
+		},
+		{
+			name: "cache eviction",
+			dedup: &Dedup{
+				DedupInterval: internal.Duration{Duration: 10 * time.Minute},
+				CacheSize:     2,
+			},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "b"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "c"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				// b was the least recently seen series and has been
+				// evicted.
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "b"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "b"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "c"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("snmp",
+					map[string]string{"host": "b"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.dedup.Init())
+
+			var actual []telegraf.Metric
+			for _, m := range tt.metrics {
+				actual = append(actual, tt.dedup.Apply(m)...)
+			}
+			testutil.RequireMetricsEqual(t, tt.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package execd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/process"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/processors"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+const sampleConfig = `
+  ## Program to run as daemon
+  ## eg: command = ["/path/to/your_program", "arg1", "arg2"]
+  command = ["cat"]
+
+  ## Delay before the process is restarted after an unexpected termination,
+  ## the delay doubles if the process keeps exiting.
+  restart_delay = "10s"
+
+  ## Data format used to exchange metrics with the program, it is used both
+  ## to serialize the metrics written to its stdin and to parse its stdout.
+  ## Each data format has its own unique set of configuration options, read
+  ## more about them here:
+  ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_INPUT.md
+  ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_OUTPUT.md
+  data_format = "influx"
+`
+
+type Execd struct {
+	Command      []string          `toml:"command"`
+	RestartDelay internal.Duration `toml:"restart_delay"`
+	Log          telegraf.Logger   `toml:"-"`
+
+	parser     parsers.Parser
+	serializer serializers.Serializer
+	acc        telegraf.Accumulator
+	process    *process.Process
+}
+
+func New() *Execd {
+	return &Execd{
+		RestartDelay: internal.Duration{Duration: 10 * time.Second},
+	}
+}
+
+func (e *Execd) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *Execd) Description() string {
+	return "Run executable as long-running processor plugin"
+}
+
+func (e *Execd) SetParser(parser parsers.Parser) {
+	e.parser = parser
+}
+
+func (e *Execd) SetSerializer(serializer serializers.Serializer) {
+	e.serializer = serializer
+}
+
+func (e *Execd) Init() error {
+	if len(e.Command) == 0 {
+		return errors.New("no command specified")
+	}
+	if e.RestartDelay.Duration <= 0 {
+		return errors.New("restart_delay must be positive")
+	}
+	return nil
+}
+
+func (e *Execd) Start(acc telegraf.Accumulator) error {
+	e.acc = acc
+
+	var err error
+	e.process, err = process.New(e.Command)
+	if err != nil {
+		return fmt.Errorf("error creating new process: %v", err)
+	}
+	e.process.Log = e.Log
+	e.process.RestartDelay = e.RestartDelay.Duration
+	e.process.ReadStdoutFn = e.cmdReadOut
+	e.process.ReadStderrFn = e.cmdReadErr
+
+	err = e.process.Start()
+	if err != nil {
+		return fmt.Errorf("failed to start process %s: %v", e.Command, err)
+	}
+	return nil
+}
+
+// Apply writes the metrics to the program, the metrics it outputs are added
+// to the accumulator passed to Start.
+func (e *Execd) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	for _, m := range in {
+		b, err := e.serializer.Serialize(m)
+		if err != nil {
+			e.Log.Errorf("Could not serialize metric: %v", err)
+			m.Drop()
+			continue
+		}
+
+		_, err = e.process.Write(b)
+		if err != nil {
+			e.Log.Errorf("Error writing to process stdin, dropping metric: %v", err)
+			m.Drop()
+			continue
+		}
+		m.Accept()
+	}
+	return nil
+}
+
+func (e *Execd) Stop() {
+	e.process.Stop()
+}
+
+func (e *Execd) cmdReadOut(out io.Reader) {
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		metrics, err := e.parser.Parse(scanner.Bytes())
+		if err != nil {
+			e.Log.Errorf("Parse error: %v", err)
+		}
+
+		for _, metric := range metrics {
+			e.acc.AddMetric(metric)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		e.Log.Errorf("Error reading stdout: %v", err)
+	}
+}
+
+func (e *Execd) cmdReadErr(out io.Reader) {
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		e.Log.Errorf("stderr: %q", scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		e.Log.Errorf("Error reading stderr: %v", err)
+	}
+}
+
+func init() {
+	processors.Add("execd", func() telegraf.Processor {
+		return New()
+	})
+}
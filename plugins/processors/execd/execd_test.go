@@ -0,0 +1,105 @@
+//go:build !windows
+// +build !windows
+
+package execd
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	influxParser "github.com/influxdata/telegraf/plugins/parsers/influx"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecd_Apply(t *testing.T) {
+	parser := influxParser.NewParser(influxParser.NewMetricHandler())
+
+	e := New()
+	e.Command = []string{"sed", "-u", "s/value=/count=/"}
+	e.RestartDelay = internal.Duration{Duration: 10 * time.Millisecond}
+	e.Log = testutil.Logger{}
+	e.SetParser(parser)
+	e.SetSerializer(influx.NewSerializer())
+	require.NoError(t, e.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, e.Start(&acc))
+
+	m := testutil.MustMetric(
+		"cpu",
+		map[string]string{"host": "a"},
+		map[string]interface{}{"value": int64(42)},
+		time.Unix(1, 0),
+	)
+	require.Empty(t, e.Apply(m))
+
+	// Stop waits until all output of the program has been read.
+	e.Stop()
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"cpu",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"count": int64(42)},
+			time.Unix(1, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestExecd_NoCommand(t *testing.T) {
+	e := New()
+	require.Error(t, e.Init())
+}
+
+func TestExecd_InvalidRestartDelay(t *testing.T) {
+	e := New()
+	e.Command = []string{"cat"}
+	e.RestartDelay.Duration = 0
+	require.Error(t, e.Init())
+}
+
+type failingSerializer struct{}
+
+func (failingSerializer) Serialize(telegraf.Metric) ([]byte, error) {
+	return nil, errors.New("serialize error")
+}
+
+func (failingSerializer) SerializeBatch([]telegraf.Metric) ([]byte, error) {
+	return nil, errors.New("serialize error")
+}
+
+func TestExecd_SerializeErrorDropsMetric(t *testing.T) {
+	e := New()
+	e.Command = []string{"cat"}
+	e.Log = testutil.Logger{}
+	e.SetParser(influxParser.NewParser(influxParser.NewMetricHandler()))
+	e.SetSerializer(failingSerializer{})
+	require.NoError(t, e.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, e.Start(&acc))
+	defer e.Stop()
+
+	// The metric is finalized even though it was not written to the program.
+	var notified int
+	m, _ := metric.WithTracking(
+		testutil.MustMetric(
+			"cpu",
+			map[string]string{},
+			map[string]interface{}{"value": int64(42)},
+			time.Unix(1, 0),
+		),
+		func(telegraf.DeliveryInfo) {
+			notified++
+		},
+	)
+	require.Empty(t, e.Apply(m))
+	require.Equal(t, 1, notified)
+}
@@ -0,0 +1,339 @@
+package geoip
+
+import (
+	"container/list"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/mmdb"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const sampleConfig = `
+  ## MaxMind DB files, such as the GeoLite2 City, Country and ASN databases.
+  ## The attributes are taken from the first database containing them.
+  databases = ["/var/lib/GeoIP/GeoLite2-City.mmdb", "/var/lib/GeoIP/GeoLite2-ASN.mmdb"]
+
+  ## Tags and fields containing the IP address to look up.  The first address
+  ## found in the databases is used.
+  ip_tags = ["client_ip"]
+  # ip_fields = []
+
+  ## Attributes to add, any of "continent_code", "country_code",
+  ## "country_name", "subdivision_code", "subdivision_name", "city",
+  ## "postal_code", "latitude", "longitude", "timezone", "asn" and "asn_org".
+  # attributes = ["country_code", "city", "latitude", "longitude", "asn", "asn_org"]
+
+  ## Language of the names.
+  # language = "en"
+
+  ## Add the attributes as "tag" or as "field".
+  # add_as = "tag"
+
+  ## Prefix of the names of the attributes.
+  # prefix = ""
+
+  ## Number of addresses to keep the attributes of, the least recently used
+  ## addresses are forgotten first.
+  # cache_size = 10000
+
+  ## Interval to check the databases for changes, they are reloaded when
+  ## their modification time changes.
+  # reload_interval = "1m"
+
+  ## Names of the attributes, replacing the default names.
+  # [processors.geoip.names]
+  #   country_code = "country"
+  #   asn_org = "isp"
+`
+
+// attributes are the paths of the attributes in the database records, the
+// language placeholder is replaced by the language.
+var attributes = map[string][]interface{}{
+	"continent_code":   {"continent", "code"},
+	"country_code":     {"country", "iso_code"},
+	"country_name":     {"country", "names", language},
+	"subdivision_code": {"subdivisions", 0, "iso_code"},
+	"subdivision_name": {"subdivisions", 0, "names", language},
+	"city":             {"city", "names", language},
+	"postal_code":      {"postal", "code"},
+	"latitude":         {"location", "latitude"},
+	"longitude":        {"location", "longitude"},
+	"timezone":         {"location", "time_zone"},
+	"asn":              {"autonomous_system_number"},
+	"asn_org":          {"autonomous_system_organization"},
+}
+
+type languagePlaceholder struct{}
+
+// language is the placeholder of the language in the attribute paths.
+var language = languagePlaceholder{}
+
+// GeoIP adds the location and autonomous system of IP addresses to metrics.
+type GeoIP struct {
+	Databases      []string          `toml:"databases"`
+	IPTags         []string          `toml:"ip_tags"`
+	IPFields       []string          `toml:"ip_fields"`
+	Attributes     []string          `toml:"attributes"`
+	Language       string            `toml:"language"`
+	AddAs          string            `toml:"add_as"`
+	Prefix         string            `toml:"prefix"`
+	Names          map[string]string `toml:"names"`
+	CacheSize      int               `toml:"cache_size"`
+	ReloadInterval internal.Duration `toml:"reload_interval"`
+	Log            telegraf.Logger   `toml:"-"`
+
+	databases []*database
+	lastCheck time.Time
+	cache     map[string]*list.Element
+	lru       *list.List
+}
+
+// database is an open database file.
+type database struct {
+	path    string
+	modTime time.Time
+	reader  *mmdb.Reader
+}
+
+// entry holds the attributes of an address.
+type entry struct {
+	ip         string
+	attributes map[string]interface{}
+}
+
+func New() *GeoIP {
+	return &GeoIP{
+		Attributes:     []string{"country_code", "city", "latitude", "longitude", "asn", "asn_org"},
+		Language:       "en",
+		AddAs:          "tag",
+		CacheSize:      10000,
+		ReloadInterval: internal.Duration{Duration: time.Minute},
+	}
+}
+
+func (g *GeoIP) SampleConfig() string {
+	return sampleConfig
+}
+
+func (g *GeoIP) Description() string {
+	return "Add the location and autonomous system of IP addresses from MaxMind databases"
+}
+
+func (g *GeoIP) Init() error {
+	if len(g.Databases) == 0 {
+		return fmt.Errorf("no databases specified")
+	}
+	if len(g.IPTags)+len(g.IPFields) == 0 {
+		return fmt.Errorf("no ip_tags or ip_fields specified")
+	}
+	for _, attr := range g.Attributes {
+		if _, ok := attributes[attr]; !ok {
+			return fmt.Errorf("invalid attribute %q", attr)
+		}
+	}
+	for attr := range g.Names {
+		if _, ok := attributes[attr]; !ok {
+			return fmt.Errorf("invalid attribute %q in names", attr)
+		}
+	}
+	switch g.AddAs {
+	case "tag", "field":
+	default:
+		return fmt.Errorf("invalid add_as %q, must be \"tag\" or \"field\"", g.AddAs)
+	}
+	if g.CacheSize <= 0 {
+		return fmt.Errorf("cache_size must be positive")
+	}
+
+	g.databases = make([]*database, 0, len(g.Databases))
+	for _, path := range g.Databases {
+		db := &database{path: path}
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if err := db.load(info.ModTime()); err != nil {
+			return err
+		}
+		g.databases = append(g.databases, db)
+	}
+	g.resetCache()
+	return nil
+}
+
+func (g *GeoIP) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	g.reload()
+
+	for _, m := range in {
+		for _, ip := range g.addresses(m) {
+			attrs := g.lookup(ip)
+			if len(attrs) == 0 {
+				continue
+			}
+			for attr, v := range attrs {
+				name := attr
+				if n, ok := g.Names[attr]; ok {
+					name = n
+				}
+				name = g.Prefix + name
+
+				if g.AddAs == "tag" {
+					m.AddTag(name, fmt.Sprint(v))
+				} else {
+					m.AddField(name, v)
+				}
+			}
+			break
+		}
+	}
+	return in
+}
+
+// addresses returns the values of the IP tags and fields in order.
+func (g *GeoIP) addresses(m telegraf.Metric) []string {
+	var addrs []string
+	for _, k := range g.IPTags {
+		if v, ok := m.GetTag(k); ok {
+			addrs = append(addrs, v)
+		}
+	}
+	for _, k := range g.IPFields {
+		if v, ok := m.GetField(k); ok {
+			if s, ok := v.(string); ok {
+				addrs = append(addrs, s)
+			}
+		}
+	}
+	return addrs
+}
+
+// lookup returns the attributes of the address, using the cache.
+func (g *GeoIP) lookup(addr string) map[string]interface{} {
+	if elem, ok := g.cache[addr]; ok {
+		g.lru.MoveToFront(elem)
+		return elem.Value.(*entry).attributes
+	}
+
+	attrs := make(map[string]interface{})
+	if ip := net.ParseIP(addr); ip != nil {
+		for _, db := range g.databases {
+			record, ok, err := db.reader.Lookup(ip)
+			if err != nil {
+				g.Log.Debugf("Looking up %s in %q: %v", addr, db.path, err)
+				continue
+			}
+			if !ok {
+				continue
+			}
+			for _, attr := range g.Attributes {
+				if _, ok := attrs[attr]; ok {
+					continue
+				}
+				if v, ok := g.attribute(record, attributes[attr]); ok {
+					attrs[attr] = v
+				}
+			}
+		}
+	}
+
+	for g.lru.Len() >= g.CacheSize {
+		oldest := g.lru.Back()
+		g.lru.Remove(oldest)
+		delete(g.cache, oldest.Value.(*entry).ip)
+	}
+	g.cache[addr] = g.lru.PushFront(&entry{ip: addr, attributes: attrs})
+	return attrs
+}
+
+// attribute returns the value at the path of the record.
+func (g *GeoIP) attribute(record interface{}, path []interface{}) (interface{}, bool) {
+	v := record
+	for _, p := range path {
+		if p == language {
+			p = g.Language
+		}
+		switch p := p.(type) {
+		case string:
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if v, ok = m[p]; !ok {
+				return nil, false
+			}
+		case int:
+			a, ok := v.([]interface{})
+			if !ok || p >= len(a) {
+				return nil, false
+			}
+			v = a[p]
+		}
+	}
+
+	switch v := v.(type) {
+	case string:
+		return v, v != ""
+	case float64:
+		return v, true
+	case uint64:
+		return int64(v), true
+	case int64:
+		return v, true
+	}
+	return nil, false
+}
+
+// reload loads the databases again if their modification time has changed,
+// at most once per reload interval.  The cache is cleared when a database is
+// reloaded.
+func (g *GeoIP) reload() {
+	now := time.Now()
+	if now.Sub(g.lastCheck) < g.ReloadInterval.Duration {
+		return
+	}
+	g.lastCheck = now
+
+	for _, db := range g.databases {
+		info, err := os.Stat(db.path)
+		if err != nil {
+			g.Log.Errorf("Checking database: %v", err)
+			continue
+		}
+		if info.ModTime().Equal(db.modTime) {
+			continue
+		}
+
+		if err := db.load(info.ModTime()); err != nil {
+			g.Log.Errorf("Reloading database: %v", err)
+			continue
+		}
+		g.Log.Debugf("Reloaded database %q", db.path)
+		g.resetCache()
+	}
+}
+
+func (g *GeoIP) resetCache() {
+	g.cache = make(map[string]*list.Element)
+	g.lru = list.New()
+}
+
+func (db *database) load(modTime time.Time) error {
+	reader, err := mmdb.Open(db.path)
+	if err != nil {
+		return fmt.Errorf("loading %q: %v", db.path, err)
+	}
+	db.reader = reader
+	db.modTime = modTime
+	return nil
+}
+
+func init() {
+	processors.Add("geoip", func() telegraf.Processor {
+		return New()
+	})
+}
@@ -0,0 +1,289 @@
+package geoip
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeoIP(t *testing.T) {
+	now := time.Unix(0, 0)
+	tests := []struct {
+		name     string
+		geoip    *GeoIP
+		metrics  []telegraf.Metric
+		expected []telegraf.Metric
+	}{
+		{
+			name: "tags",
+			geoip: &GeoIP{
+				Databases:  []string{"testdata/city.mmdb", "testdata/asn.mmdb"},
+				IPTags:     []string{"client_ip"},
+				Attributes: []string{"country_code", "city", "latitude", "longitude", "asn", "asn_org"},
+				Language:   "en",
+				AddAs:      "tag",
+				CacheSize:  10,
+			},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric("nginx",
+					map[string]string{"client_ip": "192.0.2.10"},
+					map[string]interface{}{"bytes": int64(512)},
+					now,
+				),
+				testutil.MustMetric("nginx",
+					map[string]string{"client_ip": "198.51.100.7"},
+					map[string]interface{}{"bytes": int64(512)},
+					now,
+				),
+				testutil.MustMetric("nginx",
+					map[string]string{"client_ip": "203.0.113.1"},
+					map[string]interface{}{"bytes": int64(512)},
+					now,
+				),
+				testutil.MustMetric("nginx",
+					map[string]string{"client_ip": "invalid"},
+					map[string]interface{}{"bytes": int64(512)},
+					now,
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("nginx",
+					map[string]string{
+						"client_ip":    "192.0.2.10",
+						"country_code": "DE",
+						"city":         "Berlin",
+						"latitude":     "52.5244",
+						"longitude":    "13.4105",
+						"asn":          "64512",
+						"asn_org":      "Example Networks",
+					},
+					map[string]interface{}{"bytes": int64(512)},
+					now,
+				),
+				testutil.MustMetric("nginx",
+					map[string]string{
+						"client_ip": "198.51.100.7",
+						"asn":       "64513",
+						"asn_org":   "Documentation Transit",
+					},
+					map[string]interface{}{"bytes": int64(512)},
+					now,
+				),
+				testutil.MustMetric("nginx",
+					map[string]string{"client_ip": "203.0.113.1"},
+					map[string]interface{}{"bytes": int64(512)},
+					now,
+				),
+				testutil.MustMetric("nginx",
+					map[string]string{"client_ip": "invalid"},
+					map[string]interface{}{"bytes": int64(512)},
+					now,
+				),
+			},
+		},
+		{
+			name: "fields and naming",
+			geoip: &GeoIP{
+				Databases:  []string{"testdata/city.mmdb", "testdata/asn.mmdb"},
+				IPFields:   []string{"forwarded_for", "remote_addr"},
+				Attributes: []string{"continent_code", "country_name", "subdivision_name", "timezone", "latitude", "asn"},
+				Language:   "de",
+				AddAs:      "field",
+				Prefix:     "geo_",
+				Names:      map[string]string{"country_name": "country"},
+				CacheSize:  10,
+			},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric("nginx",
+					map[string]string{},
+					map[string]interface{}{"remote_addr": "192.0.2.10"},
+					now,
+				),
+				testutil.MustMetric("nginx",
+					map[string]string{},
+					map[string]interface{}{"forwarded_for": "10.0.0.1", "remote_addr": "2001:db8::1"},
+					now,
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("nginx",
+					map[string]string{},
+					map[string]interface{}{
+						"remote_addr":          "192.0.2.10",
+						"geo_continent_code":   "EU",
+						"geo_country":          "Deutschland",
+						"geo_subdivision_name": "Berlin",
+						"geo_timezone":         "Europe/Berlin",
+						"geo_latitude":         52.5244,
+						"geo_asn":              int64(64512),
+					},
+					now,
+				),
+				testutil.MustMetric("nginx",
+					map[string]string{},
+					map[string]interface{}{
+						"forwarded_for":      "10.0.0.1",
+						"remote_addr":        "2001:db8::1",
+						"geo_continent_code": "EU",
+						"geo_country":        "Frankreich",
+						"geo_timezone":       "Europe/Paris",
+						"geo_latitude":       48.8582,
+					},
+					now,
+				),
+			},
+		},
+		{
+			name: "maxmind test databases",
+			geoip: &GeoIP{
+				Databases:  []string{"testdata/GeoIP2-City-Test.mmdb", "testdata/GeoLite2-ASN-Test.mmdb"},
+				IPTags:     []string{"client_ip"},
+				Attributes: []string{"country_code", "subdivision_code", "city", "postal_code", "latitude", "longitude", "asn", "asn_org"},
+				Language:   "en",
+				AddAs:      "tag",
+				CacheSize:  10,
+			},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric("nginx",
+					map[string]string{"client_ip": "81.2.69.142"},
+					map[string]interface{}{"bytes": int64(512)},
+					now,
+				),
+				testutil.MustMetric("nginx",
+					map[string]string{"client_ip": "216.160.83.56"},
+					map[string]interface{}{"bytes": int64(512)},
+					now,
+				),
+				testutil.MustMetric("nginx",
+					map[string]string{"client_ip": "1.128.0.0"},
+					map[string]interface{}{"bytes": int64(512)},
+					now,
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("nginx",
+					map[string]string{
+						"client_ip":        "81.2.69.142",
+						"country_code":     "GB",
+						"subdivision_code": "ENG",
+						"city":             "London",
+						"latitude":         "51.5142",
+						"longitude":        "-0.0931",
+					},
+					map[string]interface{}{"bytes": int64(512)},
+					now,
+				),
+				testutil.MustMetric("nginx",
+					map[string]string{
+						"client_ip":        "216.160.83.56",
+						"country_code":     "US",
+						"subdivision_code": "WA",
+						"city":             "Milton",
+						"postal_code":      "98354",
+						"latitude":         "47.2513",
+						"longitude":        "-122.3149",
+						"asn":              "209",
+					},
+					map[string]interface{}{"bytes": int64(512)},
+					now,
+				),
+				testutil.MustMetric("nginx",
+					map[string]string{
+						"client_ip": "1.128.0.0",
+						"asn":       "1221",
+						"asn_org":   "Telstra Pty Ltd",
+					},
+					map[string]interface{}{"bytes": int64(512)},
+					now,
+				),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.geoip.Log = testutil.Logger{}
+			require.NoError(t, tt.geoip.Init())
+
+			actual := tt.geoip.Apply(tt.metrics...)
+			testutil.RequireMetricsEqual(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestCache(t *testing.T) {
+	g := &GeoIP{
+		Databases:  []string{"testdata/city.mmdb"},
+		IPTags:     []string{"client_ip"},
+		Attributes: []string{"country_code"},
+		Language:   "en",
+		AddAs:      "tag",
+		CacheSize:  2,
+		Log:        testutil.Logger{},
+	}
+	require.NoError(t, g.Init())
+
+	for _, ip := range []string{"192.0.2.1", "192.0.2.2", "192.0.2.1", "192.0.2.3"} {
+		g.Apply(testutil.MustMetric("nginx",
+			map[string]string{"client_ip": ip},
+			map[string]interface{}{"bytes": int64(512)},
+			time.Unix(0, 0),
+		))
+	}
+
+	// The least recently used address has been evicted.
+	var cached []string
+	for elem := g.lru.Front(); elem != nil; elem = elem.Next() {
+		cached = append(cached, elem.Value.(*entry).ip)
+	}
+	require.Equal(t, []string{"192.0.2.3", "192.0.2.1"}, cached)
+}
+
+func TestReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "geoip")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	db := filepath.Join(dir, "db.mmdb")
+	copyFile := func(src string) {
+		buf, err := ioutil.ReadFile(src)
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(db, buf, 0644))
+	}
+	copyFile("testdata/asn.mmdb")
+
+	g := &GeoIP{
+		Databases:  []string{db},
+		IPTags:     []string{"client_ip"},
+		Attributes: []string{"country_code", "asn"},
+		Language:   "en",
+		AddAs:      "tag",
+		CacheSize:  10,
+		Log:        testutil.Logger{},
+	}
+	require.NoError(t, g.Init())
+
+	now := time.Unix(0, 0)
+	apply := func(tags map[string]string) {
+		actual := g.Apply(testutil.MustMetric("nginx",
+			map[string]string{"client_ip": "192.0.2.10"},
+			map[string]interface{}{"bytes": int64(512)},
+			now,
+		))
+		expected := []telegraf.Metric{
+			testutil.MustMetric("nginx", tags, map[string]interface{}{"bytes": int64(512)}, now),
+		}
+		testutil.RequireMetricsEqual(t, expected, actual)
+	}
+	apply(map[string]string{"client_ip": "192.0.2.10", "asn": "64512"})
+
+	copyFile("testdata/city.mmdb")
+	mtime := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(db, mtime, mtime))
+	apply(map[string]string{"client_ip": "192.0.2.10", "country_code": "DE"})
+}
@@ -0,0 +1,282 @@
+package lookup
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/processors"
+	"github.com/influxdata/telegraf/selfstat"
+	"github.com/influxdata/toml"
+)
+
+const sampleConfig = `
+  ## File containing the lookup table.
+  file = "/etc/telegraf/hosts.csv"
+
+  ## Format of the file, "csv", "json" or "toml".  When empty the format is
+  ## detected from the file extension.
+  # file_format = ""
+
+  ## Tags and fields whose values are the key of the table, in this order.
+  ## When more than one value is used they are joined with the key_separator.
+  key_tags = ["host"]
+  # key_fields = []
+  # key_separator = ":"
+
+  ## Add the values of the table as "tag" or as "field".
+  # add_as = "tag"
+
+  ## Interval to check the file for changes, it is reloaded when its
+  ## modification time changes.
+  # reload_interval = "30s"
+`
+
+// Lookup adds the tags or fields of a table, loaded from a file, to the
+// metrics with a matching key.
+type Lookup struct {
+	File           string            `toml:"file"`
+	FileFormat     string            `toml:"file_format"`
+	KeyTags        []string          `toml:"key_tags"`
+	KeyFields      []string          `toml:"key_fields"`
+	KeySeparator   string            `toml:"key_separator"`
+	AddAs          string            `toml:"add_as"`
+	ReloadInterval internal.Duration `toml:"reload_interval"`
+	Log            telegraf.Logger   `toml:"-"`
+
+	table     map[string]map[string]interface{}
+	modTime   time.Time
+	lastCheck time.Time
+	misses    selfstat.Stat
+}
+
+func New() *Lookup {
+	return &Lookup{
+		KeySeparator:   ":",
+		AddAs:          "tag",
+		ReloadInterval: internal.Duration{Duration: 30 * time.Second},
+	}
+}
+
+func (l *Lookup) SampleConfig() string {
+	return sampleConfig
+}
+
+func (l *Lookup) Description() string {
+	return "Add tags or fields from a lookup table file"
+}
+
+func (l *Lookup) Init() error {
+	if l.File == "" {
+		return fmt.Errorf("no file specified")
+	}
+	if len(l.KeyTags)+len(l.KeyFields) == 0 {
+		return fmt.Errorf("no key_tags or key_fields specified")
+	}
+	switch l.AddAs {
+	case "tag", "field":
+	default:
+		return fmt.Errorf("invalid add_as %q, must be \"tag\" or \"field\"", l.AddAs)
+	}
+	if _, err := l.format(); err != nil {
+		return err
+	}
+
+	l.misses = selfstat.Register("lookup", "misses", map[string]string{"file": l.File})
+
+	info, err := os.Stat(l.File)
+	if err != nil {
+		return err
+	}
+	return l.load(info.ModTime())
+}
+
+func (l *Lookup) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	l.reload()
+
+	for _, m := range in {
+		key, ok := l.key(m)
+		if !ok {
+			continue
+		}
+		values, ok := l.table[key]
+		if !ok {
+			l.misses.Incr(1)
+			continue
+		}
+		for k, v := range values {
+			if l.AddAs == "tag" {
+				m.AddTag(k, fmt.Sprint(v))
+			} else {
+				m.AddField(k, v)
+			}
+		}
+	}
+	return in
+}
+
+// key returns the key of the metric, false if any of the key tags or fields
+// is missing.
+func (l *Lookup) key(m telegraf.Metric) (string, bool) {
+	parts := make([]string, 0, len(l.KeyTags)+len(l.KeyFields))
+	for _, k := range l.KeyTags {
+		v, ok := m.GetTag(k)
+		if !ok {
+			return "", false
+		}
+		parts = append(parts, v)
+	}
+	for _, k := range l.KeyFields {
+		v, ok := m.GetField(k)
+		if !ok {
+			return "", false
+		}
+		parts = append(parts, fmt.Sprint(v))
+	}
+	return strings.Join(parts, l.KeySeparator), true
+}
+
+// reload loads the file again if its modification time has changed, at most
+// once per reload interval.  The current table is kept if the file can not
+// be loaded.
+func (l *Lookup) reload() {
+	now := time.Now()
+	if now.Sub(l.lastCheck) < l.ReloadInterval.Duration {
+		return
+	}
+	l.lastCheck = now
+
+	info, err := os.Stat(l.File)
+	if err != nil {
+		l.Log.Errorf("Checking lookup table: %v", err)
+		return
+	}
+	if info.ModTime().Equal(l.modTime) {
+		return
+	}
+
+	if err := l.load(info.ModTime()); err != nil {
+		l.Log.Errorf("Reloading lookup table: %v", err)
+		return
+	}
+	l.Log.Debugf("Reloaded lookup table %q with %d entries", l.File, len(l.table))
+}
+
+func (l *Lookup) load(modTime time.Time) error {
+	buf, err := ioutil.ReadFile(l.File)
+	if err != nil {
+		return err
+	}
+
+	format, _ := l.format()
+	var table map[string]map[string]interface{}
+	switch format {
+	case "csv":
+		table, err = l.parseCSV(buf)
+	case "json":
+		table, err = parseJSON(buf)
+	case "toml":
+		table, err = parseTOML(buf)
+	}
+	if err != nil {
+		return fmt.Errorf("parsing %q: %v", l.File, err)
+	}
+
+	l.table = table
+	l.modTime = modTime
+	return nil
+}
+
+// format returns the format of the file.
+func (l *Lookup) format() (string, error) {
+	format := l.FileFormat
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(l.File)), ".")
+	}
+	switch format {
+	case "csv", "json", "toml":
+		return format, nil
+	}
+	if l.FileFormat == "" {
+		return "", fmt.Errorf("unknown format of %q, set file_format", l.File)
+	}
+	return "", fmt.Errorf("invalid file_format %q, must be \"csv\", \"json\" or \"toml\"", l.FileFormat)
+}
+
+// parseCSV parses a table with a header row.  The first columns are the key,
+// one for each key tag and field, the other columns are the values.  Empty
+// values are not added.
+func (l *Lookup) parseCSV(buf []byte) (map[string]map[string]interface{}, error) {
+	records, err := csv.NewReader(bytes.NewReader(buf)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("missing header row")
+	}
+
+	n := len(l.KeyTags) + len(l.KeyFields)
+	header := records[0]
+	if len(header) <= n {
+		return nil, fmt.Errorf("expecting more than %d columns, got %d", n, len(header))
+	}
+
+	table := make(map[string]map[string]interface{}, len(records)-1)
+	for _, record := range records[1:] {
+		values := make(map[string]interface{}, len(header)-n)
+		for i, name := range header[n:] {
+			if v := record[n+i]; v != "" {
+				values[name] = v
+			}
+		}
+		table[strings.Join(record[:n], l.KeySeparator)] = values
+	}
+	return table, nil
+}
+
+// parseJSON parses an object of keys, each with an object of values.
+func parseJSON(buf []byte) (map[string]map[string]interface{}, error) {
+	var table map[string]map[string]interface{}
+	if err := json.Unmarshal(buf, &table); err != nil {
+		return nil, err
+	}
+	return table, checkValues(table)
+}
+
+// parseTOML parses a table for each key, containing the values.
+func parseTOML(buf []byte) (map[string]map[string]interface{}, error) {
+	var table map[string]map[string]interface{}
+	if err := toml.Unmarshal(buf, &table); err != nil {
+		return nil, err
+	}
+	return table, checkValues(table)
+}
+
+// checkValues returns an error if any value is not a string, number or
+// boolean.
+func checkValues(table map[string]map[string]interface{}) error {
+	for key, values := range table {
+		for name, v := range values {
+			switch v.(type) {
+			case string, bool, float64, int64:
+			default:
+				return fmt.Errorf("value %q of key %q is not a string, number or boolean", name, key)
+			}
+		}
+	}
+	return nil
+}
+
+func init() {
+	processors.Add("lookup", func() telegraf.Processor {
+		return New()
+	})
+}
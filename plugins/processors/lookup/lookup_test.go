@@ -0,0 +1,265 @@
+package lookup
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookup(t *testing.T) {
+	now := time.Unix(0, 0)
+	tests := []struct {
+		name     string
+		lookup   *Lookup
+		metrics  []telegraf.Metric
+		expected []telegraf.Metric
+	}{
+		{
+			name: "csv",
+			lookup: &Lookup{
+				File:         "testdata/hosts.csv",
+				KeyTags:      []string{"host"},
+				KeySeparator: ":",
+				AddAs:        "tag",
+			},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "web01"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "db01"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "web01", "team": "frontend", "rack": "r1"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "db01", "team": "storage"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+			},
+		},
+		{
+			name: "json",
+			lookup: &Lookup{
+				File:         "testdata/hosts.json",
+				KeyTags:      []string{"host"},
+				KeySeparator: ":",
+				AddAs:        "tag",
+			},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "web01"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "db01"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "web01", "team": "frontend", "rack": "r1"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "db01", "team": "storage"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+			},
+		},
+		{
+			name: "toml",
+			lookup: &Lookup{
+				File:         "testdata/hosts.toml",
+				KeyTags:      []string{"host"},
+				KeySeparator: ":",
+				AddAs:        "tag",
+			},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "web01"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "db01"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "web01", "team": "frontend", "rack": "r1"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "db01", "team": "storage"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+			},
+		},
+		{
+			name: "unknown or missing key",
+			lookup: &Lookup{
+				File:         "testdata/hosts.csv",
+				KeyTags:      []string{"host"},
+				KeySeparator: ":",
+				AddAs:        "tag",
+			},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "app01"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "app01"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+			},
+		},
+		{
+			name: "multiple keys as fields",
+			lookup: &Lookup{
+				File:         "testdata/devices.json",
+				KeyTags:      []string{"host"},
+				KeyFields:    []string{"ifIndex"},
+				KeySeparator: ":",
+				AddAs:        "field",
+			},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric("interface",
+					map[string]string{"host": "sw01"},
+					map[string]interface{}{"ifIndex": int64(42)},
+					now,
+				),
+				testutil.MustMetric("interface",
+					map[string]string{"host": "sw01"},
+					map[string]interface{}{"ifIndex": int64(43)},
+					now,
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("interface",
+					map[string]string{"host": "sw01"},
+					map[string]interface{}{"ifIndex": int64(42), "port_name": "uplink", "speed": 10000.0},
+					now,
+				),
+				testutil.MustMetric("interface",
+					map[string]string{"host": "sw01"},
+					map[string]interface{}{"ifIndex": int64(43)},
+					now,
+				),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.lookup.Log = testutil.Logger{}
+			require.NoError(t, tt.lookup.Init())
+
+			actual := tt.lookup.Apply(tt.metrics...)
+			testutil.RequireMetricsEqual(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lookup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "hosts.csv")
+	require.NoError(t, ioutil.WriteFile(file, []byte("host,team\nweb01,frontend\n"), 0644))
+
+	l := &Lookup{
+		File:         file,
+		KeyTags:      []string{"host"},
+		KeySeparator: ":",
+		AddAs:        "tag",
+		Log:          testutil.Logger{},
+	}
+	require.NoError(t, l.Init())
+
+	now := time.Unix(0, 0)
+	apply := func(team string) {
+		actual := l.Apply(testutil.MustMetric("cpu",
+			map[string]string{"host": "web01"},
+			map[string]interface{}{"value": 1.0},
+			now,
+		))
+		expected := []telegraf.Metric{
+			testutil.MustMetric("cpu",
+				map[string]string{"host": "web01", "team": team},
+				map[string]interface{}{"value": 1.0},
+				now,
+			),
+		}
+		testutil.RequireMetricsEqual(t, expected, actual)
+	}
+	apply("frontend")
+
+	require.NoError(t, ioutil.WriteFile(file, []byte("host,team\nweb01,backend\n"), 0644))
+	mtime := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(file, mtime, mtime))
+	apply("backend")
+
+	// The table is kept if the file is invalid.
+	require.NoError(t, ioutil.WriteFile(file, []byte("host,team\nweb01\n"), 0644))
+	mtime = mtime.Add(time.Minute)
+	require.NoError(t, os.Chtimes(file, mtime, mtime))
+	apply("backend")
+}
+
+func TestMisses(t *testing.T) {
+	l := &Lookup{
+		File:         "testdata/hosts.csv",
+		KeyTags:      []string{"host"},
+		KeySeparator: ":",
+		AddAs:        "tag",
+		Log:          testutil.Logger{},
+	}
+	require.NoError(t, l.Init())
+
+	before := l.misses.Get()
+	l.Apply(
+		testutil.MustMetric("cpu", map[string]string{"host": "web01"}, map[string]interface{}{"value": 1.0}, time.Unix(0, 0)),
+		testutil.MustMetric("cpu", map[string]string{"host": "app01"}, map[string]interface{}{"value": 1.0}, time.Unix(0, 0)),
+		testutil.MustMetric("cpu", map[string]string{"host": "app02"}, map[string]interface{}{"value": 1.0}, time.Unix(0, 0)),
+	)
+	require.Equal(t, int64(2), l.misses.Get()-before)
+}
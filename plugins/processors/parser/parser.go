@@ -9,7 +9,6 @@ import (
 )
 
 type Parser struct {
-	parsers.Config
 	DropOriginal bool     `toml:"drop_original"`
 	Merge        string   `toml:"merge"`
 	ParseFields  []string `toml:"parse_fields"`
@@ -42,16 +41,11 @@ func (p *Parser) Description() string {
 	return "Parse a value in a specified field/tag(s) and add the result in a new metric"
 }
 
-func (p *Parser) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
-	if p.Parser == nil {
-		var err error
-		p.Parser, err = parsers.NewParser(&p.Config)
-		if err != nil {
-			log.Printf("E! [processors.parser] could not create parser: %v", err)
-			return metrics
-		}
-	}
+func (p *Parser) SetParser(parser parsers.Parser) {
+	p.Parser = parser
+}
 
+func (p *Parser) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
 	results := []telegraf.Metric{}
 
 	for _, metric := range metrics {
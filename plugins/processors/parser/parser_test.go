@@ -7,6 +7,9 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/parsers/grok"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
+	"github.com/influxdata/telegraf/plugins/parsers/json"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -32,7 +35,7 @@ func TestApply(t *testing.T) {
 	tests := []struct {
 		name         string
 		parseFields  []string
-		config       parsers.Config
+		parser       parsers.Parser
 		dropOriginal bool
 		merge        string
 		input        telegraf.Metric
@@ -42,8 +45,7 @@ func TestApply(t *testing.T) {
 			name:         "parse one field drop original",
 			parseFields:  []string{"sample"},
 			dropOriginal: true,
-			config: parsers.Config{
-				DataFormat: "json",
+			parser: &json.JSONParser{
 				TagKeys: []string{
 					"ts",
 					"lvl",
@@ -79,8 +81,7 @@ func TestApply(t *testing.T) {
 			parseFields:  []string{"sample"},
 			dropOriginal: false,
 			merge:        "override",
-			config: parsers.Config{
-				DataFormat: "json",
+			parser: &json.JSONParser{
 				TagKeys: []string{
 					"ts",
 					"lvl",
@@ -118,8 +119,7 @@ func TestApply(t *testing.T) {
 			name:         "parse one field keep",
 			parseFields:  []string{"sample"},
 			dropOriginal: false,
-			config: parsers.Config{
-				DataFormat: "json",
+			parser: &json.JSONParser{
 				TagKeys: []string{
 					"ts",
 					"lvl",
@@ -160,11 +160,9 @@ func TestApply(t *testing.T) {
 			},
 		},
 		{
-			name:        "parse one field keep with measurement name",
-			parseFields: []string{"message"},
-			config: parsers.Config{
-				DataFormat: "influx",
-			},
+			name:         "parse one field keep with measurement name",
+			parseFields:  []string{"message"},
+			parser:       influx.NewParser(influx.NewMetricHandler()),
 			dropOriginal: false,
 			input: Metric(
 				metric.New(
@@ -198,9 +196,7 @@ func TestApply(t *testing.T) {
 			parseFields:  []string{"message"},
 			dropOriginal: false,
 			merge:        "override",
-			config: parsers.Config{
-				DataFormat: "influx",
-			},
+			parser:       influx.NewParser(influx.NewMetricHandler()),
 			input: Metric(
 				metric.New(
 					"influxField",
@@ -228,9 +224,8 @@ func TestApply(t *testing.T) {
 			name:         "parse grok field",
 			parseFields:  []string{"grokSample"},
 			dropOriginal: true,
-			config: parsers.Config{
-				DataFormat:   "grok",
-				GrokPatterns: []string{"%{COMBINED_LOG_FORMAT}"},
+			parser: &grok.Parser{
+				Patterns: []string{"%{COMBINED_LOG_FORMAT}"},
 			},
 			input: Metric(
 				metric.New(
@@ -264,9 +259,8 @@ func TestApply(t *testing.T) {
 			name:         "parse two fields [replace]",
 			parseFields:  []string{"field_1", "field_2"},
 			dropOriginal: true,
-			config: parsers.Config{
-				DataFormat: "json",
-				TagKeys:    []string{"lvl", "err"},
+			parser: &json.JSONParser{
+				TagKeys: []string{"lvl", "err"},
 			},
 			input: Metric(
 				metric.New(
@@ -299,9 +293,8 @@ func TestApply(t *testing.T) {
 			parseFields:  []string{"field_1", "field_2"},
 			dropOriginal: false,
 			merge:        "override",
-			config: parsers.Config{
-				DataFormat: "json",
-				TagKeys:    []string{"lvl", "msg", "err", "fatal"},
+			parser: &json.JSONParser{
+				TagKeys: []string{"lvl", "msg", "err", "fatal"},
 			},
 			input: Metric(
 				metric.New(
@@ -332,9 +325,8 @@ func TestApply(t *testing.T) {
 			name:         "parse two fields [keep]",
 			parseFields:  []string{"field_1", "field_2"},
 			dropOriginal: false,
-			config: parsers.Config{
-				DataFormat: "json",
-				TagKeys:    []string{"lvl", "msg", "err", "fatal"},
+			parser: &json.JSONParser{
+				TagKeys: []string{"lvl", "msg", "err", "fatal"},
 			},
 			input: Metric(
 				metric.New(
@@ -376,9 +368,8 @@ func TestApply(t *testing.T) {
 			name:         "Fail to parse one field but parses other [keep]",
 			parseFields:  []string{"good", "bad"},
 			dropOriginal: false,
-			config: parsers.Config{
-				DataFormat: "json",
-				TagKeys:    []string{"lvl"},
+			parser: &json.JSONParser{
+				TagKeys: []string{"lvl"},
 			},
 			input: Metric(
 				metric.New(
@@ -411,9 +402,8 @@ func TestApply(t *testing.T) {
 			name:         "Fail to parse one field but parses other [keep] v2",
 			parseFields:  []string{"bad", "good", "ok"},
 			dropOriginal: false,
-			config: parsers.Config{
-				DataFormat: "json",
-				TagKeys:    []string{"lvl", "thing"},
+			parser: &json.JSONParser{
+				TagKeys: []string{"lvl", "thing"},
 			},
 			input: Metric(
 				metric.New(
@@ -456,9 +446,8 @@ func TestApply(t *testing.T) {
 			parseFields:  []string{"good", "bad"},
 			dropOriginal: false,
 			merge:        "override",
-			config: parsers.Config{
-				DataFormat: "json",
-				TagKeys:    []string{"lvl"},
+			parser: &json.JSONParser{
+				TagKeys: []string{"lvl"},
 			},
 			input: Metric(
 				metric.New(
@@ -489,9 +478,8 @@ func TestApply(t *testing.T) {
 			name:         "Fail to parse one field but parses other [replace]",
 			parseFields:  []string{"good", "bad"},
 			dropOriginal: true,
-			config: parsers.Config{
-				DataFormat: "json",
-				TagKeys:    []string{"lvl"},
+			parser: &json.JSONParser{
+				TagKeys: []string{"lvl"},
 			},
 			input: Metric(
 				metric.New(
@@ -518,8 +506,12 @@ func TestApply(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			if p, ok := tt.parser.(telegraf.Initializer); ok {
+				require.NoError(t, p.Init())
+			}
+
 			parser := Parser{
-				Config:       tt.config,
+				Parser:       tt.parser,
 				ParseFields:  tt.parseFields,
 				DropOriginal: tt.dropOriginal,
 				Merge:        tt.merge,
@@ -536,16 +528,14 @@ func TestBadApply(t *testing.T) {
 	tests := []struct {
 		name        string
 		parseFields []string
-		config      parsers.Config
+		parser      parsers.Parser
 		input       telegraf.Metric
 		expected    []telegraf.Metric
 	}{
 		{
 			name:        "field not found",
 			parseFields: []string{"bad_field"},
-			config: parsers.Config{
-				DataFormat: "json",
-			},
+			parser:      &json.JSONParser{},
 			input: Metric(
 				metric.New(
 					"bad",
@@ -567,9 +557,7 @@ func TestBadApply(t *testing.T) {
 		{
 			name:        "non string field",
 			parseFields: []string{"some_field"},
-			config: parsers.Config{
-				DataFormat: "json",
-			},
+			parser:      &json.JSONParser{},
 			input: Metric(
 				metric.New(
 					"bad",
@@ -593,7 +581,7 @@ func TestBadApply(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			parser := Parser{
-				Config:      tt.config,
+				Parser:      tt.parser,
 				ParseFields: tt.parseFields,
 			}
 
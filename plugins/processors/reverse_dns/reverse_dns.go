@@ -0,0 +1,351 @@
+package reverse_dns
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/processors"
+	"github.com/miekg/dns"
+)
+
+const sampleConfig = `
+  ## Address of the DNS server as "host:port", when empty the servers of
+  ## /etc/resolv.conf are used.
+  # resolver = ""
+
+  ## Maximum time to wait for all lookups of the metrics passed at once, this
+  ## is a limit per batch of metrics and not per lookup.  Addresses not
+  ## resolved in time are left unchanged.
+  # lookup_timeout = "1s"
+
+  ## Maximum number of lookups running at once.
+  # max_parallel_lookups = 10
+
+  ## Number of addresses to keep the names of, the least recently used
+  ## addresses are forgotten first.  Names are kept for the TTL of the DNS
+  ## record.
+  # cache_size = 10000
+
+  ## Time to remember addresses without a name.
+  # negative_ttl = "1m"
+
+  [[processors.reverse_dns.lookup]]
+    ## Name of the tag containing the IP address
+    tag = "source"
+
+    ## Name of the field containing the IP address
+    # field = "source"
+
+    ## Destination tag or field of the name.  By default the source tag or
+    ## field is used, overwriting the address.
+    dest = "source_name"
+`
+
+// ReverseDNS replaces IP addresses in tags and fields with their names.
+type ReverseDNS struct {
+	Lookups            []Lookup          `toml:"lookup"`
+	Resolver           string            `toml:"resolver"`
+	LookupTimeout      internal.Duration `toml:"lookup_timeout"`
+	MaxParallelLookups int               `toml:"max_parallel_lookups"`
+	CacheSize          int               `toml:"cache_size"`
+	NegativeTTL        internal.Duration `toml:"negative_ttl"`
+	Log                telegraf.Logger   `toml:"-"`
+
+	servers []string
+	client  *dns.Client
+	cache   map[string]*list.Element
+	lru     *list.List
+	now     func() time.Time
+}
+
+// Lookup is a tag or field to resolve.
+type Lookup struct {
+	Tag   string `toml:"tag"`
+	Field string `toml:"field"`
+	Dest  string `toml:"dest"`
+}
+
+// entry holds the name of an address until it expires, the name is empty
+// if the address could not be resolved.
+type entry struct {
+	addr    string
+	name    string
+	expires time.Time
+}
+
+// result is the outcome of a lookup.
+type result struct {
+	addr string
+	name string
+	ttl  time.Duration
+	err  error
+}
+
+func New() *ReverseDNS {
+	return &ReverseDNS{
+		LookupTimeout:      internal.Duration{Duration: time.Second},
+		MaxParallelLookups: 10,
+		CacheSize:          10000,
+		NegativeTTL:        internal.Duration{Duration: time.Minute},
+	}
+}
+
+func (r *ReverseDNS) SampleConfig() string {
+	return sampleConfig
+}
+
+func (r *ReverseDNS) Description() string {
+	return "Resolve the names of IP addresses in tags and fields"
+}
+
+func (r *ReverseDNS) Init() error {
+	if len(r.Lookups) == 0 {
+		return fmt.Errorf("no lookups specified")
+	}
+	for _, l := range r.Lookups {
+		if (l.Tag == "") == (l.Field == "") {
+			return fmt.Errorf("lookups must set either tag or field")
+		}
+	}
+	if r.MaxParallelLookups <= 0 {
+		return fmt.Errorf("max_parallel_lookups must be positive")
+	}
+	if r.CacheSize <= 0 {
+		return fmt.Errorf("cache_size must be positive")
+	}
+
+	if r.Resolver != "" {
+		server := r.Resolver
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "53")
+		}
+		r.servers = []string{server}
+	} else {
+		config, err := dns.ClientConfigFromFile("/etc/resolv.conf")
+		if err != nil {
+			return fmt.Errorf("reading the DNS servers, set resolver: %v", err)
+		}
+		for _, s := range config.Servers {
+			r.servers = append(r.servers, net.JoinHostPort(s, config.Port))
+		}
+		if len(r.servers) == 0 {
+			return fmt.Errorf("no DNS servers in /etc/resolv.conf, set resolver")
+		}
+	}
+
+	r.client = &dns.Client{Timeout: r.LookupTimeout.Duration}
+	r.cache = make(map[string]*list.Element)
+	r.lru = list.New()
+	if r.now == nil {
+		r.now = time.Now
+	}
+	return nil
+}
+
+func (r *ReverseDNS) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	now := r.now()
+
+	names := make(map[string]string)
+	var pending []string
+	for _, m := range in {
+		for _, l := range r.Lookups {
+			addr, ok := address(m, l)
+			if !ok {
+				continue
+			}
+			if _, ok := names[addr]; ok {
+				continue
+			}
+			name, ok := r.cached(addr, now)
+			if !ok {
+				pending = append(pending, addr)
+			}
+			names[addr] = name
+		}
+	}
+	if len(pending) > 0 {
+		for addr, name := range r.resolve(pending, now) {
+			names[addr] = name
+		}
+	}
+
+	for _, m := range in {
+		for _, l := range r.Lookups {
+			addr, ok := address(m, l)
+			if !ok || names[addr] == "" {
+				continue
+			}
+
+			if l.Tag != "" {
+				dest := l.Tag
+				if l.Dest != "" {
+					dest = l.Dest
+				}
+				m.AddTag(dest, names[addr])
+			} else {
+				dest := l.Field
+				if l.Dest != "" {
+					dest = l.Dest
+				}
+				m.AddField(dest, names[addr])
+			}
+		}
+	}
+	return in
+}
+
+// address returns the IP address of the tag or field of the lookup.
+func address(m telegraf.Metric, l Lookup) (string, bool) {
+	var addr string
+	if l.Tag != "" {
+		v, ok := m.GetTag(l.Tag)
+		if !ok {
+			return "", false
+		}
+		addr = v
+	} else {
+		v, ok := m.GetField(l.Field)
+		if !ok {
+			return "", false
+		}
+		if addr, ok = v.(string); !ok {
+			return "", false
+		}
+	}
+	return addr, net.ParseIP(addr) != nil
+}
+
+// resolve looks up the addresses, running at most MaxParallelLookups at once
+// and until the lookup timeout, and returns the names found.  Only names and
+// addresses without a name are cached, not failed lookups.
+func (r *ReverseDNS) resolve(addrs []string, now time.Time) map[string]string {
+	deadline := time.Now().Add(r.LookupTimeout.Duration)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	results := make(chan result, len(addrs))
+	sem := make(chan struct{}, r.MaxParallelLookups)
+	var wg sync.WaitGroup
+
+start:
+	for _, addr := range addrs {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break start
+		}
+		// A lookup may end at the deadline before the context is done.
+		if !time.Now().Before(deadline) {
+			break
+		}
+
+		wg.Add(1)
+		go func(addr string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			name, ttl, err := r.query(ctx, addr)
+			results <- result{addr: addr, name: name, ttl: ttl, err: err}
+		}(addr)
+	}
+	wg.Wait()
+	close(results)
+
+	names := make(map[string]string, len(addrs))
+	for res := range results {
+		names[res.addr] = res.name
+		if res.err != nil {
+			// Failed lookups, including those cut off by the timeout, are
+			// retried with the next metrics.
+			r.Log.Debugf("Resolving %s: %v", res.addr, res.err)
+			continue
+		}
+		ttl := res.ttl
+		if res.name == "" {
+			ttl = r.NegativeTTL.Duration
+		}
+		r.store(res.addr, res.name, now.Add(ttl))
+	}
+	return names
+}
+
+// query returns the name of the address and the TTL of the record, the name
+// is empty if the address has no name.
+func (r *ReverseDNS) query(ctx context.Context, addr string) (string, time.Duration, error) {
+	arpa, err := dns.ReverseAddr(addr)
+	if err != nil {
+		return "", 0, err
+	}
+	msg := new(dns.Msg)
+	msg.SetQuestion(arpa, dns.TypePTR)
+
+	for _, server := range r.servers {
+		var resp *dns.Msg
+		resp, _, err = r.client.ExchangeContext(ctx, msg, server)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+
+		switch resp.Rcode {
+		case dns.RcodeSuccess, dns.RcodeNameError:
+		default:
+			err = fmt.Errorf("%s responded %s", server, dns.RcodeToString[resp.Rcode])
+			continue
+		}
+		for _, rr := range resp.Answer {
+			if ptr, ok := rr.(*dns.PTR); ok {
+				ttl := time.Duration(ptr.Hdr.Ttl) * time.Second
+				return strings.TrimSuffix(ptr.Ptr, "."), ttl, nil
+			}
+		}
+		return "", 0, nil
+	}
+	return "", 0, err
+}
+
+// cached returns the name of the address, false if it is not cached or has
+// expired.
+func (r *ReverseDNS) cached(addr string, now time.Time) (string, bool) {
+	elem, ok := r.cache[addr]
+	if !ok {
+		return "", false
+	}
+	e := elem.Value.(*entry)
+	if !now.Before(e.expires) {
+		r.lru.Remove(elem)
+		delete(r.cache, addr)
+		return "", false
+	}
+	r.lru.MoveToFront(elem)
+	return e.name, true
+}
+
+// store caches the name of the address, evicting the least recently used
+// addresses if the cache is full.
+func (r *ReverseDNS) store(addr, name string, expires time.Time) {
+	if elem, ok := r.cache[addr]; ok {
+		r.lru.Remove(elem)
+		delete(r.cache, addr)
+	}
+	for r.lru.Len() >= r.CacheSize {
+		oldest := r.lru.Back()
+		r.lru.Remove(oldest)
+		delete(r.cache, oldest.Value.(*entry).addr)
+	}
+	r.cache[addr] = r.lru.PushFront(&entry{addr: addr, name: name, expires: expires})
+}
+
+func init() {
+	processors.Add("reverse_dns", func() telegraf.Processor {
+		return New()
+	})
+}
@@ -0,0 +1,368 @@
+package reverse_dns
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/require"
+)
+
+// stub is a DNS server answering PTR queries from a table of names, or with
+// rcode if it is set.
+type stub struct {
+	sync.Mutex
+	names   map[string]string
+	ttl     uint32
+	delay   time.Duration
+	rcode   int
+	queries int
+	server  *dns.Server
+}
+
+// start starts the server answering with the names of the addresses.
+func (s *stub) start(t *testing.T, names map[string]string) {
+	s.names = make(map[string]string)
+	for addr, name := range names {
+		arpa, err := dns.ReverseAddr(addr)
+		require.NoError(t, err)
+		s.names[arpa] = name
+	}
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	started := make(chan struct{})
+	s.server = &dns.Server{
+		PacketConn:        pc,
+		Handler:           dns.HandlerFunc(s.serve),
+		NotifyStartedFunc: func() { close(started) },
+	}
+	go s.server.ActivateAndServe()
+	<-started
+}
+
+func (s *stub) addr() string {
+	return s.server.PacketConn.LocalAddr().String()
+}
+
+func (s *stub) serve(w dns.ResponseWriter, req *dns.Msg) {
+	s.Lock()
+	s.queries++
+	s.Unlock()
+	time.Sleep(s.delay)
+
+	resp := new(dns.Msg)
+	resp.SetReply(req)
+	q := req.Question[0]
+	name, ok := s.names[q.Name]
+	switch {
+	case s.rcode != 0:
+		resp.SetRcode(req, s.rcode)
+	case !ok:
+		resp.SetRcode(req, dns.RcodeNameError)
+	default:
+		resp.Answer = append(resp.Answer, &dns.PTR{
+			Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypePTR, Class: dns.ClassINET, Ttl: s.ttl},
+			Ptr: name,
+		})
+	}
+	w.WriteMsg(resp)
+}
+
+func (s *stub) count() int {
+	s.Lock()
+	defer s.Unlock()
+	return s.queries
+}
+
+func TestReverseDNS(t *testing.T) {
+	now := time.Unix(0, 0)
+	tests := []struct {
+		name     string
+		names    map[string]string
+		lookups  []Lookup
+		metrics  []telegraf.Metric
+		expected []telegraf.Metric
+	}{
+		{
+			name:    "tag to dest",
+			names:   map[string]string{"192.0.2.1": "router.example.com."},
+			lookups: []Lookup{{Tag: "source", Dest: "source_name"}},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric("ping",
+					map[string]string{"source": "192.0.2.1"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("ping",
+					map[string]string{"source": "192.0.2.2"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("ping",
+					map[string]string{"source": "192.0.2.1", "source_name": "router.example.com"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+				testutil.MustMetric("ping",
+					map[string]string{"source": "192.0.2.2"},
+					map[string]interface{}{"value": 1.0},
+					now,
+				),
+			},
+		},
+		{
+			name:    "field in place",
+			names:   map[string]string{"2001:db8::1": "server.example.com."},
+			lookups: []Lookup{{Field: "peer"}},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric("ping",
+					map[string]string{},
+					map[string]interface{}{"peer": "2001:db8::1"},
+					now,
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("ping",
+					map[string]string{},
+					map[string]interface{}{"peer": "server.example.com"},
+					now,
+				),
+			},
+		},
+		{
+			name:    "not an address",
+			names:   map[string]string{"192.0.2.1": "router.example.com."},
+			lookups: []Lookup{{Tag: "source", Dest: "source_name"}, {Field: "peer"}},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric("ping",
+					map[string]string{"source": "localhost"},
+					map[string]interface{}{"peer": "not an address"},
+					now,
+				),
+				testutil.MustMetric("ping",
+					map[string]string{},
+					map[string]interface{}{"peer": int64(42)},
+					now,
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("ping",
+					map[string]string{"source": "localhost"},
+					map[string]interface{}{"peer": "not an address"},
+					now,
+				),
+				testutil.MustMetric("ping",
+					map[string]string{},
+					map[string]interface{}{"peer": int64(42)},
+					now,
+				),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &stub{ttl: 300}
+			s.start(t, tt.names)
+			defer s.server.Shutdown()
+
+			r := &ReverseDNS{
+				Lookups:            tt.lookups,
+				Resolver:           s.addr(),
+				LookupTimeout:      internal.Duration{Duration: time.Second},
+				MaxParallelLookups: 10,
+				CacheSize:          10,
+				NegativeTTL:        internal.Duration{Duration: time.Minute},
+				Log:                testutil.Logger{},
+			}
+			require.NoError(t, r.Init())
+
+			actual := r.Apply(tt.metrics...)
+			testutil.RequireMetricsEqual(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	s := &stub{ttl: 60}
+	s.start(t, map[string]string{"192.0.2.1": "router.example.com."})
+	defer s.server.Shutdown()
+
+	now := time.Unix(0, 0)
+	r := &ReverseDNS{
+		Lookups:            []Lookup{{Tag: "source", Dest: "source_name"}},
+		Resolver:           s.addr(),
+		LookupTimeout:      internal.Duration{Duration: time.Second},
+		MaxParallelLookups: 10,
+		CacheSize:          10,
+		NegativeTTL:        internal.Duration{Duration: 2 * time.Minute},
+		Log:                testutil.Logger{},
+		now:                func() time.Time { return now },
+	}
+	require.NoError(t, r.Init())
+
+	apply := func(queries int) {
+		actual := r.Apply(
+			testutil.MustMetric("ping",
+				map[string]string{"source": "192.0.2.1"},
+				map[string]interface{}{"value": 1.0},
+				time.Unix(0, 0),
+			),
+			testutil.MustMetric("ping",
+				map[string]string{"source": "192.0.2.9"},
+				map[string]interface{}{"value": 1.0},
+				time.Unix(0, 0),
+			),
+		)
+		expected := []telegraf.Metric{
+			testutil.MustMetric("ping",
+				map[string]string{"source": "192.0.2.1", "source_name": "router.example.com"},
+				map[string]interface{}{"value": 1.0},
+				time.Unix(0, 0),
+			),
+			testutil.MustMetric("ping",
+				map[string]string{"source": "192.0.2.9"},
+				map[string]interface{}{"value": 1.0},
+				time.Unix(0, 0),
+			),
+		}
+		testutil.RequireMetricsEqual(t, expected, actual)
+		require.Equal(t, queries, s.count())
+	}
+	apply(2)
+
+	// Names are cached for their TTL, addresses without a name for the
+	// negative TTL.
+	now = now.Add(30 * time.Second)
+	apply(2)
+
+	now = now.Add(40 * time.Second)
+	apply(3)
+
+	now = now.Add(50 * time.Second)
+	apply(4)
+}
+
+func TestFailureNotCached(t *testing.T) {
+	s := &stub{ttl: 300, rcode: dns.RcodeServerFailure}
+	s.start(t, nil)
+	defer s.server.Shutdown()
+
+	r := &ReverseDNS{
+		Lookups:            []Lookup{{Tag: "source", Dest: "source_name"}},
+		Resolver:           s.addr(),
+		LookupTimeout:      internal.Duration{Duration: time.Second},
+		MaxParallelLookups: 10,
+		CacheSize:          10,
+		NegativeTTL:        internal.Duration{Duration: time.Minute},
+		Log:                testutil.Logger{},
+	}
+	require.NoError(t, r.Init())
+
+	for i := 1; i <= 2; i++ {
+		actual := r.Apply(testutil.MustMetric("ping",
+			map[string]string{"source": "192.0.2.1"},
+			map[string]interface{}{"value": 1.0},
+			time.Unix(0, 0),
+		))
+		expected := []telegraf.Metric{
+			testutil.MustMetric("ping",
+				map[string]string{"source": "192.0.2.1"},
+				map[string]interface{}{"value": 1.0},
+				time.Unix(0, 0),
+			),
+		}
+		testutil.RequireMetricsEqual(t, expected, actual)
+		require.Equal(t, i, s.count())
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	s := &stub{ttl: 300, delay: 500 * time.Millisecond}
+	s.start(t, map[string]string{"192.0.2.1": "router.example.com."})
+	defer s.server.Shutdown()
+
+	r := &ReverseDNS{
+		Lookups:            []Lookup{{Tag: "source", Dest: "source_name"}},
+		Resolver:           s.addr(),
+		LookupTimeout:      internal.Duration{Duration: 50 * time.Millisecond},
+		MaxParallelLookups: 1,
+		CacheSize:          10,
+		NegativeTTL:        internal.Duration{Duration: time.Minute},
+		Log:                testutil.Logger{},
+	}
+	require.NoError(t, r.Init())
+
+	start := time.Now()
+	actual := r.Apply(
+		testutil.MustMetric("ping",
+			map[string]string{"source": "192.0.2.1"},
+			map[string]interface{}{"value": 1.0},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("ping",
+			map[string]string{"source": "192.0.2.2"},
+			map[string]interface{}{"value": 1.0},
+			time.Unix(0, 0),
+		),
+	)
+	require.True(t, time.Since(start) < 400*time.Millisecond)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("ping",
+			map[string]string{"source": "192.0.2.1"},
+			map[string]interface{}{"value": 1.0},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("ping",
+			map[string]string{"source": "192.0.2.2"},
+			map[string]interface{}{"value": 1.0},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, actual)
+
+	// Neither the lookup that timed out nor the one that did not start is
+	// cached.
+	require.Empty(t, r.cache)
+}
+
+func TestCacheSize(t *testing.T) {
+	s := &stub{ttl: 300}
+	s.start(t, nil)
+	defer s.server.Shutdown()
+
+	r := &ReverseDNS{
+		Lookups:            []Lookup{{Tag: "source", Dest: "source_name"}},
+		Resolver:           s.addr(),
+		LookupTimeout:      internal.Duration{Duration: time.Second},
+		MaxParallelLookups: 10,
+		CacheSize:          2,
+		NegativeTTL:        internal.Duration{Duration: time.Minute},
+		Log:                testutil.Logger{},
+	}
+	require.NoError(t, r.Init())
+
+	for _, addr := range []string{"192.0.2.1", "192.0.2.2", "192.0.2.1", "192.0.2.3"} {
+		r.Apply(testutil.MustMetric("ping",
+			map[string]string{"source": addr},
+			map[string]interface{}{"value": 1.0},
+			time.Unix(0, 0),
+		))
+	}
+
+	// The least recently used address has been evicted.
+	var cached []string
+	for elem := r.lru.Front(); elem != nil; elem = elem.Next() {
+		cached = append(cached, elem.Value.(*entry).addr)
+	}
+	require.Equal(t, []string{"192.0.2.3", "192.0.2.1"}, cached)
+}
@@ -0,0 +1,217 @@
+package starlark
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/processors"
+	"go.starlark.net/resolve"
+	"go.starlark.net/starlark"
+)
+
+const (
+	description  = "Process metrics using a Starlark script"
+	sampleConfig = `
+  ## The Starlark source can be set as a string in this configuration file, or
+  ## by referencing a file containing the script.  Only one source or script
+  ## should be set at once.
+  ##
+  ## Source of the Starlark script.
+  source = '''
+def apply(metric):
+	return metric
+'''
+
+  ## File containing a Starlark script.
+  # script = "/usr/local/bin/myscript.star"
+`
+)
+
+type Starlark struct {
+	Source string `toml:"source"`
+	Script string `toml:"script"`
+
+	Log telegraf.Logger `toml:"-"`
+
+	thread    *starlark.Thread
+	applyFunc *starlark.Function
+	args      starlark.Tuple
+}
+
+func (s *Starlark) Init() error {
+	if s.Source == "" && s.Script == "" {
+		return errors.New("one of source or script must be set")
+	}
+	if s.Source != "" && s.Script != "" {
+		return errors.New("both source or script cannot be set")
+	}
+
+	s.thread = &starlark.Thread{
+		Print: func(_ *starlark.Thread, msg string) { s.Log.Debug(msg) },
+	}
+
+	builtins := starlark.StringDict{
+		"Metric":   starlark.NewBuiltin("Metric", newMetric),
+		"deepcopy": starlark.NewBuiltin("deepcopy", deepcopy),
+		// state is shared by all calls to apply.
+		"state": starlark.NewDict(0),
+	}
+
+	program, err := s.sourceProgram(builtins)
+	if err != nil {
+		return err
+	}
+
+	// Execute source.  The globals are not frozen, so that the script can
+	// also keep state in its own global variables.
+	globals, err := program.Init(s.thread, builtins)
+	if err != nil {
+		if err, ok := err.(*starlark.EvalError); ok {
+			s.logBacktrace(err)
+		}
+		return err
+	}
+
+	// The source should define an apply function.
+	err = s.setApplyFunc(globals)
+	if err != nil {
+		return err
+	}
+
+	s.args = make(starlark.Tuple, 1)
+	return nil
+}
+
+func (s *Starlark) sourceProgram(builtins starlark.StringDict) (*starlark.Program, error) {
+	var src interface{}
+	filename := s.Script
+	if s.Source != "" {
+		src = s.Source
+		filename = "processor.starlark"
+	}
+
+	_, program, err := starlark.SourceProgram(filename, src, builtins.Has)
+	return program, err
+}
+
+func (s *Starlark) setApplyFunc(globals starlark.StringDict) error {
+	applyValue, ok := globals["apply"]
+	if !ok {
+		return errors.New("apply is not defined")
+	}
+
+	var fn *starlark.Function
+	if fn, ok = applyValue.(*starlark.Function); !ok {
+		return errors.New("apply is not a function")
+	}
+
+	if fn.NumParams() != 1 {
+		return errors.New("apply function must take one parameter")
+	}
+
+	s.applyFunc = fn
+	return nil
+}
+
+func (s *Starlark) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *Starlark) Description() string {
+	return description
+}
+
+func (s *Starlark) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
+	var results []telegraf.Metric
+	for _, m := range metrics {
+		results = append(results, s.apply(m)...)
+	}
+	return results
+}
+
+// apply calls the apply function of the script with a single metric.  If the
+// script fails the error is logged and the metric is dropped.
+func (s *Starlark) apply(m telegraf.Metric) []telegraf.Metric {
+	s.args[0] = &Metric{metric: m}
+
+	rv, err := starlark.Call(s.thread, s.applyFunc, s.args, nil)
+	if err != nil {
+		if err, ok := err.(*starlark.EvalError); ok {
+			s.logBacktrace(err)
+		} else {
+			s.Log.Errorf("Error calling apply: %v", err)
+		}
+		m.Reject()
+		return nil
+	}
+
+	var results []telegraf.Metric
+	add := func(v starlark.Value) error {
+		r, ok := v.(*Metric)
+		if !ok {
+			return fmt.Errorf("invalid type returned: %s", v.Type())
+		}
+		// A metric must not be returned more than once, it would be shared
+		// between the results.
+		if containsMetric(results, r.Unwrap()) {
+			return fmt.Errorf("metric %q returned more than once", r.Unwrap().Name())
+		}
+		results = append(results, r.Unwrap())
+		return nil
+	}
+
+	switch rv := rv.(type) {
+	case *starlark.List:
+		for i := 0; i < rv.Len(); i++ {
+			err = add(rv.Index(i))
+			if err != nil {
+				break
+			}
+		}
+	case starlark.NoneType:
+	default:
+		err = add(rv)
+	}
+
+	if err != nil {
+		s.Log.Errorf("Error in apply: %v", err)
+		m.Reject()
+		return nil
+	}
+
+	if !containsMetric(results, m) {
+		m.Drop()
+	}
+	return results
+}
+
+func (s *Starlark) logBacktrace(err *starlark.EvalError) {
+	for _, line := range strings.Split(err.Backtrace(), "\n") {
+		s.Log.Error(line)
+	}
+}
+
+func containsMetric(metrics []telegraf.Metric, metric telegraf.Metric) bool {
+	for _, m := range metrics {
+		if m == metric {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	// https://github.com/bazelbuild/starlark/issues/20
+	resolve.AllowNestedDef = true
+	resolve.AllowLambda = true
+	resolve.AllowFloat = true
+	resolve.AllowSet = true
+	resolve.AllowGlobalReassign = true
+	resolve.AllowRecursion = true
+
+	processors.Add("starlark", func() telegraf.Processor {
+		return &Starlark{}
+	})
+}
@@ -0,0 +1,405 @@
+package starlark
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newStarlark(t *testing.T, source string) *Starlark {
+	plugin := &Starlark{
+		Source: source,
+		Log:    testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+	return plugin
+}
+
+func TestInitError(t *testing.T) {
+	tests := []struct {
+		name   string
+		plugin *Starlark
+	}{
+		{
+			name:   "source and script not set",
+			plugin: &Starlark{},
+		},
+		{
+			name: "source and script both set",
+			plugin: &Starlark{
+				Source: "def apply(metric): return metric",
+				Script: "testdata/ratio.star",
+			},
+		},
+		{
+			name:   "source syntax error",
+			plugin: &Starlark{Source: "for"},
+		},
+		{
+			name:   "apply not defined",
+			plugin: &Starlark{Source: "def process(metric): return metric"},
+		},
+		{
+			name:   "apply is not a function",
+			plugin: &Starlark{Source: "apply = 42"},
+		},
+		{
+			name:   "apply has wrong number of arguments",
+			plugin: &Starlark{Source: "def apply(): return 42"},
+		},
+		{
+			name:   "script file not found",
+			plugin: &Starlark{Script: "testdata/missing.star"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.plugin.Log = testutil.Logger{}
+			require.Error(t, tt.plugin.Init())
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		input    []telegraf.Metric
+		expected []telegraf.Metric
+	}{
+		{
+			name: "pass through",
+			source: `
+def apply(metric):
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"time_idle": 42.0},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"time_idle": 42.0},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "drop metric",
+			source: `
+def apply(metric):
+	return None
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"time_idle": 42.0},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{},
+		},
+		{
+			name: "modify name tags fields and time",
+			source: `
+def apply(metric):
+	metric.name = "cpu_usage"
+	metric.tags["cpu"] = "cpu0"
+	metric.tags.pop("host")
+	metric.fields["time_busy"] = 100 - metric.fields["time_idle"]
+	metric.fields["count"] = 2
+	metric.fields["ok"] = True
+	metric.time = metric.time + 1000000000
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"time_idle": 42.0},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu_usage",
+					map[string]string{"cpu": "cpu0"},
+					map[string]interface{}{
+						"time_idle": 42.0,
+						"time_busy": 58.0,
+						"count":     int64(2),
+						"ok":        true,
+					},
+					time.Unix(1, 0),
+				),
+			},
+		},
+		{
+			name: "conditional on tag",
+			source: `
+def apply(metric):
+	if metric.tags.get("unit") == "ms":
+		metric.fields["value"] = metric.fields["value"] / 1000.0
+		metric.tags["unit"] = "s"
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("latency",
+					map[string]string{"unit": "ms"},
+					map[string]interface{}{"value": int64(1500)},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric("latency",
+					map[string]string{"unit": "s"},
+					map[string]interface{}{"value": int64(2)},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("latency",
+					map[string]string{"unit": "s"},
+					map[string]interface{}{"value": 1.5},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric("latency",
+					map[string]string{"unit": "s"},
+					map[string]interface{}{"value": int64(2)},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "emit many metrics",
+			source: `
+def apply(metric):
+	metrics = [metric]
+	for k, v in metric.fields.items():
+		m = Metric("split")
+		m.tags["field"] = k
+		m.fields["value"] = v
+		m.time = metric.time
+		metrics.append(m)
+	return metrics
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"a": int64(1), "b": int64(2)},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"a": int64(1), "b": int64(2)},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric("split",
+					map[string]string{"field": "a"},
+					map[string]interface{}{"value": int64(1)},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric("split",
+					map[string]string{"field": "b"},
+					map[string]interface{}{"value": int64(2)},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "deepcopy",
+			source: `
+def apply(metric):
+	dup = deepcopy(metric)
+	dup.name = "copy"
+	return [metric, dup]
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"value": 42.0},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"value": 42.0},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric("copy",
+					map[string]string{},
+					map[string]interface{}{"value": 42.0},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "state across calls",
+			source: `
+def apply(metric):
+	last = state.get("last")
+	state["last"] = metric.fields["value"]
+	if last != None:
+		metric.fields["delta"] = metric.fields["value"] - last
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("counter",
+					map[string]string{},
+					map[string]interface{}{"value": int64(10)},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric("counter",
+					map[string]string{},
+					map[string]interface{}{"value": int64(15)},
+					time.Unix(1, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("counter",
+					map[string]string{},
+					map[string]interface{}{"value": int64(10)},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric("counter",
+					map[string]string{},
+					map[string]interface{}{"value": int64(15), "delta": int64(5)},
+					time.Unix(1, 0),
+				),
+			},
+		},
+		{
+			name: "error drops only the failing metric",
+			source: `
+def apply(metric):
+	metric.fields["inverse"] = 1 / metric.fields["value"]
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"value": 0.0},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"value": 2.0},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"value": 2.0, "inverse": 0.5},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "metric returned twice",
+			source: `
+def apply(metric):
+	return [metric, metric]
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"value": 42.0},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{},
+		},
+		{
+			name: "invalid return type",
+			source: `
+def apply(metric):
+	return 42
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"value": 42.0},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{},
+		},
+		{
+			name: "modify tags during iteration",
+			source: `
+def apply(metric):
+	for k in metric.tags:
+		metric.tags.pop(k)
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"value": 42.0},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := newStarlark(t, tt.source)
+			actual := plugin.Apply(tt.input...)
+			testutil.RequireMetricsEqual(t, tt.expected, actual, testutil.SortMetrics())
+		})
+	}
+}
+
+func TestScript(t *testing.T) {
+	plugin := &Starlark{
+		Script: "testdata/ratio.star",
+		Log:    testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	input := []telegraf.Metric{
+		testutil.MustMetric("mem",
+			map[string]string{},
+			map[string]interface{}{"used": int64(1), "total": int64(4)},
+			time.Unix(0, 0),
+		),
+	}
+	expected := []telegraf.Metric{
+		testutil.MustMetric("mem",
+			map[string]string{},
+			map[string]interface{}{"used": int64(1), "total": int64(4), "usage": 0.25},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, plugin.Apply(input...))
+}
+
+func TestTracking(t *testing.T) {
+	var delivered bool
+	notify := func(di telegraf.DeliveryInfo) {
+		delivered = true
+	}
+
+	plugin := newStarlark(t, `
+def apply(metric):
+	return None
+`)
+
+	m := testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{"value": 42.0},
+		time.Unix(0, 0),
+	)
+	tm, _ := metric.WithTracking(m, notify)
+	require.Empty(t, plugin.Apply(tm))
+	require.True(t, delivered)
+}
@@ -0,0 +1,200 @@
+package starlark
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"go.starlark.net/starlark"
+)
+
+// TagDict is the tags of a Metric as a Starlark dict-like value.
+type TagDict struct {
+	m *Metric
+}
+
+func (d TagDict) String() string {
+	buf := new(strings.Builder)
+	buf.WriteString("{")
+	sep := ""
+	for _, item := range d.Items() {
+		k, v := item[0], item[1]
+		buf.WriteString(sep)
+		buf.WriteString(k.String())
+		buf.WriteString(": ")
+		buf.WriteString(v.String())
+		sep = ", "
+	}
+	buf.WriteString("}")
+	return buf.String()
+}
+
+func (d TagDict) Type() string {
+	return "Tags"
+}
+
+func (d TagDict) Freeze() {
+	d.m.frozen = true
+}
+
+func (d TagDict) Truth() starlark.Bool {
+	return d.Len() != 0
+}
+
+func (d TagDict) Hash() (uint32, error) {
+	return 0, errors.New("not hashable")
+}
+
+// Len implements the starlark.Sequence interface.
+func (d TagDict) Len() int {
+	return len(d.m.metric.TagList())
+}
+
+// AttrNames implements the starlark.HasAttrs interface.
+func (d TagDict) AttrNames() []string {
+	return builtinAttrNames(TagDictMethods)
+}
+
+// Attr implements the starlark.HasAttrs interface.
+func (d TagDict) Attr(name string) (starlark.Value, error) {
+	return builtinAttr(d, name, TagDictMethods)
+}
+
+var TagDictMethods = map[string]builtinMethod{
+	"clear":      dictClear,
+	"get":        dictGet,
+	"items":      dictItems,
+	"keys":       dictKeys,
+	"pop":        dictPop,
+	"popitem":    dictPopitem,
+	"setdefault": dictSetdefault,
+	"update":     dictUpdate,
+	"values":     dictValues,
+}
+
+// Get implements the starlark.Mapping interface.
+func (d TagDict) Get(key starlark.Value) (v starlark.Value, found bool, err error) {
+	if k, ok := key.(starlark.String); ok {
+		gv, found := d.m.metric.GetTag(k.GoString())
+		if !found {
+			return starlark.None, false, nil
+		}
+		return starlark.String(gv), true, err
+	}
+
+	return starlark.None, false, errors.New("key must be of type 'str'")
+}
+
+// SetKey implements the starlark.HasSetKey interface to support map update
+// using x[k]=v syntax, like a dictionary.
+func (d TagDict) SetKey(k, v starlark.Value) error {
+	if err := d.m.checkMutable(d.m.tagIterCount); err != nil {
+		return err
+	}
+
+	key, ok := k.(starlark.String)
+	if !ok {
+		return errors.New("tag key must be of type 'str'")
+	}
+
+	value, ok := v.(starlark.String)
+	if !ok {
+		return errors.New("tag value must be of type 'str'")
+	}
+
+	d.m.metric.AddTag(key.GoString(), value.GoString())
+	return nil
+}
+
+// Items implements the starlark.IterableMapping interface.
+func (d TagDict) Items() []starlark.Tuple {
+	items := make([]starlark.Tuple, 0, len(d.m.metric.TagList()))
+	for _, tag := range d.m.metric.TagList() {
+		key := starlark.String(tag.Key)
+		value := starlark.String(tag.Value)
+		items = append(items, starlark.Tuple{key, value})
+	}
+	return items
+}
+
+func (d TagDict) Clear() error {
+	if err := d.m.checkMutable(d.m.tagIterCount); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(d.m.metric.TagList()))
+	for _, tag := range d.m.metric.TagList() {
+		keys = append(keys, tag.Key)
+	}
+
+	for _, key := range keys {
+		d.m.metric.RemoveTag(key)
+	}
+	return nil
+}
+
+func (d TagDict) PopItem() (v starlark.Value, err error) {
+	if err := d.m.checkMutable(d.m.tagIterCount); err != nil {
+		return nil, err
+	}
+
+	for _, tag := range d.m.metric.TagList() {
+		k := tag.Key
+		v := tag.Value
+
+		d.m.metric.RemoveTag(k)
+
+		sk := starlark.String(k)
+		sv := starlark.String(v)
+		return starlark.Tuple{sk, sv}, nil
+	}
+
+	return nil, errors.New("popitem(): tag dictionary is empty")
+}
+
+func (d TagDict) Delete(k starlark.Value) (v starlark.Value, found bool, err error) {
+	if err := d.m.checkMutable(d.m.tagIterCount); err != nil {
+		return nil, false, err
+	}
+
+	if key, ok := k.(starlark.String); ok {
+		value, ok := d.m.metric.GetTag(key.GoString())
+		if ok {
+			d.m.metric.RemoveTag(key.GoString())
+			v := starlark.String(value)
+			return v, ok, err
+		}
+		return starlark.None, false, nil
+	}
+
+	return starlark.None, false, errors.New("key must be of type 'str'")
+}
+
+// Iterate implements the starlark.Iterator interface.
+func (d TagDict) Iterate() starlark.Iterator {
+	d.m.tagIterCount++
+	return &TagIterator{m: d.m, tags: d.m.metric.TagList()}
+}
+
+type TagIterator struct {
+	m    *Metric
+	tags []*telegraf.Tag
+}
+
+// Next implements the starlark.Iterator interface.
+func (i *TagIterator) Next(p *starlark.Value) bool {
+	if len(i.tags) == 0 {
+		return false
+	}
+
+	tag := i.tags[0]
+	i.tags = i.tags[1:]
+	*p = starlark.String(tag.Key)
+
+	return true
+}
+
+// Done implements the starlark.Iterator interface.
+func (i *TagIterator) Done() {
+	i.m.tagIterCount--
+}
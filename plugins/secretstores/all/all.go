@@ -0,0 +1,8 @@
+package all
+
+import (
+	_ "github.com/influxdata/telegraf/plugins/secretstores/encrypted_file"
+	_ "github.com/influxdata/telegraf/plugins/secretstores/file"
+	_ "github.com/influxdata/telegraf/plugins/secretstores/http"
+	_ "github.com/influxdata/telegraf/plugins/secretstores/keyring"
+)
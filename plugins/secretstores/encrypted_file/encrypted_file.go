@@ -0,0 +1,296 @@
+package encrypted_file
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/secret"
+	"github.com/influxdata/telegraf/plugins/secretstores"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// Number of PBKDF2 iterations used for new files.
+	defaultIterations = 100000
+
+	keySize  = 32
+	saltSize = 16
+
+	// checkValue is encrypted into each file to detect a wrong password.
+	checkValue = "telegraf"
+)
+
+const sampleConfig = `
+  ## Unique identifier of the store, secrets are referenced as @{id:key}
+  id = "encrypted"
+
+  ## File holding the encrypted secrets, it is created when the first
+  ## secret is set with 'telegraf secret set <id> <key>'.
+  path = "/etc/telegraf/secrets.json"
+
+  ## Password used to derive the encryption key, it is recommended to
+  ## provide it in an environment variable.
+  password = "${TELEGRAF_SECRETS_PASSWORD}"
+`
+
+// file is the content of the secrets file.  Each secret is encrypted with
+// AES-256-GCM, using the key of the secret as additional data, and stored as
+// the nonce followed by the ciphertext.
+type file struct {
+	Salt       []byte            `json:"salt"`
+	Iterations int               `json:"iterations"`
+	Check      []byte            `json:"check"`
+	Secrets    map[string][]byte `json:"secrets"`
+}
+
+type EncryptedFile struct {
+	Path     string        `toml:"path"`
+	Password secret.Secret `toml:"password"`
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	file    *file
+	aead    cipher.AEAD
+}
+
+func (e *EncryptedFile) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *EncryptedFile) Description() string {
+	return "Read secrets from a password encrypted file"
+}
+
+func (e *EncryptedFile) Init() error {
+	if e.Path == "" {
+		return errors.New("path must be set")
+	}
+	if e.Password.Empty() {
+		return errors.New("password must be set")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	_, err := e.load()
+	return err
+}
+
+// Get decrypts the secret, the file is read again when it has changed.
+func (e *EncryptedFile) Get(key string) ([]byte, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	exists, err := e.load()
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("secrets file %s does not exist", e.Path)
+	}
+
+	sealed, ok := e.file.Secrets[key]
+	if !ok {
+		return nil, fmt.Errorf("secret %q not found", key)
+	}
+	return e.open(sealed, key)
+}
+
+// Set encrypts the secret and writes it to the file, the file is created if
+// it does not exist.
+func (e *EncryptedFile) Set(key string, value []byte) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	exists, err := e.load()
+	if err != nil {
+		return err
+	}
+	if !exists {
+		err = e.create()
+		if err != nil {
+			return err
+		}
+	}
+
+	sealed, err := e.seal(value, key)
+	if err != nil {
+		return err
+	}
+	e.file.Secrets[key] = sealed
+	return e.write()
+}
+
+// load reads the file if it changed since it was last read, it returns false
+// if the file does not exist.
+func (e *EncryptedFile) load() (bool, error) {
+	info, err := os.Stat(e.Path)
+	if os.IsNotExist(err) {
+		e.file = nil
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if e.file != nil && info.ModTime().Equal(e.modTime) && info.Size() == e.size {
+		return true, nil
+	}
+
+	data, err := ioutil.ReadFile(e.Path)
+	if err != nil {
+		return false, err
+	}
+
+	var f file
+	err = json.Unmarshal(data, &f)
+	if err != nil {
+		return false, fmt.Errorf("parsing secrets file %s: %v", e.Path, err)
+	}
+	if f.Secrets == nil {
+		f.Secrets = make(map[string][]byte)
+	}
+
+	// The key only needs to be derived again if the salt changed.
+	if e.file == nil || !bytes.Equal(e.file.Salt, f.Salt) || e.file.Iterations != f.Iterations {
+		e.aead, err = e.deriveKey(f.Salt, f.Iterations)
+		if err != nil {
+			return false, err
+		}
+	}
+	e.file = &f
+
+	check, err := e.open(f.Check, "")
+	if err != nil || string(check) != checkValue {
+		e.file = nil
+		return false, fmt.Errorf("cannot decrypt secrets file %s, wrong password?", e.Path)
+	}
+
+	e.modTime = info.ModTime()
+	e.size = info.Size()
+	return true, nil
+}
+
+// create initializes a new file with a random salt.
+func (e *EncryptedFile) create() error {
+	salt := make([]byte, saltSize)
+	_, err := io.ReadFull(rand.Reader, salt)
+	if err != nil {
+		return err
+	}
+
+	e.aead, err = e.deriveKey(salt, defaultIterations)
+	if err != nil {
+		return err
+	}
+
+	check, err := e.seal([]byte(checkValue), "")
+	if err != nil {
+		return err
+	}
+
+	e.file = &file{
+		Salt:       salt,
+		Iterations: defaultIterations,
+		Check:      check,
+		Secrets:    make(map[string][]byte),
+	}
+	return nil
+}
+
+// write replaces the file atomically.
+func (e *EncryptedFile) write() error {
+	data, err := json.MarshalIndent(e.file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(e.Path), "."+filepath.Base(e.Path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+	err = os.Rename(tmp.Name(), e.Path)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(e.Path)
+	if err != nil {
+		return err
+	}
+	e.modTime = info.ModTime()
+	e.size = info.Size()
+	return nil
+}
+
+func (e *EncryptedFile) deriveKey(salt []byte, iterations int) (cipher.AEAD, error) {
+	if len(salt) == 0 || iterations <= 0 {
+		return nil, fmt.Errorf("invalid key derivation settings in %s", e.Path)
+	}
+
+	password, err := e.Password.Get()
+	if err != nil {
+		return nil, err
+	}
+	defer secret.Zero(password)
+
+	key := pbkdf2.Key(password, salt, iterations, keySize, sha256.New)
+	defer secret.Zero(key)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (e *EncryptedFile) seal(value []byte, key string) ([]byte, error) {
+	nonce := make([]byte, e.aead.NonceSize())
+	_, err := io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return nil, err
+	}
+	return e.aead.Seal(nonce, nonce, value, []byte(key)), nil
+}
+
+func (e *EncryptedFile) open(sealed []byte, key string) ([]byte, error) {
+	n := e.aead.NonceSize()
+	if len(sealed) < n {
+		return nil, fmt.Errorf("secret %q is corrupt", key)
+	}
+	value, err := e.aead.Open(nil, sealed[:n], sealed[n:], []byte(key))
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt secret %q: %v", key, err)
+	}
+	return value, nil
+}
+
+func init() {
+	secretstores.Add("encrypted_file", func() telegraf.SecretStore {
+		return &EncryptedFile{}
+	})
+}
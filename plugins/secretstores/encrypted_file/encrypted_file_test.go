@@ -0,0 +1,88 @@
+package encrypted_file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/influxdata/telegraf/internal/secret"
+	"github.com/stretchr/testify/require"
+)
+
+func newStore(t *testing.T, path string, password string) *EncryptedFile {
+	e := &EncryptedFile{
+		Path:     path,
+		Password: secret.New(password),
+	}
+	require.NoError(t, e.Init())
+	return e
+}
+
+func TestSetGet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-secrets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "secrets.json")
+
+	e := newStore(t, path, "correct horse")
+	_, err = e.Get("token")
+	require.Error(t, err)
+
+	require.NoError(t, e.Set("token", []byte("abc")))
+	v, err := e.Get("token")
+	require.NoError(t, err)
+	require.Equal(t, "abc", string(v))
+
+	// The value is not stored in plain text.
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "abc")
+
+	// Changes by another writer are picked up.
+	other := newStore(t, path, "correct horse")
+	require.NoError(t, other.Set("token", []byte("abcdef")))
+	v, err = e.Get("token")
+	require.NoError(t, err)
+	require.Equal(t, "abcdef", string(v))
+}
+
+func TestWrongPassword(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-secrets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "secrets.json")
+
+	e := newStore(t, path, "correct horse")
+	require.NoError(t, e.Set("token", []byte("abc")))
+
+	e = &EncryptedFile{
+		Path:     path,
+		Password: secret.New("battery staple"),
+	}
+	require.Error(t, e.Init())
+}
+
+func TestSecretBoundToKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-secrets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "secrets.json")
+
+	e := newStore(t, path, "correct horse")
+	require.NoError(t, e.Set("a", []byte("abc")))
+	require.NoError(t, e.Set("b", []byte("def")))
+
+	// Swapping the ciphertexts of two secrets is detected.
+	e.file.Secrets["a"], e.file.Secrets["b"] = e.file.Secrets["b"], e.file.Secrets["a"]
+	_, err = e.Get("a")
+	require.Error(t, err)
+}
+
+func TestInitRequiresSettings(t *testing.T) {
+	e := &EncryptedFile{Password: secret.New("pw")}
+	require.Error(t, e.Init())
+
+	e = &EncryptedFile{Path: "secrets.json"}
+	require.Error(t, e.Init())
+}
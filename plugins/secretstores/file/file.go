@@ -0,0 +1,95 @@
+package file
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/secretstores"
+)
+
+const sampleConfig = `
+  ## Unique identifier of the store, secrets are referenced as @{id:key}
+  id = "files"
+
+  ## Directory containing one file per secret, named after the key; the
+  ## file is read each time the secret is used.
+  directory = "/run/secrets"
+`
+
+type File struct {
+	Directory string `toml:"directory"`
+}
+
+func (f *File) SampleConfig() string {
+	return sampleConfig
+}
+
+func (f *File) Description() string {
+	return "Read secrets from the files in a directory"
+}
+
+func (f *File) Init() error {
+	if f.Directory == "" {
+		return errors.New("directory must be set")
+	}
+	return nil
+}
+
+// Get returns the content of the file of the secret, without a trailing
+// newline.
+func (f *File) Get(key string) ([]byte, error) {
+	path, err := f.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(value, "\r\n"), nil
+}
+
+// Set replaces the file of the secret.
+func (f *File) Set(key string, value []byte) error {
+	path, err := f.path(key)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(f.Directory, "."+key)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(value)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (f *File) path(key string) (string, error) {
+	if key == "" || key == "." || key == ".." || strings.ContainsAny(key, `/\`) {
+		return "", fmt.Errorf("invalid key %q", key)
+	}
+	return filepath.Join(f.Directory, key), nil
+}
+
+func init() {
+	secretstores.Add("file", func() telegraf.SecretStore {
+		return &File{}
+	})
+}
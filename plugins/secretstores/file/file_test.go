@@ -0,0 +1,63 @@
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-secrets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "password"), []byte("hunter2\n"), 0600)
+	require.NoError(t, err)
+
+	f := &File{Directory: dir}
+	require.NoError(t, f.Init())
+
+	v, err := f.Get("password")
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", string(v))
+
+	_, err = f.Get("missing")
+	require.Error(t, err)
+}
+
+func TestSetRotates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-secrets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	f := &File{Directory: dir}
+	require.NoError(t, f.Init())
+
+	require.NoError(t, f.Set("token", []byte("abc")))
+	v, err := f.Get("token")
+	require.NoError(t, err)
+	require.Equal(t, "abc", string(v))
+
+	require.NoError(t, f.Set("token", []byte("def")))
+	v, err = f.Get("token")
+	require.NoError(t, err)
+	require.Equal(t, "def", string(v))
+}
+
+func TestInvalidKey(t *testing.T) {
+	f := &File{Directory: "/run/secrets"}
+	require.NoError(t, f.Init())
+
+	for _, key := range []string{"", "..", "../etc/passwd", `a\b`} {
+		_, err := f.Get(key)
+		require.Error(t, err, key)
+	}
+}
+
+func TestInitRequiresDirectory(t *testing.T) {
+	f := &File{}
+	require.Error(t, f.Init())
+}
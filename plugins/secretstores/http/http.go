@@ -0,0 +1,198 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/secret"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/secretstores"
+)
+
+const sampleConfig = `
+  ## Unique identifier of the store, secrets are referenced as @{id:key}
+  id = "vault"
+
+  ## URL returning a JSON object of secrets
+  url = "https://vault.example.org:8200/v1/secret/data/telegraf"
+
+  ## Optional HTTP headers, such as the token to authenticate with.  It is
+  ## recommended to provide tokens in an environment variable.
+  # headers = {"X-Vault-Token" = "${VAULT_TOKEN}"}
+
+  ## Path of the object holding the secrets in the response, with the
+  ## levels separated by dots.  For the Vault KV version 2 engine use
+  ## "data.data".
+  # path = ""
+
+  ## Time the secrets are cached before they are requested again.
+  # cache_ttl = "5m"
+
+  ## Amount of time allowed to complete the HTTP request
+  # timeout = "5s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+type HTTP struct {
+	URL      string            `toml:"url"`
+	Headers  map[string]string `toml:"headers"`
+	Path     string            `toml:"path"`
+	CacheTTL internal.Duration `toml:"cache_ttl"`
+	Timeout  internal.Duration `toml:"timeout"`
+	tls.ClientConfig
+
+	Log telegraf.Logger `toml:"-"`
+
+	client *http.Client
+
+	mu      sync.Mutex
+	secrets map[string][]byte
+	expires time.Time
+}
+
+func (h *HTTP) SampleConfig() string {
+	return sampleConfig
+}
+
+func (h *HTTP) Description() string {
+	return "Read secrets from an HTTP endpoint such as HashiCorp Vault"
+}
+
+func (h *HTTP) Init() error {
+	if h.URL == "" {
+		return errors.New("url must be set")
+	}
+
+	tlsCfg, err := h.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+
+	h.client = &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsCfg,
+			Proxy:           http.ProxyFromEnvironment,
+		},
+		Timeout: h.Timeout.Duration,
+	}
+	return nil
+}
+
+// Get returns the secret from the cached response, the secrets are requested
+// again once the cache expired.  If the request fails the cached secrets
+// are used until the cache expires again.
+func (h *HTTP) Get(key string) ([]byte, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	if h.secrets == nil || !now.Before(h.expires) {
+		secrets, err := h.fetch()
+		switch {
+		case err == nil:
+			for _, value := range h.secrets {
+				secret.Zero(value)
+			}
+			h.secrets = secrets
+		case h.secrets == nil:
+			return nil, err
+		default:
+			h.Log.Warnf("Refreshing secrets failed, using cached secrets: %v", err)
+		}
+		h.expires = now.Add(h.CacheTTL.Duration)
+	}
+
+	value, ok := h.secrets[key]
+	if !ok {
+		return nil, fmt.Errorf("secret %q not found", key)
+	}
+	return append([]byte(nil), value...), nil
+}
+
+func (h *HTTP) fetch() (map[string][]byte, error) {
+	request, err := http.NewRequest("GET", h.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range h.Headers {
+		if strings.ToLower(k) == "host" {
+			request.Host = v
+		} else {
+			request.Header.Add(k, v)
+		}
+	}
+
+	resp, err := h.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received status code %d (%s), expected %d (%s)",
+			resp.StatusCode,
+			http.StatusText(resp.StatusCode),
+			http.StatusOK,
+			http.StatusText(http.StatusOK))
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	defer secret.Zero(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var object map[string]json.RawMessage
+	err = json.Unmarshal(body, &object)
+	if err != nil {
+		return nil, fmt.Errorf("parsing response: %v", err)
+	}
+
+	if h.Path != "" {
+		for _, name := range strings.Split(h.Path, ".") {
+			raw, ok := object[name]
+			if !ok {
+				return nil, fmt.Errorf("path %q not found in response", h.Path)
+			}
+			object = nil
+			err = json.Unmarshal(raw, &object)
+			if err != nil || object == nil {
+				return nil, fmt.Errorf("path %q is not an object in the response", h.Path)
+			}
+		}
+	}
+
+	secrets := make(map[string][]byte, len(object))
+	for key, raw := range object {
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			h.Log.Debugf("Ignoring secret %q, value is not a string", key)
+			continue
+		}
+		secrets[key] = []byte(value)
+	}
+	return secrets, nil
+}
+
+func init() {
+	secretstores.Add("http", func() telegraf.SecretStore {
+		return &HTTP{
+			CacheTTL: internal.Duration{Duration: 5 * time.Minute},
+			Timeout:  internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
@@ -0,0 +1,127 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGet(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, `{"data": {"data": {"password": "hunter2", "port": 42}}}`)
+	}))
+	defer ts.Close()
+
+	h := &HTTP{
+		URL:     ts.URL,
+		Headers: map[string]string{"X-Vault-Token": "root"},
+		Path:    "data.data",
+		Log:     testutil.Logger{},
+	}
+	require.NoError(t, h.Init())
+
+	v, err := h.Get("password")
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", string(v))
+
+	_, err = h.Get("port")
+	require.Error(t, err)
+}
+
+func TestGetErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		status   int
+		response string
+	}{
+		{
+			name:   "status",
+			status: http.StatusForbidden,
+		},
+		{
+			name:     "invalid json",
+			status:   http.StatusOK,
+			response: `{`,
+		},
+		{
+			name:     "missing path",
+			path:     "data.data",
+			status:   http.StatusOK,
+			response: `{"data": {}}`,
+		},
+		{
+			name:     "path not an object",
+			path:     "data",
+			status:   http.StatusOK,
+			response: `{"data": "password"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.response)
+			}))
+			defer ts.Close()
+
+			h := &HTTP{URL: ts.URL, Path: tt.path, Log: testutil.Logger{}}
+			require.NoError(t, h.Init())
+
+			_, err := h.Get("password")
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestCache(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		if n == 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, `{"token": "token%d"}`, n)
+	}))
+	defer ts.Close()
+
+	h := &HTTP{
+		URL:      ts.URL,
+		CacheTTL: internal.Duration{Duration: time.Hour},
+		Log:      testutil.Logger{},
+	}
+	require.NoError(t, h.Init())
+
+	v, err := h.Get("token")
+	require.NoError(t, err)
+	require.Equal(t, "token1", string(v))
+	v, err = h.Get("token")
+	require.NoError(t, err)
+	require.Equal(t, "token1", string(v))
+	require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	// Rotated secrets are picked up once the cache expired.
+	h.expires = time.Now()
+	v, err = h.Get("token")
+	require.NoError(t, err)
+	require.Equal(t, "token2", string(v))
+
+	// A failed refresh keeps the cached secrets.
+	h.expires = time.Now()
+	v, err = h.Get("token")
+	require.NoError(t, err)
+	require.Equal(t, "token2", string(v))
+	require.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
@@ -0,0 +1,101 @@
+// +build linux
+
+package keyring
+
+import (
+	"fmt"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/secretstores"
+	"golang.org/x/sys/unix"
+)
+
+const sampleConfig = `
+  ## Unique identifier of the store, secrets are referenced as @{id:key}
+  id = "keyring"
+
+  ## Kernel keyring to search, one of "user", "user_session", "session" or
+  ## "process".
+  # keyring = "user"
+
+  ## Prefix of the key descriptions, the secret @{keyring:password} is the
+  ## key of type "user" described as "telegraf:password".
+  # key_prefix = "telegraf:"
+`
+
+var keyrings = map[string]int{
+	"user":         unix.KEY_SPEC_USER_KEYRING,
+	"user_session": unix.KEY_SPEC_USER_SESSION_KEYRING,
+	"session":      unix.KEY_SPEC_SESSION_KEYRING,
+	"process":      unix.KEY_SPEC_PROCESS_KEYRING,
+}
+
+type Keyring struct {
+	Keyring   string `toml:"keyring"`
+	KeyPrefix string `toml:"key_prefix"`
+
+	ringID int
+}
+
+func (k *Keyring) SampleConfig() string {
+	return sampleConfig
+}
+
+func (k *Keyring) Description() string {
+	return "Read secrets from the Linux kernel keyring"
+}
+
+func (k *Keyring) Init() error {
+	id, ok := keyrings[k.Keyring]
+	if !ok {
+		return fmt.Errorf("invalid keyring %q", k.Keyring)
+	}
+	k.ringID = id
+	return nil
+}
+
+// Get reads the key from the keyring, the key is looked up each time so that
+// updated keys are picked up.
+func (k *Keyring) Get(key string) ([]byte, error) {
+	id, err := unix.KeyctlSearch(k.ringID, "user", k.KeyPrefix+key, 0)
+	if err != nil {
+		return nil, fmt.Errorf("searching key %q: %v", k.KeyPrefix+key, err)
+	}
+
+	for {
+		size, err := unix.KeyctlBuffer(unix.KEYCTL_READ, id, nil, 0)
+		if err != nil {
+			return nil, fmt.Errorf("reading key %q: %v", k.KeyPrefix+key, err)
+		}
+
+		value := make([]byte, size)
+		n, err := unix.KeyctlBuffer(unix.KEYCTL_READ, id, value, 0)
+		if err != nil {
+			return nil, fmt.Errorf("reading key %q: %v", k.KeyPrefix+key, err)
+		}
+
+		// The key was updated to a larger value in between, try again.
+		if n > size {
+			continue
+		}
+		return value[:n], nil
+	}
+}
+
+// Set adds the key to the keyring, replacing the current value.
+func (k *Keyring) Set(key string, value []byte) error {
+	_, err := unix.AddKey("user", k.KeyPrefix+key, value, k.ringID)
+	if err != nil {
+		return fmt.Errorf("adding key %q: %v", k.KeyPrefix+key, err)
+	}
+	return nil
+}
+
+func init() {
+	secretstores.Add("keyring", func() telegraf.SecretStore {
+		return &Keyring{
+			Keyring:   "user",
+			KeyPrefix: "telegraf:",
+		}
+	})
+}
@@ -0,0 +1,35 @@
+// +build !linux
+
+package keyring
+
+import (
+	"errors"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/secretstores"
+)
+
+type Keyring struct {
+	Keyring   string `toml:"keyring"`
+	KeyPrefix string `toml:"key_prefix"`
+}
+
+func (k *Keyring) SampleConfig() string { return "" }
+
+func (k *Keyring) Description() string {
+	return "Read secrets from the Linux kernel keyring"
+}
+
+func (k *Keyring) Init() error {
+	return errors.New("the keyring secret store is only supported on Linux")
+}
+
+func (k *Keyring) Get(key string) ([]byte, error) {
+	return nil, errors.New("the keyring secret store is only supported on Linux")
+}
+
+func init() {
+	secretstores.Add("keyring", func() telegraf.SecretStore {
+		return &Keyring{}
+	})
+}
@@ -0,0 +1,44 @@
+// +build linux
+
+package keyring
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestSetGet(t *testing.T) {
+	_, err := unix.KeyctlGetKeyringID(unix.KEY_SPEC_PROCESS_KEYRING, true)
+	if err != nil {
+		t.Skipf("kernel keyring not available: %v", err)
+	}
+
+	k := &Keyring{
+		Keyring:   "process",
+		KeyPrefix: fmt.Sprintf("telegraf-test-%d:", os.Getpid()),
+	}
+	require.NoError(t, k.Init())
+
+	require.NoError(t, k.Set("password", []byte("hunter2")))
+
+	v, err := k.Get("password")
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", string(v))
+
+	require.NoError(t, k.Set("password", []byte("correct horse")))
+	v, err = k.Get("password")
+	require.NoError(t, err)
+	require.Equal(t, "correct horse", string(v))
+
+	_, err = k.Get("missing")
+	require.Error(t, err)
+}
+
+func TestInvalidKeyring(t *testing.T) {
+	k := &Keyring{Keyring: "thread"}
+	require.Error(t, k.Init())
+}
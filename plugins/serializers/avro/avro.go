@@ -0,0 +1,367 @@
+package avro
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/serializers"
+	"gopkg.in/linkedin/goavro.v2"
+)
+
+// magicByte starts records framed with the schema id of a schema registry.
+const magicByte = 0
+
+// FormatConfig holds the options of the serializer.
+type FormatConfig struct {
+	// SchemaFile is the file holding the record schema.
+	SchemaFile string `toml:"avro_schema_file"`
+
+	// SchemaID is the id of the schema in a schema registry, when greater
+	// than zero each record is prefixed with the magic byte and the id.
+	SchemaID int `toml:"avro_schema_id"`
+
+	// Measurement is the record field set to the metric name.
+	Measurement string `toml:"avro_measurement"`
+
+	// Timestamp is the record field set to the metric time.
+	Timestamp string `toml:"avro_timestamp"`
+
+	// TimestampFormat is the format of the Timestamp, either a Go time
+	// layout or one of unix, unix_ms, unix_us or unix_ns.  Defaults to unix.
+	// Fields with a timestamp logical type are always set to the time.
+	TimestampFormat string `toml:"avro_timestamp_format"`
+}
+
+// Serializer serializes metrics into Avro binary encoded records.  The record
+// fields are set from the tags and fields with the same name.
+type Serializer struct {
+	FormatConfig
+
+	codec  *goavro.Codec
+	fields []field
+	header []byte
+}
+
+// field is a field of the record schema.
+type field struct {
+	name string
+
+	// types are the names of the field type, or of the members if the type
+	// is a union, as used by goavro.
+	types      []string
+	union      bool
+	hasDefault bool
+}
+
+// NewSerializer returns a serializer for the schema of the config.
+func NewSerializer(config FormatConfig) (*Serializer, error) {
+	s := &Serializer{FormatConfig: config}
+	if err := s.Init(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Serializer) Description() string {
+	return "Serialize metrics into Avro binary encoded records"
+}
+
+func (s *Serializer) SampleConfig() string {
+	return `
+  ## File holding the record schema, the record fields are set from the tags
+  ## and fields with the same name.
+  avro_schema_file = "/etc/telegraf/metric.avsc"
+
+  ## Id of the schema in a schema registry, when set each record is prefixed
+  ## with the magic byte and the id.
+  # avro_schema_id = 0
+
+  ## Record fields set to the metric name and time.
+  # avro_measurement = ""
+  # avro_timestamp = ""
+
+  ## Format of the avro_timestamp field, either a Go time layout or one of
+  ## "unix", "unix_ms", "unix_us" or "unix_ns".
+  # avro_timestamp_format = "unix"
+`
+}
+
+// Init loads the schema of the SchemaFile.
+func (s *Serializer) Init() error {
+	if s.SchemaFile == "" {
+		return fmt.Errorf("avro_schema_file must be set")
+	}
+	if s.SchemaID < 0 || int64(s.SchemaID) > math.MaxUint32 {
+		return fmt.Errorf("invalid avro_schema_id %d", s.SchemaID)
+	}
+	if s.TimestampFormat == "" {
+		s.TimestampFormat = "unix"
+	}
+
+	spec, err := ioutil.ReadFile(s.SchemaFile)
+	if err != nil {
+		return err
+	}
+	s.codec, err = goavro.NewCodec(string(spec))
+	if err != nil {
+		return fmt.Errorf("invalid schema %s: %v", s.SchemaFile, err)
+	}
+	s.fields, err = recordFields(spec)
+	if err != nil {
+		return fmt.Errorf("invalid schema %s: %v", s.SchemaFile, err)
+	}
+
+	if s.SchemaID > 0 {
+		s.header = make([]byte, 5)
+		s.header[0] = magicByte
+		binary.BigEndian.PutUint32(s.header[1:], uint32(s.SchemaID))
+	}
+	return nil
+}
+
+// Serialize serializes a single metric as one record.
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	return s.serialize(nil, metric)
+}
+
+// SerializeBatch serializes the metrics as consecutive records.
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	var buf []byte
+	for _, metric := range metrics {
+		var err error
+		buf, err = s.serialize(buf, metric)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+func (s *Serializer) serialize(buf []byte, metric telegraf.Metric) ([]byte, error) {
+	record := make(map[string]interface{}, len(s.fields))
+	for _, f := range s.fields {
+		var value interface{}
+		switch f.name {
+		case s.Measurement:
+			value = metric.Name()
+		case s.Timestamp:
+			value = metric.Time()
+		default:
+			if v, ok := metric.GetTag(f.name); ok {
+				value = v
+			} else if v, ok := metric.GetField(f.name); ok {
+				value = v
+			}
+		}
+
+		if value == nil {
+			switch {
+			case f.union && f.accepts("null"):
+				record[f.name] = nil
+			case f.hasDefault:
+			default:
+				return nil, fmt.Errorf("no value for field %q in metric %q", f.name, metric.Name())
+			}
+			continue
+		}
+
+		datum, err := s.datum(f, value)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %v", f.name, err)
+		}
+		record[f.name] = datum
+	}
+
+	buf = append(buf, s.header...)
+	return s.codec.BinaryFromNative(buf, record)
+}
+
+// datum converts the value to the type of the field, union values are wrapped
+// in a map keyed by the chosen member type.
+func (s *Serializer) datum(f field, value interface{}) (interface{}, error) {
+	typ := f.types[0]
+	if f.union {
+		var ok bool
+		typ, ok = f.member(value)
+		if !ok {
+			return nil, fmt.Errorf("no member of %v accepts %T", f.types, value)
+		}
+	}
+
+	v, err := s.convert(typ, value)
+	if err != nil {
+		return nil, err
+	}
+	if f.union {
+		return goavro.Union(typ, v), nil
+	}
+	return v, nil
+}
+
+func (s *Serializer) convert(typ string, value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case time.Time:
+		if isTimestampType(typ) {
+			return v, nil
+		}
+		switch s.TimestampFormat {
+		case "unix":
+			return v.Unix(), nil
+		case "unix_ms":
+			return v.UnixNano() / int64(time.Millisecond), nil
+		case "unix_us":
+			return v.UnixNano() / int64(time.Microsecond), nil
+		case "unix_ns":
+			return v.UnixNano(), nil
+		default:
+			return v.Format(s.TimestampFormat), nil
+		}
+	case uint64:
+		if v > math.MaxInt64 {
+			return nil, fmt.Errorf("value %d out of range", v)
+		}
+		return int64(v), nil
+	case string:
+		if typ == "bytes" {
+			return []byte(v), nil
+		}
+	}
+	return value, nil
+}
+
+// accepts returns true if the type name is the field type or a union member.
+func (f field) accepts(name string) bool {
+	for _, typ := range f.types {
+		if typ == name {
+			return true
+		}
+	}
+	return false
+}
+
+// member returns the first union member type that can hold the value.
+func (f field) member(value interface{}) (string, bool) {
+	for _, typ := range f.types {
+		base := typ
+		if i := strings.Index(typ, "."); i >= 0 && primitiveTypes[typ[:i]] {
+			base = typ[:i]
+		}
+
+		var ok bool
+		switch value.(type) {
+		case bool:
+			ok = base == "boolean"
+		case int64, uint64:
+			ok = base == "long" || base == "int" || base == "double" || base == "float"
+		case float64:
+			ok = base == "double" || base == "float"
+		case string:
+			// Named types other than records are expected to be enums.
+			ok = base == "string" || base == "bytes" || !primitiveTypes[base]
+		case time.Time:
+			ok = isTimestampType(typ) || base == "long" || base == "string"
+		}
+		if ok {
+			return typ, true
+		}
+	}
+	return "", false
+}
+
+// primitiveTypes are the unnamed types of the schema.
+var primitiveTypes = map[string]bool{
+	"null":    true,
+	"boolean": true,
+	"int":     true,
+	"long":    true,
+	"float":   true,
+	"double":  true,
+	"bytes":   true,
+	"string":  true,
+	"array":   true,
+	"map":     true,
+	"record":  true,
+}
+
+func isTimestampType(typ string) bool {
+	return strings.HasPrefix(typ, "long.timestamp-")
+}
+
+// recordFields returns the fields of the top level record of the schema.
+func recordFields(spec []byte) ([]field, error) {
+	var record struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+		Type      string `json:"type"`
+		Fields    []struct {
+			Name    string          `json:"name"`
+			Type    interface{}     `json:"type"`
+			Default json.RawMessage `json:"default"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal(spec, &record); err != nil {
+		return nil, err
+	}
+	if record.Type != "record" {
+		return nil, fmt.Errorf("expected a record schema, got %q", record.Type)
+	}
+
+	namespace := record.Namespace
+	if i := strings.LastIndex(record.Name, "."); i >= 0 {
+		namespace = record.Name[:i]
+	}
+
+	fields := make([]field, 0, len(record.Fields))
+	for _, rf := range record.Fields {
+		f := field{name: rf.Name, hasDefault: rf.Default != nil}
+		if members, ok := rf.Type.([]interface{}); ok {
+			f.union = true
+			for _, member := range members {
+				f.types = append(f.types, typeName(member, namespace))
+			}
+		} else {
+			f.types = []string{typeName(rf.Type, namespace)}
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// typeName returns the name goavro uses for the type, which is the full name
+// for named types and includes the logical type for primitive types.
+func typeName(schema interface{}, namespace string) string {
+	switch s := schema.(type) {
+	case string:
+		if primitiveTypes[s] || strings.Contains(s, ".") || namespace == "" {
+			return s
+		}
+		return namespace + "." + s
+	case map[string]interface{}:
+		typ, _ := s["type"].(string)
+		switch typ {
+		case "enum", "fixed", "record", "error":
+			name, _ := s["name"].(string)
+			if ns, ok := s["namespace"].(string); ok {
+				namespace = ns
+			}
+			return typeName(name, namespace)
+		}
+		if logical, ok := s["logicalType"].(string); ok {
+			return typ + "." + logical
+		}
+		return typ
+	}
+	return ""
+}
+
+func init() {
+	serializers.Add("avro", func() serializers.Serializer {
+		return &Serializer{}
+	})
+}
@@ -0,0 +1,157 @@
+package avro
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/linkedin/goavro.v2"
+)
+
+const schemaFile = "testdata/cpu.avsc"
+
+var exptime = time.Date(2020, time.August, 1, 15, 4, 3, 0, time.UTC)
+
+func newMetric(host string) telegraf.Metric {
+	return testutil.MustMetric("cpu",
+		map[string]string{
+			"host":  host,
+			"state": "BUSY",
+		},
+		map[string]interface{}{
+			"usage":  42.5,
+			"cores":  uint64(4),
+			"serial": "abc",
+		},
+		exptime)
+}
+
+func decode(t *testing.T, buf []byte) []map[string]interface{} {
+	spec, err := ioutil.ReadFile(schemaFile)
+	require.NoError(t, err)
+	codec, err := goavro.NewCodec(string(spec))
+	require.NoError(t, err)
+
+	var records []map[string]interface{}
+	for len(buf) > 0 {
+		var native interface{}
+		native, buf, err = codec.NativeFromBinary(buf)
+		require.NoError(t, err)
+		records = append(records, native.(map[string]interface{}))
+	}
+	return records
+}
+
+func TestSerialize(t *testing.T) {
+	s, err := NewSerializer(FormatConfig{
+		SchemaFile:  schemaFile,
+		Measurement: "measurement",
+		Timestamp:   "time",
+	})
+	require.NoError(t, err)
+
+	buf, err := s.Serialize(newMetric("server01"))
+	require.NoError(t, err)
+
+	records := decode(t, buf)
+	require.Len(t, records, 1)
+	require.Equal(t, map[string]interface{}{
+		"measurement": "cpu",
+		"time":        exptime,
+		"seconds":     nil,
+		"host":        "server01",
+		"state":       "BUSY",
+		"usage":       goavro.Union("double", 42.5),
+		"cores":       int32(4),
+		"serial":      goavro.Union("bytes", []byte("abc")),
+		"region":      "unknown",
+	}, records[0])
+}
+
+func TestSerializeTimestampFormat(t *testing.T) {
+	tests := []struct {
+		format   string
+		expected interface{}
+	}{
+		{"unix", exptime.Unix()},
+		{"unix_ms", exptime.UnixNano() / int64(time.Millisecond)},
+		{"unix_ns", exptime.UnixNano()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			s, err := NewSerializer(FormatConfig{
+				SchemaFile:      "testdata/seconds.avsc",
+				Measurement:     "name",
+				Timestamp:       "seconds",
+				TimestampFormat: tt.format,
+			})
+			require.NoError(t, err)
+
+			buf, err := s.Serialize(newMetric("server01"))
+			require.NoError(t, err)
+
+			native, _, err := s.codec.NativeFromBinary(buf)
+			require.NoError(t, err)
+			require.Equal(t, map[string]interface{}{
+				"name":    "cpu",
+				"seconds": tt.expected,
+				"created": nil,
+			}, native)
+		})
+	}
+}
+
+func TestSerializeBatch(t *testing.T) {
+	s, err := NewSerializer(FormatConfig{
+		SchemaFile:  schemaFile,
+		SchemaID:    7,
+		Measurement: "measurement",
+		Timestamp:   "time",
+	})
+	require.NoError(t, err)
+
+	first, err := s.Serialize(newMetric("server01"))
+	require.NoError(t, err)
+	require.Equal(t, byte(0), first[0])
+	require.Equal(t, uint32(7), binary.BigEndian.Uint32(first[1:5]))
+	require.Equal(t, "server01", decode(t, first[5:])[0]["host"])
+
+	second, err := s.Serialize(newMetric("server02"))
+	require.NoError(t, err)
+
+	buf, err := s.SerializeBatch([]telegraf.Metric{
+		newMetric("server01"),
+		newMetric("server02"),
+	})
+	require.NoError(t, err)
+	require.Equal(t, append(first, second...), buf)
+}
+
+func TestSerializeMissingValue(t *testing.T) {
+	s, err := NewSerializer(FormatConfig{
+		SchemaFile:  schemaFile,
+		Measurement: "measurement",
+		Timestamp:   "time",
+	})
+	require.NoError(t, err)
+
+	m := newMetric("server01")
+	m.RemoveTag("host")
+	_, err = s.Serialize(m)
+	require.Error(t, err)
+}
+
+func TestNewSerializer(t *testing.T) {
+	_, err := NewSerializer(FormatConfig{})
+	require.Error(t, err)
+
+	_, err = NewSerializer(FormatConfig{SchemaFile: "testdata/missing.avsc"})
+	require.Error(t, err)
+
+	_, err = NewSerializer(FormatConfig{SchemaFile: schemaFile, SchemaID: -1})
+	require.Error(t, err)
+}
@@ -3,9 +3,11 @@ package carbon2
 import (
 	"bytes"
 	"fmt"
-	"github.com/influxdata/telegraf"
 	"strconv"
 	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/serializers"
 )
 
 type serializer struct {
@@ -16,6 +18,14 @@ func NewSerializer() (*serializer, error) {
 	return s, nil
 }
 
+func (s *serializer) Description() string {
+	return "Serialize metrics in the Carbon2 format"
+}
+
+func (s *serializer) SampleConfig() string {
+	return ""
+}
+
 func (s *serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
 	return s.createObject(metric), nil
 }
@@ -65,3 +75,9 @@ func isNumeric(v interface{}) bool {
 		return true
 	}
 }
+
+func init() {
+	serializers.Add("carbon2", func() serializers.Serializer {
+		return &serializer{}
+	})
+}
@@ -0,0 +1,216 @@
+package csv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+// FormatConfig holds the options of the serializer.
+type FormatConfig struct {
+	// Columns are the columns of each row, one of "timestamp", "name",
+	// "tag.<key>" or "field.<key>".  If empty the timestamp, name, tags and
+	// fields of each metric are written, with the tags and fields sorted by
+	// key.
+	Columns []string `toml:"csv_columns"`
+
+	// Header writes a row with the column names before the first metric.
+	Header bool `toml:"csv_header"`
+
+	// Separator is the field separator, defaults to a comma.
+	Separator string `toml:"csv_separator"`
+
+	// TimestampFormat is the format of the timestamp column, either a Go
+	// time layout or one of unix, unix_ms, unix_us or unix_ns.  Defaults to
+	// unix.
+	TimestampFormat string `toml:"csv_timestamp_format"`
+}
+
+// Serializer serializes metrics into comma separated values, one row per
+// metric.
+type Serializer struct {
+	FormatConfig
+
+	separator rune
+
+	// headerWritten is set once the header row has been written, as it is
+	// only written before the first metric.
+	headerWritten bool
+}
+
+// NewSerializer returns a serializer with the given options.
+func NewSerializer(config FormatConfig) (*Serializer, error) {
+	s := &Serializer{FormatConfig: config}
+	if err := s.Init(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Serializer) Description() string {
+	return "Serialize metrics into comma separated values, one row per metric"
+}
+
+func (s *Serializer) SampleConfig() string {
+	return `
+  ## Columns of each row, one of "timestamp", "name", "tag.<key>" or
+  ## "field.<key>".  By default the timestamp, name, tags and fields of each
+  ## metric are written, with the tags and fields sorted by key.
+  # csv_columns = []
+
+  ## Write a row with the column names before the first metric.
+  # csv_header = false
+
+  ## The field separator.
+  # csv_separator = ","
+
+  ## Format of the timestamp column, either a Go time layout or one of
+  ## "unix", "unix_ms", "unix_us" or "unix_ns".
+  # csv_timestamp_format = "unix"
+`
+}
+
+// Init validates the options and sets their defaults.
+func (s *Serializer) Init() error {
+	if s.Separator == "" {
+		s.Separator = ","
+	}
+	separator, size := utf8.DecodeRuneInString(s.Separator)
+	if size != len(s.Separator) || separator == '"' || separator == '\r' || separator == '\n' {
+		return fmt.Errorf("invalid csv_separator %q, must be a single character", s.Separator)
+	}
+	s.separator = separator
+	if s.TimestampFormat == "" {
+		s.TimestampFormat = "unix"
+	}
+
+	for _, column := range s.Columns {
+		switch {
+		case column == "timestamp", column == "name":
+		case strings.HasPrefix(column, "tag."), strings.HasPrefix(column, "field."):
+		default:
+			return fmt.Errorf("invalid csv column %q, must be \"timestamp\", \"name\", \"tag.<key>\" or \"field.<key>\"", column)
+		}
+	}
+	return nil
+}
+
+// Serialize serializes a single metric as one row, preceded by the header if
+// it has not been written yet.
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	return s.SerializeBatch([]telegraf.Metric{metric})
+}
+
+// SerializeBatch serializes the metrics as one row each.
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = s.separator
+
+	for _, metric := range metrics {
+		columns := s.Columns
+		if len(columns) == 0 {
+			columns = metricColumns(metric)
+		}
+
+		if s.Header && !s.headerWritten {
+			if err := w.Write(header(columns)); err != nil {
+				return nil, err
+			}
+			s.headerWritten = true
+		}
+
+		if err := w.Write(s.row(columns, metric)); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *Serializer) row(columns []string, metric telegraf.Metric) []string {
+	row := make([]string, len(columns))
+	for i, column := range columns {
+		switch {
+		case column == "timestamp":
+			row[i] = internal.FormatTimestamp(metric.Time(), s.TimestampFormat)
+		case column == "name":
+			row[i] = metric.Name()
+		case strings.HasPrefix(column, "tag."):
+			row[i], _ = metric.GetTag(strings.TrimPrefix(column, "tag."))
+		case strings.HasPrefix(column, "field."):
+			if v, ok := metric.GetField(strings.TrimPrefix(column, "field.")); ok {
+				row[i] = formatValue(v)
+			}
+		}
+	}
+	return row
+}
+
+// metricColumns returns the timestamp, name, tag and field columns of the
+// metric.
+func metricColumns(metric telegraf.Metric) []string {
+	columns := []string{"timestamp", "name"}
+	for _, tag := range metric.TagList() {
+		columns = append(columns, "tag."+tag.Key)
+	}
+
+	fields := make([]string, 0, len(metric.FieldList()))
+	for _, field := range metric.FieldList() {
+		fields = append(fields, "field."+field.Key)
+	}
+	sort.Strings(fields)
+	return append(columns, fields...)
+}
+
+// header returns the column names, which are the tag and field keys for tag
+// and field columns.
+func header(columns []string) []string {
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		switch {
+		case strings.HasPrefix(column, "tag."):
+			names[i] = strings.TrimPrefix(column, "tag.")
+		case strings.HasPrefix(column, "field."):
+			names[i] = strings.TrimPrefix(column, "field.")
+		default:
+			names[i] = column
+		}
+	}
+	return names
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return v
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func init() {
+	serializers.Add("csv", func() serializers.Serializer {
+		return &Serializer{}
+	})
+}
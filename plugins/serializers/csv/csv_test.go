@@ -0,0 +1,109 @@
+package csv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var exptime = time.Date(2020, time.August, 1, 15, 4, 3, 500000000, time.UTC)
+
+func metrics() []telegraf.Metric {
+	return []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "server01", "cpu": "cpu0"},
+			map[string]interface{}{
+				"usage_idle": 98.5,
+				"count":      int64(3),
+			},
+			exptime),
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "server, 02"},
+			map[string]interface{}{
+				"usage_idle": 42.0,
+				"ok":         true,
+				"bytes":      uint64(1024),
+				"state":      "say \"hi\"",
+			},
+			exptime),
+	}
+}
+
+func TestSerializeBatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   FormatConfig
+		expected string
+	}{
+		{
+			name:   "metric columns",
+			config: FormatConfig{},
+			expected: "1596294243,cpu,cpu0,server01,3,98.5\n" +
+				"1596294243,cpu,\"server, 02\",1024,true,\"say \"\"hi\"\"\",42\n",
+		},
+		{
+			name: "configured columns",
+			config: FormatConfig{
+				Columns:         []string{"timestamp", "tag.host", "field.usage_idle", "field.ok", "name"},
+				Header:          true,
+				Separator:       ";",
+				TimestampFormat: "2006-01-02T15:04:05.000Z07:00",
+			},
+			expected: "timestamp;host;usage_idle;ok;name\n" +
+				"2020-08-01T15:04:03.500Z;server01;98.5;;cpu\n" +
+				"2020-08-01T15:04:03.500Z;server, 02;42;true;cpu\n",
+		},
+		{
+			name: "header from first metric",
+			config: FormatConfig{
+				Header:          true,
+				Separator:       "\t",
+				TimestampFormat: "unix_ms",
+			},
+			expected: "timestamp\tname\tcpu\thost\tcount\tusage_idle\n" +
+				"1596294243500\tcpu\tcpu0\tserver01\t3\t98.5\n" +
+				"1596294243500\tcpu\tserver, 02\t1024\ttrue\t\"say \"\"hi\"\"\"\t42\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSerializer(tt.config)
+			require.NoError(t, err)
+
+			buf, err := s.SerializeBatch(metrics())
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, string(buf))
+		})
+	}
+}
+
+func TestSerializeHeaderOnce(t *testing.T) {
+	s, err := NewSerializer(FormatConfig{
+		Columns: []string{"name", "field.count"},
+		Header:  true,
+	})
+	require.NoError(t, err)
+
+	m := metrics()
+	buf, err := s.Serialize(m[0])
+	require.NoError(t, err)
+	require.Equal(t, "name,count\ncpu,3\n", string(buf))
+
+	buf, err = s.Serialize(m[1])
+	require.NoError(t, err)
+	require.Equal(t, "cpu,\n", string(buf))
+}
+
+func TestNewSerializerInvalid(t *testing.T) {
+	_, err := NewSerializer(FormatConfig{Separator: ",,"})
+	require.Error(t, err)
+
+	_, err = NewSerializer(FormatConfig{Separator: "\""})
+	require.Error(t, err)
+
+	_, err = NewSerializer(FormatConfig{Columns: []string{"host"}})
+	require.Error(t, err)
+}
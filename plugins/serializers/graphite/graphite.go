@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/serializers"
 )
 
 const DEFAULT_TEMPLATE = "host.tags.measurement.field"
@@ -30,9 +31,25 @@ var (
 )
 
 type GraphiteSerializer struct {
-	Prefix     string
-	Template   string
-	TagSupport bool
+	Prefix     string `toml:"prefix"`
+	Template   string `toml:"template"`
+	TagSupport bool   `toml:"graphite_tag_support"`
+}
+
+func (s *GraphiteSerializer) Description() string {
+	return "Serialize metrics in the Graphite plaintext protocol"
+}
+
+func (s *GraphiteSerializer) SampleConfig() string {
+	return `
+  ## Prefix added to each graphite bucket
+  # prefix = ""
+  ## Graphite template pattern
+  # template = "host.tags.measurement.field"
+
+  ## Support Graphite tags, recommended to enable when using Graphite 1.1 or later.
+  # graphite_tag_support = false
+`
 }
 
 func (s *GraphiteSerializer) Serialize(metric telegraf.Metric) ([]byte, error) {
@@ -261,3 +278,9 @@ func sanitize(value string) string {
 	// Replace any remaining illegal chars
 	return allowedChars.ReplaceAllLiteralString(value, "_")
 }
+
+func init() {
+	serializers.Add("graphite", func() serializers.Serializer {
+		return &GraphiteSerializer{}
+	})
+}
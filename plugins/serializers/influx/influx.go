@@ -10,6 +10,7 @@ import (
 	"strconv"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/serializers"
 )
 
 const MaxInt64 = int64(^uint64(0) >> 1)
@@ -57,6 +58,12 @@ func (e FieldError) Error() string {
 
 // Serializer is a serializer for line protocol.
 type Serializer struct {
+	// MaxLineBytes, SortFields and UintSupport are the options of the data
+	// format, applied by Init.
+	MaxLineBytes int  `toml:"influx_max_line_bytes"`
+	SortFields   bool `toml:"influx_sort_fields"`
+	UintSupport  bool `toml:"influx_uint_support"`
+
 	maxLineBytes     int
 	bytesWritten     int
 	fieldSortOrder   FieldSortOrder
@@ -79,6 +86,40 @@ func NewSerializer() *Serializer {
 	return serializer
 }
 
+// Init applies the options.
+func (s *Serializer) Init() error {
+	s.SetMaxLineBytes(s.MaxLineBytes)
+	if s.SortFields {
+		s.SetFieldSortOrder(SortFields)
+	}
+	if s.UintSupport {
+		s.SetFieldTypeSupport(UintSupport)
+	}
+	return nil
+}
+
+func (s *Serializer) Description() string {
+	return "Serialize metrics in InfluxDB line protocol"
+}
+
+func (s *Serializer) SampleConfig() string {
+	return `
+  ## Maximum line length in bytes.  Useful only for debugging.
+  # influx_max_line_bytes = 0
+
+  ## When true, fields will be output in ascending lexical order.  Enabling
+  ## this option will result in decreased performance and is only recommended
+  ## when you need predictable ordering while debugging.
+  # influx_sort_fields = false
+
+  ## When true, Telegraf will output unsigned integers as unsigned values,
+  ## i.e.: 42u.  You will need a version of InfluxDB supporting unsigned
+  ## integer values.  Enabling this option will result in field type errors if
+  ## existing data has been written.
+  # influx_uint_support = false
+`
+}
+
 func (s *Serializer) SetMaxLineBytes(bytes int) {
 	s.maxLineBytes = bytes
 }
@@ -343,3 +384,9 @@ func appendStringField(buf []byte, value string) []byte {
 	buf = append(buf, '"')
 	return buf
 }
+
+func init() {
+	serializers.Add("influx", func() serializers.Serializer {
+		return NewSerializer()
+	})
+}
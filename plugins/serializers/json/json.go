@@ -5,9 +5,15 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/serializers"
 )
 
 type serializer struct {
+	// Units is the configured resolution of the timestamp, truncated to the
+	// power of ten less than it by Init.
+	Units internal.Duration `toml:"json_timestamp_units"`
+
 	TimestampUnits time.Duration
 }
 
@@ -18,6 +24,25 @@ func NewSerializer(timestampUnits time.Duration) (*serializer, error) {
 	return s, nil
 }
 
+// Init sets the timestamp resolution.
+func (s *serializer) Init() error {
+	s.TimestampUnits = truncateDuration(s.Units.Duration)
+	return nil
+}
+
+func (s *serializer) Description() string {
+	return "Serialize metrics as JSON objects"
+}
+
+func (s *serializer) SampleConfig() string {
+	return `
+  ## The resolution to use for the metric timestamp.  Must be a duration string
+  ## such as "1ns", "1us", "1ms", "10ms", "1s".  Durations are truncated to
+  ## the power of 10 less than the specified units.
+  # json_timestamp_units = "1s"
+`
+}
+
 func (s *serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
 	m := s.createObject(metric)
 	serialized, err := json.Marshal(m)
@@ -71,3 +96,9 @@ func truncateDuration(units time.Duration) time.Duration {
 		d = d * 10
 	}
 }
+
+func init() {
+	serializers.Add("json", func() serializers.Serializer {
+		return &serializer{}
+	})
+}
@@ -0,0 +1,143 @@
+package msgpack
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/serializers"
+	"github.com/tinylib/msgp/msgp"
+)
+
+// timestampExtension is the MessagePack extension type of timestamps.
+const timestampExtension = -1
+
+// Serializer serializes metrics into MessagePack maps holding the name, time,
+// tags, fields and value type of the metric.  Field types are preserved,
+// unsigned integers are always written using the unsigned encodings.
+type Serializer struct{}
+
+func NewSerializer() *Serializer {
+	return &Serializer{}
+}
+
+func (s *Serializer) Description() string {
+	return "Serialize metrics into MessagePack maps"
+}
+
+func (s *Serializer) SampleConfig() string {
+	return ""
+}
+
+// Serialize serializes a single metric as one map.
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	return appendMetric(nil, metric)
+}
+
+// SerializeBatch serializes the metrics as consecutive maps.
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	var buf []byte
+	for _, metric := range metrics {
+		var err error
+		buf, err = appendMetric(buf, metric)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+func appendMetric(buf []byte, metric telegraf.Metric) ([]byte, error) {
+	typ := valueTypeName(metric.Type())
+
+	size := uint32(4)
+	if typ != "" {
+		size++
+	}
+	buf = msgp.AppendMapHeader(buf, size)
+
+	buf = msgp.AppendString(buf, "name")
+	buf = msgp.AppendString(buf, metric.Name())
+
+	buf = msgp.AppendString(buf, "time")
+	buf, err := msgp.AppendExtension(buf, &msgp.RawExtension{
+		Type: timestampExtension,
+		Data: encodeTime(metric.Time()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	buf = msgp.AppendString(buf, "tags")
+	buf = msgp.AppendMapHeader(buf, uint32(len(metric.TagList())))
+	for _, tag := range metric.TagList() {
+		buf = msgp.AppendString(buf, tag.Key)
+		buf = msgp.AppendString(buf, tag.Value)
+	}
+
+	buf = msgp.AppendString(buf, "fields")
+	buf = msgp.AppendMapHeader(buf, uint32(len(metric.FieldList())))
+	for _, field := range metric.FieldList() {
+		buf = msgp.AppendString(buf, field.Key)
+		switch v := field.Value.(type) {
+		case int64:
+			buf = msgp.AppendInt64(buf, v)
+		case uint64:
+			buf = appendUint64(buf, v)
+		case float64:
+			buf = msgp.AppendFloat64(buf, v)
+		case bool:
+			buf = msgp.AppendBool(buf, v)
+		case string:
+			buf = msgp.AppendString(buf, v)
+		default:
+			return nil, fmt.Errorf("invalid value type %T for field %q", v, field.Key)
+		}
+	}
+
+	if typ != "" {
+		buf = msgp.AppendString(buf, "type")
+		buf = msgp.AppendString(buf, typ)
+	}
+	return buf, nil
+}
+
+// appendUint64 appends the value using the uint 8 encoding for small values,
+// which msgp would write as positive fixint and be read back as signed.
+func appendUint64(buf []byte, v uint64) []byte {
+	if v <= 127 {
+		return append(buf, 0xcc, byte(v))
+	}
+	return msgp.AppendUint64(buf, v)
+}
+
+func valueTypeName(t telegraf.ValueType) string {
+	switch t {
+	case telegraf.Counter:
+		return "counter"
+	case telegraf.Gauge:
+		return "gauge"
+	case telegraf.Summary:
+		return "summary"
+	case telegraf.Histogram:
+		return "histogram"
+	default:
+		return ""
+	}
+}
+
+// encodeTime returns the time in the 96 bit format of the timestamp
+// extension, holding the nanoseconds and the seconds since the epoch.
+func encodeTime(t time.Time) []byte {
+	b := make([]byte, 12)
+	binary.BigEndian.PutUint32(b[0:4], uint32(t.Nanosecond()))
+	binary.BigEndian.PutUint64(b[4:12], uint64(t.Unix()))
+	return b
+}
+
+func init() {
+	serializers.Add("msgpack", func() serializers.Serializer {
+		return &Serializer{}
+	})
+}
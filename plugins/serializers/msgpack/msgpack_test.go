@@ -0,0 +1,94 @@
+package msgpack
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestSerialize(t *testing.T) {
+	m := testutil.MustMetric("cpu",
+		map[string]string{"host": "server01"},
+		map[string]interface{}{"value": uint64(1)},
+		time.Unix(1, 2),
+		telegraf.Counter)
+
+	s := NewSerializer()
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+
+	expected := []byte{
+		0x85,
+		0xa4, 'n', 'a', 'm', 'e', 0xa3, 'c', 'p', 'u',
+		0xa4, 't', 'i', 'm', 'e', 0xc7, 12, 0xff,
+		0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 1,
+		0xa4, 't', 'a', 'g', 's', 0x81,
+		0xa4, 'h', 'o', 's', 't', 0xa8, 's', 'e', 'r', 'v', 'e', 'r', '0', '1',
+		0xa6, 'f', 'i', 'e', 'l', 'd', 's', 0x81,
+		0xa5, 'v', 'a', 'l', 'u', 'e', 0xcc, 1,
+		0xa4, 't', 'y', 'p', 'e', 0xa7, 'c', 'o', 'u', 'n', 't', 'e', 'r',
+	}
+	require.Equal(t, expected, buf)
+}
+
+func TestSerializeFieldTypes(t *testing.T) {
+	m := testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{
+			"int":       int64(-5),
+			"uint":      uint64(5),
+			"uint_max":  uint64(math.MaxUint64),
+			"float":     float64(1.5),
+			"bool":      true,
+			"string":    "foo",
+			"big_int":   int64(math.MaxInt64),
+			"small_int": int64(5),
+		},
+		time.Unix(0, 0))
+
+	buf, err := NewSerializer().Serialize(m)
+	require.NoError(t, err)
+
+	v, rest, err := msgp.ReadIntfBytes(buf)
+	require.NoError(t, err)
+	require.Empty(t, rest)
+
+	decoded := v.(map[string]interface{})
+	require.Len(t, decoded, 4)
+	require.Equal(t, map[string]interface{}{
+		"int":       int64(-5),
+		"uint":      uint64(5),
+		"uint_max":  uint64(math.MaxUint64),
+		"float":     float64(1.5),
+		"bool":      true,
+		"string":    "foo",
+		"big_int":   int64(math.MaxInt64),
+		"small_int": int64(5),
+	}, decoded["fields"])
+}
+
+func TestSerializeBatch(t *testing.T) {
+	m1 := testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{"value": 42.0},
+		time.Unix(0, 0))
+	m2 := testutil.MustMetric("mem",
+		map[string]string{},
+		map[string]interface{}{"value": 42.0},
+		time.Unix(0, 0))
+
+	s := NewSerializer()
+	first, err := s.Serialize(m1)
+	require.NoError(t, err)
+	second, err := s.Serialize(m2)
+	require.NoError(t, err)
+
+	buf, err := s.SerializeBatch([]telegraf.Metric{m1, m2})
+	require.NoError(t, err)
+	require.Equal(t, append(first, second...), buf)
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/serializers"
 )
 
 type serializer struct {
@@ -43,6 +44,14 @@ func NewSerializer() (*serializer, error) {
 	return s, nil
 }
 
+func (s *serializer) Description() string {
+	return "Serialize metrics for the ServiceNow Operational Intelligence MID server"
+}
+
+func (s *serializer) SampleConfig() string {
+	return ""
+}
+
 func (s *serializer) Serialize(metric telegraf.Metric) (out []byte, err error) {
 	serialized, err := s.createObject(metric)
 	if err != nil {
@@ -135,3 +144,9 @@ func verifyValue(v interface{}) bool {
 	}
 	return true
 }
+
+func init() {
+	serializers.Add("nowmetric", func() serializers.Serializer {
+		return &serializer{}
+	})
+}
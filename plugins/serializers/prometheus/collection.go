@@ -0,0 +1,345 @@
+package prometheus
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var (
+	invalidMetricCharRE = regexp.MustCompile(`[^a-zA-Z0-9_:]`)
+	validMetricNameRE   = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	invalidLabelCharRE  = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+	validLabelNameRE    = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+type sample struct {
+	key    string
+	time   time.Time
+	metric *dto.Metric
+}
+
+type family struct {
+	name    string
+	typ     dto.MetricType
+	samples []*sample
+	index   map[string]int
+}
+
+// collection groups the samples of metrics into metric families.
+type collection struct {
+	config FormatConfig
+	fams   []*family
+	index  map[string]*family
+}
+
+func newCollection(config FormatConfig) *collection {
+	return &collection{
+		config: config,
+		index:  make(map[string]*family),
+	}
+}
+
+// metricName returns the sanitized metric name, false is returned if the
+// name is invalid and can not be used.
+func (c *collection) metricName(name string) (string, bool) {
+	if validMetricNameRE.MatchString(name) {
+		return name, true
+	}
+	if c.config.InvalidNames == InvalidNamesDrop {
+		return "", false
+	}
+
+	name = invalidMetricCharRE.ReplaceAllString(name, "_")
+	if !validMetricNameRE.MatchString(name) {
+		name = "_" + name
+	}
+	return name, true
+}
+
+// labelName returns the sanitized label name, false is returned if the name
+// is invalid and can not be used.
+func (c *collection) labelName(name string) (string, bool) {
+	if validLabelNameRE.MatchString(name) {
+		return name, true
+	}
+	if c.config.InvalidNames == InvalidNamesDrop {
+		return "", false
+	}
+
+	name = invalidLabelCharRE.ReplaceAllString(name, "_")
+	if !validLabelNameRE.MatchString(name) {
+		name = "_" + name
+	}
+	return name, true
+}
+
+// labels returns the labels of the metric sorted by name.
+func (c *collection) labels(metric telegraf.Metric) []*dto.LabelPair {
+	values := make(map[string]string)
+	for _, tag := range metric.TagList() {
+		name, ok := c.labelName(tag.Key)
+		if ok {
+			values[name] = tag.Value
+		}
+	}
+
+	// Prometheus doesn't have a string value type, so convert string
+	// fields to labels if enabled.
+	if c.config.StringAsLabel {
+		for _, field := range metric.FieldList() {
+			value, ok := field.Value.(string)
+			if !ok {
+				continue
+			}
+			name, ok := c.labelName(field.Key)
+			if ok {
+				values[name] = value
+			}
+		}
+	}
+
+	labels := make([]*dto.LabelPair, 0, len(values))
+	for name, value := range values {
+		name, value := name, value
+		labels = append(labels, &dto.LabelPair{Name: &name, Value: &value})
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].GetName() < labels[j].GetName()
+	})
+	return labels
+}
+
+func (c *collection) add(metric telegraf.Metric) {
+	labels := c.labels(metric)
+
+	switch metric.Type() {
+	case telegraf.Summary:
+		c.addSummary(metric, labels)
+	case telegraf.Histogram:
+		c.addHistogram(metric, labels)
+	default:
+		fields := make([]*telegraf.Field, len(metric.FieldList()))
+		copy(fields, metric.FieldList())
+		sort.Slice(fields, func(i, j int) bool {
+			return fields[i].Key < fields[j].Key
+		})
+
+		for _, field := range fields {
+			value, ok := toFloat(field.Value)
+			if !ok {
+				continue
+			}
+
+			// Special handling of value field; supports passthrough from
+			// the prometheus input.
+			var name string
+			switch {
+			case metric.Type() == telegraf.Counter && field.Key == "counter",
+				metric.Type() == telegraf.Gauge && field.Key == "gauge",
+				field.Key == "value":
+				name = metric.Name()
+			default:
+				name = metric.Name() + "_" + field.Key
+			}
+
+			m := &dto.Metric{Label: labels}
+			var typ dto.MetricType
+			switch metric.Type() {
+			case telegraf.Counter:
+				typ = dto.MetricType_COUNTER
+				m.Counter = &dto.Counter{Value: &value}
+			case telegraf.Gauge:
+				typ = dto.MetricType_GAUGE
+				m.Gauge = &dto.Gauge{Value: &value}
+			default:
+				typ = dto.MetricType_UNTYPED
+				m.Untyped = &dto.Untyped{Value: &value}
+			}
+			c.addSample(name, typ, metric.Time(), m)
+		}
+	}
+}
+
+func (c *collection) addSummary(metric telegraf.Metric, labels []*dto.LabelPair) {
+	var count uint64
+	var sum float64
+	var quantiles []*dto.Quantile
+	for _, field := range metric.FieldList() {
+		value, ok := toFloat(field.Value)
+		if !ok {
+			continue
+		}
+
+		switch field.Key {
+		case "count":
+			count = uint64(value)
+		case "sum":
+			sum = value
+		default:
+			q, err := strconv.ParseFloat(field.Key, 64)
+			if err != nil {
+				continue
+			}
+			value := value
+			quantiles = append(quantiles, &dto.Quantile{Quantile: &q, Value: &value})
+		}
+	}
+	sort.Slice(quantiles, func(i, j int) bool {
+		return quantiles[i].GetQuantile() < quantiles[j].GetQuantile()
+	})
+
+	c.addSample(metric.Name(), dto.MetricType_SUMMARY, metric.Time(), &dto.Metric{
+		Label: labels,
+		Summary: &dto.Summary{
+			SampleCount: &count,
+			SampleSum:   &sum,
+			Quantile:    quantiles,
+		},
+	})
+}
+
+func (c *collection) addHistogram(metric telegraf.Metric, labels []*dto.LabelPair) {
+	var count uint64
+	var sum float64
+	var buckets []*dto.Bucket
+	for _, field := range metric.FieldList() {
+		value, ok := toFloat(field.Value)
+		if !ok {
+			continue
+		}
+
+		switch field.Key {
+		case "count":
+			count = uint64(value)
+		case "sum":
+			sum = value
+		default:
+			bound, err := strconv.ParseFloat(field.Key, 64)
+			if err != nil {
+				continue
+			}
+			cumulative := uint64(value)
+			buckets = append(buckets, &dto.Bucket{
+				UpperBound:      &bound,
+				CumulativeCount: &cumulative,
+			})
+		}
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].GetUpperBound() < buckets[j].GetUpperBound()
+	})
+
+	c.addSample(metric.Name(), dto.MetricType_HISTOGRAM, metric.Time(), &dto.Metric{
+		Label: labels,
+		Histogram: &dto.Histogram{
+			SampleCount: &count,
+			SampleSum:   &sum,
+			Bucket:      buckets,
+		},
+	})
+}
+
+// addSample adds a sample to its family.  Samples of a type that differs
+// from the type of the family are ignored.
+func (c *collection) addSample(name string, typ dto.MetricType, t time.Time, m *dto.Metric) {
+	name, ok := c.metricName(name)
+	if !ok {
+		return
+	}
+
+	fam, ok := c.index[name]
+	if !ok {
+		fam = &family{
+			name:  name,
+			typ:   typ,
+			index: make(map[string]int),
+		}
+		c.index[name] = fam
+		c.fams = append(c.fams, fam)
+	}
+	if fam.typ != typ {
+		return
+	}
+
+	if c.config.ExportTimestamp {
+		ms := t.UnixNano() / int64(time.Millisecond)
+		m.TimestampMs = &ms
+	}
+
+	key := labelsKey(m.Label)
+	if i, ok := fam.index[key]; ok {
+		if !t.Before(fam.samples[i].time) {
+			fam.samples[i] = &sample{key: key, time: t, metric: m}
+		}
+		return
+	}
+	fam.index[key] = len(fam.samples)
+	fam.samples = append(fam.samples, &sample{key: key, time: t, metric: m})
+}
+
+// families returns the metric families of the collection.
+func (c *collection) families() []*dto.MetricFamily {
+	fams := c.fams
+	if c.config.SortMetrics {
+		fams = make([]*family, len(c.fams))
+		copy(fams, c.fams)
+		sort.Slice(fams, func(i, j int) bool {
+			return fams[i].name < fams[j].name
+		})
+	}
+
+	result := make([]*dto.MetricFamily, 0, len(fams))
+	for _, fam := range fams {
+		samples := fam.samples
+		if c.config.SortMetrics {
+			samples = make([]*sample, len(fam.samples))
+			copy(samples, fam.samples)
+			sort.Slice(samples, func(i, j int) bool {
+				return samples[i].key < samples[j].key
+			})
+		}
+
+		name, typ := fam.name, fam.typ
+		mf := &dto.MetricFamily{Name: &name, Type: &typ}
+		for _, s := range samples {
+			mf.Metric = append(mf.Metric, s.metric)
+		}
+		result = append(result, mf)
+	}
+	return result
+}
+
+func labelsKey(labels []*dto.LabelPair) string {
+	var b strings.Builder
+	for _, label := range labels {
+		b.WriteString(label.GetName())
+		b.WriteByte('=')
+		b.WriteString(label.GetValue())
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	default:
+		return 0, false
+	}
+}
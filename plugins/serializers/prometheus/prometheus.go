@@ -0,0 +1,129 @@
+package prometheus
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/serializers"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// Actions for names that are not valid Prometheus metric or label names.
+const (
+	// InvalidNamesReplace replaces the invalid characters with underscores.
+	InvalidNamesReplace = "replace"
+
+	// InvalidNamesDrop drops the fields and tags with invalid names.
+	InvalidNamesDrop = "drop"
+)
+
+// FormatConfig holds the options of the serializer.
+type FormatConfig struct {
+	// ExportTimestamp adds the metric time to each sample.
+	ExportTimestamp bool `toml:"prometheus_export_timestamp"`
+
+	// SortMetrics sorts the metric families by name and the samples by
+	// their labels, otherwise they are written in the order first seen.
+	SortMetrics bool `toml:"prometheus_sort_metrics"`
+
+	// StringAsLabel converts string fields to labels, otherwise they are
+	// ignored.
+	StringAsLabel bool `toml:"prometheus_string_as_label"`
+
+	// InvalidNames is the action for invalid names, one of InvalidNamesReplace
+	// or InvalidNamesDrop.  When empty InvalidNamesReplace is used.
+	InvalidNames string `toml:"prometheus_invalid_names"`
+}
+
+// Serializer serializes metrics in the Prometheus text exposition format.
+type Serializer struct {
+	FormatConfig
+}
+
+// NewSerializer returns a serializer with the given options.
+func NewSerializer(config FormatConfig) (*Serializer, error) {
+	s := &Serializer{FormatConfig: config}
+	if err := s.Init(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Serializer) Description() string {
+	return "Serialize metrics in the Prometheus text exposition format"
+}
+
+func (s *Serializer) SampleConfig() string {
+	return `
+  ## Add the metric time to each sample.
+  # prometheus_export_timestamp = false
+
+  ## Sort the metric families and samples, otherwise they are written in the
+  ## order first seen.
+  # prometheus_sort_metrics = false
+
+  ## Convert string fields to labels, otherwise they are ignored.
+  # prometheus_string_as_label = false
+
+  ## Action for metric and label names that are not valid in Prometheus,
+  ## either "replace" the invalid characters with underscores or "drop" them.
+  # prometheus_invalid_names = "replace"
+`
+}
+
+// Init validates the options, defaulting InvalidNames to InvalidNamesReplace.
+func (s *Serializer) Init() error {
+	switch s.InvalidNames {
+	case "":
+		s.InvalidNames = InvalidNamesReplace
+	case InvalidNamesReplace, InvalidNamesDrop:
+	default:
+		return fmt.Errorf("invalid prometheus_invalid_names %q, must be %q or %q",
+			s.InvalidNames, InvalidNamesReplace, InvalidNamesDrop)
+	}
+	return nil
+}
+
+// Serialize serializes a single metric.  Each call outputs complete metric
+// families, use SerializeBatch to group the samples of multiple metrics.
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	return s.SerializeBatch([]telegraf.Metric{metric})
+}
+
+// SerializeBatch serializes the metrics, samples with the same name are
+// grouped into a single metric family.  If multiple metrics produce a sample
+// with the same name and labels the newest one is used.
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	coll := newCollection(s.FormatConfig)
+	for _, metric := range metrics {
+		coll.add(metric)
+	}
+
+	var buf bytes.Buffer
+	for _, mf := range coll.families() {
+		_, err := expfmt.MetricFamilyToText(&buf, mf)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// MetricFamilies returns the metric families of a single metric, used by
+// formats converting the samples to other encodings.  The samples always
+// have the metric time as timestamp.
+func (s *Serializer) MetricFamilies(metric telegraf.Metric) []*dto.MetricFamily {
+	config := s.FormatConfig
+	config.ExportTimestamp = true
+	coll := newCollection(config)
+	coll.add(metric)
+	return coll.families()
+}
+
+func init() {
+	serializers.Add("prometheus", func() serializers.Serializer {
+		return &Serializer{}
+	})
+}
@@ -0,0 +1,320 @@
+package prometheus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSerializeBatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   FormatConfig
+		metrics  []telegraf.Metric
+		expected string
+	}{
+		{
+			name: "untyped",
+			metrics: []telegraf.Metric{
+				testutil.MustMetric(
+					"cpu",
+					map[string]string{"host": "example.org"},
+					map[string]interface{}{"time_idle": 42.0},
+					time.Unix(0, 0),
+				),
+			},
+			expected: `# TYPE cpu_time_idle untyped
+cpu_time_idle{host="example.org"} 42
+`,
+		},
+		{
+			name: "counter and gauge passthrough",
+			metrics: []telegraf.Metric{
+				testutil.MustMetric(
+					"http_requests_total",
+					map[string]string{},
+					map[string]interface{}{"counter": 10.0},
+					time.Unix(0, 0),
+					telegraf.Counter,
+				),
+				testutil.MustMetric(
+					"temperature",
+					map[string]string{},
+					map[string]interface{}{"gauge": 21.5},
+					time.Unix(0, 0),
+					telegraf.Gauge,
+				),
+				testutil.MustMetric(
+					"uptime",
+					map[string]string{},
+					map[string]interface{}{"value": int64(3600)},
+					time.Unix(0, 0),
+				),
+			},
+			expected: `# TYPE http_requests_total counter
+http_requests_total 10
+# TYPE temperature gauge
+temperature 21.5
+# TYPE uptime untyped
+uptime 3600
+`,
+		},
+		{
+			name: "fields in key order",
+			metrics: []telegraf.Metric{
+				testutil.MustMetric(
+					"mem",
+					map[string]string{},
+					map[string]interface{}{
+						"used":      uint64(2),
+						"available": int64(1),
+						"swapped":   true,
+					},
+					time.Unix(0, 0),
+				),
+			},
+			expected: `# TYPE mem_available untyped
+mem_available 1
+# TYPE mem_swapped untyped
+mem_swapped 1
+# TYPE mem_used untyped
+mem_used 2
+`,
+		},
+		{
+			name: "summary",
+			metrics: []telegraf.Metric{
+				testutil.MustMetric(
+					"rpc_duration_seconds",
+					map[string]string{},
+					map[string]interface{}{
+						"0.5":   1.0,
+						"0.01":  0.5,
+						"0.99":  2.0,
+						"count": 10.0,
+						"sum":   12.5,
+					},
+					time.Unix(0, 0),
+					telegraf.Summary,
+				),
+			},
+			expected: `# TYPE rpc_duration_seconds summary
+rpc_duration_seconds{quantile="0.01"} 0.5
+rpc_duration_seconds{quantile="0.5"} 1
+rpc_duration_seconds{quantile="0.99"} 2
+rpc_duration_seconds_sum 12.5
+rpc_duration_seconds_count 10
+`,
+		},
+		{
+			name: "histogram",
+			metrics: []telegraf.Metric{
+				testutil.MustMetric(
+					"request_duration_seconds",
+					map[string]string{},
+					map[string]interface{}{
+						"0.5":   5.0,
+						"0.1":   2.0,
+						"+Inf":  7.0,
+						"count": 7.0,
+						"sum":   3.2,
+					},
+					time.Unix(0, 0),
+					telegraf.Histogram,
+				),
+			},
+			expected: `# TYPE request_duration_seconds histogram
+request_duration_seconds_bucket{le="0.1"} 2
+request_duration_seconds_bucket{le="0.5"} 5
+request_duration_seconds_bucket{le="+Inf"} 7
+request_duration_seconds_sum 3.2
+request_duration_seconds_count 7
+`,
+		},
+		{
+			name: "string fields ignored",
+			metrics: []telegraf.Metric{
+				testutil.MustMetric(
+					"service",
+					map[string]string{},
+					map[string]interface{}{"state": "up", "value": 1.0},
+					time.Unix(0, 0),
+				),
+			},
+			expected: `# TYPE service untyped
+service 1
+`,
+		},
+		{
+			name:   "string as label",
+			config: FormatConfig{StringAsLabel: true},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric(
+					"service",
+					map[string]string{"host": "example.org"},
+					map[string]interface{}{"state": "up", "value": 1.0},
+					time.Unix(0, 0),
+				),
+			},
+			expected: `# TYPE service untyped
+service{host="example.org",state="up"} 1
+`,
+		},
+		{
+			name: "invalid names replaced",
+			metrics: []telegraf.Metric{
+				testutil.MustMetric(
+					"disk.io",
+					map[string]string{"dev-name": "sda", "1st": "x"},
+					map[string]interface{}{"read-bytes": 1.0},
+					time.Unix(0, 0),
+				),
+			},
+			expected: `# TYPE disk_io_read_bytes untyped
+disk_io_read_bytes{_1st="x",dev_name="sda"} 1
+`,
+		},
+		{
+			name:   "invalid names dropped",
+			config: FormatConfig{InvalidNames: InvalidNamesDrop},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric(
+					"disk",
+					map[string]string{"dev-name": "sda", "host": "example.org"},
+					map[string]interface{}{"read-bytes": 1.0, "writes": 2.0},
+					time.Unix(0, 0),
+				),
+			},
+			expected: `# TYPE disk_writes untyped
+disk_writes{host="example.org"} 2
+`,
+		},
+		{
+			name:   "export timestamp",
+			config: FormatConfig{ExportTimestamp: true},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric(
+					"cpu",
+					map[string]string{},
+					map[string]interface{}{"value": 42.0},
+					time.Unix(1, 500000000),
+				),
+			},
+			expected: `# TYPE cpu untyped
+cpu 42 1500
+`,
+		},
+		{
+			name: "newest sample wins",
+			metrics: []telegraf.Metric{
+				testutil.MustMetric(
+					"cpu",
+					map[string]string{"cpu": "cpu0"},
+					map[string]interface{}{"value": 2.0},
+					time.Unix(2, 0),
+				),
+				testutil.MustMetric(
+					"cpu",
+					map[string]string{"cpu": "cpu0"},
+					map[string]interface{}{"value": 1.0},
+					time.Unix(1, 0),
+				),
+				testutil.MustMetric(
+					"cpu",
+					map[string]string{"cpu": "cpu1"},
+					map[string]interface{}{"value": 3.0},
+					time.Unix(1, 0),
+				),
+			},
+			expected: `# TYPE cpu untyped
+cpu{cpu="cpu0"} 2
+cpu{cpu="cpu1"} 3
+`,
+		},
+		{
+			name: "mismatched type dropped",
+			metrics: []telegraf.Metric{
+				testutil.MustMetric(
+					"jobs",
+					map[string]string{},
+					map[string]interface{}{"counter": 1.0},
+					time.Unix(0, 0),
+					telegraf.Counter,
+				),
+				testutil.MustMetric(
+					"jobs",
+					map[string]string{"queue": "a"},
+					map[string]interface{}{"gauge": 5.0},
+					time.Unix(0, 0),
+					telegraf.Gauge,
+				),
+			},
+			expected: `# TYPE jobs counter
+jobs 1
+`,
+		},
+		{
+			name:   "sort metrics",
+			config: FormatConfig{SortMetrics: true},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric(
+					"mem",
+					map[string]string{},
+					map[string]interface{}{"value": 1.0},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric(
+					"cpu",
+					map[string]string{"cpu": "cpu1"},
+					map[string]interface{}{"value": 2.0},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric(
+					"cpu",
+					map[string]string{"cpu": "cpu0"},
+					map[string]interface{}{"value": 3.0},
+					time.Unix(0, 0),
+				),
+			},
+			expected: `# TYPE cpu untyped
+cpu{cpu="cpu0"} 3
+cpu{cpu="cpu1"} 2
+# TYPE mem untyped
+mem 1
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSerializer(tt.config)
+			require.NoError(t, err)
+			actual, err := s.SerializeBatch(tt.metrics)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, string(actual))
+		})
+	}
+}
+
+func TestSerialize(t *testing.T) {
+	s, err := NewSerializer(FormatConfig{})
+	require.NoError(t, err)
+
+	m := testutil.MustMetric(
+		"cpu",
+		map[string]string{"host": "example.org"},
+		map[string]interface{}{"value": 42.0},
+		time.Unix(0, 0),
+		telegraf.Gauge,
+	)
+	actual, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, "# TYPE cpu gauge\ncpu{host=\"example.org\"} 42\n", string(actual))
+}
+
+func TestNewSerializerInvalidNames(t *testing.T) {
+	_, err := NewSerializer(FormatConfig{InvalidNames: "ignore"})
+	require.Error(t, err)
+}
@@ -0,0 +1,204 @@
+package prometheusremotewrite
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/prompb"
+	"github.com/influxdata/telegraf/plugins/serializers"
+	"github.com/influxdata/telegraf/plugins/serializers/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Serializer serializes metrics as the snappy compressed protocol buffer
+// body of a Prometheus remote write request.
+type Serializer struct {
+	// SortMetrics sorts the series by name and labels, otherwise they are
+	// written in the order first seen.
+	SortMetrics bool `toml:"prometheus_sort_metrics"`
+
+	// StringAsLabel converts string fields to labels, otherwise they are
+	// ignored.
+	StringAsLabel bool `toml:"prometheus_string_as_label"`
+
+	// InvalidNames is the action for invalid names, one of
+	// prometheus.InvalidNamesReplace or prometheus.InvalidNamesDrop.
+	InvalidNames string `toml:"prometheus_invalid_names"`
+
+	prom *prometheus.Serializer
+}
+
+// NewSerializer returns a serializer with the given options.
+func NewSerializer(sortMetrics, stringAsLabel bool, invalidNames string) (*Serializer, error) {
+	s := &Serializer{
+		SortMetrics:   sortMetrics,
+		StringAsLabel: stringAsLabel,
+		InvalidNames:  invalidNames,
+	}
+	if err := s.Init(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Serializer) Description() string {
+	return "Serialize metrics as Prometheus remote write requests"
+}
+
+func (s *Serializer) SampleConfig() string {
+	return `
+  ## Sort the series, otherwise they are written in the order first seen.
+  # prometheus_sort_metrics = false
+
+  ## Convert string fields to labels, otherwise they are ignored.
+  # prometheus_string_as_label = false
+
+  ## Action for metric and label names that are not valid in Prometheus,
+  ## either "replace" the invalid characters with underscores or "drop" them.
+  # prometheus_invalid_names = "replace"
+`
+}
+
+// Init validates the options.
+func (s *Serializer) Init() error {
+	var err error
+	s.prom, err = prometheus.NewSerializer(prometheus.FormatConfig{
+		StringAsLabel: s.StringAsLabel,
+		InvalidNames:  s.InvalidNames,
+	})
+	return err
+}
+
+// Serialize serializes a single metric as a write request.
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	return s.SerializeBatch([]telegraf.Metric{metric})
+}
+
+// SerializeBatch serializes the metrics as a single write request, the
+// samples of the same name and labels are added to one series in time order.
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	b := newBuilder()
+	for _, metric := range metrics {
+		for _, mf := range s.prom.MetricFamilies(metric) {
+			b.addFamily(mf)
+		}
+	}
+
+	series := b.series
+	if s.SortMetrics {
+		sort.SliceStable(series, func(i, j int) bool {
+			return series[i].key < series[j].key
+		})
+	}
+
+	req := &prompb.WriteRequest{
+		Timeseries: make([]*prompb.TimeSeries, 0, len(series)),
+	}
+	for _, ts := range series {
+		sort.SliceStable(ts.Samples, func(i, j int) bool {
+			return ts.Samples[i].Timestamp < ts.Samples[j].Timestamp
+		})
+		req.Timeseries = append(req.Timeseries, ts.TimeSeries)
+	}
+	return prompb.Encode(req)
+}
+
+type keyedSeries struct {
+	key string
+	*prompb.TimeSeries
+}
+
+// builder groups samples into series by their name and labels.
+type builder struct {
+	series []*keyedSeries
+	index  map[string]*keyedSeries
+}
+
+func newBuilder() *builder {
+	return &builder{index: make(map[string]*keyedSeries)}
+}
+
+// addFamily adds the samples of the family, summaries and histograms are
+// split into the series Prometheus uses for them.
+func (b *builder) addFamily(mf *dto.MetricFamily) {
+	name := mf.GetName()
+	for _, m := range mf.Metric {
+		ts := m.GetTimestampMs()
+		switch mf.GetType() {
+		case dto.MetricType_SUMMARY:
+			summary := m.GetSummary()
+			for _, q := range summary.Quantile {
+				label := &dto.LabelPair{Name: strptr("quantile"), Value: strptr(formatFloat(q.GetQuantile()))}
+				b.add(name, m.Label, label, q.GetValue(), ts)
+			}
+			b.add(name+"_sum", m.Label, nil, summary.GetSampleSum(), ts)
+			b.add(name+"_count", m.Label, nil, float64(summary.GetSampleCount()), ts)
+		case dto.MetricType_HISTOGRAM:
+			histogram := m.GetHistogram()
+			for _, bucket := range histogram.Bucket {
+				label := &dto.LabelPair{Name: strptr("le"), Value: strptr(formatFloat(bucket.GetUpperBound()))}
+				b.add(name+"_bucket", m.Label, label, float64(bucket.GetCumulativeCount()), ts)
+			}
+			b.add(name+"_sum", m.Label, nil, histogram.GetSampleSum(), ts)
+			b.add(name+"_count", m.Label, nil, float64(histogram.GetSampleCount()), ts)
+		case dto.MetricType_COUNTER:
+			b.add(name, m.Label, nil, m.GetCounter().GetValue(), ts)
+		case dto.MetricType_GAUGE:
+			b.add(name, m.Label, nil, m.GetGauge().GetValue(), ts)
+		default:
+			b.add(name, m.Label, nil, m.GetUntyped().GetValue(), ts)
+		}
+	}
+}
+
+// add adds a sample to the series of the name and labels, the extra label
+// is added to the labels if not nil.
+func (b *builder) add(name string, labels []*dto.LabelPair, extra *dto.LabelPair, value float64, ts int64) {
+	pairs := make([]*prompb.Label, 0, len(labels)+2)
+	pairs = append(pairs, &prompb.Label{Name: prompb.MetricNameLabel, Value: name})
+	for _, label := range labels {
+		pairs = append(pairs, &prompb.Label{Name: label.GetName(), Value: label.GetValue()})
+	}
+	if extra != nil {
+		pairs = append(pairs, &prompb.Label{Name: extra.GetName(), Value: extra.GetValue()})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].Name < pairs[j].Name
+	})
+
+	var key strings.Builder
+	key.WriteString(name)
+	for _, label := range pairs {
+		key.WriteByte(0)
+		key.WriteString(label.Name)
+		key.WriteByte('=')
+		key.WriteString(label.Value)
+	}
+
+	series, ok := b.index[key.String()]
+	if !ok {
+		series = &keyedSeries{
+			key:        key.String(),
+			TimeSeries: &prompb.TimeSeries{Labels: pairs},
+		}
+		b.index[series.key] = series
+		b.series = append(b.series, series)
+	}
+	series.Samples = append(series.Samples, &prompb.Sample{Value: value, Timestamp: ts})
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func strptr(s string) *string {
+	return &s
+}
+
+func init() {
+	serializers.Add("prometheusremotewrite", func() serializers.Serializer {
+		return &Serializer{}
+	})
+}
@@ -0,0 +1,127 @@
+package prometheusremotewrite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/prompb"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSerializeBatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		metrics  []telegraf.Metric
+		expected []*prompb.TimeSeries
+	}{
+		{
+			name: "fields",
+			metrics: []telegraf.Metric{
+				testutil.MustMetric(
+					"cpu",
+					map[string]string{"host": "example.org"},
+					map[string]interface{}{"time_idle": 42.0, "time_user": 1.5},
+					time.Unix(2, 0),
+				),
+			},
+			expected: []*prompb.TimeSeries{
+				{
+					Labels: []*prompb.Label{
+						{Name: "__name__", Value: "cpu_time_idle"},
+						{Name: "host", Value: "example.org"},
+					},
+					Samples: []*prompb.Sample{{Value: 42, Timestamp: 2000}},
+				},
+				{
+					Labels: []*prompb.Label{
+						{Name: "__name__", Value: "cpu_time_user"},
+						{Name: "host", Value: "example.org"},
+					},
+					Samples: []*prompb.Sample{{Value: 1.5, Timestamp: 2000}},
+				},
+			},
+		},
+		{
+			name: "samples in time order",
+			metrics: []telegraf.Metric{
+				testutil.MustMetric(
+					"up",
+					map[string]string{},
+					map[string]interface{}{"value": 1.0},
+					time.Unix(2, 0),
+				),
+				testutil.MustMetric(
+					"up",
+					map[string]string{},
+					map[string]interface{}{"value": 0.0},
+					time.Unix(1, 0),
+				),
+			},
+			expected: []*prompb.TimeSeries{
+				{
+					Labels: []*prompb.Label{{Name: "__name__", Value: "up"}},
+					Samples: []*prompb.Sample{
+						{Value: 0, Timestamp: 1000},
+						{Value: 1, Timestamp: 2000},
+					},
+				},
+			},
+		},
+		{
+			name: "histogram",
+			metrics: []telegraf.Metric{
+				testutil.MustMetric(
+					"latency",
+					map[string]string{},
+					map[string]interface{}{"0.5": 1.0, "+Inf": 3.0, "count": 3.0, "sum": 2.5},
+					time.Unix(1, 0),
+					telegraf.Histogram,
+				),
+			},
+			expected: []*prompb.TimeSeries{
+				{
+					Labels: []*prompb.Label{
+						{Name: "__name__", Value: "latency_bucket"},
+						{Name: "le", Value: "0.5"},
+					},
+					Samples: []*prompb.Sample{{Value: 1, Timestamp: 1000}},
+				},
+				{
+					Labels: []*prompb.Label{
+						{Name: "__name__", Value: "latency_bucket"},
+						{Name: "le", Value: "+Inf"},
+					},
+					Samples: []*prompb.Sample{{Value: 3, Timestamp: 1000}},
+				},
+				{
+					Labels:  []*prompb.Label{{Name: "__name__", Value: "latency_sum"}},
+					Samples: []*prompb.Sample{{Value: 2.5, Timestamp: 1000}},
+				},
+				{
+					Labels:  []*prompb.Label{{Name: "__name__", Value: "latency_count"}},
+					Samples: []*prompb.Sample{{Value: 3, Timestamp: 1000}},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSerializer(false, false, "")
+			require.NoError(t, err)
+
+			buf, err := s.SerializeBatch(tt.metrics)
+			require.NoError(t, err)
+
+			req, err := prompb.Decode(buf)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, req.Timeseries)
+		})
+	}
+}
+
+func TestInvalidNames(t *testing.T) {
+	_, err := NewSerializer(false, false, "ignore")
+	require.Error(t, err)
+}
@@ -0,0 +1,306 @@
+package protobuf
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	dpb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/parsers/protobuf"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+const timestampType = "google.protobuf.Timestamp"
+
+// FormatConfig holds the options of the serializer.
+type FormatConfig struct {
+	// ProtoFile is the .proto file defining the MessageType.
+	ProtoFile string `toml:"protobuf_file"`
+
+	// ImportPaths are searched for the imports of the ProtoFile in addition
+	// to its own directory.
+	ImportPaths []string `toml:"protobuf_import_paths"`
+
+	// MessageType is the fully qualified name of the message.
+	MessageType string `toml:"protobuf_message_type"`
+
+	// Measurement is the message field set to the metric name.
+	Measurement string `toml:"protobuf_measurement"`
+
+	// Timestamp is the message field set to the metric time.
+	Timestamp string `toml:"protobuf_timestamp"`
+
+	// TimestampFormat is the format of the Timestamp, either a Go time
+	// layout or one of unix, unix_ms, unix_us or unix_ns.  Defaults to unix.
+	// Fields of type google.protobuf.Timestamp are always set to the time.
+	TimestampFormat string `toml:"protobuf_timestamp_format"`
+}
+
+// Serializer serializes metrics into Protocol Buffers messages.  The message
+// fields are set from the tags and fields with the same name, metrics without
+// a value for a field leave it unset.
+type Serializer struct {
+	FormatConfig
+
+	message *desc.MessageDescriptor
+}
+
+// NewSerializer returns a serializer for the message type of the config.
+func NewSerializer(config FormatConfig) (*Serializer, error) {
+	s := &Serializer{FormatConfig: config}
+	if err := s.Init(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Serializer) Description() string {
+	return "Serialize metrics into Protocol Buffers messages"
+}
+
+func (s *Serializer) SampleConfig() string {
+	return `
+  ## The .proto file defining the message type, imports are searched for in
+  ## its directory and the protobuf_import_paths.
+  protobuf_file = "/etc/telegraf/metric.proto"
+  # protobuf_import_paths = []
+
+  ## Fully qualified name of the message, its fields are set from the tags
+  ## and fields with the same name.
+  protobuf_message_type = "example.Metric"
+
+  ## Message fields set to the metric name and time.
+  # protobuf_measurement = ""
+  # protobuf_timestamp = ""
+
+  ## Format of the protobuf_timestamp field, either a Go time layout or one
+  ## of "unix", "unix_ms", "unix_us" or "unix_ns".
+  # protobuf_timestamp_format = "unix"
+`
+}
+
+// Init loads the message type from the ProtoFile.
+func (s *Serializer) Init() error {
+	message, err := protobuf.LoadMessage(s.ProtoFile, s.ImportPaths, s.MessageType)
+	if err != nil {
+		return err
+	}
+	s.message = message
+	if s.TimestampFormat == "" {
+		s.TimestampFormat = "unix"
+	}
+	return nil
+}
+
+// Serialize serializes a single metric as one message.
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	msg, err := s.newMessage(metric)
+	if err != nil {
+		return nil, err
+	}
+	return msg.Marshal()
+}
+
+// SerializeBatch serializes the metrics as consecutive messages, each prefixed
+// with its length encoded as a varint.
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	var buf []byte
+	for _, metric := range metrics {
+		msg, err := s.newMessage(metric)
+		if err != nil {
+			return nil, err
+		}
+		b, err := msg.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, proto.EncodeVarint(uint64(len(b)))...)
+		buf = append(buf, b...)
+	}
+	return buf, nil
+}
+
+func (s *Serializer) newMessage(metric telegraf.Metric) (*dynamic.Message, error) {
+	msg := dynamic.NewMessage(s.message)
+	for _, fd := range s.message.GetFields() {
+		var value interface{}
+		switch fd.GetName() {
+		case s.Measurement:
+			value = metric.Name()
+		case s.Timestamp:
+			value = metric.Time()
+		default:
+			if v, ok := metric.GetTag(fd.GetName()); ok {
+				value = v
+			} else if v, ok := metric.GetField(fd.GetName()); ok {
+				value = v
+			}
+		}
+		if value == nil {
+			continue
+		}
+
+		v, err := s.convert(fd, value)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %v", fd.GetName(), err)
+		}
+		if err := msg.TrySetField(fd, v); err != nil {
+			return nil, fmt.Errorf("field %q: %v", fd.GetName(), err)
+		}
+	}
+	return msg, nil
+}
+
+// convert returns the value as the Go type used for the field.
+func (s *Serializer) convert(fd *desc.FieldDescriptor, value interface{}) (interface{}, error) {
+	if fd.IsRepeated() {
+		return nil, fmt.Errorf("repeated fields are not supported")
+	}
+
+	if t, ok := value.(time.Time); ok {
+		if md := fd.GetMessageType(); md != nil && md.GetFullyQualifiedName() == timestampType {
+			ts := dynamic.NewMessage(md)
+			ts.SetFieldByName("seconds", t.Unix())
+			ts.SetFieldByName("nanos", int32(t.Nanosecond()))
+			return ts, nil
+		}
+		switch s.TimestampFormat {
+		case "unix":
+			value = t.Unix()
+		case "unix_ms":
+			value = t.UnixNano() / int64(time.Millisecond)
+		case "unix_us":
+			value = t.UnixNano() / int64(time.Microsecond)
+		case "unix_ns":
+			value = t.UnixNano()
+		default:
+			value = t.Format(s.TimestampFormat)
+		}
+	}
+
+	switch fd.GetType() {
+	case dpb.FieldDescriptorProto_TYPE_BOOL:
+		if v, ok := value.(bool); ok {
+			return v, nil
+		}
+	case dpb.FieldDescriptorProto_TYPE_STRING:
+		if v, ok := value.(string); ok {
+			return v, nil
+		}
+		return fmt.Sprintf("%v", value), nil
+	case dpb.FieldDescriptorProto_TYPE_BYTES:
+		if v, ok := value.(string); ok {
+			return []byte(v), nil
+		}
+	case dpb.FieldDescriptorProto_TYPE_DOUBLE:
+		if v, ok := toFloat(value); ok {
+			return v, nil
+		}
+	case dpb.FieldDescriptorProto_TYPE_FLOAT:
+		if v, ok := toFloat(value); ok {
+			return float32(v), nil
+		}
+	case dpb.FieldDescriptorProto_TYPE_INT64,
+		dpb.FieldDescriptorProto_TYPE_SINT64,
+		dpb.FieldDescriptorProto_TYPE_SFIXED64:
+		if v, ok := toInt(value, math.MinInt64, math.MaxInt64); ok {
+			return v, nil
+		}
+	case dpb.FieldDescriptorProto_TYPE_INT32,
+		dpb.FieldDescriptorProto_TYPE_SINT32,
+		dpb.FieldDescriptorProto_TYPE_SFIXED32:
+		if v, ok := toInt(value, math.MinInt32, math.MaxInt32); ok {
+			return int32(v), nil
+		}
+	case dpb.FieldDescriptorProto_TYPE_UINT64,
+		dpb.FieldDescriptorProto_TYPE_FIXED64:
+		if v, ok := toUint(value, math.MaxUint64); ok {
+			return v, nil
+		}
+	case dpb.FieldDescriptorProto_TYPE_UINT32,
+		dpb.FieldDescriptorProto_TYPE_FIXED32:
+		if v, ok := toUint(value, math.MaxUint32); ok {
+			return uint32(v), nil
+		}
+	case dpb.FieldDescriptorProto_TYPE_ENUM:
+		if v, ok := value.(string); ok {
+			if ev := fd.GetEnumType().FindValueByName(v); ev != nil {
+				return ev.GetNumber(), nil
+			}
+			return nil, fmt.Errorf("unknown enum value %q", v)
+		}
+		if v, ok := toInt(value, math.MinInt32, math.MaxInt32); ok {
+			return int32(v), nil
+		}
+	}
+	return nil, fmt.Errorf("cannot convert %T to %s", value, fd.GetType())
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func toInt(value interface{}, min, max int64) (int64, bool) {
+	var i int64
+	switch v := value.(type) {
+	case int64:
+		i = v
+	case uint64:
+		if v > math.MaxInt64 {
+			return 0, false
+		}
+		i = int64(v)
+	case string:
+		var err error
+		if i, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	return i, i >= min && i <= max
+}
+
+func toUint(value interface{}, max uint64) (uint64, bool) {
+	var u uint64
+	switch v := value.(type) {
+	case uint64:
+		u = v
+	case int64:
+		if v < 0 {
+			return 0, false
+		}
+		u = uint64(v)
+	case string:
+		var err error
+		if u, err = strconv.ParseUint(v, 10, 64); err != nil {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	return u, u <= max
+}
+
+func init() {
+	serializers.Add("protobuf", func() serializers.Serializer {
+		return &Serializer{}
+	})
+}
@@ -0,0 +1,158 @@
+package protobuf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/jhump/protoreflect/dynamic"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+const protoFile = "testdata/cpu.proto"
+
+var exptime = time.Date(2020, time.August, 1, 15, 4, 3, 500, time.UTC)
+
+func newMetric(host string) telegraf.Metric {
+	return testutil.MustMetric("cpu",
+		map[string]string{
+			"host":   host,
+			"state":  "BUSY",
+			"uptime": "3600",
+		},
+		map[string]interface{}{
+			"usage":  42.5,
+			"cores":  int64(4),
+			"serial": "abc",
+		},
+		exptime)
+}
+
+func decode(t *testing.T, s *Serializer, buf []byte) *dynamic.Message {
+	msg := dynamic.NewMessage(s.message)
+	require.NoError(t, msg.Unmarshal(buf))
+	return msg
+}
+
+func TestSerialize(t *testing.T) {
+	s, err := NewSerializer(FormatConfig{
+		ProtoFile:   protoFile,
+		MessageType: "example.CPU",
+		Measurement: "measurement",
+		Timestamp:   "time",
+	})
+	require.NoError(t, err)
+
+	buf, err := s.Serialize(newMetric("server01"))
+	require.NoError(t, err)
+
+	msg := decode(t, s, buf)
+	require.Equal(t, "cpu", msg.GetFieldByName("measurement"))
+	require.Equal(t, "server01", msg.GetFieldByName("host"))
+	require.Equal(t, int32(1), msg.GetFieldByName("state"))
+	require.Equal(t, 42.5, msg.GetFieldByName("usage"))
+	require.Equal(t, int32(4), msg.GetFieldByName("cores"))
+	require.Equal(t, uint32(3600), msg.GetFieldByName("uptime"))
+	require.Equal(t, []byte("abc"), msg.GetFieldByName("serial"))
+	require.Equal(t, int64(0), msg.GetFieldByName("seconds"))
+
+	ts, err := dynamic.AsDynamicMessage(msg.GetFieldByName("time").(proto.Message))
+	require.NoError(t, err)
+	require.Equal(t, exptime.Unix(), ts.GetFieldByName("seconds"))
+	require.Equal(t, int32(500), ts.GetFieldByName("nanos"))
+}
+
+func TestSerializeTimestampFormat(t *testing.T) {
+	tests := []struct {
+		format   string
+		expected int64
+	}{
+		{"unix", exptime.Unix()},
+		{"unix_ms", exptime.UnixNano() / int64(time.Millisecond)},
+		{"unix_us", exptime.UnixNano() / int64(time.Microsecond)},
+		{"unix_ns", exptime.UnixNano()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			s, err := NewSerializer(FormatConfig{
+				ProtoFile:       protoFile,
+				MessageType:     "example.CPU",
+				Timestamp:       "seconds",
+				TimestampFormat: tt.format,
+			})
+			require.NoError(t, err)
+
+			buf, err := s.Serialize(newMetric("server01"))
+			require.NoError(t, err)
+
+			msg := decode(t, s, buf)
+			require.Equal(t, tt.expected, msg.GetFieldByName("seconds"))
+			require.Equal(t, "", msg.GetFieldByName("measurement"))
+		})
+	}
+}
+
+func TestSerializeBatch(t *testing.T) {
+	s, err := NewSerializer(FormatConfig{
+		ProtoFile:   protoFile,
+		MessageType: "example.CPU",
+	})
+	require.NoError(t, err)
+
+	buf, err := s.SerializeBatch([]telegraf.Metric{
+		newMetric("server01"),
+		newMetric("server02"),
+	})
+	require.NoError(t, err)
+
+	var hosts []interface{}
+	for len(buf) > 0 {
+		size, n := proto.DecodeVarint(buf)
+		require.NotZero(t, n)
+		buf = buf[n:]
+		hosts = append(hosts, decode(t, s, buf[:size]).GetFieldByName("host"))
+		buf = buf[size:]
+	}
+	require.Equal(t, []interface{}{"server01", "server02"}, hosts)
+}
+
+func TestSerializeInvalid(t *testing.T) {
+	s, err := NewSerializer(FormatConfig{
+		ProtoFile:   protoFile,
+		MessageType: "example.CPU",
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		key   string
+		value interface{}
+	}{
+		{"out of range", "cores", int64(1) << 40},
+		{"negative unsigned", "uptime", int64(-1)},
+		{"unknown enum", "state", "STOPPED"},
+		{"wrong type", "usage", true},
+		{"repeated", "labels", "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := testutil.MustMetric("cpu",
+				map[string]string{},
+				map[string]interface{}{tt.key: tt.value},
+				exptime)
+			_, err := s.Serialize(m)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestNewSerializer(t *testing.T) {
+	_, err := NewSerializer(FormatConfig{MessageType: "example.CPU"})
+	require.Error(t, err)
+
+	_, err = NewSerializer(FormatConfig{ProtoFile: protoFile, MessageType: "example.Memory"})
+	require.Error(t, err)
+}
@@ -1,17 +1,7 @@
 package serializers
 
 import (
-	"fmt"
-	"time"
-
 	"github.com/influxdata/telegraf"
-	"github.com/influxdata/telegraf/plugins/serializers/carbon2"
-	"github.com/influxdata/telegraf/plugins/serializers/graphite"
-	"github.com/influxdata/telegraf/plugins/serializers/influx"
-	"github.com/influxdata/telegraf/plugins/serializers/json"
-	"github.com/influxdata/telegraf/plugins/serializers/nowmetric"
-	"github.com/influxdata/telegraf/plugins/serializers/splunkmetric"
-	"github.com/influxdata/telegraf/plugins/serializers/wavefront"
 )
 
 // SerializerOutput is an interface for output plugins that are able to
@@ -41,117 +31,16 @@ type Serializer interface {
 	SerializeBatch(metrics []telegraf.Metric) ([]byte, error)
 }
 
-// Config is a struct that covers the data types needed for all serializer types,
-// and can be used to instantiate _any_ of the serializers.
-type Config struct {
-	// Dataformat can be one of the serializer types listed in NewSerializer.
-	DataFormat string
-
-	// Support tags in graphite protocol
-	GraphiteTagSupport bool
-
-	// Maximum line length in bytes; influx format only
-	InfluxMaxLineBytes int
-
-	// Sort field keys, set to true only when debugging as it less performant
-	// than unsorted fields; influx format only
-	InfluxSortFields bool
-
-	// Support unsigned integer output; influx format only
-	InfluxUintSupport bool
-
-	// Prefix to add to all measurements, only supports Graphite
-	Prefix string
-
-	// Template for converting telegraf metrics into Graphite
-	// only supports Graphite
-	Template string
-
-	// Timestamp units to use for JSON formatted output
-	TimestampUnits time.Duration
+// Creator is the function to create a new serializer, with its options set
+// to their defaults.
+type Creator func() Serializer
 
-	// Include HEC routing fields for splunkmetric output
-	HecRouting bool
-
-	// Point tags to use as the source name for Wavefront (if none found, host will be used).
-	WavefrontSourceOverride []string
-
-	// Use Strict rules to sanitize metric and tag names from invalid characters for Wavefront
-	// When enabled forward slash (/) and comma (,) will be accepted
-	WavefrontUseStrict bool
-}
-
-// NewSerializer a Serializer interface based on the given config.
-func NewSerializer(config *Config) (Serializer, error) {
-	var err error
-	var serializer Serializer
-	switch config.DataFormat {
-	case "influx":
-		serializer, err = NewInfluxSerializerConfig(config)
-	case "graphite":
-		serializer, err = NewGraphiteSerializer(config.Prefix, config.Template, config.GraphiteTagSupport)
-	case "json":
-		serializer, err = NewJsonSerializer(config.TimestampUnits)
-	case "splunkmetric":
-		serializer, err = NewSplunkmetricSerializer(config.HecRouting)
-	case "nowmetric":
-		serializer, err = NewNowSerializer()
-	case "carbon2":
-		serializer, err = NewCarbon2Serializer()
-	case "wavefront":
-		serializer, err = NewWavefrontSerializer(config.Prefix, config.WavefrontUseStrict, config.WavefrontSourceOverride)
-	default:
-		err = fmt.Errorf("Invalid data format: %s", config.DataFormat)
-	}
-	return serializer, err
-}
-
-func NewWavefrontSerializer(prefix string, useStrict bool, sourceOverride []string) (Serializer, error) {
-	return wavefront.NewSerializer(prefix, useStrict, sourceOverride)
-}
-
-func NewJsonSerializer(timestampUnits time.Duration) (Serializer, error) {
-	return json.NewSerializer(timestampUnits)
-}
-
-func NewCarbon2Serializer() (Serializer, error) {
-	return carbon2.NewSerializer()
-}
-
-func NewSplunkmetricSerializer(splunkmetric_hec_routing bool) (Serializer, error) {
-	return splunkmetric.NewSerializer(splunkmetric_hec_routing)
-}
-
-func NewNowSerializer() (Serializer, error) {
-	return nowmetric.NewSerializer()
-}
-
-func NewInfluxSerializerConfig(config *Config) (Serializer, error) {
-	var sort influx.FieldSortOrder
-	if config.InfluxSortFields {
-		sort = influx.SortFields
-	}
-
-	var typeSupport influx.FieldTypeSupport
-	if config.InfluxUintSupport {
-		typeSupport = typeSupport + influx.UintSupport
-	}
-
-	s := influx.NewSerializer()
-	s.SetMaxLineBytes(config.InfluxMaxLineBytes)
-	s.SetFieldSortOrder(sort)
-	s.SetFieldTypeSupport(typeSupport)
-	return s, nil
-}
-
-func NewInfluxSerializer() (Serializer, error) {
-	return influx.NewSerializer(), nil
-}
+// Serializers are the registered data formats, by data_format name.
+var Serializers = map[string]Creator{}
 
-func NewGraphiteSerializer(prefix, template string, tag_support bool) (Serializer, error) {
-	return &graphite.GraphiteSerializer{
-		Prefix:     prefix,
-		Template:   template,
-		TagSupport: tag_support,
-	}, nil
+// Add registers the serializer of a data format.  The options of the format
+// are the toml tagged fields of the serializer, decoded from the table of the
+// plugin using it.
+func Add(name string, creator Creator) {
+	Serializers[name] = creator
 }
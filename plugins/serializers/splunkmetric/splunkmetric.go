@@ -6,10 +6,11 @@ import (
 	"log"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/serializers"
 )
 
 type serializer struct {
-	HecRouting bool
+	HecRouting bool `toml:"splunkmetric_hec_routing"`
 }
 
 func NewSerializer(splunkmetric_hec_routing bool) (*serializer, error) {
@@ -19,6 +20,17 @@ func NewSerializer(splunkmetric_hec_routing bool) (*serializer, error) {
 	return s, nil
 }
 
+func (s *serializer) Description() string {
+	return "Serialize metrics for the Splunk metrics index"
+}
+
+func (s *serializer) SampleConfig() string {
+	return `
+  ## Provides time, index, source overrides for the HEC
+  # splunkmetric_hec_routing = false
+`
+}
+
 func (s *serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
 
 	m, err := s.createObject(metric)
@@ -138,3 +150,9 @@ func verifyValue(v interface{}) (value interface{}, valid bool) {
 	}
 	return value, valid
 }
+
+func init() {
+	serializers.Add("splunkmetric", func() serializers.Serializer {
+		return &serializer{}
+	})
+}
@@ -0,0 +1,160 @@
+package template
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+// funcs are the helper functions available to the templates.  Functions
+// taking a string operate on their last argument, allowing them to be used
+// in pipelines.
+var funcs = template.FuncMap{
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"trim":       strings.TrimSpace,
+	"join":       func(sep string, a []string) string { return strings.Join(a, sep) },
+	"split":      func(sep, s string) []string { return strings.Split(s, sep) },
+	"replace":    func(old, repl, s string) string { return strings.Replace(s, old, repl, -1) },
+	"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+	"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+	"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+	"timestamp":  func(format string, t time.Time) string { return internal.FormatTimestamp(t, format) },
+	"sortedKeys": sortedKeys,
+	"json":       toJSON,
+}
+
+// Serializer renders metrics as text using Go templates.  The metric template
+// is executed with each metric, the batch template with the slice of metrics.
+type Serializer struct {
+	Template      string `toml:"template"`
+	BatchTemplate string `toml:"batch_template"`
+
+	metric *template.Template
+	batch  *template.Template
+}
+
+// NewSerializer returns a serializer for the templates, at least one of which
+// must be set.
+func NewSerializer(metricTemplate, batchTemplate string) (*Serializer, error) {
+	s := &Serializer{
+		Template:      metricTemplate,
+		BatchTemplate: batchTemplate,
+	}
+	if err := s.Init(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Serializer) Description() string {
+	return "Render metrics as text using Go templates"
+}
+
+func (s *Serializer) SampleConfig() string {
+	return `
+  ## Template executed with each metric, at least one of template or
+  ## batch_template must be set.
+  template = "{{ .Name }} {{ .Time | timestamp \"unix\" }}\n"
+
+  ## Template executed with the slice of metrics of a batch.
+  # batch_template = ""
+`
+}
+
+// Init parses the templates.
+func (s *Serializer) Init() error {
+	if s.Template == "" && s.BatchTemplate == "" {
+		return fmt.Errorf("one of template or batch_template must be set")
+	}
+
+	if s.Template != "" {
+		t, err := template.New("template").Funcs(funcs).Parse(s.Template)
+		if err != nil {
+			return fmt.Errorf("invalid template: %v", err)
+		}
+		s.metric = t
+	}
+	if s.BatchTemplate != "" {
+		t, err := template.New("batch_template").Funcs(funcs).Parse(s.BatchTemplate)
+		if err != nil {
+			return fmt.Errorf("invalid batch_template: %v", err)
+		}
+		s.batch = t
+	}
+	return nil
+}
+
+// Serialize renders the metric with the metric template, or the batch
+// template if only that is set.
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if s.metric != nil {
+		err = s.metric.Execute(&buf, metric)
+	} else {
+		err = s.batch.Execute(&buf, []telegraf.Metric{metric})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SerializeBatch renders the metrics with the batch template, or each metric
+// with the metric template if only that is set.
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	var buf bytes.Buffer
+	if s.batch != nil {
+		if err := s.batch.Execute(&buf, metrics); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	for _, metric := range metrics {
+		if err := s.metric.Execute(&buf, metric); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// sortedKeys returns the keys of a map with string keys, such as the tags or
+// fields of a metric, in sorted order.
+func sortedKeys(m interface{}) ([]string, error) {
+	v := reflect.ValueOf(m)
+	if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+		return nil, fmt.Errorf("sortedKeys: expected a map with string keys, got %T", m)
+	}
+
+	keys := make([]string, 0, v.Len())
+	for _, k := range v.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func toJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func init() {
+	serializers.Add("template", func() serializers.Serializer {
+		return &Serializer{}
+	})
+}
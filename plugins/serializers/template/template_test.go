@@ -0,0 +1,109 @@
+package template
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var exptime = time.Date(2020, time.August, 1, 15, 4, 3, 0, time.UTC)
+
+func metrics() []telegraf.Metric {
+	return []telegraf.Metric{
+		testutil.MustMetric("cpu_usage",
+			map[string]string{"host": "server01", "cpu": "cpu0"},
+			map[string]interface{}{"idle": 98.5, "user": 1.5},
+			exptime),
+		testutil.MustMetric("mem",
+			map[string]string{"host": "server02"},
+			map[string]interface{}{"used": int64(1024)},
+			exptime),
+	}
+}
+
+func TestSerialize(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{
+			name:     "fields",
+			template: "{{ .Name }} {{ index .Tags \"host\" }} {{ index .Fields \"idle\" }} {{ .Time.Unix }}\n",
+			expected: "cpu_usage server01 98.5 1596294243\n",
+		},
+		{
+			name:     "sorted keys",
+			template: "{{ $m := . }}{{ range sortedKeys .Fields }}{{ $m.Name }}.{{ . }}={{ index $m.Fields . }};{{ end }}",
+			expected: "cpu_usage.idle=98.5;cpu_usage.user=1.5;",
+		},
+		{
+			name:     "string helpers",
+			template: "{{ .Name | replace \"_\" \".\" | upper }} {{ split \"_\" .Name | join \"-\" }} {{ contains \"usage\" .Name }}",
+			expected: "CPU.USAGE cpu-usage true",
+		},
+		{
+			name:     "timestamp",
+			template: "{{ .Time | timestamp \"unix_ms\" }} {{ .Time | timestamp \"2006-01-02\" }}",
+			expected: "1596294243000 2020-08-01",
+		},
+		{
+			name:     "json",
+			template: "{{ json .Tags }}",
+			expected: `{"cpu":"cpu0","host":"server01"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSerializer(tt.template, "")
+			require.NoError(t, err)
+
+			buf, err := s.Serialize(metrics()[0])
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, string(buf))
+		})
+	}
+}
+
+func TestSerializeBatch(t *testing.T) {
+	s, err := NewSerializer("{{ .Name }}\n", "")
+	require.NoError(t, err)
+	buf, err := s.SerializeBatch(metrics())
+	require.NoError(t, err)
+	require.Equal(t, "cpu_usage\nmem\n", string(buf))
+
+	s, err = NewSerializer("{{ .Name }}\n", "{{ len . }} metrics:{{ range . }} {{ .Name }}{{ end }}\n")
+	require.NoError(t, err)
+	buf, err = s.SerializeBatch(metrics())
+	require.NoError(t, err)
+	require.Equal(t, "2 metrics: cpu_usage mem\n", string(buf))
+
+	buf, err = s.Serialize(metrics()[1])
+	require.NoError(t, err)
+	require.Equal(t, "mem\n", string(buf))
+
+	s, err = NewSerializer("", "{{ range . }}{{ .Name }};{{ end }}")
+	require.NoError(t, err)
+	buf, err = s.Serialize(metrics()[1])
+	require.NoError(t, err)
+	require.Equal(t, "mem;", string(buf))
+}
+
+func TestSerializeErrors(t *testing.T) {
+	_, err := NewSerializer("", "")
+	require.Error(t, err)
+
+	_, err = NewSerializer("{{ .Name ", "")
+	require.Error(t, err)
+
+	_, err = NewSerializer("", "{{ range }}")
+	require.Error(t, err)
+
+	s, err := NewSerializer("{{ sortedKeys .Name }}", "")
+	require.NoError(t, err)
+	_, err = s.Serialize(metrics()[0])
+	require.Error(t, err)
+}
@@ -8,13 +8,14 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/outputs/wavefront"
+	"github.com/influxdata/telegraf/plugins/serializers"
 )
 
 // WavefrontSerializer : WavefrontSerializer struct
 type WavefrontSerializer struct {
-	Prefix         string
-	UseStrict      bool
-	SourceOverride []string
+	Prefix         string   `toml:"prefix"`
+	UseStrict      bool     `toml:"wavefront_use_strict"`
+	SourceOverride []string `toml:"wavefront_source_override"`
 	scratch        buffer
 	mu             sync.Mutex // buffer mutex
 }
@@ -49,6 +50,24 @@ func NewSerializer(prefix string, useStrict bool, sourceOverride []string) (*Wav
 	return s, nil
 }
 
+func (s *WavefrontSerializer) Description() string {
+	return "Serialize metrics in the Wavefront data format"
+}
+
+func (s *WavefrontSerializer) SampleConfig() string {
+	return `
+  ## Prefix added to each metric name
+  # prefix = ""
+
+  ## Use Strict rules to sanitize metric and tag names from invalid characters
+  ## When enabled forward slash (/) and comma (,) will be accpeted
+  # wavefront_use_strict = false
+
+  ## point tags to use as the source name for Wavefront (if none found, host will be used)
+  # wavefront_source_override = ["hostname", "address", "agent_host", "node_host"]
+`
+}
+
 func (s *WavefrontSerializer) serialize(buf *buffer, m telegraf.Metric) {
 	const metricSeparator = "."
 
@@ -216,3 +235,9 @@ func (b *buffer) WriteUint64(val uint64) {
 func (b *buffer) WriteFloat64(val float64) {
 	*b = strconv.AppendFloat(*b, val, 'f', 6, 64)
 }
+
+func init() {
+	serializers.Add("wavefront", func() serializers.Serializer {
+		return &WavefrontSerializer{}
+	})
+}
@@ -10,3 +10,16 @@ type Processor interface {
 	// Apply the filter to the given metric.
 	Apply(in ...Metric) []Metric
 }
+
+type StreamingProcessor interface {
+	Processor
+
+	// Start the StreamingProcessor.  Metrics created by the processor outside
+	// of Apply are added to the Accumulator, which may be retained and used
+	// until Stop returns.
+	Start(Accumulator) error
+
+	// Stop the StreamingProcessor.  Metrics that are still being processed
+	// should be added to the Accumulator before Stop returns.
+	Stop()
+}
@@ -0,0 +1,23 @@
+package telegraf
+
+// SecretStore provides secrets that are referenced in the configuration of
+// other plugins as @{id:key}.
+type SecretStore interface {
+	// SampleConfig returns the default configuration of the SecretStore
+	SampleConfig() string
+
+	// Description returns a one-sentence description on the SecretStore
+	Description() string
+
+	// Get returns the current value of the secret with the given key.  The
+	// returned slice is owned by the caller, which should overwrite it once
+	// the secret is no longer needed.
+	Get(key string) ([]byte, error)
+}
+
+// SecretSetter is implemented by a SecretStore that can store new secrets.
+type SecretSetter interface {
+	// Set stores the value of the secret with the given key, replacing the
+	// current value.
+	Set(key string, value []byte) error
+}